@@ -0,0 +1,200 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const codegenCacheFilename = "codegen-cache.json"
+
+// codegenCache remembers the input and output hashes of the in-process generators, so a
+// generator whose inputs and output are unchanged since its last run is skipped.
+type codegenCache struct {
+	path    string
+	force   bool
+	Entries map[string]codegenCacheEntry `json:"entries"`
+}
+
+type codegenCacheEntry struct {
+	Inputs string `json:"inputs"`
+	Output string `json:"output"`
+}
+
+func loadCodegenCache(workdir string) (*codegenCache, error) {
+	cache := &codegenCache{
+		path:    filepath.Join(getStorePath(workdir), codegenCacheFilename),
+		Entries: map[string]codegenCacheEntry{},
+	}
+	data, err := os.ReadFile(cache.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cache, nil
+		}
+		return nil, errors.Wrap(err, "failed to read codegen cache")
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		// a corrupted cache only costs a full regeneration
+		return &codegenCache{path: cache.path, Entries: map[string]codegenCacheEntry{}}, nil
+	}
+	if cache.Entries == nil {
+		cache.Entries = map[string]codegenCacheEntry{}
+	}
+	return cache, nil
+}
+
+func (c *codegenCache) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal codegen cache")
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create codegen cache directory")
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write codegen cache")
+	}
+	return nil
+}
+
+// run calls gen unless the hashes of inputs and outputs match the cached ones. settings
+// are hashed with the inputs, so a config change also triggers generation. With force,
+// gen always runs and the cache is refreshed. A nil cache always runs gen.
+func (c *codegenCache) run(workdir, key string, settings any, inputs, outputs []string, gen func() error) error {
+	if c == nil {
+		return gen()
+	}
+
+	fresh, inputsHash, err := c.check(workdir, key, settings, inputs, outputs)
+	if err != nil {
+		return err
+	}
+	if fresh {
+		return nil
+	}
+
+	delete(c.Entries, key)
+	if err := gen(); err != nil {
+		return err
+	}
+	outputHash, err := hashPaths(workdir, outputs)
+	if err != nil {
+		return err
+	}
+	c.Entries[key] = codegenCacheEntry{Inputs: inputsHash, Output: outputHash}
+	return nil
+}
+
+// upToDate reports whether run would skip the generator.
+func (c *codegenCache) upToDate(workdir, key string, settings any, inputs, outputs []string) (bool, error) {
+	if c == nil {
+		return false, nil
+	}
+	fresh, _, err := c.check(workdir, key, settings, inputs, outputs)
+	return fresh, err
+}
+
+// check compares the hashes of inputs and outputs with the cached ones, it also returns the
+// inputs hash to store after generating.
+func (c *codegenCache) check(workdir, key string, settings any, inputs, outputs []string) (bool, string, error) {
+	inputsHash, err := hashInputs(workdir, settings, inputs)
+	if err != nil {
+		return false, "", err
+	}
+	entry, ok := c.Entries[key]
+	if !ok || c.force || entry.Inputs != inputsHash {
+		return false, inputsHash, nil
+	}
+	outputHash, err := hashPaths(workdir, outputs)
+	if err != nil {
+		return false, "", err
+	}
+	return entry.Output == outputHash, inputsHash, nil
+}
+
+func hashInputs(workdir string, settings any, paths []string) (string, error) {
+	h := sha256.New()
+	encodedSettings, err := json.Marshal(settings)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode generator settings")
+	}
+	fmt.Fprintf(h, "version:%s\nsettings:%s\n", version, encodedSettings)
+	if err := writePathsHash(h, workdir, paths); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashPaths(workdir string, paths []string) (string, error) {
+	h := sha256.New()
+	if err := writePathsHash(h, workdir, paths); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writePathsHash feeds the name and content of every file under paths into h. Missing
+// paths are hashed as missing rather than failing, the generator reports them.
+func writePathsHash(h hash.Hash, workdir string, paths []string) error {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		root := path
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(workdir, path)
+		}
+
+		var files []string
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			if os.IsNotExist(err) {
+				fmt.Fprintf(h, "missing:%s\n", path)
+				continue
+			}
+			return errors.Wrapf(err, "failed to walk %s", path)
+		}
+		sort.Strings(files)
+
+		for _, file := range files {
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get relative path of %s", file)
+			}
+			fmt.Fprintf(h, "file:%s/%s\n", path, filepath.ToSlash(rel))
+			if err := hashFile(h, file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hashFile(h hash.Hash, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const cacheTestTaskDef = `tasks:
+  - name: ping
+    parameters:
+      type: object
+      required: [target]
+      properties:
+        target:
+          type: string
+`
+
+func writeCacheTestProject(t *testing.T) string {
+	t.Helper()
+	workdir := t.TempDir()
+	configYAML := `task-handler:
+  path: tasks.yaml
+  package: taskgen
+  out: gen/runner_gen.go
+`
+	if err := os.WriteFile(filepath.Join(workdir, "anclax.yaml"), []byte(configYAML), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workdir, "tasks.yaml"), []byte(cacheTestTaskDef), 0644); err != nil {
+		t.Fatalf("write tasks: %v", err)
+	}
+	return workdir
+}
+
+// markGenerated overwrites the generated file so a later run shows whether it regenerated.
+func markGenerated(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("marker"), 0644); err != nil {
+		t.Fatalf("write marker: %v", err)
+	}
+}
+
+func TestCodegenSkipsUnchangedGenerator(t *testing.T) {
+	workdir := writeCacheTestProject(t)
+	out := filepath.Join(workdir, "gen", "runner_gen.go")
+
+	if err := codegen("anclax.yaml", workdir, false); err != nil {
+		t.Fatalf("codegen: %v", err)
+	}
+	first, err := os.Stat(out)
+	if err != nil {
+		t.Fatalf("stat output: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(out, past, past); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	if err := codegen("anclax.yaml", workdir, false); err != nil {
+		t.Fatalf("codegen: %v", err)
+	}
+	second, err := os.Stat(out)
+	if err != nil {
+		t.Fatalf("stat output: %v", err)
+	}
+	if !second.ModTime().Equal(past) || second.Size() != first.Size() {
+		t.Fatalf("unchanged inputs should skip generation, mtime = %v, want %v", second.ModTime(), past)
+	}
+}
+
+func TestCodegenRegeneratesChangedInputs(t *testing.T) {
+	workdir := writeCacheTestProject(t)
+	out := filepath.Join(workdir, "gen", "runner_gen.go")
+
+	if err := codegen("anclax.yaml", workdir, false); err != nil {
+		t.Fatalf("codegen: %v", err)
+	}
+
+	modified := strings.Replace(cacheTestTaskDef, "name: ping", "name: pong", 1)
+	if err := os.WriteFile(filepath.Join(workdir, "tasks.yaml"), []byte(modified), 0644); err != nil {
+		t.Fatalf("write tasks: %v", err)
+	}
+	if err := codegen("anclax.yaml", workdir, false); err != nil {
+		t.Fatalf("codegen: %v", err)
+	}
+	code, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if !strings.Contains(string(code), "type PongParameters struct {") {
+		t.Fatalf("modified spec should regenerate, got:\n%s", code)
+	}
+}
+
+func TestCodegenRegeneratesModifiedOutputAndForce(t *testing.T) {
+	workdir := writeCacheTestProject(t)
+	out := filepath.Join(workdir, "gen", "runner_gen.go")
+
+	if err := codegen("anclax.yaml", workdir, false); err != nil {
+		t.Fatalf("codegen: %v", err)
+	}
+
+	// a hand-edited output is regenerated
+	markGenerated(t, out)
+	if err := codegen("anclax.yaml", workdir, false); err != nil {
+		t.Fatalf("codegen: %v", err)
+	}
+	if code, _ := os.ReadFile(out); string(code) == "marker" {
+		t.Fatal("modified output should regenerate")
+	}
+
+	// force regenerates even when nothing changed
+	if err := os.Chtimes(out, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if err := codegen("anclax.yaml", workdir, true); err != nil {
+		t.Fatalf("codegen: %v", err)
+	}
+	info, err := os.Stat(out)
+	if err != nil {
+		t.Fatalf("stat output: %v", err)
+	}
+	if time.Since(info.ModTime()) > time.Minute {
+		t.Fatal("force should regenerate")
+	}
+}
+
+func TestCodegenDoesNotCleanUpToDateOutputs(t *testing.T) {
+	workdir := writeCacheTestProject(t)
+	configYAML := `clean:
+  - gen/*
+task-handler:
+  path: tasks.yaml
+  package: taskgen
+  out: gen/runner_gen.go
+`
+	if err := os.WriteFile(filepath.Join(workdir, "anclax.yaml"), []byte(configYAML), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	out := filepath.Join(workdir, "gen", "runner_gen.go")
+	stale := filepath.Join(workdir, "gen", "stale_gen.go")
+
+	if err := codegen("anclax.yaml", workdir, false); err != nil {
+		t.Fatalf("codegen: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(out, past, past); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if err := os.WriteFile(stale, []byte("package taskgen\n"), 0644); err != nil {
+		t.Fatalf("write stale file: %v", err)
+	}
+
+	if err := codegen("anclax.yaml", workdir, false); err != nil {
+		t.Fatalf("codegen: %v", err)
+	}
+	info, err := os.Stat(out)
+	if err != nil {
+		t.Fatalf("up-to-date output should be kept: %v", err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Fatalf("up-to-date output should not regenerate, mtime = %v, want %v", info.ModTime(), past)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("other matches of the clean items should still be cleaned, stat err = %v", err)
+	}
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"embed"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/cloudcarver/anclax"
 	dst_codegen "github.com/cloudcarver/anclax/lib/dst"
@@ -24,6 +27,14 @@ var genCmd = &cli.Command{
 			Usage: "Path to the config file",
 			Value: "anclax.yaml",
 		},
+		&cli.BoolFlag{
+			Name:  "stdout",
+			Usage: "Print the output of the in-process generators (oapi, task handler, dst) to stdout instead of writing files",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "Run every generator even if its inputs and output are unchanged since the last run",
+		},
 	},
 	Action: runGen,
 }
@@ -108,7 +119,7 @@ func runClean(c *cli.Context) error {
 	}
 	defer os.RemoveAll(tempDir)
 
-	return clean(tempDir, config, workdir)
+	return clean(tempDir, config, workdir, nil)
 }
 
 func genTaskHandler(workdir string, config *TaskHandlerConfig, schemasConfig *SchemasConfig) error {
@@ -183,10 +194,15 @@ func runGen(c *cli.Context) error {
 	if workdir == "" {
 		workdir = "."
 	}
-	return codegen(c.String("config"), c.Args().First())
+	if c.Bool("stdout") {
+		return codegenToWriter(c.String("config"), c.Args().First(), os.Stdout)
+	}
+	return codegen(c.String("config"), c.Args().First(), c.Bool("force"))
 }
 
-func clean(tempDir string, config *Config, workdir string) error {
+// clean moves the files matching the clean items of the config to tempDir. Matches that
+// overlap one of the keep paths are left in place.
+func clean(tempDir string, config *Config, workdir string, keep []string) error {
 	for _, pattern := range config.CleanItems {
 		matches, err := filepath.Glob(filepath.Join(workdir, pattern))
 		if err != nil {
@@ -194,6 +210,10 @@ func clean(tempDir string, config *Config, workdir string) error {
 		}
 
 		for _, match := range matches {
+			if overlapsAny(workdir, match, keep) {
+				continue
+			}
+
 			// Create target directory in temp folder with the same relative structure
 			relPath, err := filepath.Rel(workdir, match)
 			if err != nil {
@@ -215,6 +235,27 @@ func clean(tempDir string, config *Config, workdir string) error {
 	return nil
 }
 
+// overlapsAny reports whether path equals, contains or is inside one of paths.
+func overlapsAny(workdir, path string, paths []string) bool {
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(workdir, p)
+		}
+		if isWithin(path, p) || isWithin(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 func restore(tempDir string, config *Config, workdir string) error {
 	return filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -246,18 +287,20 @@ func restore(tempDir string, config *Config, workdir string) error {
 	})
 }
 
-func codegen(configPath string, workdir string) error {
+// codegen runs the configured generators. Unless force is set, the in-process generators
+// (schemas, oapi, task handler, dst) are skipped when their inputs and output are unchanged.
+func codegen(configPath string, workdir string, force bool) error {
 	tempDir, err := os.MkdirTemp("", "anclax-codegen-")
 	if err != nil {
 		return errors.Wrap(err, "failed to create temporary directory")
 	}
 	defer os.RemoveAll(tempDir)
 
-	preCodegen := func(config *Config) error {
+	preCodegen := func(config *Config, keep []string) error {
 		if len(config.CleanItems) == 0 {
 			return nil
 		}
-		if err := clean(tempDir, config, workdir); err != nil {
+		if err := clean(tempDir, config, workdir, keep); err != nil {
 			return errors.Wrap(err, "failed to clean")
 		}
 		return nil
@@ -284,13 +327,26 @@ func codegen(configPath string, workdir string) error {
 		return errors.Wrap(err, "failed to parse config")
 	}
 
-	// pre-codegen
-	if err := preCodegen(config); err != nil {
+	cache, err := loadCodegenCache(workdir)
+	if err != nil {
+		return err
+	}
+	cache.force = force
+
+	// pre-codegen, outputs the cache will skip are not cleaned
+	keep, err := upToDateOutputs(config, workdir, cache)
+	if err != nil {
+		return err
+	}
+	if err := preCodegen(config, keep); err != nil {
 		return errors.Wrap(err, "failed to pre-codegen")
 	}
 
 	// codegen
-	codegenErr := _codegen(config, workdir)
+	codegenErr := _codegen(config, workdir, cache)
+	if err := cache.save(); err != nil && codegenErr == nil {
+		codegenErr = err
+	}
 
 	// post-codegen
 	if err := postCodegen(config, codegenErr); err != nil {
@@ -300,28 +356,97 @@ func codegen(configPath string, workdir string) error {
 	return codegenErr
 }
 
-func _codegen(config *Config, workdir string) error {
+// cachedGenerator is an in-process generator whose runs are skipped by the codegen cache.
+type cachedGenerator struct {
+	name     string
+	key      string
+	settings any
+	inputs   []string
+	outputs  []string
+	gen      func() error
+}
+
+func cachedGenerators(config *Config, workdir string) []cachedGenerator {
+	var gens []cachedGenerator
+	var schemasPath string
 	if config.Schemas != nil {
-		if err := genSchemas(workdir, config.Schemas); err != nil {
-			return errors.Wrap(err, "failed to generate schemas")
-		}
+		schemasPath = config.Schemas.Path
+		gens = append(gens, cachedGenerator{
+			name:     "schemas",
+			key:      "schemas",
+			settings: config.Schemas,
+			inputs:   []string{config.Schemas.Path},
+			outputs:  []string{config.Schemas.Output},
+			gen: func() error {
+				return genSchemas(workdir, config.Schemas)
+			},
+		})
 	}
 
 	for i := range config.OapiCodegen {
-		if err := genOapi(workdir, &config.OapiCodegen[i], config.Schemas); err != nil {
-			return errors.Wrapf(err, "failed to generate oapi-codegen[%d]", i)
-		}
+		c := &config.OapiCodegen[i]
+		gens = append(gens, cachedGenerator{
+			name:     fmt.Sprintf("oapi-codegen[%d]", i),
+			key:      "oapi-codegen:" + c.Out,
+			settings: c,
+			inputs:   []string{c.Path, schemasPath},
+			outputs:  []string{c.Out},
+			gen: func() error {
+				return genOapi(workdir, c, config.Schemas)
+			},
+		})
 	}
 
 	for i := range config.TaskHandler {
-		if err := genTaskHandler(workdir, &config.TaskHandler[i], config.Schemas); err != nil {
-			return errors.Wrapf(err, "failed to generate task-handler[%d]", i)
-		}
+		c := &config.TaskHandler[i]
+		gens = append(gens, cachedGenerator{
+			name:     fmt.Sprintf("task-handler[%d]", i),
+			key:      "task-handler:" + c.Out,
+			settings: c,
+			inputs:   []string{c.Path, schemasPath},
+			outputs:  []string{c.Out},
+			gen: func() error {
+				return genTaskHandler(workdir, c, config.Schemas)
+			},
+		})
 	}
 
 	for i := range config.DST {
-		if err := genDST(workdir, &config.DST[i]); err != nil {
-			return errors.Wrapf(err, "failed to generate dst[%d]", i)
+		c := &config.DST[i]
+		gens = append(gens, cachedGenerator{
+			name:     fmt.Sprintf("dst[%d]", i),
+			key:      "dst:" + c.Out,
+			settings: c,
+			inputs:   []string{c.Path},
+			outputs:  []string{c.Out},
+			gen: func() error {
+				return genDST(workdir, c)
+			},
+		})
+	}
+	return gens
+}
+
+// upToDateOutputs returns the outputs of the generators the cache will skip, cleaning them
+// would make the cache miss.
+func upToDateOutputs(config *Config, workdir string, cache *codegenCache) ([]string, error) {
+	var outputs []string
+	for _, g := range cachedGenerators(config, workdir) {
+		fresh, err := cache.upToDate(workdir, g.key, g.settings, g.inputs, g.outputs)
+		if err != nil {
+			return nil, err
+		}
+		if fresh {
+			outputs = append(outputs, g.outputs...)
+		}
+	}
+	return outputs, nil
+}
+
+func _codegen(config *Config, workdir string, cache *codegenCache) error {
+	for _, g := range cachedGenerators(config, workdir) {
+		if err := cache.run(workdir, g.key, g.settings, g.inputs, g.outputs, g.gen); err != nil {
+			return errors.Wrapf(err, "failed to generate %s", g.name)
 		}
 	}
 
@@ -358,6 +483,75 @@ func _codegen(config *Config, workdir string) error {
 	return nil
 }
 
+// codegenToWriter renders the in-process generators to w without touching the
+// filesystem. Generators backed by external tools (sqlc, mockgen, wire) are skipped.
+func codegenToWriter(configPath string, workdir string, w io.Writer) error {
+	config, err := parseConfig(filepath.Join(workdir, configPath))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse config")
+	}
+
+	var schemaCfg *schema_codegen.Config
+	if config.Schemas != nil {
+		schemaCfg = &schema_codegen.Config{Path: config.Schemas.Path, Output: config.Schemas.Output}
+	}
+
+	for i, oapiConfig := range config.OapiCodegen {
+		code, err := oapi_codegen.GenerateSource(workdir, oapi_codegen.Config{
+			Path:    oapiConfig.Path,
+			Out:     oapiConfig.Out,
+			Package: oapiConfig.Package,
+			Schemas: schemaCfg,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate oapi-codegen[%d]", i)
+		}
+		if err := writeGenerated(w, oapiConfig.Out, code); err != nil {
+			return err
+		}
+	}
+
+	for i, taskConfig := range config.TaskHandler {
+		code, err := task_codegen.GenerateSource(workdir, taskConfig.Package, taskConfig.Path, schemaCfg)
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate task-handler[%d]", i)
+		}
+		if err := writeGenerated(w, taskConfig.Out, code); err != nil {
+			return err
+		}
+	}
+
+	for i, dstConfig := range config.DST {
+		specPath := dstConfig.Path
+		if !filepath.IsAbs(specPath) {
+			specPath = filepath.Join(workdir, dstConfig.Path)
+		}
+		spec, err := dst_codegen.LoadHybridSpecFromFile(specPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load dst[%d] spec", i)
+		}
+		if err := dst_codegen.ValidateHybridSpec(spec); err != nil {
+			return errors.Wrapf(err, "failed to validate dst[%d] spec", i)
+		}
+		code, err := dst_codegen.GenerateHybridGo(spec, dstConfig.Package)
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate dst[%d]", i)
+		}
+		if err := writeGenerated(w, dstConfig.Out, code); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeGenerated(w io.Writer, path, code string) error {
+	if _, err := fmt.Fprintf(w, "// ===== %s =====\n%s\n", path, code); err != nil {
+		return errors.Wrapf(err, "failed to write generated code for %s", path)
+	}
+	return nil
+}
+
 func command(name string) string {
 	return filepath.Join(storePath, binDir, name)
 }
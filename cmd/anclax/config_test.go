@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -119,3 +121,44 @@ sqlc:
 		t.Fatalf("sqlc[1].path = %q, want %q", got, "dev/sqlc-admin.yaml")
 	}
 }
+
+func TestCodegenToWriterDoesNotWriteFiles(t *testing.T) {
+	workdir := t.TempDir()
+	configYAML := `task-handler:
+  path: tasks.yaml
+  package: taskgen
+  out: gen/runner_gen.go
+sqlc:
+  path: dev/sqlc.yaml
+`
+	taskDef := `tasks:
+  - name: ping
+    parameters:
+      type: object
+      required: [target]
+      properties:
+        target:
+          type: string
+`
+	if err := os.WriteFile(filepath.Join(workdir, "anclax.yaml"), []byte(configYAML), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workdir, "tasks.yaml"), []byte(taskDef), 0644); err != nil {
+		t.Fatalf("write tasks: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := codegenToWriter("anclax.yaml", workdir, &out); err != nil {
+		t.Fatalf("codegen to writer: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "// ===== gen/runner_gen.go =====") {
+		t.Fatalf("output missing file header, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "type PingParameters struct {") {
+		t.Fatalf("output missing generated task parameters")
+	}
+	if _, err := os.Stat(filepath.Join(workdir, "gen")); !os.IsNotExist(err) {
+		t.Fatalf("stdout mode should not create output directory, stat err = %v", err)
+	}
+}
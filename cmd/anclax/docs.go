@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -38,6 +40,10 @@ var docsCmd = &cli.Command{
 					Name:  "yaml",
 					Usage: "output yaml sample",
 				},
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "output json for tooling",
+				},
 				&cli.StringFlag{
 					Name:  "prefix",
 					Usage: "prefix for environment variables",
@@ -112,10 +118,10 @@ func runGenWithTemplate(c *cli.Context) error {
 }
 
 func runGenConfigDocs(c *cli.Context) error {
-	return genConfigDocs(c.String("path"), c.Bool("markdown"), c.Bool("env"), c.Bool("yaml"), c.String("prefix"), c.String("struct"))
+	return genConfigDocs(c.String("path"), c.Bool("markdown"), c.Bool("env"), c.Bool("yaml"), c.Bool("json"), c.String("prefix"), c.String("struct"))
 }
 
-func genConfigDocs(path string, markdown, env, yaml bool, prefix string, structName string) error {
+func genConfigDocs(path string, markdown, env, yaml, jsonOutput bool, prefix string, structName string) error {
 	configStructName := "Config"
 	if structName != "" {
 		configStructName = structName
@@ -129,7 +135,15 @@ func genConfigDocs(path string, markdown, env, yaml bool, prefix string, structN
 		return errors.New("yaml and env flags cannot be used together")
 	}
 
-	if !yaml && !env {
+	if jsonOutput && yaml {
+		return errors.New("json and yaml flags cannot be used together")
+	}
+
+	if jsonOutput && env {
+		return errors.New("json and env flags cannot be used together")
+	}
+
+	if !yaml && !env && !jsonOutput {
 		env = true // default to env output
 	}
 
@@ -218,7 +232,9 @@ func genConfigDocs(path string, markdown, env, yaml bool, prefix string, structN
 		processFieldWithResolver(field, nil, &vars, typeResolver)
 	}
 
-	if yaml {
+	if jsonOutput {
+		return printJSON(prefix, vars)
+	} else if yaml {
 		printYAMLSample(prefix, vars)
 	} else if env {
 		if markdown {
@@ -384,6 +400,7 @@ func (tr *TypeResolver) extractFieldsFromASTStruct(structType *ast.StructType) [
 				Name:    yamlName,
 				Type:    fieldType,
 				Comment: comment,
+				Default: extractDefaultValue(field.Tag),
 			})
 		}
 	}
@@ -417,6 +434,14 @@ func extractYAMLFieldName(tag, defaultName string) string {
 	return strings.ToLower(defaultName)
 }
 
+// extractDefaultValue extracts the declared default value from the `default` struct tag
+func extractDefaultValue(tag *ast.BasicLit) string {
+	if tag == nil {
+		return ""
+	}
+	return reflect.StructTag(strings.Trim(tag.Value, "`")).Get("default")
+}
+
 // shouldExpandExternalType determines if we should try to expand an external type
 func (tr *TypeResolver) shouldExpandExternalType(typeStr string) bool {
 	// Remove pointer prefix
@@ -486,6 +511,7 @@ type Field struct {
 	Name    string
 	Type    string
 	Comment string
+	Default string
 }
 
 // EnvVar represents an environment variable derived from a config field
@@ -501,14 +527,20 @@ func (e EnvVar) Path(prefix string) string {
 	for _, field := range e.Chain {
 		parts = append(parts, field.Name)
 	}
+	if _, ok := mapValueType(e.LastField().Type); ok {
+		parts = append(parts, mapKeyPlaceholder)
+	}
 	return strings.ToUpper(strings.Join(parts, "_"))
 }
 
 func (e EnvVar) YAMLPath() string {
-	parts := make([]string, len(e.Chain))
+	parts := make([]string, len(e.Chain), len(e.Chain)+1)
 	for i, field := range e.Chain {
 		parts[i] = field.Name
 	}
+	if _, ok := mapValueType(e.LastField().Type); ok {
+		parts = append(parts, mapKeyPlaceholder)
+	}
 	return strings.Join(parts, ".")
 }
 
@@ -528,11 +560,40 @@ func getTypeString(expr ast.Expr) string {
 		return "*" + getTypeString(t.X)
 	case *ast.SelectorExpr:
 		return fmt.Sprintf("%s.%s", t.X.(*ast.Ident).Name, t.Sel.Name)
+	case *ast.ArrayType:
+		return "[]" + getTypeString(t.Elt)
+	case *ast.MapType:
+		return fmt.Sprintf("map[%s]%s", getTypeString(t.Key), getTypeString(t.Value))
 	default:
 		return fmt.Sprintf("%T", expr)
 	}
 }
 
+// mapKeyPlaceholder stands for the user-chosen key of a map field in env and yaml paths
+const mapKeyPlaceholder = "<key>"
+
+// sliceElemType returns the element type of a slice type string like []string
+func sliceElemType(fieldType string) (string, bool) {
+	baseType := strings.TrimPrefix(fieldType, "*")
+	if !strings.HasPrefix(baseType, "[]") {
+		return "", false
+	}
+	return strings.TrimPrefix(baseType, "[]"), true
+}
+
+// mapValueType returns the value type of a map type string like map[string]int
+func mapValueType(fieldType string) (string, bool) {
+	baseType := strings.TrimPrefix(fieldType, "*")
+	if !strings.HasPrefix(baseType, "map[") {
+		return "", false
+	}
+	i := strings.Index(baseType, "]")
+	if i == -1 {
+		return "", false
+	}
+	return baseType[i+1:], true
+}
+
 // processStructFieldsWithResolver recursively processes struct fields with type resolution
 func processStructFieldsWithResolver(field ast.Expr, chain []Field, vars *[]EnvVar, resolver *TypeResolver) {
 	switch t := field.(type) {
@@ -616,6 +677,9 @@ func processStructFieldsWithResolver(field ast.Expr, chain []Field, vars *[]EnvV
 		for _, f := range t.Fields.List {
 			processFieldWithResolver(f, chain, vars, resolver)
 		}
+	case *ast.ArrayType, *ast.MapType:
+		// Slices and maps are documented as a single entry, their element type is not expanded
+		*vars = append(*vars, EnvVar{Chain: chain})
 	}
 }
 
@@ -649,6 +713,7 @@ func processFieldWithResolver(field *ast.Field, parentChain []Field, vars *[]Env
 		Name:    fieldName,
 		Type:    getTypeString(field.Type),
 		Comment: comment,
+		Default: extractDefaultValue(field.Tag),
 	}
 	chain := make([]Field, len(parentChain))
 	copy(chain, parentChain)
@@ -659,6 +724,12 @@ func processFieldWithResolver(field *ast.Field, parentChain []Field, vars *[]Env
 
 // getEnvExampleValue returns an example value for environment variables based on the type
 func getEnvExampleValue(fieldType string) string {
+	if elemType, ok := sliceElemType(fieldType); ok {
+		return "list of " + getEnvExampleValue(elemType)
+	}
+	if valueType, ok := mapValueType(fieldType); ok {
+		return getEnvExampleValue(valueType)
+	}
 	baseType := strings.TrimPrefix(fieldType, "*")
 	switch {
 	case baseType == "string":
@@ -674,6 +745,14 @@ func getEnvExampleValue(fieldType string) string {
 	}
 }
 
+// getFieldValue returns the declared default of a field, or an example value based on its type
+func getFieldValue(field Field) string {
+	if field.Default != "" {
+		return field.Default
+	}
+	return getEnvExampleValue(field.Type)
+}
+
 func printEnvText(prefix string, vars []EnvVar) {
 	fmt.Println("Environment variable paths:")
 	fmt.Println("NAME                           VALUE           DESCRIPTION")
@@ -681,9 +760,9 @@ func printEnvText(prefix string, vars []EnvVar) {
 	for _, v := range vars {
 		lastField := v.LastField()
 		if lastField.Comment != "" {
-			fmt.Printf("%-30s %-15s // %s\n", v.Path(prefix), getEnvExampleValue(lastField.Type), lastField.Comment)
+			fmt.Printf("%-30s %-15s // %s\n", v.Path(prefix), getFieldValue(lastField), lastField.Comment)
 		} else {
-			fmt.Printf("%-30s %s\n", v.Path(prefix), getEnvExampleValue(lastField.Type))
+			fmt.Printf("%-30s %s\n", v.Path(prefix), getFieldValue(lastField))
 		}
 	}
 }
@@ -697,7 +776,7 @@ func printEnvMarkdown(prefix string, vars []EnvVar) {
 		if comment == "" {
 			comment = "-"
 		}
-		fmt.Printf("| `%s` | `%s` | %s |\n", v.Path(prefix), getEnvExampleValue(lastField.Type), comment)
+		fmt.Printf("| `%s` | `%s` | %s |\n", v.Path(prefix), getFieldValue(lastField), comment)
 	}
 }
 
@@ -712,8 +791,14 @@ func printYAMLSample(prefix string, vars []EnvVar) {
 		indent := ""
 		for i, part := range parts {
 			if i == len(parts)-1 {
+				lastField := v.LastField()
+				if elemType, ok := sliceElemType(lastField.Type); ok && lastField.Default == "" {
+					// Slices are printed as a list with a single sample element
+					fmt.Printf("%s%s:\n%s  - %s\n", indent, part, indent, getEnvExampleValue(elemType))
+					continue
+				}
 				// Last part - print with a sample value based on type
-				fmt.Printf("%s%s: %s\n", indent, part, getEnvExampleValue(v.LastField().Type))
+				fmt.Printf("%s%s: %s\n", indent, part, getFieldValue(lastField))
 			} else {
 				if current != "" {
 					current += "."
@@ -728,3 +813,35 @@ func printYAMLSample(prefix string, vars []EnvVar) {
 		}
 	}
 }
+
+// JSONEnvVar is the machine-readable description of a config field printed by --json
+type JSONEnvVar struct {
+	Path        string `json:"path"`
+	YAMLPath    string `json:"yamlPath"`
+	Type        string `json:"type"`
+	Example     string `json:"example"`
+	Description string `json:"description"`
+	Default     string `json:"default,omitempty"`
+}
+
+func printJSON(prefix string, vars []EnvVar) error {
+	out := make([]JSONEnvVar, 0, len(vars))
+	for _, v := range vars {
+		lastField := v.LastField()
+		out = append(out, JSONEnvVar{
+			Path:        v.Path(prefix),
+			YAMLPath:    v.YAMLPath(),
+			Type:        lastField.Type,
+			Example:     getEnvExampleValue(lastField.Type),
+			Description: lastField.Comment,
+			Default:     lastField.Default,
+		})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		return errors.Wrap(err, "failed to encode json")
+	}
+	return nil
+}
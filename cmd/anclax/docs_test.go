@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleConfigSource = `package sample
+
+type Config struct {
+	// The port to listen on
+	Port int ` + "`yaml:\"port\"`" + `
+
+	Pg Pg ` + "`yaml:\"pg\"`" + `
+}
+
+type Pg struct {
+	// The DSN of the database
+	DSN *string ` + "`yaml:\"dsn\"`" + `
+}
+`
+
+func writeSampleConfig(t *testing.T, source string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("write sample config: %v", err)
+	}
+	return dir
+}
+
+func captureStdout(t *testing.T, f func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	runErr := f()
+	os.Stdout = stdout
+	if err := w.Close(); err != nil {
+		t.Fatalf("close pipe: %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read pipe: %v", err)
+	}
+	if runErr != nil {
+		t.Fatalf("run: %v", runErr)
+	}
+	return string(out)
+}
+
+func TestGenConfigDocsJSON(t *testing.T) {
+	dir := writeSampleConfig(t, sampleConfigSource)
+
+	out := captureStdout(t, func() error {
+		return genConfigDocs(dir, false, false, false, true, "myapp", "")
+	})
+
+	var got []JSONEnvVar
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal json output %q: %v", out, err)
+	}
+
+	want := []JSONEnvVar{
+		{Path: "MYAPP_PORT", YAMLPath: "port", Type: "int", Example: "integer", Description: "The port to listen on"},
+		{Path: "MYAPP_PG_DSN", YAMLPath: "pg.dsn", Type: "*string", Example: "string", Description: "The DSN of the database"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("json entries = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("json entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGenConfigDocsJSONExclusiveFlags(t *testing.T) {
+	dir := writeSampleConfig(t, sampleConfigSource)
+
+	if err := genConfigDocs(dir, false, false, true, true, "", ""); err == nil {
+		t.Fatalf("expected error when combining json and yaml")
+	}
+	if err := genConfigDocs(dir, false, true, false, true, "", ""); err == nil {
+		t.Fatalf("expected error when combining json and env")
+	}
+}
+
+func TestGenConfigDocsDefaultValue(t *testing.T) {
+	dir := writeSampleConfig(t, `package sample
+
+type Config struct {
+	// The port to listen on
+	Port int `+"`yaml:\"port\" default:\"8020\"`"+`
+
+	Host string `+"`yaml:\"host\"`"+`
+}
+`)
+
+	cases := map[string]struct {
+		markdown, env, yaml bool
+		want                []string
+	}{
+		"env": {
+			env:  true,
+			want: []string{"MYAPP_PORT                     8020", "MYAPP_HOST                     string"},
+		},
+		"markdown": {
+			markdown: true,
+			env:      true,
+			want:     []string{"| `MYAPP_PORT` | `8020` |", "| `MYAPP_HOST` | `string` |"},
+		},
+		"yaml": {
+			yaml: true,
+			want: []string{"port: 8020\n", "host: string\n"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out := captureStdout(t, func() error {
+				return genConfigDocs(dir, tc.markdown, tc.env, tc.yaml, false, "myapp", "")
+			})
+			for _, want := range tc.want {
+				if !strings.Contains(out, want) {
+					t.Fatalf("output does not contain %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestGenConfigDocsSliceAndMapFields(t *testing.T) {
+	dir := writeSampleConfig(t, `package sample
+
+type Config struct {
+	// Hosts to connect to
+	Hosts []string `+"`yaml:\"hosts\"`"+`
+
+	// Labels attached to the service
+	Labels map[string]string `+"`yaml:\"labels\"`"+`
+}
+`)
+
+	env := captureStdout(t, func() error {
+		return genConfigDocs(dir, false, true, false, false, "myapp", "")
+	})
+	for _, want := range []string{
+		"MYAPP_HOSTS                    list of string  // Hosts to connect to",
+		"MYAPP_LABELS_<KEY>             string          // Labels attached to the service",
+	} {
+		if !strings.Contains(env, want) {
+			t.Fatalf("env output does not contain %q:\n%s", want, env)
+		}
+	}
+
+	yaml := captureStdout(t, func() error {
+		return genConfigDocs(dir, false, false, true, false, "", "")
+	})
+	wantYAML := "hosts:\n  - string\nlabels:\n  <key>: string\n"
+	if yaml != wantYAML {
+		t.Fatalf("yaml output = %q, want %q", yaml, wantYAML)
+	}
+
+	out := captureStdout(t, func() error {
+		return genConfigDocs(dir, false, false, false, true, "myapp", "")
+	})
+	var got []JSONEnvVar
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal json output %q: %v", out, err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("json entries = %d, want 2: %+v", len(got), got)
+	}
+	if got[0].Type != "[]string" || got[1].Type != "map[string]string" {
+		t.Fatalf("json types = %q, %q, want []string, map[string]string", got[0].Type, got[1].Type)
+	}
+}
@@ -112,7 +112,7 @@ func runGenInit(c *cli.Context) error {
 	}
 
 	// run codegen
-	if err := codegen(configName, projectDir); err != nil {
+	if err := codegen(configName, projectDir, false); err != nil {
 		return errors.Wrap(err, "failed to run codegen")
 	}
 
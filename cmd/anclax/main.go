@@ -38,6 +38,7 @@ func main() {
 			initCmd,
 			docsCmd,
 			installCmd,
+			validateCmd,
 			versionCmd,
 			cleanCmd,
 		},
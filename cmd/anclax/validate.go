@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var validateCmd = &cli.Command{
+	Name:  "validate",
+	Usage: "Check the config file without running any generator",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "config",
+			Usage: "Path to the config file",
+			Value: "anclax.yaml",
+		},
+	},
+	Action: runValidate,
+}
+
+func runValidate(c *cli.Context) error {
+	configPath := c.String("config")
+	if configPath == "" {
+		return errors.New("config is required")
+	}
+
+	workdir := c.Args().First()
+	if workdir == "" {
+		workdir = "."
+	}
+
+	if err := validate(workdir, configPath); err != nil {
+		return err
+	}
+	fmt.Println("config is valid")
+	return nil
+}
+
+// validate parses the config and reports all problems found at once.
+func validate(workdir, configPath string) error {
+	config, err := parseConfig(filepath.Join(workdir, configPath))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse config")
+	}
+	problems := validateConfig(workdir, config)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("config %s has %d problem(s):\n  - %s", configPath, len(problems), strings.Join(problems, "\n  - "))
+}
+
+func validateConfig(workdir string, config *Config) []string {
+	v := &configValidator{workdir: workdir}
+
+	externals := make([]string, 0, len(config.Externals))
+	for external := range config.Externals {
+		externals = append(externals, external)
+	}
+	sort.Strings(externals)
+	for _, external := range externals {
+		if _, ok := installMap[external]; !ok {
+			v.addf("externals.%s: unknown external tool", external)
+		}
+	}
+
+	if config.Schemas != nil {
+		v.pathExists("schemas.path", config.Schemas.Path)
+		v.required("schemas.output", config.Schemas.Output)
+	}
+
+	for i, c := range config.OapiCodegen {
+		field := fmt.Sprintf("oapi-codegen[%d]", i)
+		v.pathExists(field+".path", c.Path)
+		v.required(field+".out", c.Out)
+		v.packageName(field+".package", c.Package, true)
+	}
+
+	for i, c := range config.TaskHandler {
+		field := fmt.Sprintf("task-handler[%d]", i)
+		v.pathExists(field+".path", c.Path)
+		v.required(field+".out", c.Out)
+		v.packageName(field+".package", c.Package, true)
+	}
+
+	for i, c := range config.DST {
+		field := fmt.Sprintf("dst[%d]", i)
+		v.pathExists(field+".path", c.Path)
+		v.required(field+".out", c.Out)
+		v.packageName(field+".package", c.Package, false)
+	}
+
+	for i, c := range config.Sqlc {
+		v.pathExists(fmt.Sprintf("sqlc[%d].path", i), c.Path)
+	}
+	if len(config.Sqlc) > 0 {
+		v.toolInstalled(Sqlc)
+	}
+
+	if config.Mockgen != nil {
+		for i, f := range config.Mockgen.Files {
+			field := fmt.Sprintf("mockgen.files[%d]", i)
+			v.pathExists(field+".source", f.Source)
+			v.required(field+".destination", f.Destination)
+			v.packageName(field+".package", f.Package, true)
+		}
+		if len(config.Mockgen.Files) > 0 {
+			v.toolInstalled(Mockgen)
+		}
+	}
+
+	for i, c := range config.Wire {
+		v.pathExists(fmt.Sprintf("wire[%d].path", i), c.Path)
+	}
+	if len(config.Wire) > 0 {
+		v.toolInstalled(Wire)
+	}
+
+	return v.problems
+}
+
+type configValidator struct {
+	workdir  string
+	problems []string
+}
+
+func (v *configValidator) addf(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *configValidator) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(v.workdir, path)
+}
+
+func (v *configValidator) required(field, value string) bool {
+	if value == "" {
+		v.addf("%s: is required", field)
+		return false
+	}
+	return true
+}
+
+func (v *configValidator) pathExists(field, path string) {
+	if !v.required(field, path) {
+		return
+	}
+	if _, err := os.Stat(v.resolve(path)); err != nil {
+		if os.IsNotExist(err) {
+			v.addf("%s: %s does not exist", field, path)
+			return
+		}
+		v.addf("%s: failed to stat %s: %v", field, path, err)
+	}
+}
+
+func (v *configValidator) packageName(field, name string, required bool) {
+	if name == "" {
+		if required {
+			v.addf("%s: is required", field)
+		}
+		return
+	}
+	if !token.IsIdentifier(name) {
+		v.addf("%s: %q is not a valid Go package name", field, name)
+	}
+}
+
+func (v *configValidator) toolInstalled(name string) {
+	if _, err := os.Stat(filepath.Join(v.workdir, storePath, binDir, name)); err != nil {
+		v.addf("externals: %s is not installed, run `anclax install`", name)
+	}
+}
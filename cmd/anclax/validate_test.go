@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProjectFiles(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("create dir for %s: %v", file, err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("write %s: %v", file, err)
+		}
+	}
+}
+
+func TestValidateAcceptsValidConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectFiles(t, dir,
+		"api/openapi/main.yaml",
+		"api/tasks/tasks.yaml",
+		"sql/sqlc.yaml",
+		"app/wire/wire.go",
+		"pkg/model/model.go",
+		".anclax/bin/wire",
+		".anclax/bin/sqlc",
+		".anclax/bin/mockgen",
+	)
+	configYAML := `externals:
+  wire: v0.7.0
+  sqlc: v1.30.0
+  mockgen: v0.6.0
+oapi-codegen:
+  path: api/openapi
+  out: pkg/zgen/apigen/spec_gen.go
+  package: apigen
+wire:
+  path: ./app/wire
+task-handler:
+  path: api/tasks/tasks.yaml
+  package: taskgen
+  out: pkg/zgen/taskgen/taskgen_gen.go
+sqlc:
+  path: sql/sqlc.yaml
+mockgen:
+  files:
+    - source: pkg/model/model.go
+      destination: pkg/model/mock_gen.go
+      package: model
+`
+	if err := os.WriteFile(filepath.Join(dir, "anclax.yaml"), []byte(configYAML), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	if err := validate(dir, "anclax.yaml"); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectFiles(t, dir, "api/tasks/tasks.yaml")
+	configYAML := `externals:
+  protoc: v1.0.0
+oapi-codegen:
+  path: api/openapi
+  out: pkg/zgen/apigen/spec_gen.go
+  package: api-gen
+task-handler:
+  path: api/tasks/tasks.yaml
+  package: type
+sqlc:
+  path: sql/sqlc.yaml
+`
+	if err := os.WriteFile(filepath.Join(dir, "anclax.yaml"), []byte(configYAML), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	err := validate(dir, "anclax.yaml")
+	if err == nil {
+		t.Fatal("validate succeeded, want problems")
+	}
+	for _, want := range []string{
+		"has 7 problem(s)",
+		"externals.protoc: unknown external tool",
+		"oapi-codegen[0].path: api/openapi does not exist",
+		`oapi-codegen[0].package: "api-gen" is not a valid Go package name`,
+		"task-handler[0].out: is required",
+		`task-handler[0].package: "type" is not a valid Go package name`,
+		"sqlc[0].path: sql/sqlc.yaml does not exist",
+		"externals: sqlc is not installed",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %q, want it to contain %q", err.Error(), want)
+		}
+	}
+}
+
+func TestValidateReportsMalformedConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "anclax.yaml"), []byte("wire: path\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	err := validate(dir, "anclax.yaml")
+	if err == nil || !strings.Contains(err.Error(), "wire must be a mapping or sequence") {
+		t.Fatalf("validate error = %v, want wire shape error", err)
+	}
+}
@@ -0,0 +1,71 @@
+//go:build smoke
+// +build smoke
+
+package e2e_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveEventsKeepsRecentAndPendingTaskEvents(t *testing.T) {
+	requireE2E(t)
+
+	ctx := context.Background()
+	anclaxApp := newE2EApplication(t)
+
+	conn, err := pgx.Connect(ctx, e2eDBDSN)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+
+	insertTask := func(status string) int32 {
+		var id int32
+		require.NoError(t, conn.QueryRow(ctx,
+			`INSERT INTO anclax.tasks (attributes, spec, status) VALUES ('{}', '{"type":"e2e","payload":{}}', $1) RETURNING id`,
+			status,
+		).Scan(&id))
+		return id
+	}
+	insertEvent := func(taskID int32, createdAt time.Time) int32 {
+		var id int32
+		require.NoError(t, conn.QueryRow(ctx,
+			`INSERT INTO anclax.events (spec, created_at) VALUES ($1, $2) RETURNING id`,
+			fmt.Sprintf(`{"type":"TaskCompleted","taskCompleted":{"taskID":%d}}`, taskID),
+			createdAt,
+		).Scan(&id))
+		return id
+	}
+
+	now := time.Now()
+	completedTaskID := insertTask("completed")
+	pendingTaskID := insertTask("pending")
+
+	oldEventID := insertEvent(completedTaskID, now.Add(-48*time.Hour))
+	recentEventID := insertEvent(completedTaskID, now.Add(-time.Minute))
+	pendingTaskEventID := insertEvent(pendingTaskID, now.Add(-48*time.Hour))
+
+	archived, err := anclaxApp.GetService().ArchiveEvents(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, archived, int64(1))
+
+	eventExists := func(table string, id int32) bool {
+		var exists bool
+		require.NoError(t, conn.QueryRow(ctx,
+			fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM anclax.%s WHERE id = $1)`, table),
+			id,
+		).Scan(&exists))
+		return exists
+	}
+
+	require.False(t, eventExists("events", oldEventID))
+	require.True(t, eventExists("events_archive", oldEventID))
+	require.True(t, eventExists("events", recentEventID))
+	require.False(t, eventExists("events_archive", recentEventID))
+	require.True(t, eventExists("events", pendingTaskEventID))
+	require.False(t, eventExists("events_archive", pendingTaskEventID))
+}
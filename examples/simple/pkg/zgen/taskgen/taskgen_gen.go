@@ -10,10 +10,10 @@ import (
 	"myexampleapp/pkg/zgen/schemas/counter"
 
 	"github.com/cloudcarver/anclax/core"
-	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
 	taskcore "github.com/cloudcarver/anclax/pkg/taskcore/store"
 	"github.com/cloudcarver/anclax/pkg/taskcore/worker"
 	"github.com/cloudcarver/anclax/pkg/utils"
+	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
 	"github.com/pkg/errors"
 )
 
@@ -21,23 +21,21 @@ func init() {
 	utils.Noop()
 }
 
-const ( 
-	AutoIncrementCounter = "AutoIncrementCounter" 
+const (
+	AutoIncrementCounter = "AutoIncrementCounter"
 
-	IncrementCounter = "IncrementCounter" 
+	IncrementCounter = "IncrementCounter"
 )
 
-
-
-type TaskRunner interface { 
-    // Increment the counter
+type TaskRunner interface {
+	// Increment the counter
 	RunAutoIncrementCounter(ctx context.Context, params *counter.IncrementCounterParams, overrides ...taskcore.TaskOverride) (int32, error)
-    // Increment the counter
+	// Increment the counter
 	RunAutoIncrementCounterWithTx(ctx context.Context, tx core.Tx, params *counter.IncrementCounterParams, overrides ...taskcore.TaskOverride) (int32, error)
 
-    // Increment the counter
+	// Increment the counter
 	RunIncrementCounter(ctx context.Context, params *counter.IncrementCounterParams, overrides ...taskcore.TaskOverride) (int32, error)
-    // Increment the counter
+	// Increment the counter
 	RunIncrementCounterWithTx(ctx context.Context, tx core.Tx, params *counter.IncrementCounterParams, overrides ...taskcore.TaskOverride) (int32, error)
 }
 
@@ -53,7 +51,6 @@ func NewTaskRunner(taskStore taskcore.TaskStoreInterface) TaskRunner {
 	}
 }
 
-
 func (c *Client) RunAutoIncrementCounter(ctx context.Context, params *counter.IncrementCounterParams, overrides ...taskcore.TaskOverride) (int32, error) {
 	return c.runAutoIncrementCounter(ctx, c.taskStore, nil, params, overrides...)
 }
@@ -73,7 +70,7 @@ func (c *Client) runAutoIncrementCounter(ctx context.Context, taskstore taskcore
 		Payload: payload,
 	}
 	attributes := apigen.TaskAttributes{}
-	
+
 	attributes.RetryPolicy = &apigen.TaskRetryPolicy{
 		Interval:    "30m",
 		MaxAttempts: -1,
@@ -81,14 +78,13 @@ func (c *Client) runAutoIncrementCounter(ctx context.Context, taskstore taskcore
 	attributes.Cronjob = &apigen.TaskCronjob{
 		CronExpression: "*/5 * * * * *",
 	}
-	
-	
+
 	task := &apigen.Task{
 		Attributes: attributes,
 		Spec:       spec,
 		Status:     apigen.Pending,
 	}
-	
+
 	for _, override := range overrides {
 		if err := override(task); err != nil {
 			return 0, errors.Wrap(err, "failed to apply task override")
@@ -124,20 +120,18 @@ func (c *Client) runIncrementCounter(ctx context.Context, taskstore taskcore.Tas
 		Payload: payload,
 	}
 	attributes := apigen.TaskAttributes{}
-	
+
 	attributes.RetryPolicy = &apigen.TaskRetryPolicy{
 		Interval:    "30m",
 		MaxAttempts: -1,
 	}
-	
-	
-	
+
 	task := &apigen.Task{
 		Attributes: attributes,
 		Spec:       spec,
 		Status:     apigen.Pending,
 	}
-	
+
 	for _, override := range overrides {
 		if err := override(task); err != nil {
 			return 0, errors.Wrap(err, "failed to apply task override")
@@ -155,16 +149,12 @@ func (c *Client) runIncrementCounter(ctx context.Context, taskstore taskcore.Tas
 	return taskID, nil
 }
 
-
-
-type ExecutorInterface interface { 
-     // Increment the counter
+type ExecutorInterface interface {
+	// Increment the counter
 	ExecuteAutoIncrementCounter(ctx context.Context, task worker.Task, params *counter.IncrementCounterParams) error
- 
 
-     // Increment the counter
+	// Increment the counter
 	ExecuteIncrementCounter(ctx context.Context, task worker.Task, params *counter.IncrementCounterParams) error
- 
 }
 
 type TaskHandler struct {
@@ -194,21 +184,21 @@ func (f *TaskHandler) HandleTask(ctx context.Context, task worker.Task) error {
 		return nil
 	}
 
-	switch task.GetType() { 
+	switch task.GetType() {
 	case AutoIncrementCounter:
 		var params counter.IncrementCounterParams
 		if err := json.Unmarshal(task.GetPayload(), &params); err != nil {
 			return fmt.Errorf("failed to parse AutoIncrementCounter parameters: %w", err)
 		}
 		return f.executor.ExecuteAutoIncrementCounter(ctx, task, &params)
-		
+
 	case IncrementCounter:
 		var params counter.IncrementCounterParams
 		if err := json.Unmarshal(task.GetPayload(), &params); err != nil {
 			return fmt.Errorf("failed to parse IncrementCounter parameters: %w", err)
 		}
 		return f.executor.ExecuteIncrementCounter(ctx, task, &params)
-		
+
 	default:
 		return errors.Wrapf(worker.ErrUnknownTaskType, "unknown task type: %s", task.GetType())
 	}
@@ -226,7 +216,25 @@ func (f *TaskHandler) OnTaskFailed(ctx context.Context, tx core.Tx, failedTaskSp
 	}
 
 	// Call the appropriate OnXXXFailed hook method
-	switch failedTaskSpec.GetType() { 
+	switch failedTaskSpec.GetType() {
+	default:
+		return nil // No hook configured for this task type
+	}
+}
+
+func (f *TaskHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	for _, handler := range f.externalTaskHandler {
+		if err := handler.OnTaskCompleted(ctx, tx, completedTaskSpec, taskID); err != nil {
+			if errors.Is(err, worker.ErrUnknownTaskType) {
+				continue
+			}
+			return err
+		}
+		return nil
+	}
+
+	// Call the appropriate OnXXXCompleted hook method
+	switch completedTaskSpec.GetType() {
 	default:
 		return nil // No hook configured for this task type
 	}
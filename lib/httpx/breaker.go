@@ -0,0 +1,144 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit of its host is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerConfig configures the per-host circuit breaker of an HTTPClient.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures, transport errors or 5xx
+	// responses, that opens the circuit of a host. Zero disables the breaker.
+	FailureThreshold int
+
+	// CoolDown is how long an open circuit fails fast before one probe request is let through.
+	CoolDown time.Duration
+}
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+type hostCircuit struct {
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+type circuitBreaker struct {
+	cfg BreakerConfig
+	now func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*hostCircuit
+}
+
+func newCircuitBreaker(cfg BreakerConfig) *circuitBreaker {
+	return &circuitBreaker{
+		cfg:   cfg,
+		now:   time.Now,
+		hosts: make(map[string]*hostCircuit),
+	}
+}
+
+func (b *circuitBreaker) circuit(host string) *hostCircuit {
+	hc, ok := b.hosts[host]
+	if !ok {
+		hc = &hostCircuit{}
+		b.hosts[host] = hc
+	}
+	return hc
+}
+
+// allow returns ErrCircuitOpen if a request to host must not be sent. Once the cool-down
+// has passed, it lets a single probe through, reported by the returned bool, and moves the
+// circuit to half-open.
+func (b *circuitBreaker) allow(host string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hc := b.circuit(host)
+	switch hc.state {
+	case BreakerOpen:
+		if b.now().Sub(hc.openedAt) < b.cfg.CoolDown {
+			return false, ErrCircuitOpen
+		}
+		hc.state = BreakerHalfOpen
+		hc.probing = true
+		return true, nil
+	case BreakerHalfOpen:
+		if hc.probing {
+			return false, ErrCircuitOpen
+		}
+		hc.probing = true
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// record updates the circuit of host with the outcome of a request let through by allow.
+func (b *circuitBreaker) record(host string, probe bool, res *http.Response, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hc := b.circuit(host)
+	if probe {
+		hc.probing = false
+	} else if hc.state != BreakerClosed {
+		// sent before the circuit opened, only the probe decides how it closes
+		return
+	}
+	// the caller gave up, that says nothing about the upstream
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err == nil && res != nil && res.StatusCode < http.StatusInternalServerError {
+		hc.state = BreakerClosed
+		hc.failures = 0
+		return
+	}
+
+	hc.failures++
+	if hc.state == BreakerHalfOpen || hc.failures >= b.cfg.FailureThreshold {
+		hc.state = BreakerOpen
+		hc.openedAt = b.now()
+	}
+}
+
+func (b *circuitBreaker) state(host string) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hc, ok := b.hosts[host]
+	if !ok {
+		return BreakerClosed
+	}
+	if hc.state == BreakerOpen && b.now().Sub(hc.openedAt) >= b.cfg.CoolDown {
+		return BreakerHalfOpen
+	}
+	return hc.state
+}
@@ -0,0 +1,117 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// switchableDelegate answers with status, or fails with err if it is set.
+type switchableDelegate struct {
+	status int
+	err    error
+	calls  int
+}
+
+func (d *switchableDelegate) Do(req *http.Request) (*http.Response, error) {
+	d.calls++
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &http.Response{StatusCode: d.status, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func TestCircuitBreakerStates(t *testing.T) {
+	delegate := &switchableDelegate{err: errors.New("connection refused")}
+	c := NewHTTPClient("http://upstream.example", delegate)
+	c.SetCircuitBreaker(BreakerConfig{FailureThreshold: 2, CoolDown: time.Minute})
+	now := time.Now()
+	c.breaker.now = func() time.Time { return now }
+
+	do := func() error {
+		res, err := c.Get(context.Background(), "/ping").Do()
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	// closed: failures below the threshold are sent
+	require.Error(t, do())
+	require.Equal(t, BreakerClosed, c.CircuitState("upstream.example"))
+	require.Error(t, do())
+	require.Equal(t, BreakerOpen, c.CircuitState("upstream.example"))
+
+	// open: fail fast without calling the upstream
+	require.ErrorIs(t, do(), ErrCircuitOpen)
+	require.Equal(t, 2, delegate.calls)
+
+	// half-open: the probe fails and the circuit opens again
+	now = now.Add(time.Minute)
+	require.Equal(t, BreakerHalfOpen, c.CircuitState("upstream.example"))
+	require.Error(t, do())
+	require.Equal(t, 3, delegate.calls)
+	require.Equal(t, BreakerOpen, c.CircuitState("upstream.example"))
+	require.ErrorIs(t, do(), ErrCircuitOpen)
+
+	// half-open: a successful probe closes the circuit
+	now = now.Add(time.Minute)
+	delegate.err = nil
+	delegate.status = http.StatusOK
+	require.NoError(t, do())
+	require.Equal(t, BreakerClosed, c.CircuitState("upstream.example"))
+	require.NoError(t, do())
+	require.Equal(t, 5, delegate.calls)
+}
+
+func TestCircuitBreakerCountsServerErrors(t *testing.T) {
+	delegate := &switchableDelegate{status: http.StatusServiceUnavailable}
+	c := NewHTTPClient("http://upstream.example", delegate)
+	c.SetCircuitBreaker(BreakerConfig{FailureThreshold: 1, CoolDown: time.Minute})
+
+	res, err := c.Get(context.Background(), "/ping").Do()
+	require.NoError(t, err)
+	res.Body.Close()
+
+	_, err = c.Get(context.Background(), "/ping").Do()
+	require.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestCircuitBreakerAllowsOneProbe(t *testing.T) {
+	b := newCircuitBreaker(BreakerConfig{FailureThreshold: 1, CoolDown: time.Minute})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	b.record("host", false, nil, errors.New("boom"))
+	now = now.Add(time.Minute)
+
+	probe, err := b.allow("host")
+	require.NoError(t, err)
+	require.True(t, probe)
+	_, err = b.allow("host")
+	require.ErrorIs(t, err, ErrCircuitOpen, "only one probe at a time")
+
+	// a canceled probe lets the next request probe again
+	b.record("host", true, nil, context.Canceled)
+	probe, err = b.allow("host")
+	require.NoError(t, err)
+	require.True(t, probe)
+}
+
+func TestCircuitBreakerDisabledByDefault(t *testing.T) {
+	delegate := &switchableDelegate{err: errors.New("connection refused")}
+	c := NewHTTPClient("http://upstream.example", delegate)
+
+	for range 5 {
+		_, err := c.Get(context.Background(), "/ping").Do()
+		require.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+	require.Equal(t, 5, delegate.calls)
+	require.Equal(t, BreakerClosed, c.CircuitState("upstream.example"))
+}
@@ -0,0 +1,280 @@
+package httpx
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCachedBodySize caps the body of a cacheable response, larger responses are not cached.
+const maxCachedBodySize = 1 << 20
+
+// CacheConfig configures the response cache of an HTTPClient.
+type CacheConfig struct {
+	// MaxEntries bounds the number of cached responses, the least recently used one is
+	// evicted first. Zero disables the cache.
+	MaxEntries int
+
+	// TTL drops cached responses older than this, zero keeps them until they are evicted.
+	TTL time.Duration
+}
+
+type cacheEntry struct {
+	key          string
+	url          string
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+	storedAt     time.Time
+}
+
+// setConditions turns the request into a conditional one, unless the caller did already.
+func (e *cacheEntry) setConditions(h http.Header) {
+	if e.etag != "" && h.Get("If-None-Match") == "" {
+		h.Set("If-None-Match", e.etag)
+	}
+	if e.lastModified != "" && h.Get("If-Modified-Since") == "" {
+		h.Set("If-Modified-Since", e.lastModified)
+	}
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// urlVary holds the request headers the last cacheable response of a URL varied on, and
+// how many entries of the URL are cached.
+type urlVary struct {
+	headers []string
+	entries int
+}
+
+// responseCache keeps the bodies of GET responses carrying an ETag or Last-Modified
+// validator, keyed by URL and the values of the request headers listed in Vary.
+type responseCache struct {
+	cfg CacheConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	vary    map[string]*urlVary
+}
+
+func newResponseCache(cfg CacheConfig) *responseCache {
+	return &responseCache{
+		cfg:     cfg,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		vary:    make(map[string]*urlVary),
+	}
+}
+
+// cacheKey identifies the response to a request for u with header h, given the request
+// headers the response varies on.
+func cacheKey(u string, vary []string, h http.Header) string {
+	if len(vary) == 0 {
+		return u
+	}
+	var b strings.Builder
+	b.WriteString(u)
+	for _, name := range vary {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(h.Values(name), ", "))
+	}
+	return b.String()
+}
+
+// get returns the cached response to a request for u with header h.
+func (c *responseCache) get(u string, h http.Header) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var vary []string
+	if v, ok := c.vary[u]; ok {
+		vary = v.headers
+	}
+	el, ok := c.entries[cacheKey(u, vary, h)]
+	if !ok {
+		return nil
+	}
+	entry := el.Value.(*cacheEntry)
+	if c.cfg.TTL > 0 && c.now().Sub(entry.storedAt) >= c.cfg.TTL {
+		c.removeLocked(el)
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return entry
+}
+
+func (c *responseCache) put(entry *cacheEntry, vary []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.storedAt = c.now()
+	v, ok := c.vary[entry.url]
+	if !ok {
+		v = &urlVary{}
+		c.vary[entry.url] = v
+	}
+	v.headers = vary
+	if el, ok := c.entries[entry.key]; ok {
+		el.Value = entry
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	v.entries++
+	for c.lru.Len() > c.cfg.MaxEntries {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+// remove drops an entry the server no longer allows to be cached.
+func (c *responseCache) remove(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[entry.key]; ok && el.Value == entry {
+		c.removeLocked(el)
+	}
+}
+
+func (c *responseCache) removeLocked(el *list.Element) {
+	entry := el.Value.(*cacheEntry)
+	c.lru.Remove(el)
+	delete(c.entries, entry.key)
+	if v, ok := c.vary[entry.url]; ok {
+		if v.entries--; v.entries <= 0 {
+			delete(c.vary, entry.url)
+		}
+	}
+}
+
+// refresh restarts the TTL of an entry the server just confirmed with a 304.
+func (c *responseCache) refresh(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.storedAt = c.now()
+}
+
+// handle serves a 304 answering the conditional request from cached, and arranges for a
+// fresh cacheable response to be stored once its body has been read. h is the header of
+// the request for u before the conditions were added.
+func (c *responseCache) handle(u string, h http.Header, cached *cacheEntry, res *http.Response) *http.Response {
+	switch {
+	case res.StatusCode == http.StatusNotModified && cached != nil:
+		if res.Body != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxCachedBodySize))
+			res.Body.Close()
+		}
+		c.refresh(cached)
+		return cached.response(res.Request)
+	case res.StatusCode == http.StatusOK && res.Body != nil:
+		vary, ok := varyHeaders(res.Header)
+		if !ok || !storable(res.Header) {
+			if cached != nil {
+				c.remove(cached)
+			}
+			return res
+		}
+		etag, lastModified := res.Header.Get("ETag"), res.Header.Get("Last-Modified")
+		if etag == "" && lastModified == "" {
+			return res
+		}
+		key := cacheKey(u, vary, h)
+		res.Body = &cachingBody{
+			ReadCloser: res.Body,
+			onEOF: func(body []byte) {
+				c.put(&cacheEntry{
+					key:          key,
+					url:          u,
+					etag:         etag,
+					lastModified: lastModified,
+					header:       res.Header.Clone(),
+					body:         body,
+				}, vary)
+			},
+		}
+	}
+	return res
+}
+
+// storable reports whether the Cache-Control header of a response allows it to be cached.
+// The client may be shared by many users, so private responses are not cached either.
+func storable(h http.Header) bool {
+	for _, v := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if strings.EqualFold(name, "no-store") || strings.EqualFold(name, "private") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// varyHeaders returns the sorted canonical names of the request headers listed in Vary,
+// and false if the response varies on more than request headers ("*").
+func varyHeaders(h http.Header) ([]string, bool) {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name == "*" {
+				return nil, false
+			}
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+	}
+	sort.Strings(names)
+	return names, true
+}
+
+// cachingBody copies the body while it is read and hands it to onEOF once fully read.
+// Bodies larger than maxCachedBodySize are passed through without being kept.
+type cachingBody struct {
+	io.ReadCloser
+	buf      bytes.Buffer
+	tooLarge bool
+	done     bool
+	onEOF    func([]byte)
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if !b.tooLarge {
+		if b.buf.Len()+n > maxCachedBodySize {
+			b.tooLarge = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF && !b.tooLarge && !b.done {
+		b.done = true
+		b.onEOF(bytes.Clone(b.buf.Bytes()))
+	}
+	return n, err
+}
@@ -0,0 +1,150 @@
+package httpx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// etagDelegate serves body under etag, and answers 304 to a matching If-None-Match.
+type etagDelegate struct {
+	etag     string
+	body     string
+	header   http.Header
+	requests []*http.Request
+}
+
+func (d *etagDelegate) Do(req *http.Request) (*http.Response, error) {
+	d.requests = append(d.requests, req)
+	if req.Header.Get("If-None-Match") == d.etag {
+		return &http.Response{StatusCode: http.StatusNotModified, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+	header := d.header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("ETag", d.etag)
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(d.body)), Request: req}, nil
+}
+
+func getBody(t *testing.T, c *HTTPClient, path string) (int, string) {
+	t.Helper()
+	res, err := c.Get(context.Background(), path).Do()
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, string(body)
+}
+
+func TestCacheServesNotModifiedFromCache(t *testing.T) {
+	delegate := &etagDelegate{etag: `"v1"`, body: "hello"}
+	c := NewHTTPClient("http://upstream.example", delegate)
+	c.SetCache(CacheConfig{MaxEntries: 10})
+
+	status, body := getBody(t, c, "/doc")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "hello", body)
+	require.Empty(t, delegate.requests[0].Header.Get("If-None-Match"))
+
+	status, body = getBody(t, c, "/doc")
+	require.Len(t, delegate.requests, 2)
+	require.Equal(t, `"v1"`, delegate.requests[1].Header.Get("If-None-Match"))
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "hello", body)
+
+	// a changed resource replaces the cached body
+	delegate.etag, delegate.body = `"v2"`, "world"
+	_, body = getBody(t, c, "/doc")
+	require.Equal(t, "world", body)
+	_, body = getBody(t, c, "/doc")
+	require.Equal(t, "world", body)
+	require.Equal(t, `"v2"`, delegate.requests[3].Header.Get("If-None-Match"))
+}
+
+func TestCacheEvictsAndExpires(t *testing.T) {
+	delegate := &etagDelegate{etag: `"v1"`, body: "hello"}
+	c := NewHTTPClient("http://upstream.example", delegate)
+	c.SetCache(CacheConfig{MaxEntries: 1, TTL: time.Minute})
+	now := time.Now()
+	c.cache.now = func() time.Time { return now }
+
+	getBody(t, c, "/a")
+	getBody(t, c, "/b")
+	getBody(t, c, "/a")
+	require.Empty(t, delegate.requests[2].Header.Get("If-None-Match"), "/a was evicted by /b")
+
+	now = now.Add(time.Minute)
+	getBody(t, c, "/a")
+	require.Empty(t, delegate.requests[3].Header.Get("If-None-Match"), "/a expired")
+}
+
+func TestCacheIgnoresUnreadBodiesAndDisabledCache(t *testing.T) {
+	delegate := &etagDelegate{etag: `"v1"`, body: "hello"}
+	c := NewHTTPClient("http://upstream.example", delegate)
+	c.SetCache(CacheConfig{MaxEntries: 10})
+
+	res, err := c.Get(context.Background(), "/doc").Do()
+	require.NoError(t, err)
+	res.Body.Close()
+	getBody(t, c, "/doc")
+	require.Empty(t, delegate.requests[1].Header.Get("If-None-Match"), "a partially read body is not cached")
+
+	c.SetCache(CacheConfig{})
+	getBody(t, c, "/doc")
+	require.Empty(t, delegate.requests[2].Header.Get("If-None-Match"))
+}
+
+func TestCacheKeysByVaryHeaders(t *testing.T) {
+	delegate := &etagDelegate{etag: `"v1"`, body: "hello", header: http.Header{"Vary": {"accept-language"}}}
+	c := NewHTTPClient("http://upstream.example", delegate)
+	c.SetCache(CacheConfig{MaxEntries: 10})
+
+	get := func(lang string) {
+		res, err := c.Get(context.Background(), "/doc").WithHeader("Accept-Language", lang).Do()
+		require.NoError(t, err)
+		_, err = io.ReadAll(res.Body)
+		require.NoError(t, err)
+		res.Body.Close()
+	}
+
+	get("en")
+	get("de")
+	require.Empty(t, delegate.requests[1].Header.Get("If-None-Match"), "the response for en is not used for de")
+	get("en")
+	require.Equal(t, `"v1"`, delegate.requests[2].Header.Get("If-None-Match"))
+	get("de")
+	require.Equal(t, `"v1"`, delegate.requests[3].Header.Get("If-None-Match"))
+
+	delegate.etag = `"v2"`
+	delegate.header.Set("Vary", "*")
+	get("en")
+	get("en")
+	require.Empty(t, delegate.requests[5].Header.Get("If-None-Match"), "Vary: * is not cached")
+}
+
+func TestCacheSkipsNoStoreAndPrivate(t *testing.T) {
+	for _, cacheControl := range []string{"no-store", "private, max-age=60", "max-age=60, No-Store"} {
+		t.Run(cacheControl, func(t *testing.T) {
+			delegate := &etagDelegate{etag: `"v1"`, body: "hello"}
+			c := NewHTTPClient("http://upstream.example", delegate)
+			c.SetCache(CacheConfig{MaxEntries: 10})
+
+			getBody(t, c, "/doc")
+			delegate.header = http.Header{"Cache-Control": {cacheControl}}
+			getBody(t, c, "/doc")
+			require.Equal(t, `"v1"`, delegate.requests[1].Header.Get("If-None-Match"))
+
+			// the 304 was answered from the cache, the next response drops the entry
+			delegate.etag = `"v2"`
+			getBody(t, c, "/doc")
+			getBody(t, c, "/doc")
+			require.Empty(t, delegate.requests[3].Header.Get("If-None-Match"))
+		})
+	}
+}
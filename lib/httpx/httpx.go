@@ -6,15 +6,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
 	neturl "net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/cloudcarver/anclax/pkg/logger"
 	"github.com/pkg/errors"
 )
 
@@ -35,6 +38,67 @@ type HTTPClient struct {
 	m       sync.RWMutex
 	headers http.Header
 	client  HTTPDelegate
+	timeout time.Duration
+
+	propagator Propagator
+	breaker    *circuitBreaker
+	cache      *responseCache
+	jar        http.CookieJar
+	redirect   RedirectPolicy
+
+	// transport is nil when a custom delegate is used.
+	transport *http.Transport
+	proxy     func(*http.Request) (*neturl.URL, error)
+}
+
+// RedirectPolicy decides whether a redirect is followed, see http.Client.CheckRedirect.
+type RedirectPolicy func(req *http.Request, via []*http.Request) error
+
+// Propagator writes tracing headers, such as traceparent and tracestate, derived from
+// the request context into the outgoing headers.
+type Propagator func(ctx context.Context, h http.Header)
+
+// HeaderRequestID carries the request ID of the inbound request to downstream services.
+const HeaderRequestID = "X-Request-ID"
+
+// PropagateRequestID forwards the request ID stored by logger.WithRequestID, unless the
+// header is already set.
+func PropagateRequestID(ctx context.Context, h http.Header) {
+	if rid := logger.RequestIDFromContext(ctx); rid != "" && h.Get(HeaderRequestID) == "" {
+		h.Set(HeaderRequestID, rid)
+	}
+}
+
+// ChainPropagators returns a Propagator calling each of ps in order.
+func ChainPropagators(ps ...Propagator) Propagator {
+	return func(ctx context.Context, h http.Header) {
+		for _, p := range ps {
+			if p != nil {
+				p(ctx, h)
+			}
+		}
+	}
+}
+
+// TransportConfig tunes connection reuse of the built-in transport. Zero values keep
+// the net/http defaults.
+type TransportConfig struct {
+	// MaxIdleConns limits idle connections across all hosts, zero means no limit.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost limits idle connections per host, zero means 2.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout closes connections idle for longer, zero means no limit.
+	IdleConnTimeout time.Duration
+}
+
+// NewHTTPClientWithTimeout creates a client whose requests, including reading the
+// response body, fail after timeout. See SetTimeout.
+func NewHTTPClientWithTimeout(base string, timeout time.Duration, httpDelegate ...HTTPDelegate) *HTTPClient {
+	c := NewHTTPClient(base, httpDelegate...)
+	c.timeout = timeout
+	return c
 }
 
 func NewHTTPClient(base string, httpDelegate ...HTTPDelegate) *HTTPClient {
@@ -42,43 +106,203 @@ func NewHTTPClient(base string, httpDelegate ...HTTPDelegate) *HTTPClient {
 	if strings.HasSuffix(base, "/") {
 		pathBase = strings.TrimRight(base, "/")
 	}
-	var delegate HTTPDelegate
+	c := &HTTPClient{
+		base:    pathBase,
+		headers: http.Header{},
+		proxy:   http.ProxyFromEnvironment,
+	}
 	if len(httpDelegate) != 0 {
-		delegate = httpDelegate[0]
+		c.client = httpDelegate[0]
 	} else {
-		delegate = &http.Client{
-			Transport: &http.Transport{
-				Proxy: http.ProxyFromEnvironment,
-			},
-		}
+		c.useTransport(TransportConfig{})
 	}
-	return &HTTPClient{
-		base:    pathBase,
-		headers: http.Header{},
-		client:  delegate,
+	return c
+}
+
+// useTransport installs a transport built from cfg, replacing the delegate. The
+// transport looks up the proxy on every request so that SetProxy keeps its connections.
+func (c *HTTPClient) useTransport(cfg TransportConfig) {
+	c.transport = &http.Transport{
+		Proxy: func(req *http.Request) (*neturl.URL, error) {
+			c.m.RLock()
+			proxy := c.proxy
+			c.m.RUnlock()
+			return proxy(req)
+		},
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+	}
+	c.client = c.newClient()
+}
+
+func (c *HTTPClient) newClient() *http.Client {
+	return &http.Client{Transport: c.transport, Jar: c.jar, CheckRedirect: c.redirect}
+}
+
+// SetTransportConfig replaces the transport with one tuned by cfg, closing idle
+// connections of the previous one. It also replaces a custom delegate.
+func (c *HTTPClient) SetTransportConfig(cfg TransportConfig) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
 	}
+	c.useTransport(cfg)
 }
 
+// SetProxy routes requests through proxy. With a custom delegate, it switches to the
+// built-in transport.
 func (c *HTTPClient) SetProxy(proxy string) {
 	c.m.Lock()
 	defer c.m.Unlock()
-	c.client = &http.Client{
-		Transport: &http.Transport{
-			Proxy: func(req *http.Request) (*neturl.URL, error) {
-				return neturl.Parse(proxy)
-			},
-		},
+	c.proxy = func(req *http.Request) (*neturl.URL, error) {
+		return neturl.Parse(proxy)
+	}
+	if c.transport == nil {
+		c.useTransport(TransportConfig{})
 	}
 }
 
 func (c *HTTPClient) UnsetProxy() {
 	c.m.Lock()
 	defer c.m.Unlock()
-	c.client = &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-		},
+	c.proxy = http.ProxyFromEnvironment
+	if c.transport == nil {
+		c.useTransport(TransportConfig{})
+	}
+}
+
+// SetTimeout bounds every request, including reading the response body, to d. A zero
+// duration disables it. A shorter deadline on the request context still takes precedence.
+func (c *HTTPClient) SetTimeout(d time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.timeout = d
+}
+
+// SetPropagator sets the propagator called right before each request is sent. A nil
+// propagator disables propagation.
+func (c *HTTPClient) SetPropagator(p Propagator) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.propagator = p
+}
+
+// SetCircuitBreaker enables a circuit breaker per host, requests to a host whose circuit
+// is open fail with ErrCircuitOpen without being sent. A zero FailureThreshold disables it.
+func (c *HTTPClient) SetCircuitBreaker(cfg BreakerConfig) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if cfg.FailureThreshold <= 0 {
+		c.breaker = nil
+		return
+	}
+	c.breaker = newCircuitBreaker(cfg)
+}
+
+// SetCookieJar stores cookies set by responses in jar and sends them with later requests.
+// The jar is kept when the transport or proxy changes, and is applied by the client itself
+// when a custom delegate is used. A nil jar disables cookie handling.
+func (c *HTTPClient) SetCookieJar(jar http.CookieJar) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.jar = jar
+	if c.transport != nil {
+		c.client = c.newClient()
+	}
+}
+
+// SetRedirectPolicy replaces the default policy of following up to 10 redirects. Returning
+// http.ErrUseLastResponse from policy stops at the redirect response. The policy is kept
+// when the transport or proxy changes, a custom delegate handles redirects on its own. A
+// nil policy restores the default.
+func (c *HTTPClient) SetRedirectPolicy(policy RedirectPolicy) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.redirect = policy
+	if c.transport != nil {
+		c.client = c.newClient()
+	}
+}
+
+// DisableRedirects returns redirect responses as they are, their target is available
+// through ResponseHelper.Location.
+func (c *HTTPClient) DisableRedirects() {
+	c.SetRedirectPolicy(func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	})
+}
+
+// CookieJar returns the jar set by SetCookieJar.
+func (c *HTTPClient) CookieJar() http.CookieJar {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.jar
+}
+
+// getDelegateJar returns the cookie jar Do has to apply, nil when the built-in
+// http.Client handles it.
+func (c *HTTPClient) getDelegateJar() http.CookieJar {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	if c.transport != nil {
+		return nil
+	}
+	return c.jar
+}
+
+// CircuitState returns the circuit breaker state of host, BreakerClosed if the breaker
+// is disabled.
+func (c *HTTPClient) CircuitState(host string) BreakerState {
+	if breaker := c.getBreaker(); breaker != nil {
+		return breaker.state(host)
+	}
+	return BreakerClosed
+}
+
+// SetCache enables caching of GET responses that carry an ETag or Last-Modified header.
+// Later requests to the same URL, with the same values of the headers listed in Vary, are
+// sent as conditional requests, and a 304 response is answered with the cached body.
+// Responses marked no-store or private are not cached. A zero MaxEntries disables the cache.
+func (c *HTTPClient) SetCache(cfg CacheConfig) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if cfg.MaxEntries <= 0 {
+		c.cache = nil
+		return
 	}
+	c.cache = newResponseCache(cfg)
+}
+
+func (c *HTTPClient) getCache() *responseCache {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.cache
+}
+
+func (c *HTTPClient) getBreaker() *circuitBreaker {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.breaker
+}
+
+func (c *HTTPClient) getPropagator() Propagator {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.propagator
+}
+
+func (c *HTTPClient) getClient() HTTPDelegate {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.client
+}
+
+func (c *HTTPClient) getTimeout() time.Duration {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.timeout
 }
 
 func (c *HTTPClient) SetHeader(key, val string) {
@@ -141,6 +365,10 @@ func (c *HTTPClient) Delete(ctx context.Context, path string) *RequestContext {
 	return c.startRequest(ctx, "DELETE", path)
 }
 
+func (c *HTTPClient) Patch(ctx context.Context, path string) *RequestContext {
+	return c.startRequest(ctx, "PATCH", path)
+}
+
 func (rc *RequestContext) handleErr(err error) {
 	if err == nil {
 		return
@@ -236,28 +464,56 @@ func (rc *RequestContext) WithHeader(key, val string) *RequestContext {
 	return rc
 }
 
+// ErrPollAttemptsExhausted is returned by PollAttempts when maxAttempts responses were
+// not accepted by onResponse.
+var ErrPollAttemptsExhausted = errors.New("poll attempts exhausted")
+
 func (rc *RequestContext) Poll(onResponse func(*ResponseHelper) (bool, error), pollingInterval time.Duration, timeout time.Duration) error {
+	_, _, err := rc.PollAttempts(onResponse, pollingInterval, timeout, 0)
+	return err
+}
+
+// PollAttempts sends the request every pollingInterval until onResponse accepts a
+// response, timeout passes or maxAttempts requests were made, zero meaning no limit. It
+// returns the number of requests made and the last response passed to onResponse, whose
+// body can be read again, so that the caller can inspect it after a timeout or
+// ErrPollAttemptsExhausted.
+func (rc *RequestContext) PollAttempts(onResponse func(*ResponseHelper) (bool, error), pollingInterval time.Duration, timeout time.Duration, maxAttempts int) (*ResponseHelper, int, error) {
 	ctx, cancel := context.WithTimeout(rc.ctx, timeout)
 	defer cancel()
 
 	ticker := time.NewTicker(pollingInterval)
 	defer ticker.Stop()
 
+	var (
+		last     *ResponseHelper
+		attempts int
+	)
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return last, attempts, ctx.Err()
 		case <-ticker.C:
+			attempts++
 			res, err := rc.Do()
 			if err != nil {
-				return err
+				return last, attempts, err
+			}
+			raw, err := res.buffer()
+			if err != nil {
+				return last, attempts, errors.Wrap(err, "failed to read body from HTTP response")
 			}
 			ok, err := onResponse(res)
+			res.rewind(raw)
+			last = res
 			if err != nil {
-				return err
+				return last, attempts, err
 			}
 			if ok {
-				return nil
+				return last, attempts, nil
+			}
+			if maxAttempts > 0 && attempts >= maxAttempts {
+				return last, attempts, errors.Wrapf(ErrPollAttemptsExhausted, "after %d attempts", attempts)
 			}
 		}
 	}
@@ -279,9 +535,17 @@ func (rc *RequestContext) Do() (*ResponseHelper, error) {
 		return nil, errors.Wrapf(err, "failed to construct URL, base: %s, path: %s", rc.c.base, rc.path)
 	}
 
+	// timeout, context.WithTimeout keeps the earlier deadline of the two
+	ctx := rc.ctx
+	cancel := context.CancelFunc(func() {})
+	if timeout := rc.c.getTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
 	// new request
-	req, err := http.NewRequest(rc.method, urlStr, rc.body)
+	req, err := http.NewRequestWithContext(ctx, rc.method, urlStr, rc.body)
 	if err != nil {
+		cancel()
 		return nil, errors.Wrapf(err, "failed to construct request, method: %s, url: %s", rc.method, urlStr)
 	}
 
@@ -295,16 +559,80 @@ func (rc *RequestContext) Do() (*ResponseHelper, error) {
 	req.URL.RawQuery = query.Encode()
 
 	// headers
-	req.Header = rc.headers
+	req.Header = rc.headers.Clone()
+	if propagate := rc.c.getPropagator(); propagate != nil {
+		propagate(req.Context(), req.Header)
+	}
+
+	// cookies
+	jar := rc.c.getDelegateJar()
+	if jar != nil {
+		for _, cookie := range jar.Cookies(req.URL) {
+			req.AddCookie(cookie)
+		}
+	}
+
+	// conditional request
+	cache := rc.c.getCache()
+	if req.Method != http.MethodGet {
+		cache = nil
+	}
+	cacheURL := req.URL.String()
+	var cacheHeader http.Header
+	var cached *cacheEntry
+	if cache != nil {
+		cacheHeader = req.Header.Clone()
+		if cached = cache.get(cacheURL, cacheHeader); cached != nil {
+			cached.setConditions(req.Header)
+		}
+	}
+
+	// circuit breaker
+	breaker := rc.c.getBreaker()
+	var probe bool
+	if breaker != nil {
+		if probe, err = breaker.allow(req.URL.Host); err != nil {
+			cancel()
+			return nil, errors.Wrapf(err, "host %s", req.URL.Host)
+		}
+	}
 
 	// send request
-	res, err := rc.c.client.Do(req)
+	res, err := rc.c.getClient().Do(req)
+	if breaker != nil {
+		breaker.record(req.URL.Host, probe, res, err)
+	}
 	if err != nil {
+		cancel()
 		return nil, errors.Wrapf(err, "failed to send request, method: %s, path: %s, query: %v, headers: %v", rc.method, rc.path, rc.query, rc.headers)
 	}
+	if jar != nil && res != nil {
+		if cookies := res.Cookies(); len(cookies) != 0 {
+			jar.SetCookies(req.URL, cookies)
+		}
+	}
+	if cache != nil && res != nil {
+		res = cache.handle(cacheURL, cacheHeader, cached, res)
+	}
+	if res == nil || res.Body == nil {
+		cancel()
+	} else {
+		res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	}
 	return NewResponseHelper(res), nil
 }
 
+// cancelOnClose keeps the request context alive until the body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
 func NewResponseHelper(res *http.Response) *ResponseHelper {
 	return &ResponseHelper{res}
 }
@@ -332,16 +660,115 @@ func (rh *ResponseHelper) JSON(data any) error {
 	return nil
 }
 
+// Filename returns the file name from the Content-Disposition header, without any
+// directory components. It returns an empty string if the header has no file name.
+func (rh *ResponseHelper) Filename() string {
+	_, params, err := mime.ParseMediaType(rh.Header.Get("Content-Disposition"))
+	if err != nil {
+		return ""
+	}
+	filename := params["filename"]
+	if filename == "" {
+		return ""
+	}
+	return filepath.Base(filepath.Clean("/" + filename))
+}
+
+// SaveToFile streams the body to the file at path, replacing any existing content,
+// and closes the body.
+func (rh *ResponseHelper) SaveToFile(path string) error {
+	defer rh.Body.Close()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open file %s", path)
+	}
+	if _, err := io.Copy(f, rh.Body); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to write body to %s", path)
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close file %s", path)
+	}
+	return nil
+}
+
+// maxErrorBodySize caps the response body captured in HTTPError.
+const maxErrorBodySize = 4096
+
+// HTTPError is returned by ExpectStatus when the response has an unexpected status code.
+// Use errors.As to inspect it.
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Expected   []int
+	Message    string
+
+	// Body holds at most the first 4KB of the response body.
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	if len(e.Message) == 0 {
+		return fmt.Sprintf("unexpected status code: %d, expecting: %v", e.StatusCode, e.Expected)
+	}
+	return fmt.Sprintf("%s, unexpected status code: %d, expecting: %v", e.Message, e.StatusCode, e.Expected)
+}
+
 func (rh *ResponseHelper) ExpectStatusWithMessage(msg string, statusCodes ...int) error {
 	for _, c := range statusCodes {
 		if rh.StatusCode == c {
 			return nil
 		}
 	}
-	if len(msg) == 0 {
-		return fmt.Errorf("unexpected status code: %d, expecting: %v", rh.StatusCode, statusCodes)
+	httpErr := &HTTPError{
+		StatusCode: rh.StatusCode,
+		Expected:   statusCodes,
+		Message:    msg,
+		Body:       rh.peekBody(maxErrorBodySize),
+	}
+	if rh.Request != nil {
+		httpErr.Method = rh.Request.Method
+		if rh.Request.URL != nil {
+			httpErr.URL = rh.Request.URL.String()
+		}
+	}
+	return httpErr
+}
+
+// buffer reads and closes the body, replacing it with an in-memory copy.
+func (rh *ResponseHelper) buffer() ([]byte, error) {
+	if rh.Response == nil || rh.Body == nil {
+		return nil, nil
+	}
+	raw, err := io.ReadAll(rh.Body)
+	rh.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	rh.rewind(raw)
+	return raw, nil
+}
+
+// rewind makes the body buffered by buffer readable from the start again.
+func (rh *ResponseHelper) rewind(raw []byte) {
+	if rh.Response != nil && rh.Body != nil {
+		rh.Body = io.NopCloser(bytes.NewReader(raw))
+	}
+}
+
+// peekBody reads up to n bytes of the body without consuming them for later readers.
+func (rh *ResponseHelper) peekBody(n int64) []byte {
+	if rh.Body == nil {
+		return nil
 	}
-	return fmt.Errorf("%s, unexpected status code: %d, expecting: %v", msg, rh.StatusCode, statusCodes)
+	peeked, _ := io.ReadAll(io.LimitReader(rh.Body, n))
+	rh.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peeked), rh.Body), rh.Body}
+	return peeked
 }
 
 func (rh *ResponseHelper) ExpectStatus(statusCodes ...int) error {
@@ -7,11 +7,16 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/cloudcarver/anclax/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -123,6 +128,27 @@ func TestWithJSON(t *testing.T) {
 	assert.Equal(t, jsonRaw, bodyRaw)
 }
 
+func TestMethodsWithJSONBody(t *testing.T) {
+	d := &NoopHTTPDelegate{}
+	c := NewHTTPClient("http://test.example", d)
+
+	for method, start := range map[string]func(context.Context, string) *RequestContext{
+		http.MethodPatch:  c.Patch,
+		http.MethodDelete: c.Delete,
+	} {
+		_, err := start(context.Background(), "/items/1").WithJSON(H{"name": "new"}).Do()
+		require.NoError(t, err)
+
+		req := d.GetRequest()
+		assert.Equal(t, method, req.Method)
+		assert.Equal(t, "/items/1", req.URL.Path)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		bodyRaw, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"new"}`, string(bodyRaw))
+	}
+}
+
 func TestWithHeader(t *testing.T) {
 	var (
 		k = "test"
@@ -159,6 +185,43 @@ func TestExpectStatusWithMessage(t *testing.T) {
 	assert.Contains(t, err.Error(), "test msg")
 }
 
+func TestExpectStatusHTTPError(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://test.example/api", nil)
+	require.NoError(t, err)
+	rh := &ResponseHelper{Response: &http.Response{
+		StatusCode: http.StatusConflict,
+		Body:       io.NopCloser(strings.NewReader(`{"error":"duplicated"}`)),
+		Request:    req,
+	}}
+
+	err = rh.ExpectStatus(http.StatusOK)
+	require.Error(t, err)
+	assert.Equal(t, "unexpected status code: 409, expecting: [200]", err.Error())
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(fmt.Errorf("wrapped: %w", err), &httpErr))
+	assert.Equal(t, http.StatusConflict, httpErr.StatusCode)
+	assert.Equal(t, `{"error":"duplicated"}`, string(httpErr.Body))
+	assert.Equal(t, http.MethodPost, httpErr.Method)
+	assert.Equal(t, "http://test.example/api", httpErr.URL)
+
+	// the body is still readable after the error captured it
+	assert.Equal(t, `{"error":"duplicated"}`, rh.Text())
+}
+
+func TestExpectStatusHTTPErrorTruncatesBody(t *testing.T) {
+	body := strings.Repeat("x", maxErrorBodySize+10)
+	rh := &ResponseHelper{Response: &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}}
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(rh.ExpectStatus(http.StatusOK), &httpErr))
+	assert.Len(t, httpErr.Body, maxErrorBodySize)
+	assert.Equal(t, body, rh.Text())
+}
+
 func TestResponseHelperJSON(t *testing.T) {
 	rh := &ResponseHelper{Response: &http.Response{
 		Body: io.NopCloser(strings.NewReader(`{"test":"test"}`)),
@@ -169,6 +232,55 @@ func TestResponseHelperJSON(t *testing.T) {
 	assert.Equal(t, "test", data["test"])
 }
 
+func TestResponseHelperFilename(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{header: `attachment; filename="x.csv"`, expected: "x.csv"},
+		{header: `attachment; filename*=UTF-8''r%C3%A9sum%C3%A9.pdf`, expected: "résumé.pdf"},
+		{header: `attachment; filename="../../etc/passwd"`, expected: "passwd"},
+		{header: `inline`, expected: ""},
+		{header: ``, expected: ""},
+	}
+	for _, tt := range tests {
+		rh := &ResponseHelper{Response: &http.Response{
+			Header: http.Header{"Content-Disposition": []string{tt.header}},
+		}}
+		assert.Equal(t, tt.expected, rh.Filename(), tt.header)
+	}
+}
+
+func TestResponseHelperSaveToFile(t *testing.T) {
+	body := &closeRecorder{Reader: strings.NewReader("a,b\n1,2\n")}
+	rh := &ResponseHelper{Response: &http.Response{
+		Header: http.Header{"Content-Disposition": []string{`attachment; filename="x.csv"`}},
+		Body:   body,
+	}}
+
+	path := filepath.Join(t.TempDir(), rh.Filename())
+	require.NoError(t, rh.SaveToFile(path))
+	assert.True(t, body.closed)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", string(content))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Mode().Perm()&^0644)
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
 func TestPoll(t *testing.T) {
 	var (
 		interval = 50 * time.Millisecond
@@ -228,3 +340,357 @@ func TestGlobalHeaders(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, v, d.req.Header.Get(k))
 }
+
+type blockingHTTPDelegate struct{}
+
+func (blockingHTTPDelegate) Do(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestSetTimeout(t *testing.T) {
+	c := NewHTTPClient("http://test.example", blockingHTTPDelegate{})
+	c.SetTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	_, err := c.Get(context.Background(), "/test").Do()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestNewHTTPClientWithTimeout(t *testing.T) {
+	c := NewHTTPClientWithTimeout("http://test.example", 50*time.Millisecond, blockingHTTPDelegate{})
+
+	_, err := c.Get(context.Background(), "/test").Do()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTimeoutRespectsShorterContextDeadline(t *testing.T) {
+	c := NewHTTPClientWithTimeout("http://test.example", time.Minute, blockingHTTPDelegate{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.Get(ctx, "/test").Do()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestTimeoutCoversBody(t *testing.T) {
+	delegate := &NoopHTTPDelegate{Res: &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}}
+	c := NewHTTPClientWithTimeout("http://test.example", time.Minute, delegate)
+
+	res, err := c.Get(context.Background(), "/test").Do()
+	require.NoError(t, err)
+	require.NoError(t, delegate.GetRequest().Context().Err())
+
+	assert.Equal(t, "ok", res.Text())
+	require.NoError(t, res.Body.Close())
+	require.ErrorIs(t, delegate.GetRequest().Context().Err(), context.Canceled)
+}
+
+func TestTransportConfigPersistsAcrossProxyChanges(t *testing.T) {
+	c := NewHTTPClient("http://test.example")
+	c.SetTransportConfig(TransportConfig{
+		MaxIdleConns:        50,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     30 * time.Second,
+	})
+	transport := c.transport
+
+	c.SetProxy("http://proxy.example:8080")
+
+	require.Same(t, transport, c.transport)
+	require.Same(t, transport, c.client.(*http.Client).Transport)
+	assert.Equal(t, 50, c.transport.MaxIdleConns)
+	assert.Equal(t, 10, c.transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 30*time.Second, c.transport.IdleConnTimeout)
+
+	req, err := http.NewRequest(http.MethodGet, "http://test.example", nil)
+	require.NoError(t, err)
+	proxy, err := c.transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.example:8080", proxy.String())
+
+	c.UnsetProxy()
+	require.Same(t, transport, c.transport)
+	assert.Equal(t, 10, c.transport.MaxIdleConnsPerHost)
+}
+
+func TestSetProxyReplacesCustomDelegate(t *testing.T) {
+	c := NewHTTPClient("http://test.example", &NoopHTTPDelegate{})
+	require.Nil(t, c.transport)
+
+	c.SetProxy("http://proxy.example:8080")
+	require.NotNil(t, c.transport)
+	require.Same(t, c.transport, c.client.(*http.Client).Transport)
+}
+
+type traceKey struct{}
+
+func TestSetPropagator(t *testing.T) {
+	delegate := &NoopHTTPDelegate{Res: &http.Response{StatusCode: http.StatusOK}}
+	c := NewHTTPClient("http://test.example", delegate)
+	c.SetPropagator(func(ctx context.Context, h http.Header) {
+		if traceparent, ok := ctx.Value(traceKey{}).(string); ok {
+			h.Set("traceparent", traceparent)
+			h.Set("tracestate", "vendor=1")
+		}
+	})
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	rc := c.Get(ctx, "/test").WithHeader("X-Test", "1")
+	_, err := rc.Do()
+	require.NoError(t, err)
+
+	req := delegate.GetRequest()
+	assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", req.Header.Get("traceparent"))
+	assert.Equal(t, "vendor=1", req.Header.Get("tracestate"))
+	assert.Equal(t, "1", req.Header.Get("X-Test"))
+
+	// requests without trace context carry no trace headers
+	_, err = c.Get(context.Background(), "/test").Do()
+	require.NoError(t, err)
+	assert.Empty(t, delegate.GetRequest().Header.Get("traceparent"))
+
+	c.SetPropagator(nil)
+	_, err = c.Get(ctx, "/test").Do()
+	require.NoError(t, err)
+	assert.Empty(t, delegate.GetRequest().Header.Get("traceparent"))
+}
+
+func TestPropagateRequestID(t *testing.T) {
+	delegate := &NoopHTTPDelegate{Res: &http.Response{StatusCode: http.StatusOK}}
+	c := NewHTTPClient("http://test.example", delegate)
+	c.SetPropagator(ChainPropagators(PropagateRequestID, func(ctx context.Context, h http.Header) {
+		h.Set("traceparent", "tp")
+	}))
+
+	ctx := logger.WithRequestID(context.Background(), "rid-1")
+	_, err := c.Get(ctx, "/test").Do()
+	require.NoError(t, err)
+	assert.Equal(t, "rid-1", delegate.GetRequest().Header.Get(HeaderRequestID))
+	assert.Equal(t, "tp", delegate.GetRequest().Header.Get("traceparent"))
+
+	// an explicit header wins
+	_, err = c.Get(ctx, "/test").WithHeader(HeaderRequestID, "explicit").Do()
+	require.NoError(t, err)
+	assert.Equal(t, "explicit", delegate.GetRequest().Header.Get(HeaderRequestID))
+
+	_, err = c.Get(context.Background(), "/test").Do()
+	require.NoError(t, err)
+	assert.Empty(t, delegate.GetRequest().Header.Get(HeaderRequestID))
+}
+
+// cookieDelegate sets a session cookie on the first request and records the cookies of
+// the following ones.
+type cookieDelegate struct {
+	requests []*http.Request
+}
+
+func (d *cookieDelegate) Do(req *http.Request) (*http.Response, error) {
+	d.requests = append(d.requests, req)
+	header := http.Header{}
+	if len(d.requests) == 1 {
+		header.Add("Set-Cookie", "session=abc; Path=/")
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func TestCookieJarWithDelegate(t *testing.T) {
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	delegate := &cookieDelegate{}
+	c := NewHTTPClient("http://test.example", delegate)
+	c.SetCookieJar(jar)
+
+	_, err = c.Post(context.Background(), "/login").Do()
+	require.NoError(t, err)
+	_, err = c.Get(context.Background(), "/me").Do()
+	require.NoError(t, err)
+
+	cookie, err := delegate.requests[1].Cookie("session")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", cookie.Value)
+
+	// another host does not get the cookie
+	other := NewHTTPClient("http://other.example", delegate)
+	other.SetCookieJar(jar)
+	_, err = other.Get(context.Background(), "/me").Do()
+	require.NoError(t, err)
+	_, err = delegate.requests[2].Cookie("session")
+	require.ErrorIs(t, err, http.ErrNoCookie)
+}
+
+func TestCookieJarPersistsAcrossProxyChanges(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			return
+		}
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(cookie.Value))
+	}))
+	defer server.Close()
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	c := NewHTTPClient(server.URL)
+	c.SetCookieJar(jar)
+
+	res, err := c.Post(context.Background(), "/login").Do()
+	require.NoError(t, err)
+	res.Body.Close()
+
+	c.UnsetProxy()
+	c.SetTransportConfig(TransportConfig{MaxIdleConnsPerHost: 4})
+	require.Same(t, jar, c.client.(*http.Client).Jar)
+
+	res, err = c.Get(context.Background(), "/me").Do()
+	require.NoError(t, err)
+	require.NoError(t, res.ExpectStatus(http.StatusOK))
+	assert.Equal(t, "abc", res.Text())
+}
+
+func newRedirectServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusFound)
+		case "/b":
+			http.Redirect(w, r, "/c", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte("done"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRedirectsAreFollowedByDefault(t *testing.T) {
+	server := newRedirectServer(t)
+	c := NewHTTPClient(server.URL)
+
+	res, err := c.Get(context.Background(), "/a").Do()
+	require.NoError(t, err)
+	require.NoError(t, res.ExpectStatus(http.StatusOK))
+	assert.Equal(t, "/c", res.Request.URL.Path)
+	assert.Equal(t, "done", res.Text())
+}
+
+func TestDisableRedirects(t *testing.T) {
+	server := newRedirectServer(t)
+	c := NewHTTPClient(server.URL)
+	c.DisableRedirects()
+	c.SetProxy(server.URL)
+	c.UnsetProxy()
+
+	res, err := c.Get(context.Background(), "/a").Do()
+	require.NoError(t, err)
+	require.NoError(t, res.ExpectStatus(http.StatusFound))
+	location, err := res.Location()
+	require.NoError(t, err)
+	assert.Equal(t, "/b", location.Path)
+}
+
+func TestSetRedirectPolicy(t *testing.T) {
+	server := newRedirectServer(t)
+	c := NewHTTPClient(server.URL)
+
+	var visited []string
+	c.SetRedirectPolicy(func(req *http.Request, via []*http.Request) error {
+		visited = append(visited, req.URL.Path)
+		if len(via) >= 2 {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	})
+	res, err := c.Get(context.Background(), "/a").Do()
+	require.NoError(t, err)
+	require.NoError(t, res.ExpectStatus(http.StatusFound))
+	assert.Equal(t, "/b", res.Request.URL.Path)
+	assert.Equal(t, []string{"/b", "/c"}, visited)
+
+	stop := errors.New("no redirects")
+	c.SetRedirectPolicy(func(req *http.Request, via []*http.Request) error {
+		return stop
+	})
+	_, err = c.Get(context.Background(), "/a").Do()
+	require.ErrorIs(t, err, stop)
+
+	c.SetRedirectPolicy(nil)
+	res, err = c.Get(context.Background(), "/a").Do()
+	require.NoError(t, err)
+	assert.Equal(t, "/c", res.Request.URL.Path)
+	res.Body.Close()
+}
+
+// countingDelegate answers every request with the attempt number as body.
+type countingDelegate struct {
+	calls int
+}
+
+func (d *countingDelegate) Do(req *http.Request) (*http.Response, error) {
+	d.calls++
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(fmt.Sprint(d.calls))), Request: req}, nil
+}
+
+func TestPollAttemptsExhausted(t *testing.T) {
+	d := &countingDelegate{}
+	c := NewHTTPClient("http://test.example", d)
+
+	res, attempts, err := c.Get(context.Background(), "/test").PollAttempts(
+		func(rh *ResponseHelper) (bool, error) {
+			_, err := rh.Bytes()
+			return false, err
+		},
+		10*time.Millisecond,
+		time.Minute,
+		3,
+	)
+	require.ErrorIs(t, err, ErrPollAttemptsExhausted)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 3, d.calls)
+	require.NotNil(t, res)
+	assert.Equal(t, "3", res.Text(), "the last body is readable even though onResponse consumed it")
+}
+
+func TestPollAttemptsSucceedsWithinBudget(t *testing.T) {
+	d := &countingDelegate{}
+	c := NewHTTPClient("http://test.example", d)
+
+	res, attempts, err := c.Get(context.Background(), "/test").PollAttempts(
+		func(rh *ResponseHelper) (bool, error) {
+			return rh.Text() == "2", nil
+		},
+		10*time.Millisecond,
+		time.Minute,
+		5,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, "2", res.Text())
+}
+
+func TestPollAttemptsTimeoutReturnsLastResponse(t *testing.T) {
+	d := &countingDelegate{}
+	c := NewHTTPClient("http://test.example", d)
+
+	res, attempts, err := c.Get(context.Background(), "/test").PollAttempts(
+		func(rh *ResponseHelper) (bool, error) {
+			return false, nil
+		},
+		20*time.Millisecond,
+		110*time.Millisecond,
+		0,
+	)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Positive(t, attempts)
+	require.NotNil(t, res)
+	assert.Equal(t, fmt.Sprint(attempts), res.Text())
+}
@@ -0,0 +1,79 @@
+package ws
+
+import (
+	"strings"
+
+	"github.com/gofiber/contrib/v3/websocket"
+	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// QueryToken is the query parameter carrying the access token of the upgrade request.
+	QueryToken = "token"
+
+	// SubprotocolBearer lets browsers, which cannot set headers on the upgrade request,
+	// pass the access token as `Sec-WebSocket-Protocol: bearer, <token>`.
+	SubprotocolBearer = "bearer"
+
+	identityLocalKey = "ws_identity"
+)
+
+var (
+	ErrMissingToken    = errors.New("missing websocket token")
+	ErrNoAuthenticator = errors.New("auth required but no authenticator configured")
+)
+
+// Identity is the authenticated principal of a websocket session.
+type Identity struct {
+	UserID int32
+	OrgID  int32
+}
+
+// Authenticator validates the token of a websocket upgrade request. A non-nil error
+// rejects the upgrade with 401.
+type Authenticator func(c fiber.Ctx, token string) (*Identity, error)
+
+func denyAll(fiber.Ctx, string) (*Identity, error) {
+	return nil, ErrNoAuthenticator
+}
+
+// upgradeToken returns the token from the query string, falling back to the
+// bearer subprotocol.
+func upgradeToken(c fiber.Ctx) string {
+	if token := c.Query(QueryToken); token != "" {
+		return token
+	}
+	protocols := strings.Split(c.Get(fiber.HeaderSecWebSocketProtocol), ",")
+	if len(protocols) >= 2 && strings.TrimSpace(protocols[0]) == SubprotocolBearer {
+		return strings.TrimSpace(protocols[1])
+	}
+	return ""
+}
+
+func (w *WebsocketController) authenticate(c fiber.Ctx) error {
+	token := upgradeToken(c)
+	if token == "" {
+		return ErrMissingToken
+	}
+	identity, err := w.authenticator(c, token)
+	if err != nil {
+		return err
+	}
+	c.Locals(identityLocalKey, identity)
+	return nil
+}
+
+func (w *WebsocketController) authGuard(c fiber.Ctx) error {
+	if err := w.authenticate(c); err != nil {
+		wslog.Info("rejected websocket upgrade", zap.Error(err), zap.Any("ws_request_id", c.Locals("ws_request_id")))
+		return fiber.ErrUnauthorized
+	}
+	return c.Next()
+}
+
+func connIdentity(c *websocket.Conn) *Identity {
+	identity, _ := c.Locals(identityLocalKey).(*Identity)
+	return identity
+}
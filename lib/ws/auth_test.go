@@ -0,0 +1,99 @@
+package ws
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	fasthttpws "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthController(recorder *sessionRecorder) *WebsocketController {
+	w := New(context.Background(), &WsCfg{
+		Handler:     recorder,
+		RequireAuth: true,
+		Authenticator: func(c fiber.Ctx, token string) (*Identity, error) {
+			if token != "valid" {
+				return nil, errors.New("invalid token")
+			}
+			return &Identity{UserID: 1, OrgID: 2}, nil
+		},
+	})
+	_ = w.Hub().AddTopic("updates")
+	return w
+}
+
+func TestAuthenticatedUpgrade(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		header http.Header
+	}{
+		{name: "query", url: "/ws?token=valid"},
+		{name: "subprotocol", url: "/ws", header: http.Header{"Sec-WebSocket-Protocol": {"bearer, valid"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &sessionRecorder{created: make(chan *Session, 1)}
+			addr := serve(t, newAuthController(recorder))
+
+			conn, resp, err := fasthttpws.DefaultDialer.Dial("ws://"+addr+tt.url, tt.header)
+			require.NoError(t, err)
+			defer conn.Close()
+			if tt.header != nil {
+				require.Equal(t, SubprotocolBearer, resp.Header.Get("Sec-WebSocket-Protocol"))
+			}
+
+			session := recorder.wait(t)
+			require.Equal(t, &Identity{UserID: 1, OrgID: 2}, session.Identity())
+		})
+	}
+}
+
+func TestRejectedUpgrade(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{name: "invalid token", url: "/ws?token=invalid"},
+		{name: "missing token", url: "/ws"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &sessionRecorder{created: make(chan *Session, 1)}
+			addr := serve(t, newAuthController(recorder))
+
+			_, resp, err := fasthttpws.DefaultDialer.Dial("ws://"+addr+tt.url, nil)
+			require.ErrorIs(t, err, fasthttpws.ErrBadHandshake)
+			require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			require.Empty(t, recorder.created)
+		})
+	}
+}
+
+func TestRequireAuthWithoutAuthenticator(t *testing.T) {
+	w := New(context.Background(), &WsCfg{RequireAuth: true})
+	addr := serve(t, w)
+
+	_, resp, err := fasthttpws.DefaultDialer.Dial("ws://"+addr+"/ws?token=valid", nil)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestUnauthenticatedSessionHasNoIdentity(t *testing.T) {
+	recorder := &sessionRecorder{created: make(chan *Session, 1)}
+	w := New(context.Background(), &WsCfg{Handler: recorder})
+	require.NoError(t, w.Hub().AddTopic("updates"))
+	addr := serve(t, w)
+
+	conn, _, err := fasthttpws.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.Nil(t, recorder.wait(t).Identity())
+}
@@ -0,0 +1,58 @@
+package ws
+
+import (
+	"context"
+	"net"
+
+	"github.com/gofiber/contrib/v3/websocket"
+	"github.com/pkg/errors"
+)
+
+var ErrIdleTimeout = errors.New("idle timeout")
+
+type closeReason struct {
+	target error
+	code   int
+	reason string
+
+	// label is the reason label of the anclax_ws_closes_total metric.
+	label string
+}
+
+// closeReasons maps the cancel cause of a session to the close frame sent to the client.
+// They are checked in order with errors.Is.
+var closeReasons = []closeReason{
+	{target: ErrCloseReceived, code: websocket.CloseNormalClosure, label: "client_closed"},
+	{target: ErrBackpressure, code: websocket.ClosePolicyViolation, reason: "backpressure", label: "backpressure"},
+	{target: ErrIdleTimeout, code: websocket.CloseGoingAway, reason: "idle timeout", label: "idle_timeout"},
+	{target: context.Canceled, code: websocket.CloseGoingAway, reason: "server shutting down", label: "shutdown"},
+}
+
+var internalErrorCloseReason = closeReason{code: websocket.CloseInternalServerErr, reason: "internal error", label: "internal_error"}
+
+// lookupCloseReason returns the close reason matching the cancel cause of a session.
+// Unknown causes are reported as an internal error.
+func lookupCloseReason(cause error) closeReason {
+	for _, r := range closeReasons {
+		if errors.Is(cause, r.target) {
+			return r
+		}
+	}
+	return internalErrorCloseReason
+}
+
+// closeMessage returns the close frame payload for the cancel cause of a session.
+func closeMessage(cause error) []byte {
+	r := lookupCloseReason(cause)
+	return websocket.FormatCloseMessage(r.code, r.reason)
+}
+
+// readError wraps a read error of the connection, a read deadline being exceeded means
+// the client has been idle for too long.
+func readError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return errors.Wrap(ErrIdleTimeout, err.Error())
+	}
+	return errors.Wrap(err, "read message error")
+}
@@ -0,0 +1,46 @@
+package ws
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gofiber/contrib/v3/websocket"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestCloseMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		cause      error
+		wantCode   int
+		wantReason string
+	}{
+		{name: "backpressure", cause: ErrBackpressure, wantCode: websocket.ClosePolicyViolation, wantReason: "backpressure"},
+		{name: "idle timeout", cause: readError(timeoutError{}), wantCode: websocket.CloseGoingAway, wantReason: "idle timeout"},
+		{name: "closed by client", cause: ErrCloseReceived, wantCode: websocket.CloseNormalClosure},
+		{name: "server shutdown", cause: context.Canceled, wantCode: websocket.CloseGoingAway, wantReason: "server shutting down"},
+		{name: "handler error", cause: errors.Wrap(errors.New("boom"), "handle message error"), wantCode: websocket.CloseInternalServerErr, wantReason: "internal error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := closeMessage(tt.cause)
+			require.GreaterOrEqual(t, len(payload), 2)
+			require.Equal(t, tt.wantCode, int(binary.BigEndian.Uint16(payload[:2])))
+			require.Equal(t, tt.wantReason, string(payload[2:]))
+		})
+	}
+}
+
+func TestReadErrorKeepsNonTimeoutErrors(t *testing.T) {
+	err := readError(errors.New("unexpected EOF"))
+	require.NotErrorIs(t, err, ErrIdleTimeout)
+	require.ErrorContains(t, err, "read message error")
+}
@@ -0,0 +1,63 @@
+package ws
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/contrib/v3/websocket"
+	"github.com/ugorji/go/codec"
+)
+
+// Codec encodes the messages written by Session.WriteTextMessage and decodes incoming
+// messages in Ctx.Decode.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+
+	// MessageType is the frame type of encoded messages, websocket.TextMessage or
+	// websocket.BinaryMessage.
+	MessageType() int
+}
+
+// JSONCodec is the default codec, it writes text frames.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (JSONCodec) MessageType() int {
+	return websocket.TextMessage
+}
+
+// MsgpackCodec encodes messages as MessagePack binary frames. Struct fields are keyed by
+// their json tag, so the same types can be sent with either codec.
+type MsgpackCodec struct {
+	handle *codec.MsgpackHandle
+}
+
+func NewMsgpackCodec() *MsgpackCodec {
+	handle := &codec.MsgpackHandle{}
+	handle.TypeInfos = codec.NewTypeInfos([]string{"json"})
+	handle.WriteExt = true
+	return &MsgpackCodec{handle: handle}
+}
+
+func (c *MsgpackCodec) Marshal(v any) ([]byte, error) {
+	var out []byte
+	if err := codec.NewEncoderBytes(&out, c.handle).Encode(v); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *MsgpackCodec) Unmarshal(data []byte, v any) error {
+	return codec.NewDecoderBytes(data, c.handle).Decode(v)
+}
+
+func (c *MsgpackCodec) MessageType() int {
+	return websocket.BinaryMessage
+}
@@ -0,0 +1,81 @@
+package ws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofiber/contrib/v3/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingCodec struct {
+	JSONCodec
+	marshaled []any
+}
+
+func (c *recordingCodec) Marshal(v any) ([]byte, error) {
+	c.marshaled = append(c.marshaled, v)
+	return []byte("encoded"), nil
+}
+
+func (c *recordingCodec) MessageType() int {
+	return websocket.BinaryMessage
+}
+
+func TestControllerCodecEncodesOutboundMessages(t *testing.T) {
+	codec := &recordingCodec{}
+	w := New(context.Background(), &WsCfg{Codec: codec})
+
+	s, buf := newBufferedSession(w, 2)
+	require.NoError(t, s.WriteTextMessage(map[string]any{"msg": "a"}))
+	require.NoError(t, NewCtx(context.Background(), s).SendError(ErrBiz))
+
+	require.Len(t, codec.marshaled, 2)
+	require.Equal(t, map[string]any{"msg": "a"}, codec.marshaled[0])
+
+	m := <-buf
+	require.Equal(t, websocket.BinaryMessage, m.Type())
+	require.Equal(t, "encoded", string(m.Data()))
+}
+
+func TestSessionSetCodecOverridesController(t *testing.T) {
+	w := New(context.Background(), nil)
+
+	s, buf := newBufferedSession(w, 1)
+	codec := &recordingCodec{}
+	s.SetCodec(codec)
+	require.NoError(t, s.WriteTextMessage("hello"))
+
+	require.Equal(t, []any{"hello"}, codec.marshaled)
+	require.Equal(t, "encoded", string((<-buf).Data()))
+}
+
+func TestDefaultCodecIsJSON(t *testing.T) {
+	w := New(context.Background(), nil)
+
+	s, buf := newBufferedSession(w, 1)
+	require.NoError(t, s.WriteTextMessage(map[string]any{"msg": "a"}))
+
+	m := <-buf
+	require.Equal(t, websocket.TextMessage, m.Type())
+	require.JSONEq(t, `{"msg":"a"}`, string(m.Data()))
+}
+
+func TestMsgpackCodecRoundTrip(t *testing.T) {
+	type message struct {
+		Topic string `json:"topic"`
+		Seq   int    `json:"seq"`
+	}
+
+	codec := NewMsgpackCodec()
+	data, err := codec.Marshal(message{Topic: "tasks", Seq: 7})
+	require.NoError(t, err)
+
+	var asMap map[string]any
+	require.NoError(t, codec.Unmarshal(data, &asMap))
+	require.Equal(t, "tasks", asMap["topic"], "fields are keyed by their json tag")
+
+	var got message
+	require.NoError(t, NewCtx(context.Background(), &Session{codec: codec}).Decode(data, &got))
+	require.Equal(t, message{Topic: "tasks", Seq: 7}, got)
+}
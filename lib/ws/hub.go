@@ -1,6 +1,8 @@
 package ws
 
 import (
+	"reflect"
+	"slices"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -28,6 +30,9 @@ var (
 type Hub struct {
 	mu         sync.RWMutex
 	topicRooms map[string]map[string]*Session
+
+	// suspended holds the subscriptions of closed sessions by resume token
+	suspended map[string]suspendedSession
 }
 
 func NewHub() *Hub {
@@ -93,7 +98,7 @@ func (h *Hub) broadcastExcept(topic string, data any, exceptID string) {
 		if id == exceptID {
 			continue
 		}
-		if err := s.WriteTextMessage(data); err != nil {
+		if err := s.writeText(data, true); err != nil {
 			broadcastErrorCounter.Inc()
 			wslog.Error(
 				"failed to write text message while broadcasting",
@@ -113,7 +118,7 @@ func (h *Hub) Broadcast(topic string, data any) {
 		return
 	}
 	for _, s := range rooms {
-		if err := s.WriteTextMessage(data); err != nil {
+		if err := s.writeText(data, true); err != nil {
 			broadcastErrorCounter.Inc()
 			wslog.Error(
 				"failed to write text message while broadcasting",
@@ -140,7 +145,7 @@ func (h *Hub) broadcastExceptBinary(topic string, data []byte, exceptID string)
 		if id == exceptID {
 			continue
 		}
-		s.WriteBinaryMessage(data)
+		s.writeBinary(data, true)
 	}
 }
 
@@ -153,6 +158,60 @@ func (h *Hub) BroadcastBinary(topic string, data []byte) {
 		return
 	}
 	for _, s := range rooms {
-		s.WriteBinaryMessage(data)
+		s.writeBinary(data, true)
+	}
+}
+
+// BroadcastMany sends data once to every session subscribed to at least one of the topics.
+// The payload is encoded once per codec rather than once per session.
+func (h *Hub) BroadcastMany(topics []string, data any) {
+	h.mu.RLock()
+	targets := make(map[string]*Session)
+	for _, topic := range topics {
+		for id, s := range h.topicRooms[topic] {
+			targets[id] = s
+		}
+	}
+	h.mu.RUnlock()
+
+	encoded := make(map[Codec]BufMsg)
+	for _, s := range targets {
+		m, err := encodeFor(encoded, s.Codec(), data)
+		if err == nil {
+			if len(s.outbound) > 0 {
+				// outbound middlewares may modify the payload in place
+				m = m.WithData(slices.Clone(m.Data()))
+			}
+			err = s.enqueue(m, true)
+		}
+		if err != nil {
+			broadcastErrorCounter.Inc()
+			wslog.Error(
+				"failed to write text message while broadcasting",
+				zap.Error(err),
+				zap.Strings("topics", topics),
+				zap.String("session_id", s.id),
+			)
+		}
+	}
+}
+
+// encodeFor encodes data with codec, reusing the result of an earlier call with the same
+// codec. Codecs of non comparable types are not cached.
+func encodeFor(cache map[Codec]BufMsg, codec Codec, data any) (BufMsg, error) {
+	cacheable := reflect.TypeOf(codec).Comparable()
+	if cacheable {
+		if m, ok := cache[codec]; ok {
+			return m, nil
+		}
+	}
+	msg, err := codec.Marshal(data)
+	if err != nil {
+		return BufMsg{}, err
+	}
+	m := BufMsg{mt: codec.MessageType(), msg: msg}
+	if cacheable {
+		cache[codec] = m
 	}
+	return m, nil
 }
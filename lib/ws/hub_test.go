@@ -0,0 +1,85 @@
+package ws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// newFullSession returns a subscribed session whose write buffer is already full.
+func newFullSession(t *testing.T, w *WebsocketController, topic string) (*Session, context.Context) {
+	t.Helper()
+	buf := make(chan BufMsg, 1)
+	buf <- BufMsg{}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	s := &Session{
+		id:                 "slow",
+		writeBuf:           buf,
+		cancel:             cancel,
+		sessionIDKey:       defaultWsSessionIDKey,
+		hub:                w.Hub(),
+		dropOnBackpressure: w.dropOnBackpressure,
+	}
+	require.NoError(t, w.Hub().Subscribe(topic, s))
+	return s, ctx
+}
+
+func TestBroadcastDropsForSlowSession(t *testing.T) {
+	w := New(context.Background(), &WsCfg{DropOnBackpressure: true})
+	require.NoError(t, w.Hub().AddTopic("prices"))
+	s, ctx := newFullSession(t, w, "prices")
+	dropped := testutil.ToFloat64(droppedMessagesCounter)
+
+	w.Hub().Broadcast("prices", map[string]any{"price": 1})
+	w.Hub().BroadcastBinary("prices", []byte{0x1})
+
+	require.Equal(t, int64(2), s.DroppedMessages())
+	require.Equal(t, dropped+2, testutil.ToFloat64(droppedMessagesCounter))
+	require.NoError(t, context.Cause(ctx), "the session stays open")
+
+	require.ErrorIs(t, s.WriteTextMessage("direct"), ErrBackpressure, "direct writes are not dropped")
+	require.ErrorIs(t, context.Cause(ctx), ErrBackpressure)
+}
+
+func TestBroadcastClosesSlowSessionByDefault(t *testing.T) {
+	w := New(context.Background(), nil)
+	require.NoError(t, w.Hub().AddTopic("prices"))
+	s, ctx := newFullSession(t, w, "prices")
+
+	w.Hub().Broadcast("prices", map[string]any{"price": 1})
+
+	require.Zero(t, s.DroppedMessages())
+	require.ErrorIs(t, context.Cause(ctx), ErrBackpressure)
+}
+
+func TestBroadcastManyDeliversOncePerSession(t *testing.T) {
+	w := New(context.Background(), nil)
+	for _, topic := range []string{"orders", "prices", "alerts"} {
+		require.NoError(t, w.Hub().AddTopic(topic))
+	}
+
+	both, bothBuf := newBufferedSession(w, 4)
+	both.id = "both"
+	orders, ordersBuf := newBufferedSession(w, 4)
+	orders.id = "orders"
+	alerts, alertsBuf := newBufferedSession(w, 4)
+	alerts.id = "alerts"
+	require.NoError(t, w.Hub().Subscribe("orders", both))
+	require.NoError(t, w.Hub().Subscribe("prices", both))
+	require.NoError(t, w.Hub().Subscribe("orders", orders))
+	require.NoError(t, w.Hub().Subscribe("alerts", alerts))
+
+	codec := &recordingCodec{}
+	both.SetCodec(codec)
+	orders.SetCodec(codec)
+
+	w.Hub().BroadcastMany([]string{"orders", "prices"}, "event")
+
+	require.Len(t, bothBuf, 1)
+	require.Len(t, ordersBuf, 1)
+	require.Empty(t, alertsBuf)
+	require.Len(t, codec.marshaled, 1, "the payload is encoded once per codec")
+	require.Equal(t, "encoded", string((<-bothBuf).Data()))
+}
@@ -0,0 +1,47 @@
+package ws
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var activeConnectionsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "anclax_ws_active_connections",
+	Help: "Current number of open websocket connections",
+})
+
+var messagesSentCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "anclax_ws_messages_sent_total",
+	Help: "Total number of websocket messages sent",
+})
+
+var messagesReceivedCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "anclax_ws_messages_received_total",
+	Help: "Total number of websocket messages received",
+})
+
+var bytesSentCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "anclax_ws_sent_bytes_total",
+	Help: "Total number of websocket payload bytes sent",
+})
+
+var bytesReceivedCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "anclax_ws_received_bytes_total",
+	Help: "Total number of websocket payload bytes received",
+})
+
+var droppedMessagesCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "anclax_ws_dropped_messages_total",
+	Help: "Total number of broadcast messages dropped for sessions that could not keep up",
+})
+
+var closesCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "anclax_ws_closes_total",
+	Help: "Total number of closed websocket connections by reason",
+}, []string{"reason"})
+
+var pingRTTHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
+	Name:    "anclax_ws_ping_rtt_seconds",
+	Help:    "Round-trip time between a websocket ping and its pong",
+	Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+})
@@ -0,0 +1,100 @@
+package ws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	fasthttpws "github.com/fasthttp/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionMetrics(t *testing.T) {
+	recorder := &sessionRecorder{created: make(chan *Session, 1)}
+	w := New(context.Background(), &WsCfg{Handler: recorder})
+	require.NoError(t, w.Hub().AddTopic("updates"))
+	addr := serve(t, w)
+
+	// connections of other tests are released asynchronously after their clients close
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(activeConnectionsGauge) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	received := testutil.ToFloat64(messagesReceivedCounter)
+	receivedBytes := testutil.ToFloat64(bytesReceivedCounter)
+	clientClosed := testutil.ToFloat64(closesCounter.WithLabelValues("client_closed"))
+
+	conn, _, err := fasthttpws.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+	require.NoError(t, err)
+	recorder.wait(t)
+	require.Equal(t, float64(1), testutil.ToFloat64(activeConnectionsGauge))
+
+	require.NoError(t, conn.WriteMessage(fasthttpws.TextMessage, []byte("hello")))
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(messagesReceivedCounter) == received+1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, receivedBytes+5, testutil.ToFloat64(bytesReceivedCounter))
+
+	require.NoError(t, conn.WriteMessage(fasthttpws.CloseMessage, fasthttpws.FormatCloseMessage(fasthttpws.CloseNormalClosure, "")))
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(activeConnectionsGauge) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, clientClosed+1, testutil.ToFloat64(closesCounter.WithLabelValues("client_closed")))
+	require.NoError(t, conn.Close())
+}
+
+func TestSessionRecordsPingRTT(t *testing.T) {
+	s := &Session{}
+	require.Zero(t, s.LastRTT())
+
+	_, ok := s.pongReceived("", time.Now())
+	require.False(t, ok, "unsolicited pong")
+
+	sent := time.Now()
+	first := s.pingSent(sent)
+	second := s.pingSent(sent.Add(time.Second))
+	require.NotEqual(t, first, second)
+
+	_, ok = s.pongReceived(string(first), sent.Add(1100*time.Millisecond))
+	require.False(t, ok, "a late pong of an earlier ping is not matched to the latest one")
+
+	rtt, ok := s.pongReceived(string(second), sent.Add(1050*time.Millisecond))
+	require.True(t, ok)
+	require.Equal(t, 50*time.Millisecond, rtt)
+	require.Equal(t, 50*time.Millisecond, s.LastRTT())
+
+	_, ok = s.pongReceived(string(second), sent.Add(2*time.Second))
+	require.False(t, ok, "the ping is already answered")
+	require.Equal(t, 50*time.Millisecond, s.LastRTT())
+}
+
+func TestPingRTTMeasuredFromDelayedPong(t *testing.T) {
+	recorder := &sessionRecorder{created: make(chan *Session, 1)}
+	w := New(context.Background(), &WsCfg{Handler: recorder, PingIntervalSeconds: 1})
+	require.NoError(t, w.Hub().AddTopic("updates"))
+	addr := serve(t, w)
+
+	conn, _, err := fasthttpws.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	delay := 100 * time.Millisecond
+	conn.SetPingHandler(func(data string) error {
+		time.Sleep(delay)
+		return conn.WriteControl(fasthttpws.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	// control frames are handled while reading
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	session := recorder.wait(t)
+	require.Eventually(t, func() bool {
+		return session.LastRTT() >= delay
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Less(t, session.LastRTT(), 2*time.Second)
+}
@@ -0,0 +1,82 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gofiber/contrib/v3/websocket"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func newBufferedSession(w *WebsocketController, size int) (*Session, chan BufMsg) {
+	buf := make(chan BufMsg, size)
+	_, cancel := context.WithCancelCause(context.Background())
+	return &Session{
+		id:           "test",
+		writeBuf:     buf,
+		cancel:       cancel,
+		sessionIDKey: defaultWsSessionIDKey,
+		hub:          w.Hub(),
+		outbound:     w.outbound,
+		codec:        w.codec,
+	}, buf
+}
+
+func TestOutboundMiddlewareTransformsPayload(t *testing.T) {
+	w := New(context.Background(), nil)
+
+	var seq atomic.Int64
+	w.UseOutbound(func(m BufMsg) (BufMsg, error) {
+		if m.Type() != websocket.TextMessage {
+			return m, nil
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(m.Data(), &payload); err != nil {
+			return m, err
+		}
+		payload["seq"] = seq.Add(1)
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return m, err
+		}
+		return m.WithData(data), nil
+	})
+	w.UseOutbound(func(m BufMsg) (BufMsg, error) {
+		return m.WithData(append(m.Data(), '\n')), nil
+	})
+
+	s, buf := newBufferedSession(w, 4)
+
+	require.NoError(t, s.WriteTextMessage(map[string]any{"msg": "a"}))
+	require.NoError(t, s.WriteTextMessage(map[string]any{"msg": "b"}))
+	require.NoError(t, s.WriteBinaryMessage([]byte{0x1}))
+
+	require.Equal(t, "{\"msg\":\"a\",\"seq\":1}\n", string((<-buf).Data()))
+	require.Equal(t, "{\"msg\":\"b\",\"seq\":2}\n", string((<-buf).Data()))
+
+	m := <-buf
+	require.Equal(t, websocket.BinaryMessage, m.Type())
+	require.Equal(t, []byte{0x1, '\n'}, m.Data())
+}
+
+func TestOutboundMiddlewareErrorDropsMessage(t *testing.T) {
+	w := New(context.Background(), nil)
+
+	var calls int
+	w.UseOutbound(func(m BufMsg) (BufMsg, error) {
+		return m, errors.New("redaction failed")
+	})
+	w.UseOutbound(func(m BufMsg) (BufMsg, error) {
+		calls++
+		return m, nil
+	})
+
+	s, buf := newBufferedSession(w, 1)
+
+	require.NoError(t, s.WriteTextMessage("secret"))
+	require.Empty(t, buf)
+	require.Zero(t, calls)
+}
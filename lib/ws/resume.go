@@ -0,0 +1,91 @@
+package ws
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// QueryResume is the query parameter carrying the resume token of a previous session.
+const QueryResume = "resume"
+
+// suspendedSession keeps the subscriptions of a closed session until the client resumes
+// it or the entry expires.
+type suspendedSession struct {
+	topics    []string
+	identity  *Identity
+	expiresAt time.Time
+}
+
+func newResumeToken() string {
+	return uuid.New().String()
+}
+
+// suspend unsubscribes the session from all topics and keeps them under its resume token
+// until expiresAt.
+func (h *Hub) suspend(s *Session, now, expiresAt time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for token, suspended := range h.suspended {
+		if !now.Before(suspended.expiresAt) {
+			delete(h.suspended, token)
+		}
+	}
+
+	var topics []string
+	for topic, rooms := range h.topicRooms {
+		if _, ok := rooms[s.id]; ok {
+			delete(rooms, s.id)
+			subscriptionGauge.Dec()
+			topics = append(topics, topic)
+		}
+	}
+	if len(topics) == 0 {
+		return
+	}
+	if h.suspended == nil {
+		h.suspended = make(map[string]suspendedSession)
+	}
+	h.suspended[s.resumeToken] = suspendedSession{
+		topics:    topics,
+		identity:  s.identity,
+		expiresAt: expiresAt,
+	}
+}
+
+// resume subscribes the session to the topics suspended under token and returns them. A
+// token can be used once, and only by a session of the same identity.
+func (h *Hub) resume(token string, s *Session, now time.Time) []string {
+	h.mu.Lock()
+	suspended, ok := h.suspended[token]
+	if !ok || !sameIdentity(suspended.identity, s.identity) {
+		h.mu.Unlock()
+		return nil
+	}
+	delete(h.suspended, token)
+	h.mu.Unlock()
+
+	if !now.Before(suspended.expiresAt) {
+		return nil
+	}
+
+	resumed := make([]string, 0, len(suspended.topics))
+	for _, topic := range suspended.topics {
+		if err := h.Subscribe(topic, s); err != nil && !errors.Is(err, ErrAlreadySubscribed) {
+			wslog.Warn("failed to resume subscription", zap.Error(err), zap.String("topic", topic), zap.String("session_id", s.id))
+			continue
+		}
+		resumed = append(resumed, topic)
+	}
+	return resumed
+}
+
+func sameIdentity(a, b *Identity) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
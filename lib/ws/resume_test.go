@@ -0,0 +1,87 @@
+package ws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	fasthttpws "github.com/fasthttp/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func subscribedTopics(h *Hub, s *Session) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var topics []string
+	for topic, rooms := range h.topicRooms {
+		if _, ok := rooms[s.id]; ok {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+func TestHubResumeInheritsSubscriptions(t *testing.T) {
+	h := NewHub()
+	require.NoError(t, h.AddTopic("orders"))
+	require.NoError(t, h.AddTopic("prices"))
+
+	now := time.Now()
+	old := &Session{id: "old", resumeToken: "token", identity: &Identity{UserID: 1}}
+	require.NoError(t, h.Subscribe("orders", old))
+	require.NoError(t, h.Subscribe("prices", old))
+
+	h.suspend(old, now, now.Add(time.Minute))
+	require.Empty(t, subscribedTopics(h, old))
+
+	stranger := &Session{id: "stranger", identity: &Identity{UserID: 2}}
+	require.Empty(t, h.resume("token", stranger, now))
+
+	resumed := &Session{id: "new", identity: &Identity{UserID: 1}}
+	require.ElementsMatch(t, []string{"orders", "prices"}, h.resume("token", resumed, now))
+	require.ElementsMatch(t, []string{"orders", "prices"}, subscribedTopics(h, resumed))
+
+	require.Empty(t, h.resume("token", &Session{id: "again", identity: &Identity{UserID: 1}}, now), "tokens are single use")
+}
+
+func TestHubResumeExpires(t *testing.T) {
+	h := NewHub()
+	require.NoError(t, h.AddTopic("orders"))
+
+	now := time.Now()
+	old := &Session{id: "old", resumeToken: "token"}
+	require.NoError(t, h.Subscribe("orders", old))
+	h.suspend(old, now, now.Add(time.Minute))
+
+	require.Empty(t, h.resume("token", &Session{id: "new"}, now.Add(time.Minute)))
+}
+
+func TestReconnectWithResumeToken(t *testing.T) {
+	recorder := &sessionRecorder{created: make(chan *Session, 2)}
+	w := New(context.Background(), &WsCfg{Handler: recorder, ResumeTTLSeconds: 60})
+	require.NoError(t, w.Hub().AddTopic("updates"))
+	require.NoError(t, w.Hub().AddTopic("orders"))
+	addr := serve(t, w)
+
+	conn, _, err := fasthttpws.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+	require.NoError(t, err)
+	first := recorder.wait(t)
+	require.NotEmpty(t, first.ResumeToken())
+	require.NoError(t, w.Hub().Subscribe("orders", first))
+
+	require.NoError(t, conn.Close())
+	require.Eventually(t, func() bool {
+		return len(subscribedTopics(w.Hub(), first)) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	conn, _, err = fasthttpws.DefaultDialer.Dial("ws://"+addr+"/ws?resume="+first.ResumeToken(), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	second := recorder.wait(t)
+
+	require.Eventually(t, func() bool {
+		return len(subscribedTopics(w.Hub(), second)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.ElementsMatch(t, []string{"updates", "orders"}, subscribedTopics(w.Hub(), second))
+	require.NotEqual(t, first.ResumeToken(), second.ResumeToken())
+}
@@ -2,9 +2,10 @@ package ws
 
 import (
 	"context"
-	"encoding/json"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -31,6 +32,8 @@ const (
 	defaultPingInterval = 30 * time.Second
 	defaultWriteWait    = 10 * time.Second
 
+	defaultCompressionThreshold = 1024
+
 	defaultWsSessionIDKey = "ws_session_id"
 )
 
@@ -39,6 +42,25 @@ type BufMsg struct {
 	msg []byte
 }
 
+// Type returns the websocket message type, websocket.TextMessage or websocket.BinaryMessage.
+func (m BufMsg) Type() int {
+	return m.mt
+}
+
+func (m BufMsg) Data() []byte {
+	return m.msg
+}
+
+// WithData returns a copy of the message carrying data instead.
+func (m BufMsg) WithData(data []byte) BufMsg {
+	m.msg = data
+	return m
+}
+
+// OutboundMiddleware transforms a message before it is queued for writing. Returning
+// an error drops the message.
+type OutboundMiddleware func(BufMsg) (BufMsg, error)
+
 type Session struct {
 	id           string
 	conn         *websocket.Conn
@@ -48,10 +70,29 @@ type Session struct {
 	close        func(err error)
 	sessionIDKey string
 	hub          *Hub
+	identity     *Identity
+	outbound     []OutboundMiddleware
+	codec        Codec
+
+	// pingSeq is the payload of the latest ping, sent at pingSentAt (zero once answered).
+	// They are shared by the writer goroutine and the pong handler.
+	pingMu     sync.Mutex
+	pingSeq    uint64
+	pingSentAt time.Time
+	lastRTT    atomic.Int64
+
+	resumeToken string
+
+	dropOnBackpressure bool
+	dropped            atomic.Int64
 }
 
 func NewSession(conn *websocket.Conn, writeBuf chan<- BufMsg, cancel context.CancelCauseFunc, sessionIDKey string, hub *Hub) *Session {
-	id := uuid.New().String()
+	return NewSessionWithID(conn, uuid.New().String(), writeBuf, cancel, sessionIDKey, hub)
+}
+
+// NewSessionWithID creates a session like NewSession, with the given ID instead of a random UUID.
+func NewSessionWithID(conn *websocket.Conn, id string, writeBuf chan<- BufMsg, cancel context.CancelCauseFunc, sessionIDKey string, hub *Hub) *Session {
 	conn.Locals(sessionIDKey, id)
 	return &Session{
 		conn:         conn,
@@ -83,10 +124,67 @@ func (s *Session) ID() string {
 	return s.id
 }
 
+// Identity returns the authenticated principal of the session, or nil when the
+// controller has no Authenticator.
+func (s *Session) Identity() *Identity {
+	return s.identity
+}
+
 func (s *Session) Conn() *websocket.Conn {
 	return s.conn
 }
 
+// ResumeToken returns the token a reconnecting client passes in the `resume` query
+// parameter to get the subscriptions of this session back, empty if resuming is disabled.
+func (s *Session) ResumeToken() string {
+	return s.resumeToken
+}
+
+// LastRTT returns the round-trip time of the latest ping answered by the client, 0 before
+// the first pong.
+func (s *Session) LastRTT() time.Duration {
+	return time.Duration(s.lastRTT.Load())
+}
+
+// pingSent records a ping sent at the given time and returns its payload, a sequence number
+// the client echoes in the pong.
+func (s *Session) pingSent(at time.Time) []byte {
+	s.pingMu.Lock()
+	defer s.pingMu.Unlock()
+	s.pingSeq++
+	s.pingSentAt = at
+	return []byte(strconv.FormatUint(s.pingSeq, 10))
+}
+
+// pongReceived records the round-trip time of the latest ping if appData carries its
+// sequence number, it returns false for unsolicited pongs and pongs of earlier pings.
+func (s *Session) pongReceived(appData string, at time.Time) (time.Duration, bool) {
+	s.pingMu.Lock()
+	defer s.pingMu.Unlock()
+	if s.pingSentAt.IsZero() || appData != strconv.FormatUint(s.pingSeq, 10) {
+		return 0, false
+	}
+	rtt := at.Sub(s.pingSentAt)
+	s.pingSentAt = time.Time{}
+	s.lastRTT.Store(int64(rtt))
+	return rtt, true
+}
+
+// Codec returns the codec of the session, JSONCodec unless the controller or SetCodec
+// chose another one.
+func (s *Session) Codec() Codec {
+	if s.codec == nil {
+		return JSONCodec{}
+	}
+	return s.codec
+}
+
+// SetCodec changes the codec of the session, e.g. in OnSessionCreated after negotiating
+// it with the client.
+func (s *Session) SetCodec(codec Codec) {
+	s.codec = codec
+}
+
 func (s *Session) Broadcast(topic string, data any) {
 	s.hub.broadcastExcept(topic, data, s.id)
 }
@@ -97,32 +195,61 @@ func (s *Session) BroadcastBinary(topic string, data []byte) {
 	s.hub.broadcastExceptBinary(topic, data, s.id)
 }
 
+// WriteTextMessage encodes data with the session codec and queues it, the frame type
+// follows the codec.
 func (s *Session) WriteTextMessage(data any) error {
-	msg, err := json.Marshal(data)
+	return s.writeText(data, false)
+}
+
+func (s *Session) WriteBinaryMessage(data []byte) error {
+	return s.writeBinary(data, false)
+}
+
+// DroppedMessages returns how many broadcast messages were dropped because the session
+// could not keep up, see WsCfg.DropOnBackpressure.
+func (s *Session) DroppedMessages() int64 {
+	return s.dropped.Load()
+}
+
+func (s *Session) writeText(data any, droppable bool) error {
+	codec := s.Codec()
+	msg, err := codec.Marshal(data)
 	if err != nil {
 		return err
 	}
-
-	select {
-	case s.writeBuf <- BufMsg{mt: websocket.TextMessage, msg: msg}:
-		return nil
-	default:
-		s.cancel(ErrBackpressure)
-		return ErrBackpressure
-	}
+	return s.enqueue(BufMsg{mt: codec.MessageType(), msg: msg}, droppable)
 }
 
-func (s *Session) WriteBinaryMessage(data []byte) error {
+func (s *Session) writeBinary(data []byte, droppable bool) error {
 	if data == nil {
 		data = []byte{}
 	}
+	return s.enqueue(BufMsg{mt: websocket.BinaryMessage, msg: data}, droppable)
+}
+
+// enqueue queues the message for the writer. On a full buffer the session is closed with
+// ErrBackpressure, unless the message is droppable and the controller drops on backpressure.
+func (s *Session) enqueue(m BufMsg, droppable bool) error {
+	for _, middleware := range s.outbound {
+		var err error
+		if m, err = middleware(m); err != nil {
+			wslog.Warn("dropped outbound message", zap.Error(err), zap.String(s.sessionIDKey, s.ID()))
+			return nil
+		}
+	}
+
 	select {
-	case s.writeBuf <- BufMsg{mt: websocket.BinaryMessage, msg: data}:
+	case s.writeBuf <- m:
 		return nil
 	default:
-		s.cancel(ErrBackpressure)
-		return ErrBackpressure
 	}
+	if droppable && s.dropOnBackpressure {
+		s.dropped.Add(1)
+		droppedMessagesCounter.Inc()
+		return nil
+	}
+	s.cancel(ErrBackpressure)
+	return ErrBackpressure
 }
 
 type Ctx struct {
@@ -149,6 +276,11 @@ func (c *Ctx) SetID(id string) {
 	c.ID = &id
 }
 
+// Decode decodes an incoming message with the session codec.
+func (c *Ctx) Decode(data []byte, v any) error {
+	return c.Codec().Unmarshal(data, v)
+}
+
 type Handler interface {
 	OnSessionCreated(s *Session) error
 	Handle(ctx *Ctx, data []byte) error
@@ -164,6 +296,14 @@ func (h *defaultHandler) Handle(*Ctx, []byte) error {
 	return ErrHandlerNotRegistered
 }
 
+// IDGenerator produces the session ID for a new websocket connection. It runs
+// before OnSessionCreated, so the upgrade request locals are available on conn.
+type IDGenerator func(conn *websocket.Conn) string
+
+func defaultIDGenerator(*websocket.Conn) string {
+	return uuid.New().String()
+}
+
 type WebsocketController struct {
 	ctx context.Context
 	hub *Hub
@@ -175,8 +315,19 @@ type WebsocketController struct {
 	wsSessionIDKey string
 	wsPath         string
 
-	handler     Handler
-	middlewares []fiber.Handler
+	enableCompression    bool
+	compressionThreshold int
+
+	resumeTTL time.Duration
+
+	dropOnBackpressure bool
+
+	handler       Handler
+	middlewares   []fiber.Handler
+	idGenerator   IDGenerator
+	authenticator Authenticator
+	outbound      []OutboundMiddleware
+	codec         Codec
 }
 
 type WsCfg struct {
@@ -198,12 +349,43 @@ type WsCfg struct {
 	// (optional) Default is ws_session_id, the key to store the session ID in the websocket connection locals.
 	SessionIDKey string
 
+	// (optional) Default is false, whether to negotiate permessage-deflate compression with clients.
+	EnableCompression bool
+
+	// (optional) Default is 1KB, text messages smaller than this are sent uncompressed when compression is enabled.
+	CompressionThreshold int
+
 	// (optional, runtime only) Handler used by the websocket controller.
 	Handler Handler `json:"-" yaml:"-"`
 
 	// (optional, runtime only) Middlewares executed on the websocket upgrade request
 	// before Anclax applies its internal upgrade guard and websocket handler.
 	Middlewares []fiber.Handler `json:"-" yaml:"-"`
+
+	// (optional, runtime only) Default is a random UUID, generates the session ID of
+	// each connection. An empty result falls back to a random UUID.
+	IDGenerator IDGenerator `json:"-" yaml:"-"`
+
+	// (optional) Default is false, whether the upgrade request must carry a valid access token,
+	// either in the `token` query parameter or as `Sec-WebSocket-Protocol: bearer, <token>`.
+	RequireAuth bool
+
+	// (optional, runtime only) Validates the upgrade token when RequireAuth is set. The Anclax
+	// server uses its macaroon auth if this is nil.
+	Authenticator Authenticator `json:"-" yaml:"-"`
+
+	// (optional) Default is false, the session is closed with ErrBackpressure when its write buffer
+	// is full. If true, hub broadcasts that do not fit are dropped for that session and counted
+	// in Session.DroppedMessages instead; direct writes still close the session.
+	DropOnBackpressure bool
+
+	// (optional) Default is 0, disabled. How long the subscriptions of a closed session are kept
+	// for a reconnecting client presenting Session.ResumeToken.
+	ResumeTTLSeconds int64
+
+	// (optional, runtime only) Default is JSONCodec, encodes the messages written by sessions.
+	// Sessions can switch codecs with Session.SetCodec.
+	Codec Codec `json:"-" yaml:"-"`
 }
 
 func normalizeHandler(handler Handler) Handler {
@@ -256,11 +438,35 @@ func New(ctrlCtx context.Context, cfg *WsCfg) *WebsocketController {
 		wsPath = "/" + strings.Trim(cfg.WebSocketPath, "/")
 	}
 
+	var compressionThreshold = defaultCompressionThreshold
+	if cfg != nil && cfg.CompressionThreshold > 0 {
+		compressionThreshold = cfg.CompressionThreshold
+	}
+	var resumeTTL time.Duration
+	if cfg != nil && cfg.ResumeTTLSeconds > 0 {
+		resumeTTL = time.Duration(cfg.ResumeTTLSeconds) * time.Second
+	}
+
 	var handler Handler
 	var middlewares []fiber.Handler
+	var idGenerator IDGenerator = defaultIDGenerator
+	var authenticator Authenticator
+	var codec Codec = JSONCodec{}
 	if cfg != nil {
 		handler = cfg.Handler
+		if cfg.Codec != nil {
+			codec = cfg.Codec
+		}
 		middlewares = normalizeMiddlewares(cfg.Middlewares)
+		if cfg.IDGenerator != nil {
+			idGenerator = cfg.IDGenerator
+		}
+		if cfg.RequireAuth {
+			authenticator = cfg.Authenticator
+			if authenticator == nil {
+				authenticator = denyAll
+			}
+		}
 	}
 
 	return &WebsocketController{
@@ -274,6 +480,14 @@ func New(ctrlCtx context.Context, cfg *WsCfg) *WebsocketController {
 		wsPath:         wsPath,
 		handler:        normalizeHandler(handler),
 		middlewares:    middlewares,
+		idGenerator:    idGenerator,
+		authenticator:  authenticator,
+		codec:          codec,
+
+		enableCompression:    cfg != nil && cfg.EnableCompression,
+		compressionThreshold: compressionThreshold,
+		resumeTTL:            resumeTTL,
+		dropOnBackpressure:   cfg != nil && cfg.DropOnBackpressure,
 	}
 }
 
@@ -295,9 +509,42 @@ func (w *WebsocketController) Mount(app *fiber.App) {
 		return fiber.ErrUpgradeRequired
 	})
 
+	if w.authenticator != nil {
+		app.Use(w.wsPath, w.authGuard)
+	}
+
 	app.Get(w.wsPath, websocket.New(func(c *websocket.Conn) {
 		w.HandleConn(c)
-	}))
+	}, w.upgradeConfig()))
+}
+
+func (w *WebsocketController) upgradeConfig() websocket.Config {
+	cfg := websocket.Config{
+		EnableCompression: w.enableCompression,
+	}
+	if w.authenticator != nil {
+		cfg.Subprotocols = []string{SubprotocolBearer}
+	}
+	return cfg
+}
+
+// shouldCompress reports whether an outgoing message is worth compressing. Only text
+// frames are compressed, binary payloads are often compressed already.
+func (w *WebsocketController) shouldCompress(m BufMsg) bool {
+	return w.enableCompression && m.mt == websocket.TextMessage && len(m.msg) >= w.compressionThreshold
+}
+
+func (w *WebsocketController) sessionID(c *websocket.Conn) string {
+	if id := w.idGenerator(c); id != "" {
+		return id
+	}
+	return defaultIDGenerator(c)
+}
+
+// UseOutbound appends a middleware applied to every message written by sessions,
+// in registration order. It must be called before the controller accepts connections.
+func (w *WebsocketController) UseOutbound(middleware OutboundMiddleware) {
+	w.outbound = append(w.outbound, middleware)
 }
 
 func (w *WebsocketController) Hub() *Hub {
@@ -308,6 +555,12 @@ func (w *WebsocketController) HandleConn(c *websocket.Conn) {
 	ctx, cancel := context.WithCancelCause(w.ctx)
 	defer cancel(nil)
 
+	activeConnectionsGauge.Inc()
+	defer activeConnectionsGauge.Dec()
+	defer func() {
+		closesCounter.WithLabelValues(lookupCloseReason(context.Cause(ctx)).label).Inc()
+	}()
+
 	var (
 		onceClose sync.Once
 		writeBuf  = make(chan BufMsg, 128)
@@ -315,8 +568,20 @@ func (w *WebsocketController) HandleConn(c *websocket.Conn) {
 	)
 	defer close(writeBuf)
 
-	session := NewSession(c, writeBuf, cancel, w.wsSessionIDKey, w.hub)
+	session := NewSessionWithID(c, w.sessionID(c), writeBuf, cancel, w.wsSessionIDKey, w.hub)
+	session.identity = connIdentity(c)
+	session.outbound = w.outbound
+	session.codec = w.codec
+	session.dropOnBackpressure = w.dropOnBackpressure
 	defer session.release()
+	if w.resumeTTL > 0 {
+		session.resumeToken = newResumeToken()
+		// runs before release, so OnClose cleanup cannot drop the subscriptions first
+		defer func() {
+			now := time.Now()
+			w.hub.suspend(session, now, now.Add(w.resumeTTL))
+		}()
+	}
 
 	closeConn := func(err error) {
 		onceClose.Do(func() {
@@ -338,10 +603,19 @@ func (w *WebsocketController) HandleConn(c *websocket.Conn) {
 		return
 	}
 
+	if token := c.Query(QueryResume); w.resumeTTL > 0 && token != "" {
+		if topics := w.hub.resume(token, session, time.Now()); len(topics) > 0 {
+			wslog.Info("WebSocket session resumed", zap.Strings("topics", topics), zap.String(w.wsSessionIDKey, session.ID()))
+		}
+	}
+
 	c.SetReadLimit(w.readLimit)
 	_ = c.SetReadDeadline(time.Now().Add(w.idleTimeout))
 
-	c.SetPongHandler(func(string) error {
+	c.SetPongHandler(func(appData string) error {
+		if rtt, ok := session.pongReceived(appData, time.Now()); ok {
+			pingRTTHistogram.Observe(rtt.Seconds())
+		}
 		return c.SetReadDeadline(time.Now().Add(w.idleTimeout))
 	})
 
@@ -362,11 +636,12 @@ func (w *WebsocketController) HandleConn(c *websocket.Conn) {
 			select {
 			case <-ctx.Done():
 				_ = c.SetWriteDeadline(time.Now().Add(w.writeWait))
-				_ = c.WriteControl(websocket.CloseMessage, []byte{}, time.Now().Add(w.writeWait))
+				_ = c.WriteControl(websocket.CloseMessage, closeMessage(context.Cause(ctx)), time.Now().Add(w.writeWait))
 				_ = c.Close()
 				return
 			case <-pingTicker.C:
-				if err := c.WriteControl(websocket.PingMessage, nil, time.Now().Add(w.writeWait)); err != nil {
+				payload := session.pingSent(time.Now())
+				if err := c.WriteControl(websocket.PingMessage, payload, time.Now().Add(w.writeWait)); err != nil {
 					closeConn(errors.Wrap(err, "failed to send ping"))
 					return
 				}
@@ -375,10 +650,15 @@ func (w *WebsocketController) HandleConn(c *websocket.Conn) {
 					return
 				}
 				_ = c.SetWriteDeadline(time.Now().Add(w.writeWait))
+				if w.enableCompression {
+					c.EnableWriteCompression(w.shouldCompress(m))
+				}
 				if err := c.WriteMessage(m.mt, m.msg); err != nil {
 					closeConn(errors.Wrap(err, "write message error"))
 					return
 				}
+				messagesSentCounter.Inc()
+				bytesSentCounter.Add(float64(len(m.msg)))
 			}
 		}
 	}()
@@ -391,12 +671,14 @@ func (w *WebsocketController) HandleConn(c *websocket.Conn) {
 		for {
 			mt, msg, err := c.ReadMessage()
 			if err != nil {
-				closeConn(errors.Wrap(err, "read message error"))
+				closeConn(readError(err))
 				return
 			}
 			if mt != websocket.TextMessage && mt != websocket.BinaryMessage {
 				continue
 			}
+			messagesReceivedCounter.Inc()
+			bytesReceivedCounter.Add(float64(len(msg)))
 
 			if err := w.handler.Handle(wsCtx, msg); err != nil {
 				if errors.Is(err, ErrBiz) {
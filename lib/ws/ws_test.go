@@ -0,0 +1,127 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	fasthttpws "github.com/fasthttp/websocket"
+	"github.com/gofiber/contrib/v3/websocket"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionDisabledByDefault(t *testing.T) {
+	w := New(context.Background(), nil)
+
+	require.False(t, w.upgradeConfig().EnableCompression)
+	require.False(t, w.shouldCompress(BufMsg{mt: websocket.TextMessage, msg: []byte(strings.Repeat("a", 4096))}))
+}
+
+func TestCompressionEnabled(t *testing.T) {
+	w := New(context.Background(), &WsCfg{EnableCompression: true})
+
+	require.True(t, w.upgradeConfig().EnableCompression)
+	require.Equal(t, defaultCompressionThreshold, w.compressionThreshold)
+
+	large := []byte(strings.Repeat("a", defaultCompressionThreshold))
+	require.True(t, w.shouldCompress(BufMsg{mt: websocket.TextMessage, msg: large}))
+	require.False(t, w.shouldCompress(BufMsg{mt: websocket.TextMessage, msg: large[:defaultCompressionThreshold-1]}))
+	require.False(t, w.shouldCompress(BufMsg{mt: websocket.BinaryMessage, msg: large}))
+}
+
+func TestCompressionThreshold(t *testing.T) {
+	w := New(context.Background(), &WsCfg{EnableCompression: true, CompressionThreshold: 16})
+
+	require.True(t, w.shouldCompress(BufMsg{mt: websocket.TextMessage, msg: []byte(strings.Repeat("a", 16))}))
+	require.False(t, w.shouldCompress(BufMsg{mt: websocket.TextMessage, msg: []byte("short")}))
+}
+
+type sessionRecorder struct {
+	created chan *Session
+}
+
+func (h *sessionRecorder) OnSessionCreated(s *Session) error {
+	if err := s.hub.Subscribe("updates", s); err != nil {
+		return err
+	}
+	h.created <- s
+	return nil
+}
+
+func (h *sessionRecorder) Handle(*Ctx, []byte) error {
+	return nil
+}
+
+func (h *sessionRecorder) wait(t *testing.T) *Session {
+	t.Helper()
+	select {
+	case s := <-h.created:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("session was not created")
+		return nil
+	}
+}
+
+// serve mounts the controller on a fiber app listening on a random local port.
+func serve(t *testing.T, w *WebsocketController) string {
+	t.Helper()
+	app := fiber.New()
+	w.Mount(app)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = app.Listener(ln, fiber.ListenConfig{DisableStartupMessage: true})
+	}()
+	t.Cleanup(func() {
+		_ = app.Shutdown()
+	})
+	return ln.Addr().String()
+}
+
+func TestCustomIDGenerator(t *testing.T) {
+	recorder := &sessionRecorder{created: make(chan *Session, 1)}
+	w := New(context.Background(), &WsCfg{
+		Handler: recorder,
+		Middlewares: []fiber.Handler{func(c fiber.Ctx) error {
+			c.Locals("user_id", "42")
+			return c.Next()
+		}},
+		IDGenerator: func(conn *websocket.Conn) string {
+			return fmt.Sprintf("user-%v", conn.Locals("user_id"))
+		},
+	})
+	require.NoError(t, w.Hub().AddTopic("updates"))
+
+	addr := serve(t, w)
+
+	conn, _, err := fasthttpws.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	session := recorder.wait(t)
+
+	require.Equal(t, "user-42", session.ID())
+	require.Equal(t, "user-42", session.Conn().Locals(defaultWsSessionIDKey))
+
+	w.Hub().mu.RLock()
+	_, ok := w.Hub().topicRooms["updates"]["user-42"]
+	w.Hub().mu.RUnlock()
+	require.True(t, ok)
+}
+
+func TestEmptyGeneratedIDFallsBackToUUID(t *testing.T) {
+	w := New(context.Background(), &WsCfg{
+		IDGenerator: func(*websocket.Conn) string { return "" },
+	})
+
+	id := w.sessionID(nil)
+	_, err := uuid.Parse(id)
+	require.NoError(t, err)
+}
@@ -2,23 +2,34 @@ package app
 
 import (
 	"context"
+	"time"
 
 	"github.com/cloudcarver/anclax/pkg/app/closer"
 	"github.com/cloudcarver/anclax/pkg/auth"
 	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/cloudcarver/anclax/pkg/eventbus"
 	"github.com/cloudcarver/anclax/pkg/globalctx"
 	"github.com/cloudcarver/anclax/pkg/hooks"
 	"github.com/cloudcarver/anclax/pkg/macaroons"
+	keystore "github.com/cloudcarver/anclax/pkg/macaroons/store"
 	"github.com/cloudcarver/anclax/pkg/metrics"
 	"github.com/cloudcarver/anclax/pkg/server"
 	"github.com/cloudcarver/anclax/pkg/service"
 	taskctrl "github.com/cloudcarver/anclax/pkg/taskcore/ctrl"
 	taskcore "github.com/cloudcarver/anclax/pkg/taskcore/store"
 	"github.com/cloudcarver/anclax/pkg/taskcore/worker"
+	"github.com/cloudcarver/anclax/pkg/utils"
+	"github.com/cloudcarver/anclax/pkg/zgen/taskgen"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
+const (
+	eventArchiveUniqueTag        = "anclax:archive-events"
+	defaultEventArchiveRetention = 30 * 24 * time.Hour
+	defaultEventArchiveCron      = "0 0 3 * * *"
+)
+
 type Application struct {
 	server             *server.Server
 	prometheus         *metrics.MetricsServer
@@ -31,8 +42,11 @@ type Application struct {
 	service            service.ServiceInterface
 	hooks              hooks.AnclaxHookInterface
 	caveatParser       macaroons.CaveatParserInterface
+	eventBus           *eventbus.EventBus
 	globalctx          *globalctx.GlobalContext
 	cm                 *closer.CloserManager
+	startablePlugins   []StartablePlugin
+	keySweeper         *keystore.Sweeper
 }
 
 func NewApplication(
@@ -48,6 +62,9 @@ func NewApplication(
 	service service.ServiceInterface,
 	hooks hooks.AnclaxHookInterface,
 	caveatParser macaroons.CaveatParserInterface,
+	eventBus *eventbus.EventBus,
+	taskRunner taskgen.TaskRunner,
+	keyStore keystore.KeyStore,
 	cm *closer.CloserManager,
 ) (*Application, error) {
 
@@ -57,6 +74,12 @@ func NewApplication(
 		}
 	}
 
+	if cfg.EventArchive != nil {
+		if err := scheduleEventArchive(context.TODO(), taskRunner, cfg.EventArchive); err != nil {
+			return nil, errors.Wrapf(err, "failed to schedule event archive")
+		}
+	}
+
 	app := &Application{
 		server:             server,
 		prometheus:         prometheus,
@@ -69,13 +92,31 @@ func NewApplication(
 		service:            service,
 		hooks:              hooks,
 		caveatParser:       caveatParser,
+		eventBus:           eventBus,
 		globalctx:          globalctx,
 		cm:                 cm,
 	}
 
+	if sweepInterval := utils.UnwrapOrDefault(cfg.Auth.KeySweepInterval, keystore.DefaultSweepInterval); sweepInterval > 0 {
+		app.keySweeper = keystore.NewSweeper(keyStore, sweepInterval)
+	}
+
 	return app, nil
 }
 
+// scheduleEventArchive pushes the archiveEvents cronjob. The unique tag makes
+// it a no-op when another instance already scheduled it.
+func scheduleEventArchive(ctx context.Context, taskRunner taskgen.TaskRunner, cfg *config.EventArchive) error {
+	retention := utils.UnwrapOrDefault(cfg.Retention, defaultEventArchiveRetention)
+	cron := utils.UnwrapOrDefault(cfg.Cron, defaultEventArchiveCron)
+	if _, err := taskRunner.RunArchiveEvents(ctx, &taskgen.ArchiveEventsParameters{
+		Retention: retention.String(),
+	}, taskcore.WithCronjob(cron), taskcore.WithUniqueTag(eventArchiveUniqueTag)); err != nil {
+		return errors.Wrapf(err, "failed to push archive events task")
+	}
+	return nil
+}
+
 func (a *Application) Start() error {
 	defer func() {
 		if r := recover(); r != nil {
@@ -86,6 +127,17 @@ func (a *Application) Start() error {
 		}
 	}()
 
+	if err := a.startPlugins(a.globalctx.Context()); err != nil {
+		return err
+	}
+
+	a.eventBus.Start(a.globalctx.Context())
+
+	if a.keySweeper != nil {
+		a.keySweeper.Start(a.globalctx.Context())
+		a.cm.Register(a.keySweeper.Stop)
+	}
+
 	go a.debugServer.Start()
 	go a.prometheus.Start()
 	if !a.disableWorker {
@@ -94,6 +146,18 @@ func (a *Application) Start() error {
 	return a.server.Listen()
 }
 
+// startPlugins starts the plugged startable plugins in order, and registers
+// their Stop to the closer manager once they are started.
+func (a *Application) startPlugins(ctx context.Context) error {
+	for _, plugin := range a.startablePlugins {
+		if err := plugin.Start(ctx); err != nil {
+			return errors.Wrapf(err, "failed to start plugin %T", plugin)
+		}
+		a.cm.Register(plugin.Stop)
+	}
+	return nil
+}
+
 func (a *Application) GetCloserManager() *closer.CloserManager {
 	return a.cm
 }
@@ -134,6 +198,10 @@ func (a *Application) GetCaveatParser() macaroons.CaveatParserInterface {
 	return a.caveatParser
 }
 
+func (a *Application) GetEventBus() *eventbus.EventBus {
+	return a.eventBus
+}
+
 func (a *Application) GetGlobalCtx() *globalctx.GlobalContext {
 	return a.globalctx
 }
@@ -143,6 +211,9 @@ func (a *Application) Plug(plugins ...Plugin) error {
 		if err := plugin.PlugTo(a); err != nil {
 			return errors.Wrapf(err, "failed to plug plugin %T", plugin)
 		}
+		if startable, ok := plugin.(StartablePlugin); ok {
+			a.startablePlugins = append(a.startablePlugins, startable)
+		}
 	}
 	return nil
 }
@@ -150,3 +221,12 @@ func (a *Application) Plug(plugins ...Plugin) error {
 type Plugin interface {
 	PlugTo(app *Application) error
 }
+
+// StartablePlugin is a plugin that owns background work. Start is called when
+// the application starts and must not block, Stop is called when the
+// application is closed.
+type StartablePlugin interface {
+	Plugin
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
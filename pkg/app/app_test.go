@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/app/closer"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePlugin struct {
+	plugged bool
+}
+
+func (p *fakePlugin) PlugTo(app *Application) error {
+	p.plugged = true
+	return nil
+}
+
+type fakeStartablePlugin struct {
+	fakePlugin
+	startErr error
+	started  bool
+	stopped  bool
+}
+
+func (p *fakeStartablePlugin) Start(ctx context.Context) error {
+	p.started = true
+	return p.startErr
+}
+
+func (p *fakeStartablePlugin) Stop(ctx context.Context) error {
+	p.stopped = true
+	return nil
+}
+
+func TestStartablePluginLifecycle(t *testing.T) {
+	a := &Application{cm: closer.NewCloserManager()}
+	plain := &fakePlugin{}
+	startable := &fakeStartablePlugin{}
+
+	require.NoError(t, a.Plug(plain, startable))
+	require.True(t, plain.plugged)
+	require.True(t, startable.plugged)
+	require.False(t, startable.started)
+
+	require.NoError(t, a.startPlugins(context.Background()))
+	require.True(t, startable.started)
+	require.False(t, startable.stopped)
+
+	a.Close()
+	require.True(t, startable.stopped)
+}
+
+func TestStartablePluginStartFailure(t *testing.T) {
+	a := &Application{cm: closer.NewCloserManager()}
+	failing := &fakeStartablePlugin{startErr: errors.New("boom")}
+	next := &fakeStartablePlugin{}
+
+	require.NoError(t, a.Plug(failing, next))
+	require.ErrorContains(t, a.startPlugins(context.Background()), "boom")
+	require.False(t, next.started)
+
+	a.Close()
+	require.False(t, failing.stopped)
+	require.False(t, next.stopped)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/cloudcarver/anclax/pkg/hooks"
 	"github.com/cloudcarver/anclax/pkg/taskcore/worker"
 	"github.com/cloudcarver/anclax/pkg/zcore/model"
 	"github.com/cloudcarver/anclax/pkg/zgen/taskgen"
@@ -17,15 +18,17 @@ const (
 type Executor struct {
 	model                     model.ModelInterface
 	runner                    taskgen.TaskRunner
+	hooks                     hooks.AnclaxHookInterface
 	localWorker               worker.WorkerInterface
 	now                       func() time.Time
 	runtimeConfigHeartbeatTTL time.Duration
 }
 
-func NewExecutor(cfg *config.Config, model model.ModelInterface, runner taskgen.TaskRunner) *Executor {
+func NewExecutor(cfg *config.Config, model model.ModelInterface, runner taskgen.TaskRunner, hooks hooks.AnclaxHookInterface) *Executor {
 	return &Executor{
 		model:                     model,
 		runner:                    runner,
+		hooks:                     hooks,
 		now:                       time.Now,
 		runtimeConfigHeartbeatTTL: runtimeConfigHeartbeatTTLFromConfig(cfg),
 	}
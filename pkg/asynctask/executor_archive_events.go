@@ -0,0 +1,25 @@
+package asynctask
+
+import (
+	"context"
+	"time"
+
+	taskcore "github.com/cloudcarver/anclax/pkg/taskcore/store"
+	"github.com/cloudcarver/anclax/pkg/taskcore/worker"
+	"github.com/cloudcarver/anclax/pkg/zgen/taskgen"
+	"github.com/pkg/errors"
+)
+
+func (e *Executor) ExecuteArchiveEvents(ctx context.Context, _ worker.Task, params *taskgen.ArchiveEventsParameters) error {
+	retention, err := time.ParseDuration(params.Retention)
+	if err != nil {
+		return errors.Wrapf(taskcore.ErrFatalTask, "invalid retention %q: %v", params.Retention, err)
+	}
+	if retention <= 0 {
+		return errors.Wrapf(taskcore.ErrFatalTask, "retention must be positive, got %s", params.Retention)
+	}
+	if _, err := e.model.ArchiveEventsBefore(ctx, e.now().Add(-retention)); err != nil {
+		return errors.Wrap(err, "archive events")
+	}
+	return nil
+}
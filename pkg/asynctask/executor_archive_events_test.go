@@ -0,0 +1,37 @@
+package asynctask
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	taskcore "github.com/cloudcarver/anclax/pkg/taskcore/store"
+	"github.com/cloudcarver/anclax/pkg/taskcore/worker"
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
+	"github.com/cloudcarver/anclax/pkg/zgen/taskgen"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExecuteArchiveEventsArchivesBeforeRetention(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	now := time.Date(2026, 1, 31, 3, 0, 0, 0, time.UTC)
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().ArchiveEventsBefore(ctx, now.Add(-24*time.Hour)).Return(int64(5), nil)
+
+	exec := &Executor{model: mockModel, now: func() time.Time { return now }}
+	err := exec.ExecuteArchiveEvents(ctx, worker.Task{}, &taskgen.ArchiveEventsParameters{Retention: "24h"})
+	require.NoError(t, err)
+}
+
+func TestExecuteArchiveEventsRejectsInvalidRetention(t *testing.T) {
+	exec := &Executor{now: time.Now}
+	for _, retention := range []string{"", "soon", "-1h"} {
+		err := exec.ExecuteArchiveEvents(context.Background(), worker.Task{}, &taskgen.ArchiveEventsParameters{Retention: retention})
+		require.True(t, errors.Is(err, taskcore.ErrFatalTask), retention)
+	}
+}
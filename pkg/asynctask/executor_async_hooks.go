@@ -0,0 +1,24 @@
+package asynctask
+
+import (
+	"context"
+
+	"github.com/cloudcarver/anclax/pkg/hooks"
+	taskcore "github.com/cloudcarver/anclax/pkg/taskcore/store"
+	"github.com/cloudcarver/anclax/pkg/taskcore/worker"
+	"github.com/cloudcarver/anclax/pkg/zgen/taskgen"
+	"github.com/pkg/errors"
+)
+
+func (e *Executor) ExecuteAsyncUserCreatedHook(ctx context.Context, _ worker.Task, params *taskgen.AsyncUserCreatedHookParameters) error {
+	if e.hooks == nil {
+		return errors.Wrap(taskcore.ErrFatalTask, "hooks are not configured")
+	}
+	if err := e.hooks.RunOnUserCreatedAsync(ctx, params.Hook, params.UserID); err != nil {
+		if errors.Is(err, hooks.ErrHookNotFound) {
+			return errors.Wrap(taskcore.ErrFatalTask, err.Error())
+		}
+		return errors.Wrapf(err, "run async user created hook %s", params.Hook)
+	}
+	return nil
+}
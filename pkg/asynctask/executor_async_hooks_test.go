@@ -0,0 +1,42 @@
+package asynctask
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/hooks"
+	taskcore "github.com/cloudcarver/anclax/pkg/taskcore/store"
+	"github.com/cloudcarver/anclax/pkg/taskcore/worker"
+	"github.com/cloudcarver/anclax/pkg/zgen/taskgen"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteAsyncUserCreatedHook(t *testing.T) {
+	ctx := context.Background()
+	hookErr := errors.New("smtp unavailable")
+
+	var ran []int32
+	baseHook := hooks.NewBaseHook()
+	require.NoError(t, baseHook.RegisterOnUserCreatedAsync("welcome-email", func(ctx context.Context, userID int32) error {
+		ran = append(ran, userID)
+		return nil
+	}))
+	require.NoError(t, baseHook.RegisterOnUserCreatedAsync("flaky", func(ctx context.Context, userID int32) error {
+		return hookErr
+	}))
+	exec := &Executor{hooks: baseHook}
+
+	err := exec.ExecuteAsyncUserCreatedHook(ctx, worker.Task{}, &taskgen.AsyncUserCreatedHookParameters{Hook: "welcome-email", UserID: 7})
+	require.NoError(t, err)
+	require.Equal(t, []int32{7}, ran)
+
+	// hook errors are retried
+	err = exec.ExecuteAsyncUserCreatedHook(ctx, worker.Task{}, &taskgen.AsyncUserCreatedHookParameters{Hook: "flaky", UserID: 7})
+	require.ErrorIs(t, err, hookErr)
+	require.False(t, errors.Is(err, taskcore.ErrFatalTask))
+
+	// hooks that are no longer registered are not
+	err = exec.ExecuteAsyncUserCreatedHook(ctx, worker.Task{}, &taskgen.AsyncUserCreatedHookParameters{Hook: "removed", UserID: 7})
+	require.True(t, errors.Is(err, taskcore.ErrFatalTask))
+}
@@ -15,6 +15,6 @@ func TestNewExecutorSetsRuntimeConfigHeartbeatTTL(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	exec := NewExecutor(&config.Config{}, model.NewMockModelInterface(ctrl), taskgen.NewMockTaskRunner(ctrl))
+	exec := NewExecutor(&config.Config{}, model.NewMockModelInterface(ctrl), taskgen.NewMockTaskRunner(ctrl), nil)
 	require.Equal(t, 9*time.Second, exec.runtimeConfigHeartbeatTTL)
 }
@@ -66,6 +66,10 @@ func (h *WorkerControlTaskHandler) OnTaskFailed(ctx context.Context, tx core.Tx,
 	return worker.ErrUnknownTaskType
 }
 
+func (h *WorkerControlTaskHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	return worker.ErrUnknownTaskType
+}
+
 func (h *WorkerControlTaskHandler) RegisterTaskHandler(handler worker.TaskHandler) {
 }
 
@@ -86,6 +86,9 @@ func TestWorkerControlTaskHandlerOnFailedAndRegister(t *testing.T) {
 	err := handler.OnTaskFailed(context.Background(), nil, worker.NewTaskSpec(apigen.TaskSpec{Type: "x"}), 1)
 	require.ErrorIs(t, err, worker.ErrUnknownTaskType)
 
+	err = handler.OnTaskCompleted(context.Background(), nil, worker.NewTaskSpec(apigen.TaskSpec{Type: "x"}), 1)
+	require.ErrorIs(t, err, worker.ErrUnknownTaskType)
+
 	// no-op, should not panic
 	handler.RegisterTaskHandler(nil)
 }
@@ -2,12 +2,14 @@ package auth
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"time"
 
 	"github.com/cloudcarver/anclax/pkg/config"
 	"github.com/cloudcarver/anclax/pkg/hooks"
 	"github.com/cloudcarver/anclax/pkg/macaroons"
+	"github.com/cloudcarver/anclax/pkg/macaroons/store"
 	"github.com/cloudcarver/anclax/pkg/utils"
 	"github.com/gofiber/fiber/v3"
 	"github.com/pkg/errors"
@@ -17,8 +19,17 @@ const (
 	ContextKeyUserID = iota
 	ContextKeyOrgID
 	ContextKeyMacaroon
+	ContextKeyResource
+	ContextKeyIdentity
+
+	// contextKeyResourceAuth marks requests authenticated by Auth.ResourceAuthFunc, the only
+	// place where a ResourceCaveat is accepted.
+	contextKeyResourceAuth
 )
 
+// QueryShareToken is the query parameter carrying the share token checked by Auth.ResourceAuthFunc.
+const QueryShareToken = "share_token"
+
 const (
 	DefaultTimeoutAccessToken  = time.Minute * 10
 	DefaultTimeoutRefreshToken = time.Hour * 2
@@ -38,13 +49,19 @@ type User struct {
 type AuthInterface interface {
 	Authfunc(c fiber.Ctx) error
 
+	// AuthenticateToken validates the given access token against the request, storing the
+	// token and its user context in the request locals. Authfunc uses it for the Authorization header.
+	// Only tokens issued by CreateUserTokens, or refreshed from them, are accepted.
+	AuthenticateToken(c fiber.Ctx, tokenString string) error
+
 	// CreateTokenWithRefreshToken creates both access token and refresh token
 	CreateUserTokens(ctx context.Context, userID int32, orgID int32, caveats ...macaroons.Caveat) (*macaroons.Macaroon, *macaroons.Macaroon, error)
 
 	// CreateToken creates a macaroon token, the group tracks related generated keys.
 	CreateToken(ctx context.Context, group string, ttl time.Duration, caveats ...macaroons.Caveat) (*macaroons.Macaroon, error)
 
-	// CreateRefreshToken creates a refresh token for the given group and access token.
+	// CreateRefreshToken creates a refresh token for the given group and access token. Both
+	// tokens belong to the same session.
 	CreateRefreshToken(ctx context.Context, group string, accessToken *macaroons.Macaroon, ttl time.Duration) (*macaroons.Macaroon, error)
 
 	// ParseRefreshToken parses the given refresh token and returns the carrying info
@@ -58,6 +75,13 @@ type AuthInterface interface {
 
 	// InvalidateToken invalidates the token with the given key ID
 	InvalidateToken(ctx context.Context, keyID int64) error
+
+	// ListUserTokens returns the keys of the unexpired tokens of the given user.
+	ListUserTokens(ctx context.Context, userID int32) ([]store.KeyInfo, error)
+
+	// ResourceAuthFunc returns a middleware that only lets through requests whose share token grants
+	// access to the resource of resourceType identified by the route parameter idParam.
+	ResourceAuthFunc(resourceType, idParam string) fiber.Handler
 }
 
 type Auth struct {
@@ -75,6 +99,23 @@ func UserTokenGroup(userID int32) string {
 	return fmt.Sprintf("user:%d", userID)
 }
 
+func ResourceTokenGroup(resourceType, resourceID string) string {
+	return fmt.Sprintf("resource:%s:%s", resourceType, resourceID)
+}
+
+// EncodeShareToken encodes a token so that it can be put in a URL as is.
+func EncodeShareToken(token string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+func DecodeShareToken(shareToken string) (string, error) {
+	token, err := base64.RawURLEncoding.DecodeString(shareToken)
+	if err != nil {
+		return "", errors.Wrap(macaroons.ErrMalformedToken, "failed to decode share token")
+	}
+	return string(token), nil
+}
+
 func NewAuth(cfg *config.Config, macaroonManager macaroons.MacaroonManagerInterface, caveatParser macaroons.CaveatParserInterface, hooks hooks.AnclaxHookInterface) (AuthInterface, error) {
 	if err := caveatParser.Register(CaveatUserContext, func() macaroons.Caveat {
 		return &UserContextCaveat{}
@@ -86,6 +127,21 @@ func NewAuth(cfg *config.Config, macaroonManager macaroons.MacaroonManagerInterf
 	}); err != nil {
 		return nil, err
 	}
+	if err := caveatParser.Register(CaveatResource, func() macaroons.Caveat {
+		return &ResourceCaveat{}
+	}); err != nil {
+		return nil, err
+	}
+	if err := caveatParser.Register(CaveatExpiry, func() macaroons.Caveat {
+		return &ExpiryCaveat{}
+	}); err != nil {
+		return nil, err
+	}
+	if err := caveatParser.Register(CaveatAttributes, func() macaroons.Caveat {
+		return &AttributesCaveat{}
+	}); err != nil {
+		return nil, err
+	}
 
 	return &Auth{
 		macaroonManager:     macaroonManager,
@@ -108,12 +164,21 @@ func (a *Auth) Authfunc(c fiber.Ctx) error {
 		tokenString = authHeader[7:]
 	}
 
+	return a.AuthenticateToken(c, tokenString)
+}
+
+func (a *Auth) AuthenticateToken(c fiber.Ctx, tokenString string) error {
 	token, err := a.macaroonManager.Parse(c.Context(), tokenString)
 	if err != nil {
 		return errors.Wrapf(fiber.ErrUnauthorized, "failed to parse macaroon token, token: %s, err: %v", tokenString, err)
 	}
 
+	if err := checkUserToken(token); err != nil {
+		return errors.Wrapf(fiber.ErrUnauthorized, "%v, token: %s", err, tokenString)
+	}
+
 	c.Locals(ContextKeyMacaroon, token)
+	identityOf(c).KeyID = token.KeyID()
 
 	for _, caveat := range token.Caveats {
 		if err := caveat.Validate(c); err != nil {
@@ -124,6 +189,86 @@ func (a *Auth) Authfunc(c fiber.Ctx) error {
 	return nil
 }
 
+// checkUserToken makes sure the token was issued to a user. Caveats can be appended by the
+// token holder, so the user context is only trusted if it names the user whose group the
+// key was created in.
+func checkUserToken(token *macaroons.Macaroon) error {
+	var uc *UserContextCaveat
+	for _, caveat := range token.Caveats {
+		switch c := caveat.(type) {
+		case *UserContextCaveat:
+			if uc != nil {
+				return errors.Wrap(macaroons.ErrCaveatCheckFailed, "user_context caveat already exists")
+			}
+			uc = c
+		case *ResourceCaveat:
+			return errors.Wrap(macaroons.ErrCaveatCheckFailed, "share tokens cannot be used to sign in")
+		}
+	}
+	if uc == nil {
+		return errors.Wrap(macaroons.ErrCaveatCheckFailed, "token has no user context")
+	}
+	if token.Group() != UserTokenGroup(uc.UserID) {
+		return errors.Wrapf(macaroons.ErrCaveatCheckFailed, "user context of user %d was not issued with the token", uc.UserID)
+	}
+	return nil
+}
+
+// checkShareToken makes sure the token was issued as a share link for the resource. Caveats
+// can be appended by the token holder, so a resource caveat is only trusted in a token whose
+// key was created in the group of the resource, and user tokens are never accepted.
+func checkShareToken(token *macaroons.Macaroon, resourceType, resourceID string) error {
+	for _, caveat := range token.Caveats {
+		if _, ok := caveat.(*UserContextCaveat); ok {
+			return errors.Wrap(macaroons.ErrCaveatCheckFailed, "user tokens cannot be used as share tokens")
+		}
+	}
+	if token.Group() != ResourceTokenGroup(resourceType, resourceID) {
+		return errors.Wrapf(macaroons.ErrCaveatCheckFailed, "share token was not issued for %s %s", resourceType, resourceID)
+	}
+	return nil
+}
+
+func (a *Auth) ResourceAuthFunc(resourceType, idParam string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		shareToken := c.Query(QueryShareToken)
+		if shareToken == "" {
+			return errors.Wrap(fiber.ErrUnauthorized, "missing share token")
+		}
+
+		tokenString, err := DecodeShareToken(shareToken)
+		if err != nil {
+			return errors.Wrapf(fiber.ErrUnauthorized, "%v", err)
+		}
+
+		token, err := a.macaroonManager.Parse(c.Context(), tokenString)
+		if err != nil {
+			return errors.Wrapf(fiber.ErrUnauthorized, "failed to parse share token, err: %v", err)
+		}
+
+		if err := checkShareToken(token, resourceType, c.Params(idParam)); err != nil {
+			return errors.Wrapf(fiber.ErrUnauthorized, "%v", err)
+		}
+
+		c.Locals(contextKeyResourceAuth, true)
+		c.Locals(ContextKeyMacaroon, token)
+		identityOf(c).KeyID = token.KeyID()
+
+		for _, caveat := range token.Caveats {
+			if err := caveat.Validate(c); err != nil {
+				return errors.Wrapf(fiber.ErrUnauthorized, "failed to validate caveat, err: %v", err)
+			}
+		}
+
+		rc, ok := c.Locals(ContextKeyResource).(*ResourceCaveat)
+		if !ok || rc.ResourceType != resourceType || rc.ResourceID != c.Params(idParam) {
+			return errors.Wrapf(fiber.ErrUnauthorized, "share token does not grant access to %s %s", resourceType, c.Params(idParam))
+		}
+
+		return c.Next()
+	}
+}
+
 func (a *Auth) CreateUserTokens(ctx context.Context, userID int32, orgID int32, caveats ...macaroons.Caveat) (*macaroons.Macaroon, *macaroons.Macaroon, error) {
 	group := UserTokenGroup(userID)
 	accessToken, err := a.macaroonManager.CreateToken(ctx, append(caveats, NewUserContextCaveat(userID, orgID)), a.timeoutAccessToken, group)
@@ -165,9 +310,9 @@ func (a *Auth) CreateRefreshToken(ctx context.Context, group string, accessToken
 		accessCaveats[i] = encoded
 	}
 
-	token, err := a.macaroonManager.CreateToken(ctx, []macaroons.Caveat{
+	token, err := a.macaroonManager.CreateSessionToken(ctx, []macaroons.Caveat{
 		NewRefreshOnlyCaveat(group, accessCaveats),
-	}, ttl, group)
+	}, ttl, group, accessToken.KeyID())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create macaroon token")
 	}
@@ -180,13 +325,27 @@ func (a *Auth) ParseRefreshToken(ctx context.Context, refreshToken string) (*mac
 		return nil, nil, errors.Wrapf(err, "failed to parse macaroon token, token: %s", refreshToken)
 	}
 
-	if len(token.Caveats) != 1 {
-		return nil, nil, errors.Wrap(ErrInvalidRefreshToken, "refresh token must have exactly one caveat")
+	// A refresh token carries exactly one RefreshOnlyCaveat. Other caveats are only
+	// allowed if they can be checked without a request.
+	var roc *RefreshOnlyCaveat
+	for _, caveat := range token.Caveats {
+		if rc, ok := caveat.(*RefreshOnlyCaveat); ok {
+			if roc != nil {
+				return nil, nil, errors.Wrap(ErrInvalidRefreshToken, "refresh token must have exactly one refresh only caveat")
+			}
+			roc = rc
+			continue
+		}
+		rfc, ok := caveat.(macaroons.RequestFreeCaveat)
+		if !ok {
+			return nil, nil, errors.Wrapf(ErrInvalidRefreshToken, "caveat %s cannot be validated on a refresh token", caveat.Type())
+		}
+		if err := rfc.ValidateWithoutRequest(); err != nil {
+			return nil, nil, errors.Wrapf(ErrInvalidRefreshToken, "failed to validate caveat %s: %v", caveat.Type(), err)
+		}
 	}
-
-	roc, ok := token.Caveats[0].(*RefreshOnlyCaveat)
-	if !ok {
-		return nil, nil, errors.Wrapf(ErrInvalidRefreshToken, "caveat is not a RefreshOnlyCaveat even though it has type %s", CaveatRefreshOnly)
+	if roc == nil {
+		return nil, nil, errors.Wrap(ErrInvalidRefreshToken, "refresh token must have exactly one refresh only caveat")
 	}
 
 	parsedCaveats := make([]macaroons.Caveat, len(roc.AccessCaveats))
@@ -214,6 +373,10 @@ func (a *Auth) InvalidateToken(ctx context.Context, keyID int64) error {
 	return a.macaroonManager.InvalidateToken(ctx, keyID)
 }
 
+func (a *Auth) ListUserTokens(ctx context.Context, userID int32) ([]store.KeyInfo, error) {
+	return a.macaroonManager.ListTokensByGroup(ctx, UserTokenGroup(userID))
+}
+
 func GetUserID(c fiber.Ctx) (int32, error) {
 	userID, ok := c.Locals(ContextKeyUserID).(int32)
 	if !ok {
@@ -230,6 +393,48 @@ func GetOrgID(c fiber.Ctx) (int32, error) {
 	return orgID, nil
 }
 
+// Identity collects what the caveats of the authenticated token tell about the caller.
+// Caveats fill it in when they are validated.
+type Identity struct {
+	KeyID  int64
+	UserID int32
+	OrgID  int32
+
+	// ExpiresAt is the earliest expiry caveat of the token, nil if there is none.
+	ExpiresAt *time.Time
+
+	// Resource is set for share tokens.
+	Resource *ResourceCaveat
+
+	// Attributes merges all attributes caveats of the token.
+	Attributes map[string]string
+}
+
+// identityOf returns the identity of the request, creating it on first use.
+func identityOf(c fiber.Ctx) *Identity {
+	if identity, ok := c.Locals(ContextKeyIdentity).(*Identity); ok {
+		return identity
+	}
+	identity := &Identity{}
+	c.Locals(ContextKeyIdentity, identity)
+	return identity
+}
+
+// GetIdentity returns the identity built from the caveats of the authenticated token.
+func GetIdentity(c fiber.Ctx) (*Identity, error) {
+	identity, ok := c.Locals(ContextKeyIdentity).(*Identity)
+	if !ok {
+		return nil, ErrUserIdentityNotExist
+	}
+	return identity, nil
+}
+
+// GetAttribute returns the attribute set by an AttributesCaveat of the authenticated token.
+func GetAttribute(c fiber.Ctx, key string) (string, bool) {
+	value, ok := c.Locals(attributeLocalPrefix + key).(string)
+	return value, ok
+}
+
 func GetToken(c fiber.Ctx) (*macaroons.Macaroon, error) {
 	token, ok := c.Locals(ContextKeyMacaroon).(*macaroons.Macaroon)
 	if !ok {
@@ -11,6 +11,8 @@ import (
 	"github.com/cloudcarver/anclax/pkg/config"
 	"github.com/cloudcarver/anclax/pkg/hooks"
 	"github.com/cloudcarver/anclax/pkg/macaroons"
+	"github.com/cloudcarver/anclax/pkg/macaroons/store"
+	"github.com/cloudcarver/anclax/pkg/orgctx"
 	"github.com/cloudcarver/anclax/pkg/utils"
 	"github.com/gofiber/fiber/v3"
 	"github.com/pkg/errors"
@@ -26,6 +28,9 @@ func TestAuth_Authfunc(t *testing.T) {
 	mockCaveatParser := macaroons.NewMockCaveatParserInterface(ctrl)
 	mockCaveatParser.EXPECT().Register(CaveatUserContext, gomock.Any()).Return(nil)
 	mockCaveatParser.EXPECT().Register(CaveatRefreshOnly, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatResource, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatExpiry, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatAttributes, gomock.Any()).Return(nil)
 	mockHooks := hooks.NewMockAnclaxHookInterface(ctrl)
 	auth, err := NewAuth(&config.Config{}, mockMacaroons, mockCaveatParser, mockHooks)
 	require.NoError(t, err)
@@ -68,7 +73,10 @@ func TestAuth_Authfunc(t *testing.T) {
 			setupMock: func() {
 				mockCaveat := macaroons.NewMockCaveat(ctrl)
 
-				macaroon, err := macaroons.CreateMacaroon(123, []byte("key"), []macaroons.Caveat{mockCaveat})
+				macaroon, err := macaroons.CreateMacaroonInGroup(123, []byte("key"), UserTokenGroup(101), []macaroons.Caveat{
+					NewUserContextCaveat(101, 202),
+					mockCaveat,
+				})
 				require.NoError(t, err)
 
 				mockMacaroons.EXPECT().Parse(gomock.Any(), testToken).Return(macaroon, nil)
@@ -81,7 +89,7 @@ func TestAuth_Authfunc(t *testing.T) {
 			name:       "duplicate user context caveat",
 			authHeader: testToken,
 			setupMock: func() {
-				macaroon, err := macaroons.CreateMacaroon(123, []byte("key"), []macaroons.Caveat{
+				macaroon, err := macaroons.CreateMacaroonInGroup(123, []byte("key"), UserTokenGroup(101), []macaroons.Caveat{
 					NewUserContextCaveat(101, 202),
 					NewUserContextCaveat(303, 404),
 				})
@@ -91,12 +99,53 @@ func TestAuth_Authfunc(t *testing.T) {
 			},
 			expectedStatus: fiber.StatusUnauthorized,
 		},
+		{
+			name:       "missing user context caveat",
+			authHeader: testToken,
+			setupMock: func() {
+				macaroon, err := macaroons.CreateMacaroonInGroup(123, []byte("key"), UserTokenGroup(101), nil)
+				require.NoError(t, err)
+
+				mockMacaroons.EXPECT().Parse(gomock.Any(), testToken).Return(macaroon, nil)
+			},
+			expectedStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:       "user context caveat of another user",
+			authHeader: testToken,
+			setupMock: func() {
+				macaroon, err := macaroons.CreateMacaroonInGroup(123, []byte("key"), "tenant:1", []macaroons.Caveat{
+					NewUserContextCaveat(101, 202),
+				})
+				require.NoError(t, err)
+
+				mockMacaroons.EXPECT().Parse(gomock.Any(), testToken).Return(macaroon, nil)
+			},
+			expectedStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:       "share token with appended user context caveat",
+			authHeader: testToken,
+			setupMock: func() {
+				macaroon, err := macaroons.CreateMacaroonInGroup(123, []byte("key"), UserTokenGroup(101), []macaroons.Caveat{
+					NewResourceCaveat("document", "42"),
+					NewUserContextCaveat(101, 202),
+				})
+				require.NoError(t, err)
+
+				mockMacaroons.EXPECT().Parse(gomock.Any(), testToken).Return(macaroon, nil)
+			},
+			expectedStatus: fiber.StatusUnauthorized,
+		},
 		{
 			name:       "successful authorization",
 			authHeader: testToken,
 			setupMock: func() {
 				mockCaveat := macaroons.NewMockCaveat(ctrl)
-				macaroon, err := macaroons.CreateMacaroon(123, []byte("key"), []macaroons.Caveat{mockCaveat})
+				macaroon, err := macaroons.CreateMacaroonInGroup(123, []byte("key"), UserTokenGroup(101), []macaroons.Caveat{
+					NewUserContextCaveat(101, 202),
+					mockCaveat,
+				})
 				require.NoError(t, err)
 
 				mockMacaroons.EXPECT().Parse(gomock.Any(), testToken).Return(macaroon, nil)
@@ -161,6 +210,9 @@ func TestAuth_CreateToken(t *testing.T) {
 	mockCaveatParser := macaroons.NewMockCaveatParserInterface(ctrl)
 	mockCaveatParser.EXPECT().Register(CaveatUserContext, gomock.Any()).Return(nil)
 	mockCaveatParser.EXPECT().Register(CaveatRefreshOnly, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatResource, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatExpiry, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatAttributes, gomock.Any()).Return(nil)
 	auth, err := NewAuth(&config.Config{}, mockMacaroons, mockCaveatParser, nil)
 	require.NoError(t, err)
 
@@ -234,6 +286,9 @@ func TestAuth_CreateRefreshToken(t *testing.T) {
 	mockCaveatParser := macaroons.NewMockCaveatParserInterface(ctrl)
 	mockCaveatParser.EXPECT().Register(CaveatUserContext, gomock.Any()).Return(nil)
 	mockCaveatParser.EXPECT().Register(CaveatRefreshOnly, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatResource, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatExpiry, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatAttributes, gomock.Any()).Return(nil)
 	mockHooks := hooks.NewMockAnclaxHookInterface(ctrl)
 	auth, err := NewAuth(&config.Config{}, mockMacaroons, mockCaveatParser, mockHooks)
 	require.NoError(t, err)
@@ -263,11 +318,12 @@ func TestAuth_CreateRefreshToken(t *testing.T) {
 			userID:      userID,
 			accessKeyID: accessKeyID,
 			setupMock: func() {
-				mockMacaroons.EXPECT().CreateToken(
+				mockMacaroons.EXPECT().CreateSessionToken(
 					gomock.Any(),
 					gomock.Any(),
 					ttl,
 					group,
+					accessToken.KeyID(),
 				).Return(macaroon, nil)
 			},
 			expectedToken: macaroon.StringToken(),
@@ -278,11 +334,12 @@ func TestAuth_CreateRefreshToken(t *testing.T) {
 			userID:      userID,
 			accessKeyID: accessKeyID,
 			setupMock: func() {
-				mockMacaroons.EXPECT().CreateToken(
+				mockMacaroons.EXPECT().CreateSessionToken(
 					gomock.Any(),
 					gomock.Any(),
 					ttl,
 					group,
+					accessToken.KeyID(),
 				).Return(nil, errors.New("token creation failed"))
 			},
 			expectedToken: "",
@@ -315,6 +372,9 @@ func TestAuth_ParseRefreshToken(t *testing.T) {
 	mockCaveatParser := macaroons.NewMockCaveatParserInterface(ctrl)
 	mockCaveatParser.EXPECT().Register(CaveatUserContext, gomock.Any()).Return(nil)
 	mockCaveatParser.EXPECT().Register(CaveatRefreshOnly, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatResource, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatExpiry, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatAttributes, gomock.Any()).Return(nil)
 	mockHooks := hooks.NewMockAnclaxHookInterface(ctrl)
 	auth, err := NewAuth(&config.Config{}, mockMacaroons, mockCaveatParser, mockHooks)
 
@@ -343,13 +403,32 @@ func TestAuth_ParseRefreshToken(t *testing.T) {
 	require.NoError(t, err)
 
 	noRefreshCaveat := macaroons.NewMockCaveat(ctrl)
+	noRefreshCaveat.EXPECT().Type().Return("mock").AnyTimes()
 	noRefreshMacaroon, err := macaroons.CreateMacaroon(0, []byte("key"), []macaroons.Caveat{noRefreshCaveat})
 	require.NoError(t, err)
 
+	withExpiryMacaroon, err := macaroons.CreateMacaroon(0, []byte("key"), []macaroons.Caveat{
+		refreshCaveat,
+		NewExpiryCaveat(time.Now().Add(time.Hour)),
+	})
+	require.NoError(t, err)
+
+	expiredMacaroon, err := macaroons.CreateMacaroon(0, []byte("key"), []macaroons.Caveat{
+		refreshCaveat,
+		NewExpiryCaveat(time.Now().Add(-time.Hour)),
+	})
+	require.NoError(t, err)
+
+	expiryOnlyMacaroon, err := macaroons.CreateMacaroon(0, []byte("key"), []macaroons.Caveat{
+		NewExpiryCaveat(time.Now().Add(time.Hour)),
+	})
+	require.NoError(t, err)
+
 	testCases := []struct {
 		name          string
 		refreshToken  string
 		setupMock     func()
+		expectedToken *macaroons.Macaroon
 		expectedGroup string
 		expectedError error
 	}{
@@ -362,9 +441,41 @@ func TestAuth_ParseRefreshToken(t *testing.T) {
 					mockCaveatParser.EXPECT().Parse(encoded).Return(accessTokenCaveats[i], nil)
 				}
 			},
+			expectedToken: macaroon,
 			expectedGroup: group,
 			expectedError: nil,
 		},
+		{
+			name:         "refresh caveat with an expiry caveat",
+			refreshToken: withExpiryMacaroon.StringToken(),
+			setupMock: func() {
+				mockMacaroons.EXPECT().Parse(gomock.Any(), withExpiryMacaroon.StringToken()).Return(withExpiryMacaroon, nil)
+				for i, encoded := range encodedAccessCaveats {
+					mockCaveatParser.EXPECT().Parse(encoded).Return(accessTokenCaveats[i], nil)
+				}
+			},
+			expectedToken: withExpiryMacaroon,
+			expectedGroup: group,
+			expectedError: nil,
+		},
+		{
+			name:         "refresh caveat with an expired expiry caveat",
+			refreshToken: expiredMacaroon.StringToken(),
+			setupMock: func() {
+				mockMacaroons.EXPECT().Parse(gomock.Any(), expiredMacaroon.StringToken()).Return(expiredMacaroon, nil)
+			},
+			expectedGroup: "",
+			expectedError: ErrInvalidRefreshToken,
+		},
+		{
+			name:         "expiry caveat without refresh caveat",
+			refreshToken: expiryOnlyMacaroon.StringToken(),
+			setupMock: func() {
+				mockMacaroons.EXPECT().Parse(gomock.Any(), expiryOnlyMacaroon.StringToken()).Return(expiryOnlyMacaroon, nil)
+			},
+			expectedGroup: "",
+			expectedError: ErrInvalidRefreshToken,
+		},
 		{
 			name:         "parse failure",
 			refreshToken: macaroon.StringToken(),
@@ -396,7 +507,7 @@ func TestAuth_ParseRefreshToken(t *testing.T) {
 				require.Contains(t, err.Error(), tc.expectedError.Error())
 			} else {
 				require.NoError(t, err)
-				require.Equal(t, macaroon, token)
+				require.Equal(t, tc.expectedToken, token)
 				require.Equal(t, tc.expectedGroup, roc.Group)
 				require.ElementsMatch(t, accessTokenCaveats, roc.AccessTokenCaveats)
 			}
@@ -454,6 +565,10 @@ func TestUserContextCaveat_ValidateRejectsDuplicateContext(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, int32(202), orgID)
 
+		taskOrgID, ok := orgctx.OrgID(c.Context())
+		require.True(t, ok)
+		require.Equal(t, int32(202), taskOrgID)
+
 		return c.SendStatus(fiber.StatusOK)
 	})
 
@@ -463,6 +578,138 @@ func TestUserContextCaveat_ValidateRejectsDuplicateContext(t *testing.T) {
 	require.Equal(t, fiber.StatusOK, resp.StatusCode)
 }
 
+func TestResourceCaveat_ValidateRejectsOtherRequests(t *testing.T) {
+	app := fiber.New()
+
+	app.Get("/test", func(c fiber.Ctx) error {
+		err := NewResourceCaveat("document", "42").Validate(c)
+		require.ErrorIs(t, err, macaroons.ErrCaveatCheckFailed)
+
+		_, ok := c.Locals(ContextKeyResource).(*ResourceCaveat)
+		require.False(t, ok)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestGetIdentityAfterAuthfunc(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMacaroons := macaroons.NewMockMacaroonManagerInterface(ctrl)
+	auth := &Auth{macaroonManager: mockMacaroons}
+
+	var (
+		testToken = "test_token"
+		earlier   = time.Now().Add(time.Hour).UTC()
+		later     = earlier.Add(time.Hour)
+	)
+	macaroon, err := macaroons.CreateMacaroonInGroup(123, []byte("key"), UserTokenGroup(101), []macaroons.Caveat{
+		NewUserContextCaveat(101, 202),
+		NewExpiryCaveat(later),
+		NewExpiryCaveat(earlier),
+	})
+	require.NoError(t, err)
+	mockMacaroons.EXPECT().Parse(gomock.Any(), testToken).Return(macaroon, nil)
+
+	app := fiber.New()
+	app.Get("/test", func(c fiber.Ctx) error {
+		_, err := GetIdentity(c)
+		require.ErrorIs(t, err, ErrUserIdentityNotExist)
+
+		require.NoError(t, auth.Authfunc(c))
+
+		identity, err := GetIdentity(c)
+		require.NoError(t, err)
+		require.Equal(t, &Identity{
+			KeyID:     123,
+			UserID:    101,
+			OrgID:     202,
+			ExpiresAt: &earlier,
+		}, identity)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestAttributesCaveatRoundTrip(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		ctx   = context.Background()
+		keyID = int64(7)
+		key   []byte
+	)
+	keyStore := store.NewMockKeyStore(ctrl)
+	keyStore.EXPECT().Create(gomock.Any(), gomock.Any(), time.Hour, UserTokenGroup(101)).DoAndReturn(func(_ context.Context, k []byte, _ time.Duration, _ string) (int64, error) {
+		key = k
+		return keyID, nil
+	})
+	keyStore.EXPECT().Get(gomock.Any(), keyID).DoAndReturn(func(context.Context, int64) ([]byte, string, error) {
+		return key, UserTokenGroup(101), nil
+	}).AnyTimes()
+
+	caveatParser := macaroons.NewCaveatParser()
+	auth, err := NewAuth(&config.Config{}, macaroons.NewMacaroonManager(keyStore, caveatParser), caveatParser, nil)
+	require.NoError(t, err)
+
+	token, err := auth.CreateToken(ctx, UserTokenGroup(101), time.Hour,
+		NewAttributesCaveat(map[string]string{"tier": "gold", "beta": "true"}),
+		NewUserContextCaveat(101, 202),
+	)
+	require.NoError(t, err)
+
+	// a holder appending a caveat can neither override an attribute nor add one
+	overridden, err := macaroons.CreateMacaroon(keyID, key, token.Caveats)
+	require.NoError(t, err)
+	require.NoError(t, overridden.AddCaveat(NewAttributesCaveat(map[string]string{"tier": "platinum"})))
+	added, err := macaroons.CreateMacaroon(keyID, key, token.Caveats)
+	require.NoError(t, err)
+	require.NoError(t, added.AddCaveat(NewAttributesCaveat(map[string]string{"admin": "true"})))
+
+	app := fiber.New(fiber.Config{ErrorHandler: utils.ErrorHandler})
+	app.Get("/test", func(c fiber.Ctx) error {
+		if err := auth.Authfunc(c); err != nil {
+			return err
+		}
+		tier, ok := GetAttribute(c, "tier")
+		require.True(t, ok)
+		require.Equal(t, "gold", tier)
+
+		_, ok = GetAttribute(c, "missing")
+		require.False(t, ok)
+
+		identity, err := GetIdentity(c)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"tier": "gold", "beta": "true"}, identity.Attributes)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token.StringToken())
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	for _, tampered := range []*macaroons.Macaroon{overridden, added} {
+		req = httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+tampered.StringToken())
+		resp, err = app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
 func TestAuth_InvalidateUserTokens(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -471,6 +718,9 @@ func TestAuth_InvalidateUserTokens(t *testing.T) {
 	mockCaveatParser := macaroons.NewMockCaveatParserInterface(ctrl)
 	mockCaveatParser.EXPECT().Register(CaveatUserContext, gomock.Any()).Return(nil)
 	mockCaveatParser.EXPECT().Register(CaveatRefreshOnly, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatResource, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatExpiry, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatAttributes, gomock.Any()).Return(nil)
 	mockHooks := hooks.NewMockAnclaxHookInterface(ctrl)
 	auth, err := NewAuth(&config.Config{}, mockMacaroons, mockCaveatParser, mockHooks)
 	require.NoError(t, err)
@@ -668,6 +918,9 @@ func TestNewAuth(t *testing.T) {
 
 	mockCaveatParser.EXPECT().Register(CaveatUserContext, gomock.Any()).Return(nil)
 	mockCaveatParser.EXPECT().Register(CaveatRefreshOnly, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatResource, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatExpiry, gomock.Any()).Return(nil)
+	mockCaveatParser.EXPECT().Register(CaveatAttributes, gomock.Any()).Return(nil)
 
 	mockHooks := hooks.NewMockAnclaxHookInterface(ctrl)
 	auth, err := NewAuth(&config.Config{}, mockMacaroons, mockCaveatParser, mockHooks)
@@ -2,8 +2,10 @@ package auth
 
 import (
 	"strings"
+	"time"
 
 	macaroons "github.com/cloudcarver/anclax/pkg/macaroons"
+	"github.com/cloudcarver/anclax/pkg/orgctx"
 	"github.com/gofiber/fiber/v3"
 	"github.com/pkg/errors"
 )
@@ -11,8 +13,14 @@ import (
 const (
 	CaveatUserContext = "user_context"
 	CaveatRefreshOnly = "refresh_only"
+	CaveatResource    = "resource"
+	CaveatExpiry      = "expiry"
+	CaveatAttributes  = "attributes"
 )
 
+// attributeLocalPrefix namespaces the locals written by AttributesCaveat.
+const attributeLocalPrefix = "anclax.auth.attr:"
+
 type UserContextCaveat struct {
 	Typ    string `json:"type"`
 	UserID int32  `json:"user_id"`
@@ -37,6 +45,10 @@ func (uc *UserContextCaveat) Validate(ctx fiber.Ctx) error {
 	}
 	ctx.Locals(ContextKeyUserID, uc.UserID)
 	ctx.Locals(ContextKeyOrgID, uc.OrgID)
+	ctx.SetContext(orgctx.WithOrgID(ctx.Context(), uc.OrgID))
+	identity := identityOf(ctx)
+	identity.UserID = uc.UserID
+	identity.OrgID = uc.OrgID
 	return nil
 }
 
@@ -65,3 +77,110 @@ func (rc *RefreshOnlyCaveat) Validate(ctx fiber.Ctx) error {
 	}
 	return errors.Wrapf(macaroons.ErrCaveatCheckFailed, "invalid request: %s %s, the token is for refresh only", ctx.Method(), ctx.Path())
 }
+
+// ExpiryCaveat rejects the token after ExpiresAt, independently of the token TTL.
+type ExpiryCaveat struct {
+	Typ       string    `json:"type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewExpiryCaveat(expiresAt time.Time) *ExpiryCaveat {
+	return &ExpiryCaveat{
+		Typ:       CaveatExpiry,
+		ExpiresAt: expiresAt,
+	}
+}
+
+func (ec *ExpiryCaveat) Type() string {
+	return ec.Typ
+}
+
+func (ec *ExpiryCaveat) Validate(ctx fiber.Ctx) error {
+	if err := ec.ValidateWithoutRequest(); err != nil {
+		return err
+	}
+	identity := identityOf(ctx)
+	if identity.ExpiresAt == nil || ec.ExpiresAt.Before(*identity.ExpiresAt) {
+		expiresAt := ec.ExpiresAt
+		identity.ExpiresAt = &expiresAt
+	}
+	return nil
+}
+
+func (ec *ExpiryCaveat) ValidateWithoutRequest() error {
+	if time.Now().After(ec.ExpiresAt) {
+		return errors.Wrapf(macaroons.ErrCaveatCheckFailed, "token expired at %s", ec.ExpiresAt)
+	}
+	return nil
+}
+
+// ResourceCaveat restricts a token to a single resource, it is enforced by Auth.ResourceAuthFunc
+// and fails validation on any other request.
+type ResourceCaveat struct {
+	Typ          string `json:"type"`
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+}
+
+func NewResourceCaveat(resourceType, resourceID string) *ResourceCaveat {
+	return &ResourceCaveat{
+		Typ:          CaveatResource,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+	}
+}
+
+func (rc *ResourceCaveat) Type() string {
+	return rc.Typ
+}
+
+func (rc *ResourceCaveat) Validate(ctx fiber.Ctx) error {
+	if ctx.Locals(contextKeyResourceAuth) == nil {
+		return errors.Wrapf(macaroons.ErrCaveatCheckFailed, "invalid request: %s %s, the token is for a shared %s only", ctx.Method(), ctx.Path(), rc.ResourceType)
+	}
+	if ctx.Locals(ContextKeyResource) != nil {
+		return errors.Wrap(macaroons.ErrCaveatCheckFailed, "resource caveat already exists")
+	}
+	ctx.Locals(ContextKeyResource, rc)
+	identityOf(ctx).Resource = rc
+	return nil
+}
+
+// AttributesCaveat carries app-specific attributes, e.g. a tenant tier, read with GetAttribute.
+// It must come before the user context caveat, which CreateUserTokens appends last, so a token
+// holder cannot add or override attributes by appending a caveat.
+type AttributesCaveat struct {
+	Typ        string            `json:"type"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+func NewAttributesCaveat(attributes map[string]string) *AttributesCaveat {
+	return &AttributesCaveat{
+		Typ:        CaveatAttributes,
+		Attributes: attributes,
+	}
+}
+
+func (ac *AttributesCaveat) Type() string {
+	return ac.Typ
+}
+
+func (ac *AttributesCaveat) Validate(ctx fiber.Ctx) error {
+	if ctx.Locals(ContextKeyUserID) != nil {
+		return errors.Wrap(macaroons.ErrCaveatCheckFailed, "attributes caveat must come before the user context caveat")
+	}
+	for key := range ac.Attributes {
+		if ctx.Locals(attributeLocalPrefix+key) != nil {
+			return errors.Wrapf(macaroons.ErrCaveatCheckFailed, "attribute %s already exists", key)
+		}
+	}
+	identity := identityOf(ctx)
+	if identity.Attributes == nil {
+		identity.Attributes = make(map[string]string, len(ac.Attributes))
+	}
+	for key, value := range ac.Attributes {
+		ctx.Locals(attributeLocalPrefix+key, value)
+		identity.Attributes[key] = value
+	}
+	return nil
+}
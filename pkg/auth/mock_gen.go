@@ -15,6 +15,7 @@ import (
 	time "time"
 
 	macaroons "github.com/cloudcarver/anclax/pkg/macaroons"
+	store "github.com/cloudcarver/anclax/pkg/macaroons/store"
 	fiber "github.com/gofiber/fiber/v3"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -43,6 +44,20 @@ func (m *MockAuthInterface) EXPECT() *MockAuthInterfaceMockRecorder {
 	return m.recorder
 }
 
+// AuthenticateToken mocks base method.
+func (m *MockAuthInterface) AuthenticateToken(c fiber.Ctx, tokenString string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateToken", c, tokenString)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthenticateToken indicates an expected call of AuthenticateToken.
+func (mr *MockAuthInterfaceMockRecorder) AuthenticateToken(c, tokenString any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateToken", reflect.TypeOf((*MockAuthInterface)(nil).AuthenticateToken), c, tokenString)
+}
+
 // Authfunc mocks base method.
 func (m *MockAuthInterface) Authfunc(c fiber.Ctx) error {
 	m.ctrl.T.Helper()
@@ -155,6 +170,21 @@ func (mr *MockAuthInterfaceMockRecorder) InvalidateUserTokens(ctx, userID any) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateUserTokens", reflect.TypeOf((*MockAuthInterface)(nil).InvalidateUserTokens), ctx, userID)
 }
 
+// ListUserTokens mocks base method.
+func (m *MockAuthInterface) ListUserTokens(ctx context.Context, userID int32) ([]store.KeyInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserTokens", ctx, userID)
+	ret0, _ := ret[0].([]store.KeyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserTokens indicates an expected call of ListUserTokens.
+func (mr *MockAuthInterfaceMockRecorder) ListUserTokens(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserTokens", reflect.TypeOf((*MockAuthInterface)(nil).ListUserTokens), ctx, userID)
+}
+
 // ParseRefreshToken mocks base method.
 func (m *MockAuthInterface) ParseRefreshToken(ctx context.Context, refreshToken string) (*macaroons.Macaroon, *RefreshOnlyCaveat, error) {
 	m.ctrl.T.Helper()
@@ -170,3 +200,17 @@ func (mr *MockAuthInterfaceMockRecorder) ParseRefreshToken(ctx, refreshToken any
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseRefreshToken", reflect.TypeOf((*MockAuthInterface)(nil).ParseRefreshToken), ctx, refreshToken)
 }
+
+// ResourceAuthFunc mocks base method.
+func (m *MockAuthInterface) ResourceAuthFunc(resourceType, idParam string) fiber.Handler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResourceAuthFunc", resourceType, idParam)
+	ret0, _ := ret[0].(fiber.Handler)
+	return ret0
+}
+
+// ResourceAuthFunc indicates an expected call of ResourceAuthFunc.
+func (mr *MockAuthInterfaceMockRecorder) ResourceAuthFunc(resourceType, idParam any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResourceAuthFunc", reflect.TypeOf((*MockAuthInterface)(nil).ResourceAuthFunc), resourceType, idParam)
+}
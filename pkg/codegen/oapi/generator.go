@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -80,6 +81,7 @@ type operationDef struct {
 	PathArgs      string
 	PathParams    []paramDef
 	QueryParams   []paramDef
+	HeaderParams  []paramDef
 	RequestBody   *requestBodyDef
 	Responses     []responseDef
 	Securities    []operationSecurity
@@ -126,6 +128,7 @@ type rawCheckRule struct {
 	UseContext  bool       `json:"useContext"`
 	Description string     `json:"description"`
 	Parameters  []rawParam `json:"parameters"`
+	Return      *rawParam  `json:"return"`
 }
 
 type rawFunction struct {
@@ -146,6 +149,9 @@ type xCheckRule struct {
 	UseContext  bool
 	Description string
 	Params      []xParam
+
+	// Return is the value computed by the rule, it is stored in the fiber locals for the handler.
+	Return *xParam
 }
 
 type xFunction struct {
@@ -163,14 +169,28 @@ type xParam struct {
 }
 
 func Generate(workdir string, config Config) error {
+	specCode, err := GenerateSource(workdir, config)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(workdir, config.Out, specCode); err != nil {
+		return errors.Wrap(err, "failed to write OpenAPI output")
+	}
+
+	return nil
+}
+
+// GenerateSource renders the code Generate would write to config.Out without
+// touching the filesystem.
+func GenerateSource(workdir string, config Config) (string, error) {
 	if config.Path == "" {
-		return errors.New("oapi-codegen path is required")
+		return "", errors.New("oapi-codegen path is required")
 	}
 	if config.Out == "" {
-		return errors.New("oapi-codegen out is required")
+		return "", errors.New("oapi-codegen out is required")
 	}
 	if config.Package == "" {
-		return errors.New("oapi-codegen package is required")
+		return "", errors.New("oapi-codegen package is required")
 	}
 
 	specPath := config.Path
@@ -179,27 +199,24 @@ func Generate(workdir string, config Config) error {
 	}
 	schemaManager, err := schema_codegen.Load(workdir, derefSchemaConfig(config.Schemas))
 	if err != nil {
-		return errors.Wrap(err, "failed to load schemas config")
+		return "", errors.Wrap(err, "failed to load schemas config")
 	}
 	swagger, sourcePath, err := loadSwagger(workdir, specPath)
 	if err != nil {
-		return errors.Wrap(err, "failed to load OpenAPI spec")
+		return "", errors.Wrap(err, "failed to load OpenAPI spec")
 	}
 
 	doc, err := buildDocument(swagger, sourcePath, config.Package, schemaManager)
 	if err != nil {
-		return errors.Wrap(err, "failed to build OpenAPI document")
+		return "", errors.Wrap(err, "failed to build OpenAPI document")
 	}
 
 	specCode, err := renderSpec(doc)
 	if err != nil {
-		return errors.Wrap(err, "failed to render OpenAPI code")
-	}
-	if err := writeFile(workdir, config.Out, specCode); err != nil {
-		return errors.Wrap(err, "failed to write OpenAPI output")
+		return "", errors.Wrap(err, "failed to render OpenAPI code")
 	}
 
-	return nil
+	return specCode, nil
 }
 
 func loadSwagger(workdir, specPath string) (*openapi3.T, string, error) {
@@ -371,6 +388,11 @@ func buildOperation(currentFile, path string, pathItem *openapi3.PathItem, metho
 		return ret, err
 	}
 	ret.QueryParams = queryParams
+	headerParams, err := collectHeaderParams(currentFile, pathItem, op, enumMap, schemaManager)
+	if err != nil {
+		return ret, err
+	}
+	ret.HeaderParams = headerParams
 
 	if op.RequestBody != nil && op.RequestBody.Value != nil {
 		if mediaType, schemaRef, ok := jsonBodySchema(op.RequestBody.Value.Content); ok {
@@ -433,6 +455,9 @@ func buildOperation(currentFile, path string, pathItem *openapi3.PathItem, metho
 		}
 	}
 	ret.NeedsAuth = len(ret.Securities) > 0
+	if err := validateMiddlewareHeaderParams(ret); err != nil {
+		return ret, err
+	}
 
 	return ret, nil
 }
@@ -550,7 +575,13 @@ func renderMiddlewareDefinitions(b *strings.Builder, doc *document) {
 				b.WriteString(param.Type)
 			}
 		}
-		b.WriteString(") error\n")
+		if rule.Return != nil {
+			b.WriteString(") (")
+			b.WriteString(rule.Return.Type)
+			b.WriteString(", error)\n")
+		} else {
+			b.WriteString(") error\n")
+		}
 	}
 	if len(doc.CheckRules) > 0 && len(doc.Functions) > 0 {
 		b.WriteString("\n")
@@ -590,6 +621,8 @@ func renderMiddlewareDefinitions(b *strings.Builder, doc *document) {
 	b.WriteString("\treturn &XMiddleware{ServerInterface: handler, Validator: validator}\n")
 	b.WriteString("}\n\n")
 
+	renderCheckRuleResults(b, doc)
+
 	for _, op := range doc.Operations {
 		if !op.NeedsAuth {
 			continue
@@ -627,6 +660,9 @@ func renderMiddlewareDefinitions(b *strings.Builder, doc *document) {
 			b.WriteString(strconv.Quote(op.Name))
 			b.WriteString("\n")
 		}
+		for _, param := range middlewareHeaderParams(op) {
+			renderHeaderParamParse(b, param)
+		}
 		for _, sec := range op.Securities {
 			for _, scope := range sec.Scopes {
 				b.WriteString("\tif err := ")
@@ -654,6 +690,82 @@ func renderMiddlewareDefinitions(b *strings.Builder, doc *document) {
 	}
 }
 
+// renderCheckRuleResults renders, for each check rule with a return value, an XMiddleware
+// method shadowing the Validator one so that security scopes keep returning only an error,
+// and a getter reading the stored result in the handler.
+func renderCheckRuleResults(b *strings.Builder, doc *document) {
+	hasReturn := false
+	for _, rule := range doc.CheckRules {
+		if rule.Return != nil {
+			hasReturn = true
+			break
+		}
+	}
+	if !hasReturn {
+		return
+	}
+
+	b.WriteString("type xCheckRuleResultKey string\n\n")
+	for _, rule := range doc.CheckRules {
+		if rule.Return == nil {
+			continue
+		}
+		b.WriteString("// ")
+		b.WriteString(rule.Name)
+		b.WriteString(" runs the check rule and stores its result, read it with Get")
+		b.WriteString(rule.Name)
+		b.WriteString("Result.\n")
+		b.WriteString("func (x *XMiddleware) ")
+		b.WriteString(rule.Name)
+		b.WriteString("(c fiber.Ctx")
+		for _, param := range rule.Params {
+			b.WriteString(", ")
+			b.WriteString(param.Name)
+			b.WriteString(" ")
+			b.WriteString(param.Type)
+		}
+		b.WriteString(") error {\n")
+		b.WriteString("\tret, err := x.Validator.")
+		b.WriteString(rule.Name)
+		b.WriteString("(c")
+		for _, param := range rule.Params {
+			b.WriteString(", ")
+			b.WriteString(param.Name)
+		}
+		b.WriteString(")\n")
+		b.WriteString("\tif err != nil {\n")
+		b.WriteString("\t\treturn err\n")
+		b.WriteString("\t}\n")
+		b.WriteString("\tc.Locals(xCheckRuleResultKey(")
+		b.WriteString(strconv.Quote(rule.Name))
+		b.WriteString("), ret)\n")
+		b.WriteString("\treturn nil\n")
+		b.WriteString("}\n\n")
+
+		if rule.Return.Description != "" {
+			writeComment(b, rule.Return.Description, "")
+		} else {
+			b.WriteString("// Get")
+			b.WriteString(rule.Name)
+			b.WriteString("Result returns the result stored by the ")
+			b.WriteString(rule.Name)
+			b.WriteString(" check rule.\n")
+		}
+		b.WriteString("func Get")
+		b.WriteString(rule.Name)
+		b.WriteString("Result(c fiber.Ctx) (")
+		b.WriteString(rule.Return.Type)
+		b.WriteString(", bool) {\n")
+		b.WriteString("\tret, ok := c.Locals(xCheckRuleResultKey(")
+		b.WriteString(strconv.Quote(rule.Name))
+		b.WriteString(")).(")
+		b.WriteString(rule.Return.Type)
+		b.WriteString(")\n")
+		b.WriteString("\treturn ret, ok\n")
+		b.WriteString("}\n\n")
+	}
+}
+
 func renderSchema(b *strings.Builder, schema schemaDef) {
 	if schema.Kind == "enum" {
 		return
@@ -1544,6 +1656,51 @@ func renderPathParamParse(b *strings.Builder, param paramDef) {
 	}
 }
 
+func renderHeaderParamParse(b *strings.Builder, param paramDef) {
+	b.WriteString("\tvar ")
+	b.WriteString(param.VarName)
+	b.WriteString(" ")
+	b.WriteString(param.Type)
+	b.WriteString("\n")
+	b.WriteString("\tif raw := c.Get(")
+	b.WriteString(strconv.Quote(param.SourceName))
+	b.WriteString("); raw != \"\" {\n")
+	switch param.Type {
+	case "string":
+		b.WriteString("\t\t")
+		b.WriteString(param.VarName)
+		b.WriteString(" = raw\n")
+	default:
+		switch param.Type {
+		case "int32":
+			b.WriteString("\t\tparsed, err := strconv.ParseInt(raw, 10, 32)\n")
+		case "int64":
+			b.WriteString("\t\tparsed, err := strconv.ParseInt(raw, 10, 64)\n")
+		case "bool":
+			b.WriteString("\t\tparsed, err := strconv.ParseBool(raw)\n")
+		}
+		b.WriteString("\t\tif err != nil {\n")
+		b.WriteString("\t\t\treturn c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf(\"Invalid format for parameter ")
+		b.WriteString(param.SourceName)
+		b.WriteString(": %v\", err))\n")
+		b.WriteString("\t\t}\n")
+		b.WriteString("\t\t")
+		b.WriteString(param.VarName)
+		if param.Type == "int32" {
+			b.WriteString(" = int32(parsed)\n")
+		} else {
+			b.WriteString(" = parsed\n")
+		}
+	}
+	if param.Required {
+		b.WriteString("\t} else {\n")
+		b.WriteString("\t\treturn c.Status(fiber.StatusBadRequest).SendString(\"Missing required parameter ")
+		b.WriteString(param.SourceName)
+		b.WriteString("\")\n")
+	}
+	b.WriteString("\t}\n")
+}
+
 func specImports(doc *document) []string {
 	imports := map[string]struct{}{
 		"context":                     {},
@@ -1640,6 +1797,17 @@ func parseXCheckRules(doc *document, currentFile string, spec *openapi3.T, enumM
 			mergeImports(doc.ScopeTypeImports, resolved.Imports...)
 			item.Params = append(item.Params, xParam{Name: lowerName(param.Name), Description: param.Description, Type: resolved.GoType})
 		}
+		if rule.Return != nil {
+			if !rule.UseContext {
+				return errors.Errorf("x-check-rules.%s: return requires useContext to store the result in the fiber locals", name)
+			}
+			resolved, err := resolveType(currentFile, "", rule.Return.Schema, name+"Result", enumMap, schemaManager)
+			if err != nil {
+				return err
+			}
+			mergeImports(doc.ScopeTypeImports, resolved.Imports...)
+			item.Return = &xParam{Name: lowerName(rule.Return.Name), Description: rule.Return.Description, Type: resolved.GoType}
+		}
 		doc.CheckRules = append(doc.CheckRules, item)
 	}
 	return nil
@@ -1847,10 +2015,33 @@ func collectPathParams(currentFile string, path string, pathItem *openapi3.PathI
 }
 
 func collectQueryParams(currentFile string, pathItem *openapi3.PathItem, op *openapi3.Operation, enumMap map[string]*enumDef, imports map[string]struct{}, schemaManager *schema_codegen.Manager) ([]paramDef, error) {
+	return collectParamsIn(openapi3.ParameterInQuery, currentFile, pathItem, op, enumMap, imports, schemaManager)
+}
+
+// collectHeaderParams collects the header parameters, they are only parsed by the
+// middleware for the security scopes, see validateMiddlewareHeaderParams.
+func collectHeaderParams(currentFile string, pathItem *openapi3.PathItem, op *openapi3.Operation, enumMap map[string]*enumDef, schemaManager *schema_codegen.Manager) ([]paramDef, error) {
+	return collectParamsIn(openapi3.ParameterInHeader, currentFile, pathItem, op, enumMap, map[string]struct{}{}, schemaManager)
+}
+
+// validateMiddlewareHeaderParams checks that the header parameters used by the security
+// scopes have a scalar type the middleware can parse. Other header parameters are ignored.
+func validateMiddlewareHeaderParams(op operationDef) error {
+	for _, param := range middlewareHeaderParams(op) {
+		switch param.Type {
+		case "string", "int32", "int64", "bool":
+		default:
+			return errors.Errorf("header parameter %s: unsupported type %s", param.SourceName, param.Type)
+		}
+	}
+	return nil
+}
+
+func collectParamsIn(in string, currentFile string, pathItem *openapi3.PathItem, op *openapi3.Operation, enumMap map[string]*enumDef, imports map[string]struct{}, schemaManager *schema_codegen.Manager) ([]paramDef, error) {
 	var orderedNames []string
 	paramMap := map[string]*openapi3.Parameter{}
 	appendParam := func(ref *openapi3.ParameterRef) {
-		if ref == nil || ref.Value == nil || ref.Value.In != "query" {
+		if ref == nil || ref.Value == nil || ref.Value.In != in {
 			return
 		}
 		if _, ok := paramMap[ref.Value.Name]; !ok {
@@ -1970,6 +2161,12 @@ func specNeedsStrconv(doc *document) bool {
 				return true
 			}
 		}
+		for _, param := range middlewareHeaderParams(op) {
+			switch param.Type {
+			case "int32", "int64", "bool":
+				return true
+			}
+		}
 	}
 	return false
 }
@@ -2006,6 +2203,24 @@ func operationNeedsOperationID(op operationDef) bool {
 	return false
 }
 
+// middlewareHeaderParams returns the header parameters referenced by the security scopes of op.
+func middlewareHeaderParams(op operationDef) []paramDef {
+	if !op.NeedsAuth {
+		return nil
+	}
+	var ret []paramDef
+	for _, param := range op.HeaderParams {
+		ident := regexp.MustCompile(`\b` + regexp.QuoteMeta(param.VarName) + `\b`)
+		for _, sec := range op.Securities {
+			if slices.ContainsFunc(sec.Scopes, ident.MatchString) {
+				ret = append(ret, param)
+				break
+			}
+		}
+	}
+	return ret
+}
+
 func mergeImports(target map[string]struct{}, imports ...string) {
 	for _, imp := range imports {
 		if imp == "" {
@@ -248,6 +248,129 @@ func TestGenerateMiddlewareUsesWrappedFiberErrorStatus(t *testing.T) {
 	}
 }
 
+func TestGenerateCheckRuleReturnStoresResultInLocals(t *testing.T) {
+	t.Parallel()
+
+	workdir := t.TempDir()
+	outPath := filepath.Join(workdir, "spec_gen.go")
+
+	if err := Generate(".", Config{
+		Path:    filepath.Join("testdata", "x_check_rules_return.yaml"),
+		Out:     outPath,
+		Package: "apigen",
+	}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	out := string(raw)
+
+	for _, needle := range []string{
+		"WidgetOwner(c fiber.Ctx, id int32) (int32, error)",
+		"type xCheckRuleResultKey string",
+		"func (x *XMiddleware) WidgetOwner(c fiber.Ctx, id int32) error {",
+		"ret, err := x.Validator.WidgetOwner(c, id)",
+		`c.Locals(xCheckRuleResultKey("WidgetOwner"), ret)`,
+		"func GetWidgetOwnerResult(c fiber.Ctx) (int32, bool) {",
+		`ret, ok := c.Locals(xCheckRuleResultKey("WidgetOwner")).(int32)`,
+		"if err := x.WidgetOwner(c, id); err != nil {",
+	} {
+		if !strings.Contains(out, needle) {
+			t.Fatalf("generated output missing %q", needle)
+		}
+	}
+}
+
+func TestGenerateCheckRuleReturnRequiresContext(t *testing.T) {
+	t.Parallel()
+
+	workdir := t.TempDir()
+	specPath := filepath.Join(workdir, "spec.yaml")
+	raw, err := os.ReadFile(filepath.Join("testdata", "x_check_rules_return.yaml"))
+	if err != nil {
+		t.Fatalf("read spec: %v", err)
+	}
+	spec := strings.Replace(string(raw), "useContext: true", "useContext: false", 1)
+	if err := os.WriteFile(specPath, []byte(spec), 0o644); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+
+	err = Generate(".", Config{
+		Path:    specPath,
+		Out:     filepath.Join(workdir, "spec_gen.go"),
+		Package: "apigen",
+	})
+	if err == nil || !strings.Contains(err.Error(), "return requires useContext") {
+		t.Fatalf("expected useContext error, got %v", err)
+	}
+}
+
+func TestGenerateMiddlewareExtractsScopeParams(t *testing.T) {
+	t.Parallel()
+
+	workdir := t.TempDir()
+	outPath := filepath.Join(workdir, "spec_gen.go")
+
+	if err := Generate(".", Config{
+		Path:    filepath.Join("testdata", "x_check_rules_params.yaml"),
+		Out:     outPath,
+		Package: "apigen",
+	}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	out := string(raw)
+
+	for _, needle := range []string{
+		`strconv.ParseInt(c.Params("orgID"), 10, 32)`,
+		"func (x *XMiddleware) ListOrgWidgets(c fiber.Ctx, orgID int32, params ListOrgWidgetsParams) error {",
+		"var projectID int64",
+		`if raw := c.Get("projectID"); raw != "" {`,
+		"parsed, err := strconv.ParseInt(raw, 10, 64)",
+		"projectID = parsed",
+		`return c.Status(fiber.StatusBadRequest).SendString("Missing required parameter projectID")`,
+		"if err := x.WidgetAccess(c, orgID, projectID, params.Owner); err != nil {",
+	} {
+		if !strings.Contains(out, needle) {
+			t.Fatalf("generated output missing %q", needle)
+		}
+	}
+	if strings.Contains(out, `c.Get("trace")`) {
+		t.Fatal("generated output parses a header parameter not used by any scope")
+	}
+}
+
+func TestGenerateRejectsUnsupportedScopeHeaderParam(t *testing.T) {
+	t.Parallel()
+
+	workdir := t.TempDir()
+	specPath := filepath.Join(workdir, "spec.yaml")
+	raw, err := os.ReadFile(filepath.Join("testdata", "x_check_rules_params.yaml"))
+	if err != nil {
+		t.Fatalf("read spec: %v", err)
+	}
+	spec := strings.Replace(string(raw), "x.WidgetAccess(c, orgID, projectID, params.Owner)", "x.WidgetAccess(c, orgID, trace, params.Owner)", 1)
+	if err := os.WriteFile(specPath, []byte(spec), 0o644); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+
+	err = Generate(".", Config{
+		Path:    specPath,
+		Out:     filepath.Join(workdir, "spec_gen.go"),
+		Package: "apigen",
+	})
+	if err == nil || !strings.Contains(err.Error(), "header parameter trace: unsupported type") {
+		t.Fatalf("expected unsupported header type error, got %v", err)
+	}
+}
+
 func TestGenerateSupportsMultilineEnumDescriptions(t *testing.T) {
 	t.Parallel()
 
@@ -312,6 +435,64 @@ components:
 	}
 }
 
+func TestGenerateSourceMatchesGeneratedFile(t *testing.T) {
+	t.Parallel()
+
+	workdir := t.TempDir()
+	specPath := filepath.Join(workdir, "spec.yaml")
+	outPath := filepath.Join(workdir, "spec_gen.go")
+
+	mustWriteFile(t, specPath, `openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /memos:
+    get:
+      operationId: ListMemos
+      summary: List memos
+      responses:
+        '200':
+          description: ok
+components:
+  schemas:
+    Memo:
+      type: object
+      required: [id]
+      properties:
+        id:
+          type: integer
+          format: int32
+`)
+
+	config := Config{
+		Path:    specPath,
+		Out:     outPath,
+		Package: "apigen",
+	}
+	source, err := GenerateSource(workdir, config)
+	if err != nil {
+		t.Fatalf("generate source: %v", err)
+	}
+	if _, err := os.Stat(outPath); !os.IsNotExist(err) {
+		t.Fatalf("GenerateSource should not write %s, stat err = %v", outPath, err)
+	}
+
+	if err := Generate(workdir, config); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if string(raw) != source {
+		t.Fatalf("GenerateSource output differs from the written file")
+	}
+	if !strings.Contains(source, "type Memo struct {") {
+		t.Fatalf("generated output missing Memo struct")
+	}
+}
+
 func mustWriteFile(t *testing.T, path string, content string) {
 	t.Helper()
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
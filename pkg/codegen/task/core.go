@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"go/format"
 	"log"
 	"math"
 	"os"
@@ -13,6 +14,7 @@ import (
 	"strings"
 	"text/template"
 	"time"
+	"unicode"
 
 	"github.com/cloudcarver/anclax/pkg/codegen/gotypes"
 	schema_codegen "github.com/cloudcarver/anclax/pkg/codegen/schemas"
@@ -103,6 +105,20 @@ func process(data map[string]any, onFunc func(f Function) error, onParam func(na
 			cronjob = &Cronjob{
 				CronExpression: cronjobStr["cronExpression"].(string),
 			}
+			if timezone, ok := cronjobStr["timezone"]; ok {
+				timezoneStr, ok := timezone.(string)
+				if !ok {
+					return errors.New("cronjob timezone must be a string")
+				}
+				cronjob.Timezone = timezoneStr
+			}
+			if runOnStart, ok := cronjobStr["runOnStart"]; ok {
+				runOnStartBool, ok := runOnStart.(bool)
+				if !ok {
+					return errors.New("cronjob runOnStart must be a boolean")
+				}
+				cronjob.RunOnStart = runOnStartBool
+			}
 		}
 
 		// parse retry policy
@@ -139,8 +155,11 @@ func process(data map[string]any, onFunc func(f Function) error, onParam func(na
 				if !ok {
 					return errors.New("event cannot be parsed to a string")
 				}
-				if eventStr == "onFailed" {
+				switch eventStr {
+				case "onFailed":
 					events.OnFailed = &eventStr
+				case "onCompleted":
+					events.OnCompleted = &eventStr
 				}
 			}
 		}
@@ -311,62 +330,104 @@ func indent(s string, spaces int) string {
 }
 
 func Generate(workdir, packageName, taskDefPath, outPath string, schemaConfig *schema_codegen.Config) error {
-	raw, err := os.ReadFile(filepath.Join(workdir, taskDefPath))
+	result, err := GenerateSource(workdir, packageName, taskDefPath, schemaConfig)
 	if err != nil {
 		return err
 	}
-	raw = schema_codegen.NormalizeRefBytes(raw)
-	var data map[string]any
-	if err := yaml.Unmarshal(raw, &data); err != nil {
+
+	if err := os.WriteFile(filepath.Join(workdir, outPath), []byte(result), 0644); err != nil {
 		return err
 	}
 
-	resetGlobalTypeNameCounter()
+	return nil
+}
 
-	schemaManager, err := schema_codegen.Load(workdir, derefSchemaConfig(schemaConfig))
+// GenerateSource renders the code Generate would write without touching the filesystem.
+// taskDefPath may be a single task definition file or a directory, in which case all
+// *.yaml and *.yml files under it are merged into one package.
+func GenerateSource(workdir, packageName, taskDefPath string, schemaConfig *schema_codegen.Config) (string, error) {
+	defs, err := loadTaskDefinitions(filepath.Join(workdir, taskDefPath))
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	result, err := generateToolInterfaces(workdir, packageName, filepath.Join(workdir, taskDefPath), data, schemaManager)
-	if err != nil {
-		return err
-	}
+	resetGlobalTypeNameCounter()
 
-	if err := os.WriteFile(filepath.Join(workdir, outPath), []byte(result), 0644); err != nil {
-		return err
+	schemaManager, err := schema_codegen.Load(workdir, derefSchemaConfig(schemaConfig))
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	return generateToolInterfaces(packageName, defs, schemaManager)
 }
 
-func generateToolInterfaces(workdir, packageName, taskDefFile string, data map[string]any, schemaManager *schema_codegen.Manager) (string, error) {
-	var structDef string
-	functions := []Function{}
-	importSet := map[string]struct{}{}
+type taskDefinitionFile struct {
+	path string
+	data map[string]any
+}
 
-	onFunc := func(f Function) error {
-		functions = append(functions, f)
-		return nil
+func loadTaskDefinitions(path string) ([]taskDefinitionFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
 	}
-
-	onParam := func(name string, params map[string]any) (paramSpec, error) {
-		ref, err := schema_codegen.UnmarshalSchemaRef(params)
+	if !info.IsDir() {
+		def, err := loadTaskDefinitionFile(path)
 		if err != nil {
-			return paramSpec{}, err
+			return nil, err
 		}
-		spec, err := resolveParamSpec(taskDefFile, name, ref, schemaManager)
+		return []taskDefinitionFile{def}, nil
+	}
+
+	var files []string
+	if err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
 		if err != nil {
-			return paramSpec{}, err
+			return err
 		}
-		if spec.StructDef != "" {
-			structDef += spec.StructDef + "\n"
+		if d.IsDir() {
+			return nil
 		}
-		for _, imp := range spec.Imports {
-			importSet[imp] = struct{}{}
+		if ext := filepath.Ext(p); ext == ".yaml" || ext == ".yml" {
+			files = append(files, p)
 		}
-		return spec, nil
+		return nil
+	}); err != nil {
+		return nil, err
 	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no task definition files found in %s", path)
+	}
+	sort.Strings(files)
+
+	defs := make([]taskDefinitionFile, 0, len(files))
+	for _, file := range files {
+		def, err := loadTaskDefinitionFile(file)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+func loadTaskDefinitionFile(path string) (taskDefinitionFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return taskDefinitionFile{}, err
+	}
+	raw = schema_codegen.NormalizeRefBytes(raw)
+	var data map[string]any
+	if err := yaml.Unmarshal(raw, &data); err != nil {
+		return taskDefinitionFile{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return taskDefinitionFile{path: path, data: data}, nil
+}
+
+func generateToolInterfaces(packageName string, defs []taskDefinitionFile, schemaManager *schema_codegen.Manager) (string, error) {
+	var structDef string
+	functions := []Function{}
+	importSet := map[string]struct{}{}
+	taskSources := map[string]string{}
 
 	tcTemplate, err := template.New("file").Funcs(template.FuncMap{
 		"upperFirst": utils.UpperFirst,
@@ -381,8 +442,39 @@ func generateToolInterfaces(workdir, packageName, taskDefFile string, data map[s
 		return "", err
 	}
 
-	if err := process(data, onFunc, onParam); err != nil {
-		return "", err
+	for _, def := range defs {
+		taskDefFile := def.path
+
+		onFunc := func(f Function) error {
+			if source, ok := taskSources[f.Name]; ok {
+				return fmt.Errorf("task %s is defined in both %s and %s", f.Name, source, taskDefFile)
+			}
+			taskSources[f.Name] = taskDefFile
+			functions = append(functions, f)
+			return nil
+		}
+
+		onParam := func(name string, params map[string]any) (paramSpec, error) {
+			ref, err := schema_codegen.UnmarshalSchemaRef(params)
+			if err != nil {
+				return paramSpec{}, err
+			}
+			spec, err := resolveParamSpec(taskDefFile, name, ref, schemaManager)
+			if err != nil {
+				return paramSpec{}, err
+			}
+			if spec.StructDef != "" {
+				structDef += spec.StructDef + "\n"
+			}
+			for _, imp := range spec.Imports {
+				importSet[imp] = struct{}{}
+			}
+			return spec, nil
+		}
+
+		if err := process(def.data, onFunc, onParam); err != nil {
+			return "", err
+		}
 	}
 
 	for i := range functions {
@@ -399,7 +491,11 @@ func generateToolInterfaces(workdir, packageName, taskDefFile string, data map[s
 		return "", err
 	}
 
-	return buf.String(), nil
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to format generated task code: %w", err)
+	}
+	return string(formatted), nil
 }
 
 func addGlobalType(name string) string {
@@ -444,6 +540,12 @@ func parseSchemaToType(currentFile, typeName string, ref *openapi3.SchemaRef, sc
 		}
 		return customType, "", nil
 	}
+	if len(ref.Value.OneOf) > 0 {
+		return parseUnionSchema(currentFile, typeName, ref.Value.OneOf, schemaManager, imports)
+	}
+	if len(ref.Value.AnyOf) > 0 {
+		return parseUnionSchema(currentFile, typeName, ref.Value.AnyOf, schemaManager, imports)
+	}
 	if ref.Value.Type == nil {
 		return "any", "", nil
 	}
@@ -462,7 +564,7 @@ func parseSchemaToType(currentFile, typeName string, ref *openapi3.SchemaRef, sc
 		return "[]" + itemType, itemDef, nil
 	case ref.Value.Type.Is("object"):
 		if len(ref.Value.Properties) == 0 {
-			return "map[string]any", "", nil
+			return parseMapSchema(currentFile, typeName, ref.Value, schemaManager, imports)
 		}
 		return parseObjectSchema(currentFile, typeName, ref.Value, schemaManager, imports)
 	default:
@@ -470,6 +572,83 @@ func parseSchemaToType(currentFile, typeName string, ref *openapi3.SchemaRef, sc
 	}
 }
 
+// parseMapSchema maps an object without properties to a Go map. A typed
+// additionalProperties schema becomes the value type; anything else is map[string]any.
+func parseMapSchema(currentFile, typeName string, schema *openapi3.Schema, schemaManager *schema_codegen.Manager, imports map[string]struct{}) (string, string, error) {
+	valueRef := schema.AdditionalProperties.Schema
+	if valueRef == nil {
+		return "map[string]any", "", nil
+	}
+	valueType, valueDef, err := parseSchemaToType(currentFile, addGlobalType(typeName+"Value"), valueRef, schemaManager, imports)
+	if err != nil {
+		return "", "", err
+	}
+	return "map[string]" + valueType, valueDef, nil
+}
+
+// parseUnionSchema generates a wrapper struct with one pointer field per
+// oneOf/anyOf variant. Unmarshalling populates the first variant that decodes
+// strictly, and Variant() reports which one was picked.
+func parseUnionSchema(currentFile, structName string, variants openapi3.SchemaRefs, schemaManager *schema_codegen.Manager, imports map[string]struct{}) (string, string, error) {
+	tmpl, err := template.New("union").Parse(unionTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	fields := make([]Field, 0, len(variants))
+	seen := map[string]struct{}{}
+	var nestedDefs string
+	for i, variantRef := range variants {
+		variantName := unionVariantName(variantRef, i)
+		if _, ok := seen[variantName]; ok {
+			return "", "", fmt.Errorf("%s: duplicate variant name %s", structName, variantName)
+		}
+		seen[variantName] = struct{}{}
+		variantType, variantDef, err := parseSchemaToType(currentFile, addGlobalType(structName+variantName), variantRef, schemaManager, imports)
+		if err != nil {
+			return "", "", err
+		}
+		if variantDef != "" {
+			nestedDefs += variantDef + "\n"
+		}
+		description := ""
+		if variantRef != nil && variantRef.Value != nil {
+			description = variantRef.Value.Description
+		}
+		fields = append(fields, Field{
+			Name:        variantName,
+			Type:        variantType,
+			Description: descriptionToComment(description),
+		})
+	}
+	imports["bytes"] = struct{}{}
+	buf := bytes.NewBuffer(nil)
+	if err := tmpl.Execute(buf, UnionTemplateVars{StructName: structName, Variants: fields}); err != nil {
+		return "", "", err
+	}
+	return structName, nestedDefs + "\n" + buf.String(), nil
+}
+
+func unionVariantName(ref *openapi3.SchemaRef, index int) string {
+	var raw string
+	if ref != nil {
+		if ref.Ref != "" {
+			raw = ref.Ref[strings.LastIndex(ref.Ref, "/")+1:]
+		} else if ref.Value != nil {
+			raw = ref.Value.Title
+		}
+	}
+	name := ""
+	for _, part := range strings.FieldsFunc(raw, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		name += utils.UpperFirst(part)
+	}
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		return fmt.Sprintf("Variant%d", index+1)
+	}
+	return name
+}
+
 func parseObjectSchema(currentFile, structName string, schema *openapi3.Schema, schemaManager *schema_codegen.Manager, imports map[string]struct{}) (string, string, error) {
 	requiredFields := map[string]struct{}{}
 	for _, r := range schema.Required {
@@ -496,18 +675,20 @@ func parseObjectSchema(currentFile, structName string, schema *openapi3.Schema,
 			nestedDefs += propDef + "\n"
 		}
 		_, isRequired := requiredFields[propName]
-		if !isRequired && !strings.HasPrefix(propType, "[]") && !strings.HasPrefix(propType, "map[") {
-			propType = "*" + propType
-		}
 		description := ""
+		nullable := false
 		if propRef != nil && propRef.Value != nil {
 			description = propRef.Value.Description
+			nullable = propRef.Value.Nullable
+		}
+		if (!isRequired || nullable) && !strings.HasPrefix(propType, "[]") && !strings.HasPrefix(propType, "map[") {
+			propType = "*" + propType
 		}
 		fields = append(fields, Field{
 			Name:        utils.UpperFirst(propName),
 			Type:        propType,
 			Description: descriptionToComment(description),
-			Tag:         "`json:\"" + propName + "\" yaml:\"" + propName + "\"`",
+			Tag:         fieldTag(propName, isRequired, nullable),
 		})
 	}
 	buf := bytes.NewBuffer(nil)
@@ -517,6 +698,16 @@ func parseObjectSchema(currentFile, structName string, schema *openapi3.Schema,
 	return structName, nestedDefs + "\n" + buf.String(), nil
 }
 
+// fieldTag omits unset optional fields from JSON, while nullable fields keep
+// serializing an explicit null.
+func fieldTag(propName string, required, nullable bool) string {
+	jsonName := propName
+	if !required && !nullable {
+		jsonName += ",omitempty"
+	}
+	return "`json:\"" + jsonName + "\" yaml:\"" + propName + "\"`"
+}
+
 func customGoType(schema *openapi3.Schema) (string, []string) {
 	if schema == nil || schema.Extensions == nil {
 		return "", nil
@@ -0,0 +1,281 @@
+package codegen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	schema_codegen "github.com/cloudcarver/anclax/pkg/codegen/schemas"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTestParams(t *testing.T, name string, params map[string]any) paramSpec {
+	t.Helper()
+	resetGlobalTypeNameCounter()
+	ref, err := schema_codegen.UnmarshalSchemaRef(params)
+	require.NoError(t, err)
+	spec, err := resolveParamSpec("tasks.yaml", name, ref, nil)
+	require.NoError(t, err)
+	return spec
+}
+
+func TestParseSchemaToTypeAdditionalProperties(t *testing.T) {
+	spec := parseTestParams(t, "SyncParameters", map[string]any{
+		"type":     "object",
+		"required": []any{"labels"},
+		"properties": map[string]any{
+			"labels": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "string"},
+			},
+			"extra": map[string]any{
+				"type":                 "object",
+				"additionalProperties": true,
+			},
+			"counters": map[string]any{
+				"type": "object",
+				"additionalProperties": map[string]any{
+					"type":   "integer",
+					"format": "int64",
+				},
+			},
+		},
+	})
+
+	require.Equal(t, "SyncParameters", spec.Type)
+	require.Contains(t, spec.StructDef, "Labels map[string]string `json:\"labels\" yaml:\"labels\"`")
+	require.Contains(t, spec.StructDef, "Extra map[string]any `json:\"extra,omitempty\" yaml:\"extra\"`")
+	require.Contains(t, spec.StructDef, "Counters map[string]int64 `json:\"counters,omitempty\" yaml:\"counters\"`")
+}
+
+func TestParseSchemaToTypeAdditionalPropertiesObjectValue(t *testing.T) {
+	spec := parseTestParams(t, "SyncParameters", map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"targets": map[string]any{
+				"type": "object",
+				"additionalProperties": map[string]any{
+					"type":     "object",
+					"required": []any{"url"},
+					"properties": map[string]any{
+						"url": map[string]any{"type": "string"},
+					},
+				},
+			},
+		},
+	})
+
+	require.Contains(t, spec.StructDef, "Targets map[string]TargetsValue `json:\"targets,omitempty\" yaml:\"targets\"`")
+	require.Contains(t, spec.StructDef, "type TargetsValue struct {")
+	require.Contains(t, spec.StructDef, "Url string `json:\"url\" yaml:\"url\"`")
+}
+
+func TestParseSchemaToTypeOneOf(t *testing.T) {
+	spec := parseTestParams(t, "NotifyParameters", map[string]any{
+		"oneOf": []any{
+			map[string]any{
+				"title":    "email",
+				"type":     "object",
+				"required": []any{"address"},
+				"properties": map[string]any{
+					"address": map[string]any{"type": "string"},
+				},
+			},
+			map[string]any{
+				"title":    "sms",
+				"type":     "object",
+				"required": []any{"phone"},
+				"properties": map[string]any{
+					"phone": map[string]any{"type": "string"},
+				},
+			},
+		},
+	})
+
+	require.Equal(t, "NotifyParameters", spec.Type)
+	require.Contains(t, spec.Imports, "bytes")
+	require.Contains(t, spec.StructDef, "type NotifyParameters struct {")
+	require.Contains(t, spec.StructDef, "Email *NotifyParametersEmail `json:\"-\" yaml:\"-\"`")
+	require.Contains(t, spec.StructDef, "Sms *NotifyParametersSms `json:\"-\" yaml:\"-\"`")
+	require.Contains(t, spec.StructDef, "type NotifyParametersEmail struct {")
+	require.Contains(t, spec.StructDef, "Address string `json:\"address\" yaml:\"address\"`")
+	require.Contains(t, spec.StructDef, "func (v NotifyParameters) Variant() string {")
+	require.Contains(t, spec.StructDef, "return \"Sms\"")
+	require.Contains(t, spec.StructDef, "func (v *NotifyParameters) UnmarshalJSON(data []byte) error {")
+}
+
+func TestParseSchemaToTypeAnyOfPrimitiveVariants(t *testing.T) {
+	spec := parseTestParams(t, "WaitParameters", map[string]any{
+		"type":     "object",
+		"required": []any{"timeout"},
+		"properties": map[string]any{
+			"timeout": map[string]any{
+				"anyOf": []any{
+					map[string]any{"type": "string"},
+					map[string]any{"type": "integer", "format": "int64"},
+				},
+			},
+		},
+	})
+
+	require.Contains(t, spec.StructDef, "Timeout Timeout `json:\"timeout\" yaml:\"timeout\"`")
+	require.Contains(t, spec.StructDef, "Variant1 *string `json:\"-\" yaml:\"-\"`")
+	require.Contains(t, spec.StructDef, "Variant2 *int64 `json:\"-\" yaml:\"-\"`")
+}
+
+func TestParseSchemaToTypeFieldTags(t *testing.T) {
+	spec := parseTestParams(t, "ReportParameters", map[string]any{
+		"type":     "object",
+		"required": []any{"name", "deletedAt"},
+		"properties": map[string]any{
+			"name":    map[string]any{"type": "string"},
+			"comment": map[string]any{"type": "string"},
+			"deletedAt": map[string]any{
+				"type":     "string",
+				"nullable": true,
+			},
+			"archivedBy": map[string]any{
+				"type":     "string",
+				"nullable": true,
+			},
+		},
+	})
+
+	require.Contains(t, spec.StructDef, "Name string `json:\"name\" yaml:\"name\"`")
+	require.Contains(t, spec.StructDef, "Comment *string `json:\"comment,omitempty\" yaml:\"comment\"`")
+	require.Contains(t, spec.StructDef, "DeletedAt *string `json:\"deletedAt\" yaml:\"deletedAt\"`")
+	require.Contains(t, spec.StructDef, "ArchivedBy *string `json:\"archivedBy\" yaml:\"archivedBy\"`")
+}
+
+func TestGenerateSourceMatchesGeneratedFile(t *testing.T) {
+	workdir := t.TempDir()
+	taskDef := `tasks:
+  - name: sendReport
+    description: Send a report
+    parameters:
+      type: object
+      required: [reportID]
+      properties:
+        reportID:
+          type: integer
+          format: int32
+        channel:
+          type: string
+    retryPolicy:
+      interval: 1m
+      maxAttempts: 3
+`
+	require.NoError(t, os.WriteFile(filepath.Join(workdir, "tasks.yaml"), []byte(taskDef), 0644))
+
+	source, err := GenerateSource(workdir, "taskgen", "tasks.yaml", nil)
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(workdir, "runner_gen.go"))
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, Generate(workdir, "taskgen", "tasks.yaml", "runner_gen.go", nil))
+	raw, err := os.ReadFile(filepath.Join(workdir, "runner_gen.go"))
+	require.NoError(t, err)
+	require.Equal(t, string(raw), source)
+	require.Contains(t, source, "type SendReportParameters struct {")
+}
+
+func TestGenerateSourceMergesTaskDefinitionDirectory(t *testing.T) {
+	workdir := t.TempDir()
+	taskDir := filepath.Join(workdir, "tasks")
+	require.NoError(t, os.MkdirAll(filepath.Join(taskDir, "billing"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "reports.yaml"), []byte(`tasks:
+  - name: sendReport
+    parameters:
+      type: object
+      required: [target]
+      properties:
+        target:
+          type: object
+          properties:
+            url:
+              type: string
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "billing", "invoices.yml"), []byte(`tasks:
+  - name: chargeInvoice
+    parameters:
+      type: object
+      required: [target]
+      properties:
+        target:
+          type: object
+          properties:
+            account:
+              type: string
+`), 0644))
+
+	source, err := GenerateSource(workdir, "taskgen", "tasks", nil)
+	require.NoError(t, err)
+
+	require.Contains(t, source, "SendReport = \"sendReport\"")
+	require.Contains(t, source, "ChargeInvoice = \"chargeInvoice\"")
+	require.Contains(t, source, "type SendReportParameters struct {")
+	require.Contains(t, source, "type ChargeInvoiceParameters struct {")
+	// nested type names are deduplicated across files
+	require.Contains(t, source, "type Target struct {")
+	require.Contains(t, source, "type Target1 struct {")
+}
+
+func TestGenerateSourceRejectsDuplicateTaskAcrossFiles(t *testing.T) {
+	workdir := t.TempDir()
+	taskDir := filepath.Join(workdir, "tasks")
+	require.NoError(t, os.MkdirAll(taskDir, 0755))
+	def := []byte(`tasks:
+  - name: sendReport
+`)
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "a.yaml"), def, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "b.yaml"), def, 0644))
+
+	_, err := GenerateSource(workdir, "taskgen", "tasks", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "task sendReport is defined in both")
+}
+
+func TestGenerateSourceRunnerAppliesDeclaredAttributes(t *testing.T) {
+	workdir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workdir, "tasks.yaml"), []byte(`tasks:
+  - name: nightlyReport
+    delay: 10m
+    timeout: 5m
+    cronjob:
+      cronExpression: "0 0 2 * * *"
+    retryPolicy:
+      interval: 1m
+      maxAttempts: 3
+`), 0644))
+
+	source, err := GenerateSource(workdir, "taskgen", "tasks.yaml", nil)
+	require.NoError(t, err)
+
+	require.Contains(t, source, "RunNightlyReport(ctx context.Context, params *NightlyReportParameters, overrides ...taskcore.TaskOverride) (int32, error)")
+	require.Contains(t, source, "Type:    NightlyReport,")
+	require.Contains(t, source, `attributes.Timeout = utils.Ptr("5m")`)
+	require.Contains(t, source, `CronExpression: "0 0 2 * * *",`)
+	require.Contains(t, source, `Interval:    "1m",`)
+	require.Contains(t, source, "MaxAttempts: 3,")
+	require.Contains(t, source, `delay, err := time.ParseDuration("10m")`)
+	require.Contains(t, source, `return 0, fmt.Errorf("failed to parse delay: %w", err)`)
+	require.Contains(t, source, "task.StartedAt = utils.Ptr(c.now().Add(delay))")
+}
+
+func TestGenerateSourceRunnerAppliesCronjobOptions(t *testing.T) {
+	workdir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workdir, "tasks.yaml"), []byte(`tasks:
+  - name: morningReport
+    cronjob:
+      cronExpression: "0 0 9 * * *"
+      timezone: Asia/Shanghai
+      runOnStart: true
+`), 0644))
+
+	source, err := GenerateSource(workdir, "taskgen", "tasks.yaml", nil)
+	require.NoError(t, err)
+
+	require.Contains(t, source, `CronExpression: "0 0 9 * * *",`)
+	require.Contains(t, source, `Timezone:       utils.Ptr("Asia/Shanghai"),`)
+	require.Contains(t, source, `RunOnStart:     utils.Ptr(true),`)
+}
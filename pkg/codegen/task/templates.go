@@ -1,6 +1,6 @@
 package codegen
 
-var structTemplate = `type {{.StructName}} struct { {{range .Fields}}
+var structTemplate = `type {{.StructName}} struct { {{- range .Fields}}
 {{.Description}}
 	{{.Name}} {{.Type}} {{.Tag}}
 {{end}}}`
@@ -34,7 +34,7 @@ const ( {{range .Functions}}
 
 
 
-type TaskRunner interface { {{range .Functions}}
+type TaskRunner interface { {{- range .Functions}}
 {{.Description}}
 	Run{{upperFirst .Name}}(ctx context.Context, params *{{.ParameterType}}, overrides ...taskcore.TaskOverride) (int32, error)
 {{.Description}}
@@ -80,9 +80,11 @@ func (c *Client) run{{upperFirst .Name}}(ctx context.Context, taskstore taskcore
 	}{{end}}
 	{{if .Cronjob }}attributes.Cronjob = &apigen.TaskCronjob{
 		CronExpression: "{{.Cronjob.CronExpression}}",
+		{{if .Cronjob.Timezone}}Timezone:       utils.Ptr("{{.Cronjob.Timezone}}"),{{end}}
+		{{if .Cronjob.RunOnStart}}RunOnStart:     utils.Ptr(true),{{end}}
 	}{{end}}
-	{{if .Labels }}attributes.Labels = &[]string{ {{range $idx, $label := .Labels}}{{if $idx}}, {{end}}"{{$label}}"{{end}} }{{end}}
-	{{if .Tags }}attributes.Tags = &[]string{ {{range $idx, $tag := .Tags}}{{if $idx}}, {{end}}"{{$tag}}"{{end}} }{{end}}
+	{{if .Labels }}attributes.Labels = &[]string{ {{- range $idx, $label := .Labels}}{{if $idx}}, {{end}}"{{$label}}"{{end -}} }{{end}}
+	{{if .Tags }}attributes.Tags = &[]string{ {{- range $idx, $tag := .Tags}}{{if $idx}}, {{end}}"{{$tag}}"{{end -}} }{{end}}
 	{{if .Priority}}attributes.Priority = utils.Ptr(int32({{derefInt32 .Priority}})){{end}}
 	task := &apigen.Task{
 		Attributes: attributes,
@@ -91,7 +93,7 @@ func (c *Client) run{{upperFirst .Name}}(ctx context.Context, taskstore taskcore
 	}
 	{{if .Delay }}delay, err := time.ParseDuration("{{.Delay}}")
 	if err != nil {
-		return fmt.Errorf("failed to parse delay: %w", err)
+		return 0, fmt.Errorf("failed to parse delay: %w", err)
 	}
 	task.StartedAt = utils.Ptr(c.now().Add(delay)){{end}}
 	for _, override := range overrides {
@@ -109,8 +111,8 @@ func (c *Client) run{{upperFirst .Name}}(ctx context.Context, taskstore taskcore
 		return 0, err
 	}
 	return taskID, nil
-}{{end}}
-
+}
+{{end}}
 {{.StructDefs}}{{range .Functions}}{{if .HasLocalHelpers}}
 func (r *{{.ParameterType}}) Parse(spec json.RawMessage) error {
 	return json.Unmarshal(spec, r)
@@ -121,12 +123,14 @@ func (r *{{.ParameterType}}) Marshal() (json.RawMessage, error) {
 }
 {{end}}{{end}}
 
-type ExecutorInterface interface { {{range .Functions}}
+type ExecutorInterface interface { {{- range .Functions}}
  {{.Description}}
 	Execute{{upperFirst .Name}}(ctx context.Context, task worker.Task, params *{{.ParameterType}}) error
  {{if .Events}}{{if .Events.OnFailed}}
 	// Hook called when {{.Name}} fails
-	On{{upperFirst .Name}}Failed(ctx context.Context, taskID int32, params *{{.ParameterType}}, tx core.Tx) error{{end}}{{end}}
+	On{{upperFirst .Name}}Failed(ctx context.Context, taskID int32, params *{{.ParameterType}}, tx core.Tx) error{{end}}{{if .Events.OnCompleted}}
+	// Hook called when {{.Name}} completes
+	On{{upperFirst .Name}}Completed(ctx context.Context, taskID int32, params *{{.ParameterType}}, tx core.Tx) error{{end}}{{end}}
 {{end}}}
 
 type TaskHandler struct {
@@ -156,7 +160,7 @@ func (f *TaskHandler) HandleTask(ctx context.Context, task worker.Task) error {
 		return nil
 	}
 
-	switch task.GetType() { {{range .Functions}}
+	switch task.GetType() { {{- range .Functions}}
 	case {{upperFirst .Name}}:
 		var params {{.ParameterType}}
 		if err := json.Unmarshal(task.GetPayload(), &params); err != nil {
@@ -181,7 +185,7 @@ func (f *TaskHandler) OnTaskFailed(ctx context.Context, tx core.Tx, failedTaskSp
 	}
 
 	// Call the appropriate OnXXXFailed hook method
-	switch failedTaskSpec.GetType() { {{range .Functions}}{{if .Events}}{{if .Events.OnFailed}}
+	switch failedTaskSpec.GetType() { {{- range .Functions}}{{if .Events}}{{if .Events.OnFailed}}
 	case {{upperFirst .Name}}:
 		var params {{.ParameterType}}
 		if err := json.Unmarshal(failedTaskSpec.GetPayload(), &params); err != nil {
@@ -192,4 +196,64 @@ func (f *TaskHandler) OnTaskFailed(ctx context.Context, tx core.Tx, failedTaskSp
 		return nil // No hook configured for this task type
 	}
 }
+
+func (f *TaskHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	for _, handler := range f.externalTaskHandler {
+		if err := handler.OnTaskCompleted(ctx, tx, completedTaskSpec, taskID); err != nil {
+			if errors.Is(err, worker.ErrUnknownTaskType) {
+				continue
+			}
+			return err
+		}
+		return nil
+	}
+
+	// Call the appropriate OnXXXCompleted hook method
+	switch completedTaskSpec.GetType() { {{- range .Functions}}{{if .Events}}{{if .Events.OnCompleted}}
+	case {{upperFirst .Name}}:
+		var params {{.ParameterType}}
+		if err := json.Unmarshal(completedTaskSpec.GetPayload(), &params); err != nil {
+			return fmt.Errorf("failed to parse {{.Name}} parameters: %w", err)
+		}
+		return f.executor.On{{upperFirst .Name}}Completed(ctx, taskID, &params, tx){{end}}{{end}}{{end}}
+	default:
+		return nil // No hook configured for this task type
+	}
+}
 `
+
+var unionTemplate = `type {{.StructName}} struct { {{- range .Variants}}
+{{.Description}}
+	{{.Name}} *{{.Type}} ` + "`json:\"-\" yaml:\"-\"`" + `
+{{end}}}
+
+// Variant returns the name of the populated variant, or an empty string if none is set.
+func (v {{.StructName}}) Variant() string {
+	switch { {{- range .Variants}}
+	case v.{{.Name}} != nil:
+		return "{{.Name}}"{{end}}
+	}
+	return ""
+}
+
+func (v {{.StructName}}) MarshalJSON() ([]byte, error) {
+	switch { {{- range .Variants}}
+	case v.{{.Name}} != nil:
+		return json.Marshal(v.{{.Name}}){{end}}
+	}
+	return []byte("null"), nil
+}
+
+func (v *{{.StructName}}) UnmarshalJSON(data []byte) error {
+	*v = {{.StructName}}{} {{range .Variants}}
+	{
+		var variant {{.Type}}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&variant); err == nil {
+			v.{{.Name}} = &variant
+			return nil
+		}
+	}{{end}}
+	return fmt.Errorf("{{.StructName}}: data does not match any variant")
+}`
@@ -12,8 +12,15 @@ type StructTemplateVars struct {
 	Fields     []Field `yaml:"fields"`
 }
 
+type UnionTemplateVars struct {
+	StructName string  `yaml:"structName"`
+	Variants   []Field `yaml:"variants"`
+}
+
 type Cronjob struct {
 	CronExpression string `yaml:"cronExpression"`
+	Timezone       string `yaml:"timezone,omitempty"`
+	RunOnStart     bool   `yaml:"runOnStart,omitempty"`
 }
 
 type RetryPolicy struct {
@@ -22,7 +29,8 @@ type RetryPolicy struct {
 }
 
 type Events struct {
-	OnFailed *string `yaml:"onFailed,omitempty"`
+	OnFailed    *string `yaml:"onFailed,omitempty"`
+	OnCompleted *string `yaml:"onCompleted,omitempty"`
 }
 
 type Function struct {
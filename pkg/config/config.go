@@ -16,6 +16,25 @@ type Pg struct {
 
 	// (Optional) The SSL mode for postgres connection, default is "required". Other options are "disable", "verify-ca", "verify-full".
 	SSLMode string `yaml:"sslmode"`
+
+	// (Optional) DSNs of read replicas. If set, queries of models returned by ModelInterface.ReadOnly
+	// are spread across the replicas in round-robin order. Writes and transactions always use the primary.
+	ReplicaDSNs []string `yaml:"replicadsns"`
+
+	// (Optional) The maximum number of connections of each pool, default is LibConfig.Pg.MaxConnections (10)
+	MaxConns *int32 `yaml:"maxconns"`
+
+	// (Optional) The minimum number of idle connections kept in each pool, default is LibConfig.Pg.MinConnections (1)
+	MinConns *int32 `yaml:"minconns"`
+
+	// (Optional) Connections older than this are closed and replaced, default is 1h
+	MaxConnLifetime *time.Duration `yaml:"maxconnlifetime"`
+
+	// (Optional) Idle connections older than this are closed, default is 30m
+	MaxConnIdleTime *time.Duration `yaml:"maxconnidletime"`
+
+	// (Optional) The default timeout of each statement, see model.WithQueryTimeout to override it per call. Default is no timeout.
+	QueryTimeout *time.Duration `yaml:"querytimeout"`
 }
 
 type Auth struct {
@@ -26,6 +45,37 @@ type Auth struct {
 	// (Optional) Whether to enable single session, default is false.
 	// If enabled, the user can only have one session at a time, login from different devices will invalidate the previous session.
 	SingleSession bool `yaml:"singlesession"`
+
+	// (Optional) Maps an email domain to the ID of an existing org, e.g. acme.com: 3.
+	// A user whose username is an email in a mapped domain joins that org once the application has verified the
+	// email and calls Service.JoinOrgByVerifiedEmail. Signing up alone only creates the user's personal org.
+	OrgDomains map[string]int32 `yaml:"orgdomains"`
+
+	// (Optional) Rules a password must follow when a user is created or changes the password.
+	PasswordPolicy PasswordPolicy `yaml:"passwordpolicy"`
+
+	// (Optional) How often expired token keys are deleted, default is 1h. Zero or a negative value disables the sweeper.
+	KeySweepInterval *time.Duration `yaml:"keysweepinterval"`
+
+	// (Optional) Secret used to encrypt the TOTP secrets of users. TOTP enrollment is disabled if empty.
+	TOTPKey string `yaml:"totpkey"`
+
+	// (Optional) Issuer shown in authenticator apps, default is "anclax".
+	TOTPIssuer *string `yaml:"totpissuer"`
+}
+
+type PasswordPolicy struct {
+	// (Optional) The minimum length of a password, default is 8
+	MinLength *int `yaml:"minlength"`
+
+	// (Optional) Whether a password must contain both upper and lower case letters, default is false
+	RequireMixedCase bool `yaml:"requiremixedcase"`
+
+	// (Optional) Whether a password must contain a digit, default is false
+	RequireDigit bool `yaml:"requiredigit"`
+
+	// (Optional) Whether a password must contain a symbol, default is false
+	RequireSymbol bool `yaml:"requiresymbol"`
 }
 
 type TestAccount struct {
@@ -45,6 +95,10 @@ type Worker struct {
 	// (Optional) The interval of the poll, default is 1 second
 	PollInterval *time.Duration `yaml:"pollinterval"`
 
+	// (Optional) Randomizes each poll interval by up to this fraction (0-1) of pollinterval in either direction, so
+	// that workers started together do not poll at the same time. Default is 0 (disabled).
+	PollJitter *float64 `yaml:"pollJitter"`
+
 	// (Optional) Heartbeat interval for worker registry, default is 3s
 	HeartbeatInterval *time.Duration `yaml:"heartbeatInterval"`
 
@@ -54,15 +108,28 @@ type Worker struct {
 	// (Optional) Task lock refresh interval, default is heartbeat interval
 	LockRefreshInterval *time.Duration `yaml:"lockRefreshInterval"`
 
+	// (Optional) A claimed task is only started if the worker context has at least this much time left before its
+	// deadline, otherwise its lock is released for the next pull. Default is 1s, 0 disables the check.
+	MinExecutionBudget *time.Duration `yaml:"minExecutionBudget"`
+
 	// (Optional) Worker labels for task filtering
 	Labels []string `yaml:"labels"`
 
+	// (Optional) If set, the worker only runs tasks belonging to these organizations, e.g. for tenant-dedicated
+	// worker pools. Tasks without an organization are skipped too. Default is to run tasks of every organization.
+	OrgIDs []int32 `yaml:"orgIds"`
+
 	// (Optional) Static worker ID (UUID). If unset, a random UUID is generated.
 	WorkerID *string `yaml:"workerId"`
 
 	// (Optional) Maximum percentage (0-100) of worker concurrency allowed for strict-priority tasks. Default is 100.
 	MaxStrictPercentage *int `yaml:"maxStrictPercentage"`
 
+	// (Optional) After claiming this many normal tasks of the same type in a row, the worker prefers a task of
+	// another type for its next claim so that a backlog of one type cannot starve the others. Strict-priority
+	// tasks are not affected. Default is 0 (disabled).
+	MaxConsecutiveTaskType *int `yaml:"maxConsecutiveTaskType"`
+
 	// (Optional) Fallback poll interval for runtime scheduling config refresh when notifications are missed/unavailable. Disabled by default.
 	RuntimeConfigPollInterval *time.Duration `yaml:"runtimeConfigPollInterval"`
 
@@ -70,6 +137,35 @@ type Worker struct {
 	UseLegacyWorker bool `yaml:"useLegacyWorker"`
 }
 
+type EventArchive struct {
+	// (Optional) Task events older than this are moved to anclax.events_archive, default is 720h (30 days)
+	Retention *time.Duration `yaml:"retention"`
+
+	// (Optional) The cron expression (second minute hour dayOfMonth month dayOfWeek) of the archive job, default is "0 0 3 * * *"
+	Cron *string `yaml:"cron"`
+}
+
+type TLS struct {
+	// (Optional) The path of the PEM encoded server certificate. Either CertFile or Cert must be set.
+	CertFile string `yaml:"certFile"`
+
+	// (Optional) The path of the PEM encoded server private key. Either KeyFile or Key must be set.
+	KeyFile string `yaml:"keyFile"`
+
+	// (Optional) The inline PEM encoded server certificate.
+	Cert string `yaml:"cert"`
+
+	// (Optional) The inline PEM encoded server private key.
+	Key string `yaml:"key"`
+
+	// (Optional) The path of the PEM encoded CA certificates used to verify client certificates.
+	// If ClientCAFile or ClientCA is set, clients must present a certificate signed by one of them (mTLS).
+	ClientCAFile string `yaml:"clientCaFile"`
+
+	// (Optional) The inline PEM encoded CA certificates used to verify client certificates.
+	ClientCA string `yaml:"clientCa"`
+}
+
 type Debug struct {
 	// (Optional) Whether to enable the debug server, default is false
 	Enable bool `yaml:"enable"`
@@ -88,6 +184,9 @@ type Config struct {
 	// (Optional) The port of the anclax server, default is 8020
 	Port int `yaml:"port"`
 
+	// (Optional) Serve HTTPS instead of HTTP, for deployments without a TLS-terminating proxy
+	TLS *TLS `yaml:"tls"`
+
 	// The Auth configuration
 	Auth Auth `yaml:"auth"`
 
@@ -110,6 +209,12 @@ type Config struct {
 
 	Debug Debug `yaml:"debug"`
 
+	// (Optional) Periodically archive old task events, disabled if not set
+	EventArchive *EventArchive `yaml:"eventArchive"`
+
 	// (Optional) The timeout for the request, default is no timeout
 	RequestTimeout *time.Duration `yaml:"requesttimeout"`
+
+	// (Optional) The maximum request body size in bytes, default is 50MB
+	BodyLimit *int `yaml:"bodylimit"`
 }
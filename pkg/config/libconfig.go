@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/cloudcarver/anclax/lib/ws"
 	"github.com/gofiber/fiber/v3/middleware/cors"
 )
@@ -11,6 +13,12 @@ type PgCfg struct {
 }
 
 type LogCfg struct {
+	// (optional) Log encoding, "json" or "console". Default is "json".
+	Format *string
+
+	// (optional) Minimum level of logged entries, e.g. "debug", "info", "warn" or "error". Default is "info".
+	Level *string
+
 	// (optional) If set, only log entries where the request path starts with this prefix will be logged.
 	RequestPathPrefix *string
 
@@ -21,6 +29,51 @@ type LogCfg struct {
 	// Deprecated: use ErrorOnlyPathPrefixes.
 	// (optional) If set, only error will be logged for this exact health check path.
 	HealthCheckPath *string
+
+	// (optional) Logged response bodies are truncated to this many bytes, 0 disables body logging.
+	// Default is 512.
+	BodyMaxLength *int
+
+	// (optional) Bodies are not logged for paths starting with any of these prefixes, see also server.DisableBodyLog.
+	DisableBodyLogPathPrefixes []string
+
+	// (optional) If greater than 1, only 1 in SampleRate requests is logged. Responses with a status of 400 or
+	// above are always logged. Default is to log every request.
+	SampleRate *int
+}
+
+type BodySpoolCfg struct {
+	// Path prefixes of the routes whose bodies are spooled, e.g. "/api/v1/uploads". Their handlers must read
+	// the body with server.RequestBody, fiber's body accessors and binders see an empty body once it is spooled.
+	// Bodies of other routes are kept in memory.
+	Paths []string
+
+	// (optional) Request bodies larger than this many bytes are spooled to a temp file instead of
+	// being kept in memory, default is 1MB.
+	Threshold int64
+
+	// (optional) Directory for spooled bodies, default is the system temp directory.
+	TempDir string
+
+	// (optional) Maximum accepted request body size in bytes, 0 means unlimited.
+	MaxSize int64
+}
+
+type RateLimitCfg struct {
+	// Maximum number of requests a client can make in one window.
+	Max int
+
+	// (optional) The window size, default is 1 minute.
+	Window time.Duration
+
+	// (optional) If true, API requests are limited per authenticated user (auth.GetUserID) instead of per client
+	// IP. The limit is applied after the request is authenticated, requests without a valid token are limited by
+	// client IP. Other routes are always limited by client IP.
+	KeyByUser bool
+
+	// (optional) Requests whose path starts with any of these prefixes are not limited. LogCfg.HealthCheckPath
+	// is never limited.
+	SkipPathPrefixes []string
 }
 
 type LibConfig struct {
@@ -28,6 +81,28 @@ type LibConfig struct {
 	Pg   *PgCfg
 	Log  LogCfg
 	Ws   *ws.WsCfg
+
+	// (optional, runtime only) If set, origins not allowed by Cors.AllowOrigins are allowed when it returns
+	// true, e.g. to allow every subdomain of a domain. It replaces Cors.AllowOriginsFunc.
+	CorsAllowOriginFunc func(origin string) bool `json:"-" yaml:"-"`
+
+	// (optional) If set, request bodies are streamed and large ones are spooled to disk, see server.RequestBody.
+	BodySpool *BodySpoolCfg
+
+	// (optional) Request body size limits in bytes keyed by request path prefix, e.g. "/api/v1/uploads".
+	// They override Config.BodyLimit, and the longest matching prefix wins.
+	BodyLimits map[string]int
+
+	// (optional) If set, requests are rate limited, exceeding the limit returns 429 with a Retry-After header.
+	RateLimit *RateLimitCfg
+
+	// (optional) Maximum time to wait for in-flight requests when the server shuts down. Connections still
+	// open at the deadline are closed. Default is to wait indefinitely.
+	GracefulTimeout *time.Duration
+
+	// (optional) Path of the readiness endpoint, which returns 503 if the database is unreachable.
+	// Default is "/readyz".
+	ReadinessPath *string
 }
 
 func DefaultLibConfig() *LibConfig {
@@ -55,6 +55,15 @@ func (controller *Controller) SignIn(c fiber.Ctx) error {
 		if errors.Is(err, service.ErrInvalidPassword) {
 			return c.SendStatus(fiber.StatusUnauthorized)
 		}
+		if errors.Is(err, service.ErrTOTPRequired) || errors.Is(err, service.ErrInvalidTOTPCode) {
+			return c.Status(fiber.StatusUnauthorized).SendString(err.Error())
+		}
+		if errors.Is(err, service.ErrTOTPThrottled) {
+			return c.Status(fiber.StatusTooManyRequests).SendString(err.Error())
+		}
+		if errors.Is(err, service.ErrTOTPDisabled) {
+			return c.Status(fiber.StatusServiceUnavailable).SendString(err.Error())
+		}
 		return err
 	}
 
@@ -109,6 +118,9 @@ func (controller *Controller) SignUp(c fiber.Ctx) error {
 
 	userMeta, err := controller.svc.CreateNewUser(c.Context(), params.Name, params.Password)
 	if err != nil {
+		if errors.Is(err, service.ErrWeakPassword) {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
 		return err
 	}
 
@@ -155,8 +167,19 @@ func (controller *Controller) TryExecuteTask(c fiber.Ctx, taskID int32) error {
 		return c.Status(fiber.StatusNotFound).SendString("Cannot GET /api/v1/tasks/try-execute")
 	}
 
-	err := controller.svc.TryExecuteTask(c.Context(), taskID)
+	orgID, err := auth.GetOrgID(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+
+	err = controller.svc.TryExecuteTask(c.Context(), orgID, taskID)
 	if err != nil {
+		if errors.Is(err, service.ErrTaskNotFound) {
+			return c.Status(fiber.StatusNotFound).SendString(err.Error())
+		}
+		if errors.Is(err, service.ErrTaskNotPending) || errors.Is(err, service.ErrTaskAlreadyRunning) {
+			return c.Status(fiber.StatusConflict).SendString(err.Error())
+		}
 		return err
 	}
 
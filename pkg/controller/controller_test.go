@@ -14,6 +14,7 @@ import (
 	"github.com/cloudcarver/anclax/pkg/utils"
 	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
 	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -24,6 +25,7 @@ type stubService struct {
 	isUsernameExists   func(context.Context, string) (bool, error)
 	createNewUser      func(context.Context, string, string) (*service.UserMeta, error)
 	signIn             func(context.Context, int32) (*apigen.Credentials, error)
+	tryExecuteTask     func(context.Context, int32, int32) error
 }
 
 func (s stubService) SignInWithPassword(ctx context.Context, params apigen.SignInRequest) (*apigen.Credentials, error) {
@@ -46,6 +48,10 @@ func (s stubService) SignIn(ctx context.Context, userID int32) (*apigen.Credenti
 	return s.signIn(ctx, userID)
 }
 
+func (s stubService) TryExecuteTask(ctx context.Context, orgID, taskID int32) error {
+	return s.tryExecuteTask(ctx, orgID, taskID)
+}
+
 var _ service.ServiceInterface = stubService{}
 
 type stubAuth struct {
@@ -103,6 +109,21 @@ func TestControllerSignIn(t *testing.T) {
 			serviceError:   service.ErrInvalidPassword,
 			expectedStatus: fiber.StatusUnauthorized,
 		},
+		{
+			name:           "totp required",
+			serviceError:   service.ErrTOTPRequired,
+			expectedStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:           "totp throttled",
+			serviceError:   service.ErrTOTPThrottled,
+			expectedStatus: fiber.StatusTooManyRequests,
+		},
+		{
+			name:           "totp disabled",
+			serviceError:   service.ErrTOTPDisabled,
+			expectedStatus: fiber.StatusServiceUnavailable,
+		},
 		{
 			name: "success",
 			serviceResult: &apigen.Credentials{
@@ -280,3 +301,77 @@ func TestControllerSignUpDisabledByDefault(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "Cannot POST /api/v1/auth/sign-up", string(respBody))
 }
+
+func TestControllerTryExecuteTask(t *testing.T) {
+	testCases := []struct {
+		name           string
+		orgID          *int32
+		serviceError   error
+		expectedStatus int
+		expectService  bool
+	}{
+		{
+			name:           "missing org",
+			expectedStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:           "task not found",
+			orgID:          utils.Ptr(int32(1)),
+			serviceError:   errors.Wrap(service.ErrTaskNotFound, "task 7"),
+			expectedStatus: fiber.StatusNotFound,
+			expectService:  true,
+		},
+		{
+			name:           "task already running",
+			orgID:          utils.Ptr(int32(1)),
+			serviceError:   errors.Wrap(service.ErrTaskAlreadyRunning, "task 7"),
+			expectedStatus: fiber.StatusConflict,
+			expectService:  true,
+		},
+		{
+			name:           "task not pending",
+			orgID:          utils.Ptr(int32(1)),
+			serviceError:   errors.Wrap(service.ErrTaskNotPending, "task 7 is completed"),
+			expectedStatus: fiber.StatusConflict,
+			expectService:  true,
+		},
+		{
+			name:           "success",
+			orgID:          utils.Ptr(int32(1)),
+			expectedStatus: fiber.StatusOK,
+			expectService:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: utils.ErrorHandler})
+			called := false
+			controller := &Controller{
+				enableWorkerHTTPTrigger: true,
+				svc: stubService{
+					tryExecuteTask: func(ctx context.Context, orgID, taskID int32) error {
+						called = true
+						require.Equal(t, *tc.orgID, orgID)
+						require.Equal(t, int32(7), taskID)
+						return tc.serviceError
+					},
+				},
+			}
+			app.Post("/tasks/:taskID/try-execute", func(c fiber.Ctx) error {
+				if tc.orgID != nil {
+					c.Locals(anclaxauth.ContextKeyOrgID, *tc.orgID)
+				}
+				return controller.TryExecuteTask(c, 7)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/tasks/7/try-execute", nil)
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tc.expectedStatus, resp.StatusCode)
+			require.Equal(t, tc.expectService, called)
+		})
+	}
+}
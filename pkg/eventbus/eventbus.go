@@ -0,0 +1,223 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/logger"
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
+	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
+	"github.com/cloudcarver/anclax/pkg/zgen/querier"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var log = logger.NewLogAgent("eventbus")
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+
+	// defaultLagWindow is how long a gap in event IDs is waited for. IDs are taken when an
+	// event is inserted but become visible when its transaction commits, so a lower ID can
+	// show up after a higher one was read.
+	defaultLagWindow = time.Minute
+
+	// maxDeliveryAttempts bounds how often a failing handler is retried for one event.
+	maxDeliveryAttempts = 10
+)
+
+// Handler consumes a committed event. Returning an error makes the bus
+// redeliver the event to that handler on a later poll, so handlers must be idempotent.
+type Handler func(ctx context.Context, event apigen.Event) error
+
+// delivery tracks an event above the cursor.
+type delivery struct {
+	// seenAt is when the event was first read.
+	seenAt time.Time
+
+	// failed holds the indexes of the handlers that have yet to succeed, nil once all did.
+	failed   []int
+	attempts int
+}
+
+// EventBus relays events committed to anclax.events to in-process
+// subscribers. Delivery is at-least-once: events above the cursor are read again
+// on every poll, so an event committed after a higher ID was read is still
+// delivered, and a handler that failed is retried without blocking later events
+// or the other handlers.
+type EventBus struct {
+	model model.ModelInterface
+	now   func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[apigen.EventSpecType][]Handler
+
+	// cursor is the ID up to which every event has been delivered.
+	cursor      int32
+	initialized bool
+	deliveries  map[int32]*delivery
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewEventBus(model model.ModelInterface) *EventBus {
+	return &EventBus{
+		model:      model,
+		now:        time.Now,
+		handlers:   map[apigen.EventSpecType][]Handler{},
+		deliveries: map[int32]*delivery{},
+	}
+}
+
+// Subscribe registers handler for events of eventType. Only events committed
+// after the bus started are delivered.
+func (b *EventBus) Subscribe(eventType apigen.EventSpecType, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Start relays events in the background until ctx is done or Close is called.
+func (b *EventBus) Start(ctx context.Context) {
+	ctx, b.cancel = context.WithCancel(ctx)
+	b.done = make(chan struct{})
+	go b.loop(ctx)
+}
+
+// Close stops the relay and waits for an in-flight poll to finish.
+func (b *EventBus) Close(ctx context.Context) error {
+	if b.cancel == nil {
+		return nil
+	}
+	b.cancel()
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *EventBus) loop(ctx context.Context) {
+	defer close(b.done)
+	ticker := time.NewTicker(defaultPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.poll(ctx); err != nil && ctx.Err() == nil {
+				log.Error("failed to relay events", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (b *EventBus) poll(ctx context.Context) error {
+	if !b.initialized {
+		latestID, err := b.model.GetLatestEventID(ctx)
+		if err != nil {
+			return errors.Wrap(err, "get latest event id")
+		}
+		b.cursor = latestID
+		b.initialized = true
+		return nil
+	}
+
+	// events soft-deleted with their task were still committed, hiding them would leave gaps
+	// that hold the cursor back for the lag window
+	events, err := b.model.ListEventsAfterID(ctx, querier.ListEventsAfterIDParams{
+		AfterID:        b.cursor,
+		IncludeDeleted: true,
+		MaxCount:       defaultBatchSize,
+	})
+	if err != nil {
+		return errors.Wrap(err, "list events after id")
+	}
+
+	for _, event := range events {
+		d, ok := b.deliveries[event.ID]
+		if !ok {
+			d = &delivery{seenAt: b.now()}
+			b.deliveries[event.ID] = d
+		} else if d.done() {
+			continue
+		}
+		b.deliver(ctx, event, d)
+	}
+	b.advanceCursor()
+	return nil
+}
+
+// deliver runs the handlers of the event that have not succeeded yet.
+func (b *EventBus) deliver(ctx context.Context, event *querier.AnclaxEvent, d *delivery) {
+	b.mu.RLock()
+	handlers := append([]Handler(nil), b.handlers[event.Spec.Type]...)
+	b.mu.RUnlock()
+
+	pending := d.failed
+	if d.attempts == 0 {
+		pending = make([]int, len(handlers))
+		for i := range handlers {
+			pending[i] = i
+		}
+	}
+
+	apiEvent := apigen.Event{
+		ID:        event.ID,
+		CreatedAt: event.CreatedAt,
+		Spec:      event.Spec,
+	}
+	var failed []int
+	for _, i := range pending {
+		if err := handlers[i](ctx, apiEvent); err != nil {
+			log.Warn("event handler failed", zap.Int32("event-id", event.ID), zap.Int("handler", i), zap.Error(err))
+			failed = append(failed, i)
+		}
+	}
+
+	d.attempts++
+	if len(failed) > 0 && d.attempts >= maxDeliveryAttempts {
+		log.Error("giving up delivering event", zap.Int32("event-id", event.ID), zap.Ints("handlers", failed), zap.Int("attempts", d.attempts))
+		failed = nil
+	}
+	d.failed = failed
+}
+
+func (d *delivery) done() bool {
+	return d.attempts > 0 && len(d.failed) == 0
+}
+
+// advanceCursor moves the cursor over the delivered events that directly follow it. A gap
+// is skipped once the event after it has been seen for longer than the lag window, by
+// then the transaction that took the missing ID has either committed or rolled back.
+func (b *EventBus) advanceCursor() {
+	horizon := b.now().Add(-defaultLagWindow)
+	for len(b.deliveries) > 0 {
+		next := b.cursor + 1
+		if d, ok := b.deliveries[next]; ok {
+			if !d.done() {
+				return
+			}
+			delete(b.deliveries, next)
+			b.cursor = next
+			continue
+		}
+
+		first := int32(0)
+		for id := range b.deliveries {
+			if first == 0 || id < first {
+				first = id
+			}
+		}
+		if b.deliveries[first].seenAt.After(horizon) {
+			return
+		}
+		b.cursor = first - 1
+	}
+}
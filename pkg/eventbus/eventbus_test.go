@@ -0,0 +1,239 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
+	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
+	"github.com/cloudcarver/anclax/pkg/zgen/querier"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func listAfter(id int32) querier.ListEventsAfterIDParams {
+	return querier.ListEventsAfterIDParams{AfterID: id, IncludeDeleted: true, MaxCount: defaultBatchSize}
+}
+
+func completedEvent(id int32, taskID int32) *querier.AnclaxEvent {
+	return &querier.AnclaxEvent{
+		ID:        id,
+		CreatedAt: time.Unix(int64(id), 0),
+		Spec: apigen.EventSpec{
+			Type:          apigen.EventSpecTypeTaskCompleted,
+			TaskCompleted: &apigen.EventTaskCompleted{TaskID: taskID},
+		},
+	}
+}
+
+func errorEvent(id int32, taskID int32) *querier.AnclaxEvent {
+	return &querier.AnclaxEvent{
+		ID:        id,
+		CreatedAt: time.Unix(int64(id), 0),
+		Spec: apigen.EventSpec{
+			Type:      apigen.EventSpecTypeTaskError,
+			TaskError: &apigen.EventTaskError{TaskID: taskID, Error: "boom"},
+		},
+	}
+}
+
+func TestSubscriberReceivesOnlySubscribedType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetLatestEventID(ctx).Return(int32(10), nil)
+	mockModel.EXPECT().ListEventsAfterID(ctx, listAfter(10)).Return([]*querier.AnclaxEvent{
+		completedEvent(11, 1),
+		errorEvent(12, 2),
+		completedEvent(13, 3),
+	}, nil)
+	mockModel.EXPECT().ListEventsAfterID(ctx, listAfter(13)).Return(nil, nil)
+
+	b := NewEventBus(mockModel)
+
+	var received []apigen.Event
+	b.Subscribe(apigen.EventSpecTypeTaskCompleted, func(ctx context.Context, event apigen.Event) error {
+		received = append(received, event)
+		return nil
+	})
+
+	require.NoError(t, b.poll(ctx))
+	require.NoError(t, b.poll(ctx))
+	require.NoError(t, b.poll(ctx))
+
+	require.Len(t, received, 2)
+	require.Equal(t, int32(11), received[0].ID)
+	require.Equal(t, int32(1), received[0].Spec.TaskCompleted.TaskID)
+	require.Equal(t, int32(13), received[1].ID)
+	require.Equal(t, int32(3), received[1].Spec.TaskCompleted.TaskID)
+}
+
+func TestSubscriberFailureRedeliversEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetLatestEventID(ctx).Return(int32(0), nil)
+	gomock.InOrder(
+		mockModel.EXPECT().ListEventsAfterID(ctx, listAfter(0)).Return([]*querier.AnclaxEvent{
+			errorEvent(1, 1),
+			errorEvent(2, 2),
+		}, nil),
+		mockModel.EXPECT().ListEventsAfterID(ctx, listAfter(1)).Return([]*querier.AnclaxEvent{
+			errorEvent(2, 2),
+		}, nil),
+	)
+
+	b := NewEventBus(mockModel)
+
+	var deliveries []int32
+	failed := false
+	b.Subscribe(apigen.EventSpecTypeTaskError, func(ctx context.Context, event apigen.Event) error {
+		deliveries = append(deliveries, event.ID)
+		if event.ID == 2 && !failed {
+			failed = true
+			return errors.New("transient")
+		}
+		return nil
+	})
+
+	require.NoError(t, b.poll(ctx))
+	require.NoError(t, b.poll(ctx))
+	require.Equal(t, int32(1), b.cursor)
+	require.NoError(t, b.poll(ctx))
+
+	require.Equal(t, []int32{1, 2, 2}, deliveries)
+	require.Equal(t, int32(2), b.cursor)
+}
+
+func TestSubscriberFailureDoesNotBlockOtherHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetLatestEventID(ctx).Return(int32(0), nil)
+	gomock.InOrder(
+		mockModel.EXPECT().ListEventsAfterID(ctx, listAfter(0)).Return([]*querier.AnclaxEvent{
+			errorEvent(1, 1),
+			errorEvent(2, 2),
+		}, nil),
+		mockModel.EXPECT().ListEventsAfterID(ctx, listAfter(0)).Return([]*querier.AnclaxEvent{
+			errorEvent(1, 1),
+			errorEvent(2, 2),
+		}, nil),
+	)
+
+	b := NewEventBus(mockModel)
+
+	var failing, healthy []int32
+	b.Subscribe(apigen.EventSpecTypeTaskError, func(ctx context.Context, event apigen.Event) error {
+		failing = append(failing, event.ID)
+		if event.ID == 1 && len(failing) == 1 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	b.Subscribe(apigen.EventSpecTypeTaskError, func(ctx context.Context, event apigen.Event) error {
+		healthy = append(healthy, event.ID)
+		return nil
+	})
+
+	require.NoError(t, b.poll(ctx))
+	require.NoError(t, b.poll(ctx))
+	require.NoError(t, b.poll(ctx))
+
+	require.Equal(t, []int32{1, 2, 1}, failing)
+	require.Equal(t, []int32{1, 2}, healthy)
+	require.Equal(t, int32(2), b.cursor)
+}
+
+func TestLateCommittedEventIsDelivered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetLatestEventID(ctx).Return(int32(0), nil)
+	gomock.InOrder(
+		// event 2 is not committed yet when event 3 is read
+		mockModel.EXPECT().ListEventsAfterID(ctx, listAfter(0)).Return([]*querier.AnclaxEvent{
+			completedEvent(1, 1),
+			completedEvent(3, 3),
+		}, nil),
+		mockModel.EXPECT().ListEventsAfterID(ctx, listAfter(1)).Return([]*querier.AnclaxEvent{
+			completedEvent(2, 2),
+			completedEvent(3, 3),
+		}, nil),
+	)
+
+	b := NewEventBus(mockModel)
+
+	var received []int32
+	b.Subscribe(apigen.EventSpecTypeTaskCompleted, func(ctx context.Context, event apigen.Event) error {
+		received = append(received, event.ID)
+		return nil
+	})
+
+	require.NoError(t, b.poll(ctx))
+	require.NoError(t, b.poll(ctx))
+	require.Equal(t, int32(1), b.cursor)
+	require.NoError(t, b.poll(ctx))
+
+	require.Equal(t, []int32{1, 3, 2}, received)
+	require.Equal(t, int32(3), b.cursor)
+}
+
+func TestGapIsSkippedAfterLagWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetLatestEventID(ctx).Return(int32(0), nil)
+	mockModel.EXPECT().ListEventsAfterID(ctx, listAfter(0)).Return([]*querier.AnclaxEvent{
+		completedEvent(2, 2),
+	}, nil).Times(2)
+
+	b := NewEventBus(mockModel)
+	now := time.Unix(0, 0)
+	b.now = func() time.Time { return now }
+
+	var received []int32
+	b.Subscribe(apigen.EventSpecTypeTaskCompleted, func(ctx context.Context, event apigen.Event) error {
+		received = append(received, event.ID)
+		return nil
+	})
+
+	require.NoError(t, b.poll(ctx))
+	require.NoError(t, b.poll(ctx))
+	require.Equal(t, int32(0), b.cursor)
+
+	// the transaction of event 1 rolled back, so the gap is never filled
+	now = now.Add(defaultLagWindow + time.Second)
+	require.NoError(t, b.poll(ctx))
+
+	require.Equal(t, []int32{2}, received)
+	require.Equal(t, int32(2), b.cursor)
+	require.Empty(t, b.deliveries)
+}
+
+func TestCloseStopsLoop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetLatestEventID(gomock.Any()).Return(int32(0), nil).AnyTimes()
+	mockModel.EXPECT().ListEventsAfterID(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	b := NewEventBus(mockModel)
+	b.Start(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, b.Close(ctx))
+}
@@ -5,6 +5,13 @@ import (
 
 	"github.com/cloudcarver/anclax/core"
 	"github.com/cloudcarver/anclax/pkg/macaroons"
+	"github.com/cloudcarver/anclax/pkg/zgen/taskgen"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrHookNotFound          = errors.New("hook not found")
+	ErrHookAlreadyRegistered = errors.New("hook is already registered")
 )
 
 type (
@@ -13,6 +20,10 @@ type (
 	OnCreateToken func(ctx context.Context, userID int32, macaroon *macaroons.Macaroon) error
 
 	OnUserCreated func(ctx context.Context, tx core.Tx, userID int32) error
+
+	OnSignIn func(ctx context.Context, userID int32, orgID int32) error
+
+	OnUserCreatedAsync func(ctx context.Context, userID int32) error
 )
 
 // There are two types of hooks:
@@ -25,6 +36,8 @@ type AnclaxHookInterface interface {
 
 	OnUserCreated(ctx context.Context, tx core.Tx, userID int32) error
 
+	OnSignIn(ctx context.Context, userID int32, orgID int32) error
+
 	// RegisterOnOrgCreatedHook registers a hook function that is executed after an organization is created.
 	RegisterOnOrgCreated(hook OnOrgCreated)
 
@@ -33,18 +46,43 @@ type AnclaxHookInterface interface {
 	RegisterOnCreateToken(hook OnCreateToken)
 
 	RegisterOnUserCreated(hook OnUserCreated)
+
+	// RegisterOnSignIn registers a hook function that is executed after a user signed in and the
+	// tokens were issued. An error fails the sign-in.
+	RegisterOnSignIn(hook OnSignIn)
+
+	// RegisterOnUserCreatedAsync registers a hook function that is executed by a worker after a user is created.
+	// The task running the hook is pushed in the transaction creating the user and is retried on failure, so
+	// the hook must be idempotent. The name identifies the hook in the task, it must be unique and stay the same
+	// across releases. Registering a name twice returns ErrHookAlreadyRegistered.
+	RegisterOnUserCreatedAsync(name string, hook OnUserCreatedAsync) error
+
+	// RunOnUserCreatedAsync runs the async hook registered with name, it is called by the worker.
+	RunOnUserCreatedAsync(ctx context.Context, name string, userID int32) error
 }
 
 type BaseHook struct {
 	OnOrgCreatedHooks  []OnOrgCreated
 	OnCreateTokenHooks []OnCreateToken
 	OnUserCreatedHooks []OnUserCreated
+	OnSignInHooks      []OnSignIn
+
+	taskRunner              taskgen.TaskRunner
+	onUserCreatedAsyncNames []string
+	onUserCreatedAsyncHooks map[string]OnUserCreatedAsync
 }
 
 func NewBaseHook() AnclaxHookInterface {
 	return &BaseHook{}
 }
 
+// NewBaseHookWithTaskRunner returns a BaseHook that pushes the async hooks with taskRunner.
+func NewBaseHookWithTaskRunner(taskRunner taskgen.TaskRunner) AnclaxHookInterface {
+	return &BaseHook{
+		taskRunner: taskRunner,
+	}
+}
+
 func (b *BaseHook) RegisterOnOrgCreated(hook OnOrgCreated) {
 	b.OnOrgCreatedHooks = append(b.OnOrgCreatedHooks, hook)
 }
@@ -81,5 +119,49 @@ func (b *BaseHook) OnUserCreated(ctx context.Context, tx core.Tx, userID int32)
 			return err
 		}
 	}
+	if len(b.onUserCreatedAsyncNames) > 0 && b.taskRunner == nil {
+		return errors.New("async user created hooks are registered but there is no task runner")
+	}
+	for _, name := range b.onUserCreatedAsyncNames {
+		if _, err := b.taskRunner.RunAsyncUserCreatedHookWithTx(ctx, tx, &taskgen.AsyncUserCreatedHookParameters{
+			Hook:   name,
+			UserID: userID,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to push async user created hook %s", name)
+		}
+	}
+	return nil
+}
+
+func (b *BaseHook) RegisterOnUserCreatedAsync(name string, hook OnUserCreatedAsync) error {
+	if _, ok := b.onUserCreatedAsyncHooks[name]; ok {
+		return errors.Wrapf(ErrHookAlreadyRegistered, "async user created hook %s", name)
+	}
+	if b.onUserCreatedAsyncHooks == nil {
+		b.onUserCreatedAsyncHooks = map[string]OnUserCreatedAsync{}
+	}
+	b.onUserCreatedAsyncNames = append(b.onUserCreatedAsyncNames, name)
+	b.onUserCreatedAsyncHooks[name] = hook
+	return nil
+}
+
+func (b *BaseHook) RunOnUserCreatedAsync(ctx context.Context, name string, userID int32) error {
+	hook, ok := b.onUserCreatedAsyncHooks[name]
+	if !ok {
+		return errors.Wrapf(ErrHookNotFound, "async user created hook %s", name)
+	}
+	return hook(ctx, userID)
+}
+
+func (b *BaseHook) RegisterOnSignIn(hook OnSignIn) {
+	b.OnSignInHooks = append(b.OnSignInHooks, hook)
+}
+
+func (b *BaseHook) OnSignIn(ctx context.Context, userID int32, orgID int32) error {
+	for _, hook := range b.OnSignInHooks {
+		if err := hook(ctx, userID, orgID); err != nil {
+			return err
+		}
+	}
 	return nil
 }
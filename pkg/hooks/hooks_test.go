@@ -0,0 +1,48 @@
+package hooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudcarver/anclax/core"
+	"github.com/cloudcarver/anclax/pkg/zgen/taskgen"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOnUserCreatedAsyncEnqueuesTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := int32(7)
+	mockTx := core.NewMockTx(ctrl)
+	mockRunner := taskgen.NewMockTaskRunner(ctrl)
+
+	var ran []int32
+	b := NewBaseHookWithTaskRunner(mockRunner)
+	require.NoError(t, b.RegisterOnUserCreatedAsync("welcome-email", func(ctx context.Context, userID int32) error {
+		ran = append(ran, userID)
+		return nil
+	}))
+
+	mockRunner.EXPECT().RunAsyncUserCreatedHookWithTx(ctx, mockTx, &taskgen.AsyncUserCreatedHookParameters{
+		Hook:   "welcome-email",
+		UserID: userID,
+	}).Return(int32(1), nil)
+
+	require.NoError(t, b.OnUserCreated(ctx, mockTx, userID))
+	require.Empty(t, ran)
+
+	require.NoError(t, b.RunOnUserCreatedAsync(ctx, "welcome-email", userID))
+	require.Equal(t, []int32{userID}, ran)
+
+	require.ErrorIs(t, b.RunOnUserCreatedAsync(ctx, "unknown", userID), ErrHookNotFound)
+}
+
+func TestRegisterOnUserCreatedAsyncDuplicateName(t *testing.T) {
+	b := NewBaseHook()
+	hook := func(ctx context.Context, userID int32) error { return nil }
+	require.NoError(t, b.RegisterOnUserCreatedAsync("welcome-email", hook))
+	require.ErrorIs(t, b.RegisterOnUserCreatedAsync("welcome-email", hook), ErrHookAlreadyRegistered)
+}
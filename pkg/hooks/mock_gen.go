@@ -56,6 +56,20 @@ func (mr *MockAnclaxHookInterfaceMockRecorder) OnOrgCreated(ctx, tx, orgID any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnOrgCreated", reflect.TypeOf((*MockAnclaxHookInterface)(nil).OnOrgCreated), ctx, tx, orgID)
 }
 
+// OnSignIn mocks base method.
+func (m *MockAnclaxHookInterface) OnSignIn(ctx context.Context, userID, orgID int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OnSignIn", ctx, userID, orgID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// OnSignIn indicates an expected call of OnSignIn.
+func (mr *MockAnclaxHookInterfaceMockRecorder) OnSignIn(ctx, userID, orgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnSignIn", reflect.TypeOf((*MockAnclaxHookInterface)(nil).OnSignIn), ctx, userID, orgID)
+}
+
 // OnUserCreated mocks base method.
 func (m *MockAnclaxHookInterface) OnUserCreated(ctx context.Context, tx core.Tx, userID int32) error {
 	m.ctrl.T.Helper()
@@ -108,6 +122,18 @@ func (mr *MockAnclaxHookInterfaceMockRecorder) RegisterOnOrgCreated(hook any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterOnOrgCreated", reflect.TypeOf((*MockAnclaxHookInterface)(nil).RegisterOnOrgCreated), hook)
 }
 
+// RegisterOnSignIn mocks base method.
+func (m *MockAnclaxHookInterface) RegisterOnSignIn(hook OnSignIn) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RegisterOnSignIn", hook)
+}
+
+// RegisterOnSignIn indicates an expected call of RegisterOnSignIn.
+func (mr *MockAnclaxHookInterfaceMockRecorder) RegisterOnSignIn(hook any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterOnSignIn", reflect.TypeOf((*MockAnclaxHookInterface)(nil).RegisterOnSignIn), hook)
+}
+
 // RegisterOnUserCreated mocks base method.
 func (m *MockAnclaxHookInterface) RegisterOnUserCreated(hook OnUserCreated) {
 	m.ctrl.T.Helper()
@@ -119,3 +145,31 @@ func (mr *MockAnclaxHookInterfaceMockRecorder) RegisterOnUserCreated(hook any) *
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterOnUserCreated", reflect.TypeOf((*MockAnclaxHookInterface)(nil).RegisterOnUserCreated), hook)
 }
+
+// RegisterOnUserCreatedAsync mocks base method.
+func (m *MockAnclaxHookInterface) RegisterOnUserCreatedAsync(name string, hook OnUserCreatedAsync) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterOnUserCreatedAsync", name, hook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterOnUserCreatedAsync indicates an expected call of RegisterOnUserCreatedAsync.
+func (mr *MockAnclaxHookInterfaceMockRecorder) RegisterOnUserCreatedAsync(name, hook any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterOnUserCreatedAsync", reflect.TypeOf((*MockAnclaxHookInterface)(nil).RegisterOnUserCreatedAsync), name, hook)
+}
+
+// RunOnUserCreatedAsync mocks base method.
+func (m *MockAnclaxHookInterface) RunOnUserCreatedAsync(ctx context.Context, name string, userID int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunOnUserCreatedAsync", ctx, name, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunOnUserCreatedAsync indicates an expected call of RunOnUserCreatedAsync.
+func (mr *MockAnclaxHookInterfaceMockRecorder) RunOnUserCreatedAsync(ctx, name, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunOnUserCreatedAsync", reflect.TypeOf((*MockAnclaxHookInterface)(nil).RunOnUserCreatedAsync), ctx, name, userID)
+}
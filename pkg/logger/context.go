@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID, so that logs and
+// outgoing calls made on behalf of the request can be correlated.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored by WithRequestID, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	rid, _ := ctx.Value(requestIDKey{}).(string)
+	return rid
+}
+
+// WithContext returns a LogAgent whose logs also carry the request ID of ctx, if any.
+func (a *LogAgent) WithContext(ctx context.Context) *LogAgent {
+	rid := RequestIDFromContext(ctx)
+	if rid == "" {
+		return a
+	}
+	fields := make([]zap.Field, 0, len(a.fileds)+1)
+	fields = append(fields, a.fileds...)
+	return &LogAgent{name: a.name, fileds: append(fields, zap.String("request-id", rid))}
+}
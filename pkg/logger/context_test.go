@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/logger/loggertest"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWithContextAddsRequestID(t *testing.T) {
+	logs := loggertest.Observe(t, zap.InfoLevel)
+
+	agent := NewLogAgent("test")
+	ctx := WithRequestID(context.Background(), "rid-1")
+
+	agent.WithContext(ctx).Info("with request id")
+	agent.WithContext(context.Background()).Info("without request id")
+	agent.Info("plain")
+
+	entries := logs.All()
+	require.Len(t, entries, 3)
+	require.Equal(t, "rid-1", entries[0].ContextMap()["request-id"])
+	require.NotContains(t, entries[1].ContextMap(), "request-id")
+	require.NotContains(t, entries[2].ContextMap(), "request-id")
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	require.Equal(t, "", RequestIDFromContext(context.Background()))
+	require.Equal(t, "rid-1", RequestIDFromContext(WithRequestID(context.Background(), "rid-1")))
+}
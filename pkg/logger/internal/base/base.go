@@ -0,0 +1,13 @@
+// Package base holds the zap logger shared by all logger.LogAgents, so that loggertest
+// can replace it without the logger package exporting a setter.
+package base
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// Logger is swapped atomically since logger.Configure may run while other goroutines are
+// logging.
+var Logger atomic.Pointer[zap.Logger]
@@ -3,6 +3,7 @@ package logger
 import (
 	"fmt"
 
+	"github.com/cloudcarver/anclax/pkg/logger/internal/base"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -12,14 +13,57 @@ type LogAgent struct {
 	fileds []zap.Field
 }
 
-var log *zap.Logger
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// log is the logger shared by all LogAgents.
+var log = &base.Logger
 
 func init() {
 	logger, err := zap.NewProduction(zap.AddCaller(), zap.AddCallerSkip(1))
 	if err != nil {
 		panic(err)
 	}
-	log = logger
+	log.Store(logger)
+}
+
+// Configure replaces the logger shared by all LogAgents with one writing entries in the
+// given format from the given level on, empty values default to json and info. It is safe
+// to call while other goroutines are logging.
+func Configure(format, level string) error {
+	logCfg, err := newConfig(format, level)
+	if err != nil {
+		return err
+	}
+	logger, err := logCfg.Build(zap.AddCaller(), zap.AddCallerSkip(1))
+	if err != nil {
+		return fmt.Errorf("failed to build logger: %w", err)
+	}
+	log.Store(logger)
+	return nil
+}
+
+func newConfig(format, level string) (zap.Config, error) {
+	logCfg := zap.NewProductionConfig()
+	switch format {
+	case "", FormatJSON:
+	case FormatConsole:
+		logCfg.Encoding = FormatConsole
+		logCfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		logCfg.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	default:
+		return logCfg, fmt.Errorf("unknown log format %q, must be %q or %q", format, FormatJSON, FormatConsole)
+	}
+	if level != "" {
+		lvl, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return logCfg, fmt.Errorf("invalid log level: %w", err)
+		}
+		logCfg.Level = zap.NewAtomicLevelAt(lvl)
+	}
+	return logCfg, nil
 }
 
 func NewLogAgent(name string) *LogAgent {
@@ -33,35 +77,35 @@ func (a *LogAgent) AppendFiled(field zap.Field) *LogAgent {
 
 // provide basic observability
 func (a *LogAgent) Info(msg string, fields ...zapcore.Field) {
-	log.Info(msg, append(a.fileds, fields...)...)
+	log.Load().Info(msg, append(a.fileds, fields...)...)
 }
 
 // expected situation but worth a look
 func (a *LogAgent) Warn(msg string, fields ...zapcore.Field) {
-	log.Warn(msg, append(a.fileds, fields...)...)
+	log.Load().Warn(msg, append(a.fileds, fields...)...)
 }
 
 // unexpected error causing broken connection
 func (a *LogAgent) Error(msg string, fields ...zapcore.Field) {
-	log.Error(msg, append(a.fileds, fields...)...)
+	log.Load().Error(msg, append(a.fileds, fields...)...)
 }
 
 // fatal error causing application shutdown
 func (a *LogAgent) Fatal(msg string, fields ...zapcore.Field) {
-	log.Fatal(msg, append(a.fileds, fields...)...)
+	log.Load().Fatal(msg, append(a.fileds, fields...)...)
 }
 
 // provide basic observability
 func (a *LogAgent) Infof(msg string, args ...any) {
-	log.Info(fmt.Sprintf(msg, args...), a.fileds...)
+	log.Load().Info(fmt.Sprintf(msg, args...), a.fileds...)
 }
 
 // expected situation but worth a look
 func (a *LogAgent) Warnf(msg string, args ...any) {
-	log.Warn(fmt.Sprintf(msg, args...), a.fileds...)
+	log.Load().Warn(fmt.Sprintf(msg, args...), a.fileds...)
 }
 
 // unexpected error causing broken connection
 func (a *LogAgent) Errorf(msg string, args ...any) {
-	log.Error(fmt.Sprintf(msg, args...), a.fileds...)
+	log.Load().Error(fmt.Sprintf(msg, args...), a.fileds...)
 }
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/logger/loggertest"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewConfigDefaults(t *testing.T) {
+	cfg, err := newConfig("", "")
+	require.NoError(t, err)
+	require.Equal(t, FormatJSON, cfg.Encoding)
+	require.Equal(t, zapcore.InfoLevel, cfg.Level.Level())
+}
+
+func TestNewConfigFormatAndLevel(t *testing.T) {
+	cfg, err := newConfig(FormatConsole, "debug")
+	require.NoError(t, err)
+	require.Equal(t, FormatConsole, cfg.Encoding)
+	require.Equal(t, zapcore.DebugLevel, cfg.Level.Level())
+
+	cfg, err = newConfig(FormatJSON, "warn")
+	require.NoError(t, err)
+	require.Equal(t, FormatJSON, cfg.Encoding)
+	require.Equal(t, zapcore.WarnLevel, cfg.Level.Level())
+}
+
+func TestNewConfigRejectsInvalidValues(t *testing.T) {
+	_, err := newConfig("xml", "")
+	require.ErrorContains(t, err, "unknown log format")
+
+	_, err = newConfig("", "loud")
+	require.ErrorContains(t, err, "invalid log level")
+}
+
+func TestConfigureReplacesLogger(t *testing.T) {
+	loggertest.Replace(t, zap.NewNop())
+
+	require.NoError(t, Configure(FormatConsole, "error"))
+	require.False(t, log.Load().Core().Enabled(zapcore.WarnLevel))
+	require.True(t, log.Load().Core().Enabled(zapcore.ErrorLevel))
+
+	require.Error(t, Configure("xml", ""))
+}
+
+func TestConfigureWhileLogging(t *testing.T) {
+	loggertest.Replace(t, zap.NewNop())
+
+	agent := NewLogAgent("test")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				agent.Info("concurrent")
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, Configure(FormatJSON, "error"))
+	}
+	wg.Wait()
+}
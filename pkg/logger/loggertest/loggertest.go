@@ -0,0 +1,27 @@
+// Package loggertest captures the logs written through logger.LogAgents in tests.
+package loggertest
+
+import (
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/logger/internal/base"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Observe replaces the logger shared by all LogAgents with one recording the entries from
+// level on, until the test ends.
+func Observe(t testing.TB, level zapcore.Level) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(level)
+	Replace(t, zap.New(core))
+	return logs
+}
+
+// Replace makes all LogAgents write to l until the test ends.
+func Replace(t testing.TB, l *zap.Logger) {
+	t.Helper()
+	prev := base.Logger.Swap(l)
+	t.Cleanup(func() { base.Logger.Store(prev) })
+}
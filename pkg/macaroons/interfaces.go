@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/cloudcarver/anclax/pkg/macaroons/store"
 	"github.com/gofiber/fiber/v3"
 )
 
@@ -21,13 +22,28 @@ type Caveat interface {
 	Validate(fiber.Ctx) error
 }
 
+// RequestFreeCaveat is a caveat whose check does not depend on the request, so it can
+// also be validated where there is no fiber context, e.g. when parsing a refresh token.
+type RequestFreeCaveat interface {
+	Caveat
+
+	ValidateWithoutRequest() error
+}
+
 type MacaroonManagerInterface interface {
 	CreateToken(ctx context.Context, caveats []Caveat, ttl time.Duration, group string) (*Macaroon, error)
 
+	// CreateSessionToken creates a token like CreateToken whose key belongs to the session
+	// of the token with key sessionKeyID, so both are listed and revoked together.
+	CreateSessionToken(ctx context.Context, caveats []Caveat, ttl time.Duration, group string, sessionKeyID int64) (*Macaroon, error)
+
 	Parse(ctx context.Context, token string) (*Macaroon, error)
 
 	// InvalidateTokensByGroup invalidates all tokens in the given group.
 	InvalidateTokensByGroup(ctx context.Context, group string) error
 
 	InvalidateToken(ctx context.Context, keyID int64) error
+
+	// ListTokensByGroup returns the keys of the unexpired tokens in the given group.
+	ListTokensByGroup(ctx context.Context, group string) ([]store.KeyInfo, error)
 }
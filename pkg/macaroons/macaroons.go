@@ -23,6 +23,7 @@ type Macaroon struct {
 	Caveats []Caveat `json:"caveats"`
 
 	keyID             int64
+	group             string
 	signature         []byte
 	encodedToken      string
 	encodedTokenNoSig string
@@ -36,6 +37,12 @@ func (m *Macaroon) KeyID() int64 {
 	return m.keyID
 }
 
+// Group returns the group the key of the token was created in. Unlike the caveats, it is
+// chosen by the issuer and cannot be changed by the token holder.
+func (m *Macaroon) Group() string {
+	return m.group
+}
+
 func (m *Macaroon) AddCaveat(caveat Caveat) error {
 	// encode caveat
 	encodedCaveat, err := EncodeCaveat(caveat)
@@ -82,10 +89,28 @@ func (m *MacaroonsManager) CreateToken(ctx context.Context, caveats []Caveat, tt
 		return nil, errors.Wrap(err, "failed to get key")
 	}
 
-	return CreateMacaroon(keyID, key, caveats)
+	return CreateMacaroonInGroup(keyID, key, group, caveats)
+}
+
+func (m *MacaroonsManager) CreateSessionToken(ctx context.Context, caveats []Caveat, ttl time.Duration, group string, sessionKeyID int64) (*Macaroon, error) {
+	key, err := m.randomKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate random key")
+	}
+	keyID, err := m.keyStore.CreateInSession(ctx, key, ttl, group, sessionKeyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get key")
+	}
+
+	return CreateMacaroonInGroup(keyID, key, group, caveats)
 }
 
 func CreateMacaroon(keyID int64, key []byte, caveats []Caveat) (*Macaroon, error) {
+	return CreateMacaroonInGroup(keyID, key, "", caveats)
+}
+
+// CreateMacaroonInGroup creates a macaroon like CreateMacaroon for a key of the given group.
+func CreateMacaroonInGroup(keyID int64, key []byte, group string, caveats []Caveat) (*Macaroon, error) {
 	encodedKeyID := base64.StdEncoding.EncodeToString([]byte(strconv.FormatInt(keyID, 10)))
 	token := encodedKeyID
 
@@ -110,6 +135,7 @@ func CreateMacaroon(keyID int64, key []byte, caveats []Caveat) (*Macaroon, error
 
 	return &Macaroon{
 		keyID:             keyID,
+		group:             group,
 		Caveats:           caveats,
 		signature:         signature,
 		encodedTokenNoSig: encodedTokenNoSig,
@@ -135,7 +161,7 @@ func (m *MacaroonsManager) Parse(ctx context.Context, token string) (*Macaroon,
 	if err != nil {
 		return nil, errors.Wrap(ErrMalformedToken, "failed to convert keyID to int")
 	}
-	key, err := m.keyStore.Get(ctx, keyID)
+	key, group, err := m.keyStore.Get(ctx, keyID)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get key")
 	}
@@ -167,6 +193,7 @@ func (m *MacaroonsManager) Parse(ctx context.Context, token string) (*Macaroon,
 
 	return &Macaroon{
 		keyID:             keyID,
+		group:             group,
 		Caveats:           caveats,
 		signature:         signature,
 		encodedTokenNoSig: strings.TrimSuffix(token, "."+encodedSignature),
@@ -194,6 +221,14 @@ func (m *MacaroonsManager) InvalidateToken(ctx context.Context, keyID int64) err
 	return nil
 }
 
+func (m *MacaroonsManager) ListTokensByGroup(ctx context.Context, group string) ([]store.KeyInfo, error) {
+	keys, err := m.keyStore.ListGroupKeys(ctx, group)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list group keys")
+	}
+	return keys, nil
+}
+
 func chainedHmac(key []byte, encodedKeyID string, encodedCaveats []string) ([]byte, error) {
 	parts := make([]string, len(encodedCaveats)+1)
 	parts[0] = encodedKeyID
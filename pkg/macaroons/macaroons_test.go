@@ -2,10 +2,13 @@ package macaroons
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/cloudcarver/anclax/pkg/macaroons/store"
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
+	"github.com/cloudcarver/anclax/pkg/zgen/querier"
 	"github.com/gofiber/fiber/v3"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -42,7 +45,7 @@ func TestMacaroonManager_CreateMacaroon(t *testing.T) {
 	)
 
 	keyStore.EXPECT().Create(gomock.Any(), []byte("key"), ttl, group).Return(keyID, nil)
-	keyStore.EXPECT().Get(gomock.Any(), keyID).Return([]byte("key"), nil).Times(2)
+	keyStore.EXPECT().Get(gomock.Any(), keyID).Return([]byte("key"), group, nil).Times(2)
 
 	encodedCaveat1, err := EncodeCaveat(caveats[0])
 	require.NoError(t, err)
@@ -64,6 +67,7 @@ func TestMacaroonManager_CreateMacaroon(t *testing.T) {
 	parsed, err := manager.Parse(context.Background(), macaroon.StringToken())
 	require.NoError(t, err)
 	require.Equal(t, keyID, parsed.keyID)
+	require.Equal(t, group, parsed.Group())
 	require.Equal(t, caveats, parsed.Caveats)
 
 	macaroon.AddCaveat(&TestCaveat{Data: "caveat3"})
@@ -129,6 +133,57 @@ func TestInvalidateTokensByGroupDeletesGroupKeys(t *testing.T) {
 	}
 }
 
+func TestParseConcurrentlySharesKeyLookup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		keyID       = int64(9527)
+		parallelism = 50
+		release     = make(chan struct{})
+	)
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetOpaqueKey(gomock.Any(), keyID).DoAndReturn(func(ctx context.Context, id int64) (*querier.GetOpaqueKeyRow, error) {
+		<-release
+		return &querier.GetOpaqueKeyRow{Key: []byte("key")}, nil
+	}).Times(1)
+
+	keyStore := store.NewMockKeyStore(ctrl)
+	keyStore.EXPECT().Create(gomock.Any(), []byte("key"), time.Duration(0), "").Return(keyID, nil)
+	creator := &MacaroonsManager{
+		keyStore:  keyStore,
+		randomKey: func() ([]byte, error) { return []byte("key"), nil },
+	}
+	macaroon, err := creator.CreateToken(context.Background(), nil, 0, "")
+	require.NoError(t, err)
+
+	manager := &MacaroonsManager{
+		keyStore:     store.NewStore(mockModel, nil),
+		caveatParser: NewMockCaveatParserInterface(ctrl),
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, parallelism)
+	for range parallelism {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := manager.Parse(context.Background(), macaroon.StringToken())
+			errs <- err
+		}()
+	}
+	// give every goroutine the chance to join the in-flight lookup before it returns
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
+
 func TestChainedHmac(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
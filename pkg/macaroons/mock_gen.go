@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 	time "time"
 
+	store "github.com/cloudcarver/anclax/pkg/macaroons/store"
 	fiber "github.com/gofiber/fiber/v3"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -147,6 +148,21 @@ func (m *MockMacaroonManagerInterface) EXPECT() *MockMacaroonManagerInterfaceMoc
 	return m.recorder
 }
 
+// CreateSessionToken mocks base method.
+func (m *MockMacaroonManagerInterface) CreateSessionToken(ctx context.Context, caveats []Caveat, ttl time.Duration, group string, sessionKeyID int64) (*Macaroon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSessionToken", ctx, caveats, ttl, group, sessionKeyID)
+	ret0, _ := ret[0].(*Macaroon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSessionToken indicates an expected call of CreateSessionToken.
+func (mr *MockMacaroonManagerInterfaceMockRecorder) CreateSessionToken(ctx, caveats, ttl, group, sessionKeyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSessionToken", reflect.TypeOf((*MockMacaroonManagerInterface)(nil).CreateSessionToken), ctx, caveats, ttl, group, sessionKeyID)
+}
+
 // CreateToken mocks base method.
 func (m *MockMacaroonManagerInterface) CreateToken(ctx context.Context, caveats []Caveat, ttl time.Duration, group string) (*Macaroon, error) {
 	m.ctrl.T.Helper()
@@ -190,6 +206,21 @@ func (mr *MockMacaroonManagerInterfaceMockRecorder) InvalidateTokensByGroup(ctx,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateTokensByGroup", reflect.TypeOf((*MockMacaroonManagerInterface)(nil).InvalidateTokensByGroup), ctx, group)
 }
 
+// ListTokensByGroup mocks base method.
+func (m *MockMacaroonManagerInterface) ListTokensByGroup(ctx context.Context, group string) ([]store.KeyInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTokensByGroup", ctx, group)
+	ret0, _ := ret[0].([]store.KeyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTokensByGroup indicates an expected call of ListTokensByGroup.
+func (mr *MockMacaroonManagerInterfaceMockRecorder) ListTokensByGroup(ctx, group any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTokensByGroup", reflect.TypeOf((*MockMacaroonManagerInterface)(nil).ListTokensByGroup), ctx, group)
+}
+
 // Parse mocks base method.
 func (m *MockMacaroonManagerInterface) Parse(ctx context.Context, token string) (*Macaroon, error) {
 	m.ctrl.T.Helper()
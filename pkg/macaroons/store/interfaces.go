@@ -5,16 +5,40 @@ import (
 	"time"
 )
 
+// KeyInfo describes a stored key without its secret.
+type KeyInfo struct {
+	ID        int64
+	CreatedAt time.Time
+
+	// ExpiresAt is nil for keys without a TTL.
+	ExpiresAt *time.Time
+
+	// SessionID is the ID of the key the session started with, the key's own ID unless it
+	// was created by CreateInSession.
+	SessionID int64
+}
+
 type KeyStore interface {
 	// Create creates a new key and returns the keyID.
 	Create(ctx context.Context, key []byte, ttl time.Duration, group string) (int64, error)
 
-	// Get returns the key for the given keyID. returns ErrKeyNotFound if the key is not found.
-	Get(ctx context.Context, keyID int64) ([]byte, error)
+	// CreateInSession creates a key like Create that belongs to the session of the key
+	// sessionID, e.g. a refresh key to the session of its access key.
+	CreateInSession(ctx context.Context, key []byte, ttl time.Duration, group string, sessionID int64) (int64, error)
+
+	// Get returns the key for the given keyID and the group it was created in. returns
+	// ErrKeyNotFound if the key is not found.
+	Get(ctx context.Context, keyID int64) (key []byte, group string, err error)
 
 	// Delete deletes the key for the given keyID. returns ErrKeyNotFound if the key is not found.
 	Delete(ctx context.Context, keyID int64) error
 
 	// DeleteGroupKeys deletes all keys for the given group.
 	DeleteGroupKeys(ctx context.Context, group string) error
+
+	// ListGroupKeys returns the unexpired keys of the given group, newest first.
+	ListGroupKeys(ctx context.Context, group string) ([]KeyInfo, error)
+
+	// DeleteExpired deletes all keys whose TTL has passed and returns how many were deleted.
+	DeleteExpired(ctx context.Context) (int64, error)
 }
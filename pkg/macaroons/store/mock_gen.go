@@ -56,6 +56,21 @@ func (mr *MockKeyStoreMockRecorder) Create(ctx, key, ttl, group any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockKeyStore)(nil).Create), ctx, key, ttl, group)
 }
 
+// CreateInSession mocks base method.
+func (m *MockKeyStore) CreateInSession(ctx context.Context, key []byte, ttl time.Duration, group string, sessionID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInSession", ctx, key, ttl, group, sessionID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInSession indicates an expected call of CreateInSession.
+func (mr *MockKeyStoreMockRecorder) CreateInSession(ctx, key, ttl, group, sessionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInSession", reflect.TypeOf((*MockKeyStore)(nil).CreateInSession), ctx, key, ttl, group, sessionID)
+}
+
 // Delete mocks base method.
 func (m *MockKeyStore) Delete(ctx context.Context, keyID int64) error {
 	m.ctrl.T.Helper()
@@ -70,6 +85,21 @@ func (mr *MockKeyStoreMockRecorder) Delete(ctx, keyID any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockKeyStore)(nil).Delete), ctx, keyID)
 }
 
+// DeleteExpired mocks base method.
+func (m *MockKeyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockKeyStoreMockRecorder) DeleteExpired(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockKeyStore)(nil).DeleteExpired), ctx)
+}
+
 // DeleteGroupKeys mocks base method.
 func (m *MockKeyStore) DeleteGroupKeys(ctx context.Context, group string) error {
 	m.ctrl.T.Helper()
@@ -85,12 +115,13 @@ func (mr *MockKeyStoreMockRecorder) DeleteGroupKeys(ctx, group any) *gomock.Call
 }
 
 // Get mocks base method.
-func (m *MockKeyStore) Get(ctx context.Context, keyID int64) ([]byte, error) {
+func (m *MockKeyStore) Get(ctx context.Context, keyID int64) ([]byte, string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Get", ctx, keyID)
 	ret0, _ := ret[0].([]byte)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // Get indicates an expected call of Get.
@@ -98,3 +129,18 @@ func (mr *MockKeyStoreMockRecorder) Get(ctx, keyID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockKeyStore)(nil).Get), ctx, keyID)
 }
+
+// ListGroupKeys mocks base method.
+func (m *MockKeyStore) ListGroupKeys(ctx context.Context, group string) ([]KeyInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroupKeys", ctx, group)
+	ret0, _ := ret[0].([]KeyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroupKeys indicates an expected call of ListGroupKeys.
+func (mr *MockKeyStoreMockRecorder) ListGroupKeys(ctx, group any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroupKeys", reflect.TypeOf((*MockKeyStore)(nil).ListGroupKeys), ctx, group)
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/cloudcarver/anclax/core"
@@ -11,6 +12,7 @@ import (
 	runner "github.com/cloudcarver/anclax/pkg/zgen/taskgen"
 	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
@@ -21,6 +23,10 @@ type Store struct {
 	model      model.ModelInterface
 	taskRunner runner.TaskRunner
 	now        func() time.Time
+
+	// gets coalesces concurrent lookups of the same key, so a burst of requests carrying
+	// the same token runs one query.
+	gets singleflight.Group
 }
 
 func NewStore(model model.ModelInterface, taskRunner runner.TaskRunner) KeyStore {
@@ -32,15 +38,30 @@ func NewStore(model model.ModelInterface, taskRunner runner.TaskRunner) KeyStore
 }
 
 func (s *Store) Create(ctx context.Context, key []byte, ttl time.Duration, group string) (int64, error) {
+	return s.create(ctx, key, ttl, group, nil)
+}
+
+func (s *Store) CreateInSession(ctx context.Context, key []byte, ttl time.Duration, group string, sessionID int64) (int64, error) {
+	return s.create(ctx, key, ttl, group, &sessionID)
+}
+
+func (s *Store) create(ctx context.Context, key []byte, ttl time.Duration, group string, sessionID *int64) (int64, error) {
 	var ret int64
 	if err := s.model.RunTransactionWithTx(ctx, func(tx core.Tx, txm model.ModelInterface) error {
 		var groupPtr *string
 		if group != "" {
 			groupPtr = &group
 		}
+		var expiresAt *time.Time
+		if ttl > 0 {
+			t := s.now().Add(ttl)
+			expiresAt = &t
+		}
 		keyID, err := txm.CreateOpaqueKey(ctx, querier.CreateOpaqueKeyParams{
-			Group: groupPtr,
-			Key:   key,
+			Group:     groupPtr,
+			Key:       key,
+			ExpiresAt: expiresAt,
+			SessionID: sessionID,
 		})
 		if err != nil {
 			return errors.Wrap(err, "failed to create key")
@@ -51,7 +72,7 @@ func (s *Store) Create(ctx context.Context, key []byte, ttl time.Duration, group
 		if ttl > 0 {
 			if _, err := s.taskRunner.RunDeleteOpaqueKeyWithTx(ctx, tx, &runner.DeleteOpaqueKeyParameters{
 				KeyID: keyID,
-			}, taskcore.WithStartedAt(s.now().Add(ttl))); err != nil {
+			}, taskcore.WithStartedAt(*expiresAt)); err != nil {
 				return errors.Wrap(err, "failed to run task to delete key")
 			}
 		}
@@ -62,8 +83,30 @@ func (s *Store) Create(ctx context.Context, key []byte, ttl time.Duration, group
 	return ret, nil
 }
 
-func (s *Store) Get(ctx context.Context, keyID int64) ([]byte, error) {
-	key, err := s.model.GetOpaqueKey(ctx, keyID)
+func (s *Store) Get(ctx context.Context, keyID int64) ([]byte, string, error) {
+	// the shared lookup must not fail because the caller that started it went away,
+	// each caller still stops waiting when its own context is done
+	ch := s.gets.DoChan(strconv.FormatInt(keyID, 10), func() (any, error) {
+		return s.get(context.WithoutCancel(ctx), keyID)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, "", res.Err
+		}
+		row := res.Val.(*querier.GetOpaqueKeyRow)
+		var group string
+		if row.Group != nil {
+			group = *row.Group
+		}
+		return row.Key, group, nil
+	}
+}
+
+func (s *Store) get(ctx context.Context, keyID int64) (*querier.GetOpaqueKeyRow, error) {
+	row, err := s.model.GetOpaqueKey(ctx, keyID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrKeyNotFound
@@ -71,7 +114,7 @@ func (s *Store) Get(ctx context.Context, keyID int64) ([]byte, error) {
 		return nil, errors.Wrap(err, "failed to get key")
 	}
 
-	return key, nil
+	return row, nil
 }
 
 func (s *Store) Delete(ctx context.Context, keyID int64) error {
@@ -98,3 +141,38 @@ func (s *Store) DeleteGroupKeys(ctx context.Context, group string) error {
 	}
 	return nil
 }
+
+func (s *Store) DeleteExpired(ctx context.Context) (int64, error) {
+	deleted, err := s.model.DeleteExpiredOpaqueKeys(ctx, s.now())
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to delete expired keys")
+	}
+	return deleted, nil
+}
+
+func (s *Store) ListGroupKeys(ctx context.Context, group string) ([]KeyInfo, error) {
+	if group == "" {
+		return nil, nil
+	}
+	rows, err := s.model.ListActiveOpaqueKeysByGroup(ctx, querier.ListActiveOpaqueKeysByGroupParams{
+		Group: &group,
+		Now:   s.now(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list group keys")
+	}
+	keys := make([]KeyInfo, 0, len(rows))
+	for _, row := range rows {
+		sessionID := row.ID
+		if row.SessionID != nil {
+			sessionID = *row.SessionID
+		}
+		keys = append(keys, KeyInfo{
+			ID:        row.ID,
+			CreatedAt: row.CreatedAt,
+			ExpiresAt: row.ExpiresAt,
+			SessionID: sessionID,
+		})
+	}
+	return keys, nil
+}
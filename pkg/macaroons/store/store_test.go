@@ -32,9 +32,11 @@ func TestCreate(t *testing.T) {
 		taskID   = int32(101)
 	)
 
+	expiresAt := currTime.Add(ttl)
 	mockModel.EXPECT().CreateOpaqueKey(gomock.Any(), querier.CreateOpaqueKeyParams{
-		Group: &group,
-		Key:   key,
+		Group:     &group,
+		Key:       key,
+		ExpiresAt: &expiresAt,
 	}).Return(keyID, nil)
 	taskRunner.EXPECT().RunDeleteOpaqueKeyWithTx(
 		ctx,
@@ -135,6 +137,7 @@ func TestGet(t *testing.T) {
 		ctx   = context.Background()
 		keyID = int64(101)
 		key   = []byte("test")
+		group = "user:1"
 	)
 
 	for _, tc := range testCases {
@@ -146,15 +149,16 @@ func TestGet(t *testing.T) {
 			}
 
 			if tc.err == nil {
-				model.EXPECT().GetOpaqueKey(gomock.Any(), keyID).Return(key, nil)
+				model.EXPECT().GetOpaqueKey(gomock.Any(), keyID).Return(&querier.GetOpaqueKeyRow{Key: key, Group: &group}, nil)
 			} else {
 				model.EXPECT().GetOpaqueKey(gomock.Any(), keyID).Return(nil, tc.err)
 			}
 
-			key, err := store.Get(ctx, keyID)
+			gotKey, gotGroup, err := store.Get(ctx, keyID)
 			if tc.err == nil {
 				require.NoError(t, err)
-				require.Equal(t, key, key)
+				require.Equal(t, key, gotKey)
+				require.Equal(t, group, gotGroup)
 			} else if tc.err == pgx.ErrNoRows {
 				require.ErrorIs(t, err, ErrKeyNotFound)
 			} else {
@@ -216,3 +220,113 @@ func TestDeleteGroupKeysDeletesGroupKeys(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		ctx      = context.Background()
+		currTime = time.Now()
+		keys     = map[int64]*time.Time{}
+		nextID   = int64(0)
+	)
+
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	taskRunner := taskgen.NewMockTaskRunner(ctrl)
+	taskRunner.EXPECT().RunDeleteOpaqueKeyWithTx(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int32(1), nil).AnyTimes()
+
+	// the mock model keeps the keys in memory and deletes them like the query does
+	mockModel.EXPECT().CreateOpaqueKey(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, arg querier.CreateOpaqueKeyParams) (int64, error) {
+		nextID++
+		keys[nextID] = arg.ExpiresAt
+		return nextID, nil
+	}).Times(3)
+	mockModel.EXPECT().DeleteExpiredOpaqueKeys(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, now time.Time) (int64, error) {
+		var deleted int64
+		for id, expiresAt := range keys {
+			if expiresAt != nil && !expiresAt.After(now) {
+				delete(keys, id)
+				deleted++
+			}
+		}
+		return deleted, nil
+	})
+
+	store := &Store{
+		model:      mockModel,
+		taskRunner: taskRunner,
+		now:        func() time.Time { return currTime },
+	}
+
+	shortLived, err := store.Create(ctx, []byte("access"), time.Minute, "user:201")
+	require.NoError(t, err)
+	longLived, err := store.Create(ctx, []byte("refresh"), time.Hour, "user:201")
+	require.NoError(t, err)
+	permanent, err := store.Create(ctx, []byte("permanent"), 0, "")
+	require.NoError(t, err)
+
+	currTime = currTime.Add(30 * time.Minute)
+	deleted, err := store.DeleteExpired(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	require.NotContains(t, keys, shortLived)
+	require.Contains(t, keys, longLived)
+	require.Contains(t, keys, permanent)
+}
+
+func TestDeleteExpiredError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	currTime := time.Now()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockModel.EXPECT().DeleteExpiredOpaqueKeys(gomock.Any(), currTime).Return(int64(0), errors.New("boom"))
+
+	store := &Store{
+		model: mockModel,
+		now:   func() time.Time { return currTime },
+	}
+
+	_, err := store.DeleteExpired(context.Background())
+	require.Error(t, err)
+}
+
+func TestListGroupKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		ctx       = context.Background()
+		group     = "user:201"
+		currTime  = time.Now()
+		createdAt = currTime.Add(-time.Minute)
+		expiresAt = currTime.Add(time.Hour)
+		sessionID = int64(1)
+	)
+
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockModel.EXPECT().ListActiveOpaqueKeysByGroup(gomock.Any(), querier.ListActiveOpaqueKeysByGroupParams{
+		Group: &group,
+		Now:   currTime,
+	}).Return([]*querier.ListActiveOpaqueKeysByGroupRow{
+		{ID: 2, CreatedAt: createdAt, ExpiresAt: &expiresAt, SessionID: &sessionID},
+		{ID: 1, CreatedAt: createdAt},
+	}, nil)
+
+	store := &Store{
+		model: mockModel,
+		now:   func() time.Time { return currTime },
+	}
+
+	keys, err := store.ListGroupKeys(ctx, group)
+	require.NoError(t, err)
+	require.Equal(t, []KeyInfo{
+		{ID: 2, CreatedAt: createdAt, ExpiresAt: &expiresAt, SessionID: sessionID},
+		{ID: 1, CreatedAt: createdAt, SessionID: 1},
+	}, keys)
+
+	keys, err = store.ListGroupKeys(ctx, "")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/logger"
+	"go.uber.org/zap"
+)
+
+var log = logger.NewLogAgent("keystore")
+
+const DefaultSweepInterval = time.Hour
+
+// Sweeper periodically deletes expired keys. The delete task scheduled by Create
+// removes a key once its TTL passes, the sweeper catches the keys whose task was
+// lost, e.g. cancelled or failed.
+type Sweeper struct {
+	store    KeyStore
+	interval time.Duration
+
+	newTicker func(d time.Duration) (<-chan time.Time, func())
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewSweeper(store KeyStore, interval time.Duration) *Sweeper {
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+	return &Sweeper{
+		store:     store,
+		interval:  interval,
+		newTicker: newTicker,
+	}
+}
+
+func newTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// Start runs the sweeper in the background until ctx is done or Stop is called.
+func (s *Sweeper) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	ticks, stop := s.newTicker(s.interval)
+	go func() {
+		defer close(s.done)
+		defer stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticks:
+				s.sweep(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops the sweeper and waits for an in-flight sweep to finish.
+func (s *Sweeper) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Sweeper) sweep(ctx context.Context) {
+	deleted, err := s.store.DeleteExpired(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error("failed to sweep expired keys", zap.Error(err))
+		}
+		return
+	}
+	if deleted > 0 {
+		log.Info("swept expired keys", zap.Int64("deleted", deleted))
+	}
+}
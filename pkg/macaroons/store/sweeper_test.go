@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+)
+
+func newTestSweeper(store KeyStore) (*Sweeper, chan time.Time) {
+	ticks := make(chan time.Time)
+	s := NewSweeper(store, time.Minute)
+	s.newTicker = func(d time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	}
+	return s, ticks
+}
+
+func TestSweeperDeletesExpiredKeysOnEachTick(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	keyStore := NewMockKeyStore(ctrl)
+	swept := make(chan struct{}, 3)
+	gomock.InOrder(
+		keyStore.EXPECT().DeleteExpired(gomock.Any()).Return(int64(2), nil),
+		keyStore.EXPECT().DeleteExpired(gomock.Any()).Return(int64(0), errors.New("boom")),
+		keyStore.EXPECT().DeleteExpired(gomock.Any()).Return(int64(0), nil),
+	)
+
+	s, ticks := newTestSweeper(&notifyingKeyStore{KeyStore: keyStore, swept: swept})
+	s.Start(context.Background())
+
+	for i := 0; i < 3; i++ {
+		ticks <- time.Now()
+		<-swept
+	}
+	require.NoError(t, s.Stop(context.Background()))
+}
+
+func TestSweeperStopsWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s, _ := newTestSweeper(NewMockKeyStore(ctrl))
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	cancel()
+
+	select {
+	case <-s.done:
+	case <-time.After(time.Second):
+		t.Fatal("sweeper did not stop")
+	}
+	require.NoError(t, s.Stop(context.Background()))
+}
+
+func TestNewSweeperDefaultsInterval(t *testing.T) {
+	require.Equal(t, DefaultSweepInterval, NewSweeper(nil, 0).interval)
+	require.Equal(t, time.Minute, NewSweeper(nil, time.Minute).interval)
+}
+
+type notifyingKeyStore struct {
+	KeyStore
+	swept chan struct{}
+}
+
+func (s *notifyingKeyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	defer func() { s.swept <- struct{}{} }()
+	return s.KeyStore.DeleteExpired(ctx)
+}
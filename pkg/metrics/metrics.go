@@ -97,6 +97,23 @@ var TaskListenerPollDurationSeconds = promauto.NewHistogram(
 	},
 )
 
+var HTTPRequests = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "anclax_http_requests_total",
+		Help: "Total number of HTTP requests, labeled by method, route template and status.",
+	},
+	[]string{"method", "route", "status"},
+)
+
+var HTTPRequestDurationSeconds = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "anclax_http_request_duration_seconds",
+		Help:    "Time spent handling HTTP requests, labeled by method, route template and status.",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"method", "route", "status"},
+)
+
 type MetricsServer struct {
 	port      int
 	server    *http.Server
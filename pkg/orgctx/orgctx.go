@@ -0,0 +1,19 @@
+// Package orgctx carries the organization of a request or task in a context, so packages
+// that set it, like auth, and packages that read it, like the task store, do not depend on
+// each other.
+package orgctx
+
+import "context"
+
+type orgIDContextKey struct{}
+
+// WithOrgID returns a context that belongs to the organization.
+func WithOrgID(ctx context.Context, orgID int32) context.Context {
+	return context.WithValue(ctx, orgIDContextKey{}, orgID)
+}
+
+// OrgID returns the organization set by WithOrgID.
+func OrgID(ctx context.Context) (int32, bool) {
+	orgID, ok := ctx.Value(orgIDContextKey{}).(int32)
+	return orgID, ok
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	contextKeyBodyLimit = "anclax_body_limit"
+
+	defaultBodyLimit = 50 * 1024 * 1024 // 50MB
+)
+
+// NewBodyLimitMiddleware rejects requests whose body is larger than the limit of the
+// longest path prefix in limits that matches the request path, or defaultLimit if
+// none matches. The fiber app BodyLimit must be at least the largest of these limits.
+//
+// The size of a streamed body without Content-Length, e.g. a chunked one, is only known
+// once it is read, so the limit is handed to the body spool middleware, which enforces
+// it while reading. Streaming is only enabled together with the body spool middleware.
+func NewBodyLimitMiddleware(defaultLimit int, limits map[string]int) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		limit := bodyLimitForPath(c.Path(), defaultLimit, limits)
+
+		size := c.Request().Header.ContentLength()
+		if size < 0 {
+			if c.Request().BodyStream() != nil {
+				c.Locals(contextKeyBodyLimit, limit)
+				return c.Next()
+			}
+			size = len(c.Request().Body())
+		}
+		if size > limit {
+			return fiber.ErrRequestEntityTooLarge
+		}
+		return c.Next()
+	}
+}
+
+func bodyLimitForPath(path string, defaultLimit int, limits map[string]int) int {
+	limit, matched := defaultLimit, ""
+	for prefix, l := range limits {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(matched) {
+			limit, matched = l, prefix
+		}
+	}
+	return limit
+}
+
+func maxBodyLimit(defaultLimit int, limits map[string]int) int {
+	ret := defaultLimit
+	for _, l := range limits {
+		if l > ret {
+			ret = l
+		}
+	}
+	return ret
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func newBodyLimitTestApp(defaultLimit int, limits map[string]int) *fiber.App {
+	app := fiber.New(fiber.Config{BodyLimit: maxBodyLimit(defaultLimit, limits)})
+	app.Use(NewBodyLimitMiddleware(defaultLimit, limits))
+	ok := func(c fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	}
+	app.Post("/api/v1/uploads/file", ok)
+	app.Post("/api/v1/uploads/small/file", ok)
+	app.Post("/api/v1/users", ok)
+	return app
+}
+
+func postBody(t *testing.T, app *fiber.App, path string, size int) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(bytes.Repeat([]byte("a"), size)))
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestBodyLimitPerPathPrefix(t *testing.T) {
+	app := newBodyLimitTestApp(16, map[string]int{
+		"/api/v1/uploads":       64,
+		"/api/v1/uploads/small": 8,
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		size   int
+		status int
+	}{
+		{name: "prefix just under limit", path: "/api/v1/uploads/file", size: 64, status: http.StatusOK},
+		{name: "prefix just over limit", path: "/api/v1/uploads/file", size: 65, status: http.StatusRequestEntityTooLarge},
+		{name: "longest prefix wins under limit", path: "/api/v1/uploads/small/file", size: 8, status: http.StatusOK},
+		{name: "longest prefix wins over limit", path: "/api/v1/uploads/small/file", size: 9, status: http.StatusRequestEntityTooLarge},
+		{name: "default just under limit", path: "/api/v1/users", size: 16, status: http.StatusOK},
+		{name: "default just over limit", path: "/api/v1/users", size: 17, status: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.status, postBody(t, app, tt.path, tt.size))
+		})
+	}
+}
+
+func TestBodyLimitChunkedStreamedBody(t *testing.T) {
+	// mirrors NewServer with body spooling enabled, where request bodies are streamed
+	limits := map[string]int{"/api/v1/uploads": 64}
+	app := fiber.New(fiber.Config{BodyLimit: maxBodyLimit(16, limits), StreamRequestBody: true})
+	app.Use(NewBodyLimitMiddleware(16, limits))
+	app.Use(NewBodySpoolMiddleware(config.BodySpoolCfg{Threshold: 8, TempDir: t.TempDir()}))
+	app.Post("/api/v1/uploads/file", func(c fiber.Ctx) error {
+		if _, err := io.Copy(io.Discard, RequestBody(c)); err != nil {
+			return err
+		}
+		return c.SendStatus(http.StatusOK)
+	})
+
+	postChunked := func(size int) int {
+		// a reader of unknown length makes the request use chunked transfer encoding
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/uploads/file", io.MultiReader(bytes.NewReader(bytes.Repeat([]byte("a"), size))))
+		req.ContentLength = -1
+		req.TransferEncoding = []string{"chunked"}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, postChunked(64))
+	require.Equal(t, http.StatusRequestEntityTooLarge, postChunked(65))
+	require.Equal(t, http.StatusRequestEntityTooLarge, postChunked(1024))
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/gofiber/fiber/v3/middleware/cors"
+)
+
+// newCorsConfig returns LibConfig.Cors with LibConfig.CorsAllowOriginFunc applied.
+func newCorsConfig(libCfg *config.LibConfig) cors.Config {
+	var cfg cors.Config
+	if libCfg.Cors != nil {
+		cfg = *libCfg.Cors
+	}
+	if libCfg.CorsAllowOriginFunc != nil {
+		cfg.AllowOriginsFunc = libCfg.CorsAllowOriginFunc
+	}
+	return cfg
+}
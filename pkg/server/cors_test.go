@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/cors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorsAllowOriginFunc(t *testing.T) {
+	app := fiber.New()
+	app.Use(cors.New(newCorsConfig(&config.LibConfig{
+		Cors: &cors.Config{AllowOrigins: []string{"https://partner.example"}},
+		CorsAllowOriginFunc: func(origin string) bool {
+			return strings.HasSuffix(origin, ".ourdomain.com") && strings.HasPrefix(origin, "https://")
+		},
+	})))
+	app.Get("/", func(c fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://app.ourdomain.com", allowed: true},
+		{origin: "https://a.b.ourdomain.com", allowed: true},
+		{origin: "https://partner.example", allowed: true},
+		{origin: "https://evil.com", allowed: false},
+		{origin: "https://ourdomain.com.evil.com", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(fiber.HeaderOrigin, tt.origin)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			if tt.allowed {
+				require.Equal(t, tt.origin, resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+			} else {
+				require.Empty(t, resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+			}
+		})
+	}
+}
+
+func TestCorsConfigWithoutAllowOriginFunc(t *testing.T) {
+	cfg := newCorsConfig(&config.LibConfig{Cors: &cors.Config{AllowOrigins: []string{"https://partner.example"}}})
+	require.Nil(t, cfg.AllowOriginsFunc)
+	require.Equal(t, []string{"https://partner.example"}, cfg.AllowOrigins)
+
+	cfg = newCorsConfig(&config.LibConfig{})
+	require.Nil(t, cfg.AllowOriginsFunc)
+	require.Empty(t, cfg.AllowOrigins)
+}
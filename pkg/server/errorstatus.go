@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/cloudcarver/anclax/pkg/utils"
+	"github.com/gofiber/fiber/v3"
+)
+
+type errorStatus struct {
+	target error
+	status int
+}
+
+// errorStatusRegistry maps errors to HTTP status codes before they reach utils.ErrorHandler.
+type errorStatusRegistry struct {
+	mu       sync.RWMutex
+	mappings []errorStatus
+}
+
+func (r *errorStatusRegistry) register(target error, status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mappings = append(r.mappings, errorStatus{target: target, status: status})
+}
+
+// lookup returns the status of the first registered error that err matches with errors.Is.
+func (r *errorStatusRegistry) lookup(err error) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, m := range r.mappings {
+		if errors.Is(err, m.target) {
+			return m.status, true
+		}
+	}
+	return 0, false
+}
+
+func (r *errorStatusRegistry) errorHandler(c fiber.Ctx, err error) error {
+	var fe *fiber.Error
+	if !errors.As(err, &fe) {
+		if status, ok := r.lookup(err); ok {
+			err = fiber.NewError(status, err.Error())
+		}
+	}
+	return utils.ErrorHandler(c, err)
+}
+
+// RegisterErrorStatus makes the server respond with status when a handler returns an
+// error matching target with errors.Is, e.g. service.ErrUserNotFound -> 404. Errors
+// already carrying a status (*fiber.Error) are left as is. Mappings are checked in
+// registration order.
+func (s *Server) RegisterErrorStatus(target error, status int) {
+	s.errorStatus.register(target, status)
+}
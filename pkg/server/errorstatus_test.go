@@ -0,0 +1,54 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/service"
+	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterErrorStatus(t *testing.T) {
+	errorStatus := &errorStatusRegistry{}
+	s := &Server{
+		app:         fiber.New(fiber.Config{ErrorHandler: errorStatus.errorHandler}),
+		errorStatus: errorStatus,
+	}
+	s.RegisterErrorStatus(service.ErrUserNotFound, fiber.StatusNotFound)
+
+	s.app.Get("/wrapped", func(c fiber.Ctx) error {
+		return errors.Wrapf(service.ErrUserNotFound, "user %s not found", "alice")
+	})
+	s.app.Get("/fiber-error", func(c fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusConflict, service.ErrUserNotFound.Error())
+	})
+	s.app.Get("/unmapped", func(c fiber.Ctx) error {
+		return errors.New("boom")
+	})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/wrapped", wantStatus: http.StatusNotFound, wantBody: "user alice not found: user not found"},
+		{path: "/fiber-error", wantStatus: http.StatusConflict, wantBody: "user not found"},
+		{path: "/unmapped", wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := s.app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				require.Equal(t, tt.wantBody, string(body))
+			}
+		})
+	}
+}
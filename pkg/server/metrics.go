@@ -0,0 +1,53 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatchedRoute labels requests that did not match any route, so that scanning
+// arbitrary paths does not create a new series per path.
+const unmatchedRoute = "unmatched"
+
+// newMetricsMiddleware records the count and latency of requests labeled by method,
+// route template (e.g. /api/v1/tasks/:id, not the concrete path) and status.
+func newMetricsMiddleware(requests *prometheus.CounterVec, duration *prometheus.HistogramVec, errorStatus *errorStatusRegistry) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		start := time.Now()
+		own := c.Route()
+
+		err := c.Next()
+
+		// the route is still the one of this middleware if no handler matched
+		route := c.Route().Path
+		if c.Route() == own {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(responseStatus(c, err, errorStatus))
+
+		requests.WithLabelValues(c.Method(), route, status).Inc()
+		duration.WithLabelValues(c.Method(), route, status).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// responseStatus returns the status the client receives. Errors have not been through
+// the error handler yet, so their status is resolved the same way the handler does.
+func responseStatus(c fiber.Ctx, err error, errorStatus *errorStatusRegistry) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code
+	}
+	if status, ok := errorStatus.lookup(err); ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsMiddlewareLabelsRouteTemplate(t *testing.T) {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_requests_total"}, []string{"method", "route", "status"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_request_duration_seconds"}, []string{"method", "route", "status"})
+
+	errTeapot := errors.New("teapot")
+	errorStatus := &errorStatusRegistry{}
+	errorStatus.register(errTeapot, http.StatusTeapot)
+
+	app := fiber.New(fiber.Config{ErrorHandler: errorStatus.errorHandler})
+	app.Use(newMetricsMiddleware(requests, duration, errorStatus))
+	app.Get("/api/v1/tasks/:id", func(c fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+	app.Delete("/api/v1/tasks/:id", func(c fiber.Ctx) error {
+		return errTeapot
+	})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/v1/tasks/1", nil),
+		httptest.NewRequest(http.MethodGet, "/api/v1/tasks/2", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/v1/tasks/3", nil),
+		httptest.NewRequest(http.MethodGet, "/not/a/route", nil),
+	} {
+		_, err := app.Test(req)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 2.0, testutil.ToFloat64(requests.WithLabelValues(http.MethodGet, "/api/v1/tasks/:id", "200")))
+	require.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues(http.MethodDelete, "/api/v1/tasks/:id", "418")))
+	require.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues(http.MethodGet, unmatchedRoute, "404")))
+	require.Equal(t, 3, testutil.CollectAndCount(requests))
+
+	// one series per label set, none for the concrete paths
+	require.Equal(t, 3, testutil.CollectAndCount(duration))
+	require.Equal(t, uint64(2), histogramSampleCount(t, duration, http.MethodGet, "/api/v1/tasks/:id", "200"))
+}
+
+func histogramSampleCount(t *testing.T, h *prometheus.HistogramVec, labels ...string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	require.NoError(t, h.WithLabelValues(labels...).(prometheus.Histogram).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/auth"
+	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/limiter"
+)
+
+const (
+	defaultRateLimitWindow = time.Minute
+
+	contextKeyAuthResult = "anclax_auth_result"
+)
+
+// NewRateLimitMiddleware limits each client IP to cfg.Max requests per window. It runs before
+// the auth middleware, so the user of a request is not known yet, see NewUserRateLimitMiddleware.
+func NewRateLimitMiddleware(cfg config.RateLimitCfg, healthCheckPath *string) fiber.Handler {
+	return newRateLimiter(cfg, healthCheckPath, func(c fiber.Ctx) string {
+		return "ip:" + c.IP()
+	})
+}
+
+// NewUserRateLimitMiddleware limits each authenticated user to cfg.Max requests per window,
+// requests without a valid token are limited by client IP. authFunc authenticates the request
+// when it carries an Authorization header, it should be the AuthFunc of an authOnceValidator
+// so the route handler does not authenticate the request a second time.
+func NewUserRateLimitMiddleware(cfg config.RateLimitCfg, authFunc func(fiber.Ctx) error) fiber.Handler {
+	limit := newRateLimiter(cfg, nil, func(c fiber.Ctx) string {
+		if userID, err := auth.GetUserID(c); err == nil {
+			return fmt.Sprintf("user:%d", userID)
+		}
+		return "ip:" + c.IP()
+	})
+	return func(c fiber.Ctx) error {
+		if c.Get(fiber.HeaderAuthorization) != "" {
+			// a failed authentication is reported by the route handler
+			_ = authFunc(c)
+		}
+		return limit(c)
+	}
+}
+
+func newRateLimiter(cfg config.RateLimitCfg, healthCheckPath *string, key func(c fiber.Ctx) string) fiber.Handler {
+	window := cfg.Window
+	if window <= 0 {
+		window = defaultRateLimitWindow
+	}
+	return limiter.New(limiter.Config{
+		Max:        cfg.Max,
+		Expiration: window,
+		Next: func(c fiber.Ctx) bool {
+			path := c.Path()
+			if healthCheckPath != nil && path == *healthCheckPath {
+				return true
+			}
+			for _, prefix := range cfg.SkipPathPrefixes {
+				if prefix != "" && strings.HasPrefix(path, prefix) {
+					return true
+				}
+			}
+			return false
+		},
+		KeyGenerator: key,
+	})
+}
+
+// authOnceValidator runs the AuthFunc of the wrapped validator at most once per request, so a
+// middleware can authenticate a request before the route handler does.
+type authOnceValidator struct {
+	apigen.Validator
+}
+
+type authResult struct {
+	err error
+}
+
+func (v *authOnceValidator) AuthFunc(c fiber.Ctx) error {
+	if res, ok := c.Locals(contextKeyAuthResult).(authResult); ok {
+		return res.err
+	}
+	err := v.Validator.AuthFunc(c)
+	c.Locals(contextKeyAuthResult, authResult{err: err})
+	return err
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/auth"
+	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateLimitTestApp(cfg config.RateLimitCfg, healthCheckPath *string) *fiber.App {
+	app := fiber.New()
+	app.Use(NewRateLimitMiddleware(cfg, healthCheckPath))
+	ok := func(c fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	}
+	app.Get("/api/v1/users", ok)
+	app.Get("/healthz", ok)
+	app.Get("/metrics", ok)
+	return app
+}
+
+func doGet(t *testing.T, app *fiber.App, path string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestRateLimitByIP(t *testing.T) {
+	app := newRateLimitTestApp(config.RateLimitCfg{Max: 2, Window: time.Minute}, nil)
+
+	require.Equal(t, http.StatusOK, doGet(t, app, "/api/v1/users").StatusCode)
+	require.Equal(t, http.StatusOK, doGet(t, app, "/api/v1/users").StatusCode)
+
+	resp := doGet(t, app, "/api/v1/users")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	retryAfter, err := strconv.Atoi(resp.Header.Get(fiber.HeaderRetryAfter))
+	require.NoError(t, err)
+	require.Positive(t, retryAfter)
+	require.LessOrEqual(t, retryAfter, 60)
+}
+
+func TestRateLimitSkipsHealthCheckAndSkippedPrefixes(t *testing.T) {
+	app := newRateLimitTestApp(config.RateLimitCfg{Max: 1, SkipPathPrefixes: []string{"/metrics"}}, stringPtr("/healthz"))
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusOK, doGet(t, app, "/healthz").StatusCode)
+		require.Equal(t, http.StatusOK, doGet(t, app, "/metrics").StatusCode)
+	}
+	require.Equal(t, http.StatusOK, doGet(t, app, "/api/v1/users").StatusCode)
+	require.Equal(t, http.StatusTooManyRequests, doGet(t, app, "/api/v1/users").StatusCode)
+}
+
+// tokenValidator authenticates "Bearer <user id>" tokens.
+type tokenValidator struct {
+	calls int
+}
+
+func (v *tokenValidator) AuthFunc(c fiber.Ctx) error {
+	v.calls++
+	userID, err := strconv.Atoi(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "))
+	if err != nil {
+		return fiber.ErrUnauthorized
+	}
+	c.Locals(auth.ContextKeyUserID, int32(userID))
+	return nil
+}
+
+func (v *tokenValidator) PreValidate(c fiber.Ctx) error  { return nil }
+func (v *tokenValidator) PostValidate(c fiber.Ctx) error { return nil }
+func (v *tokenValidator) GetOrgID(c fiber.Ctx) int32     { return 0 }
+
+func TestUserRateLimit(t *testing.T) {
+	validator := &tokenValidator{}
+	authOnce := &authOnceValidator{Validator: validator}
+
+	app := fiber.New()
+	app.Get("/api/v1/users", NewUserRateLimitMiddleware(config.RateLimitCfg{Max: 1, KeyByUser: true}, authOnce.AuthFunc), func(c fiber.Ctx) error {
+		// stands in for the generated route handler
+		if err := authOnce.AuthFunc(c); err != nil {
+			return c.SendStatus(http.StatusUnauthorized)
+		}
+		return c.SendStatus(http.StatusOK)
+	})
+	get := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		if token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, get("1"))
+	require.Equal(t, 1, validator.calls, "the request is authenticated once")
+	require.Equal(t, http.StatusTooManyRequests, get("1"))
+
+	// other users have their own budget
+	require.Equal(t, http.StatusOK, get("2"))
+
+	// requests without a valid token share the budget of the client IP
+	require.Equal(t, http.StatusUnauthorized, get("invalid"))
+	require.Equal(t, http.StatusTooManyRequests, get(""))
+	require.Equal(t, http.StatusOK, get("3"))
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultReadinessPath = "/readyz"
+	readinessTimeout     = 3 * time.Second
+)
+
+// NewReadinessHandler responds 200 if the database is reachable and 503 otherwise.
+func NewReadinessHandler(m model.ModelInterface) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.Context(), readinessTimeout)
+		defer cancel()
+
+		if err := m.Ping(ctx); err != nil {
+			log.Warn("readiness check failed", zap.Error(err))
+			return c.Status(fiber.StatusServiceUnavailable).SendString("database is unreachable")
+		}
+		return c.SendString("ok")
+	}
+}
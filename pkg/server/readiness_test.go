@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
+	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		pingErr    error
+		wantStatus int
+	}{
+		{
+			name:       "healthy",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "database unreachable",
+			pingErr:    errors.New("closed pool"),
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockModel := model.NewMockModelInterface(ctrl)
+			mockModel.EXPECT().Ping(gomock.Any()).Return(tt.pingErr)
+
+			app := fiber.New()
+			app.Get(defaultReadinessPath, NewReadinessHandler(mockModel))
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, defaultReadinessPath, nil))
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
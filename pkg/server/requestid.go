@@ -0,0 +1,17 @@
+package server
+
+import (
+	"github.com/cloudcarver/anclax/pkg/logger"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+)
+
+// requestIDContext stores the request ID in the user context, so handlers passing
+// c.Context() to services get it in their logs (LogAgent.WithContext) and outgoing
+// httpx requests (httpx.PropagateRequestID).
+func requestIDContext(c fiber.Ctx) error {
+	if rid := requestid.FromContext(c); rid != "" {
+		c.SetContext(logger.WithRequestID(c.Context(), rid))
+	}
+	return c.Next()
+}
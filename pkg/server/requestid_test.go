@@ -0,0 +1,35 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/logger"
+	"github.com/cloudcarver/anclax/pkg/logger/loggertest"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRequestIDContext(t *testing.T) {
+	logs := loggertest.Observe(t, zap.InfoLevel)
+
+	handlerLog := logger.NewLogAgent("handler")
+	app := fiber.New()
+	app.Use(requestid.New())
+	app.Use(requestIDContext)
+	app.Get("/", func(c fiber.Ctx) error {
+		handlerLog.WithContext(c.Context()).Info("handled")
+		return c.SendString("ok")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	rid := resp.Header.Get(fiber.HeaderXRequestID)
+	require.NotEmpty(t, rid)
+
+	entries := logs.FilterMessage("handled").All()
+	require.Len(t, entries, 1)
+	require.Equal(t, rid, entries[0].ContextMap()["request-id"])
+}
@@ -0,0 +1,19 @@
+package server
+
+import (
+	"github.com/gofiber/fiber/v3"
+)
+
+// RegisterRoutes lets plugins mount their own endpoints under prefix. Every route of
+// the group goes through the same auth check as the generated API handlers, so only
+// requests with a valid access token reach them.
+func (s *Server) RegisterRoutes(prefix string, register func(r fiber.Router)) {
+	register(s.app.Group(prefix, s.authMiddleware))
+}
+
+func (s *Server) authMiddleware(c fiber.Ctx) error {
+	if err := s.validator.AuthFunc(c); err != nil {
+		return c.Status(fiber.StatusUnauthorized).SendString(err.Error())
+	}
+	return c.Next()
+}
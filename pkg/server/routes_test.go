@@ -0,0 +1,65 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeValidator struct {
+	authCalls int
+}
+
+func (v *fakeValidator) AuthFunc(c fiber.Ctx) error {
+	v.authCalls++
+	if c.Get("Authorization") != "Bearer valid" {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+func (v *fakeValidator) PreValidate(c fiber.Ctx) error { return nil }
+
+func (v *fakeValidator) PostValidate(c fiber.Ctx) error { return nil }
+
+func (v *fakeValidator) GetOrgID(c fiber.Ctx) int32 { return 0 }
+
+func TestRegisterRoutes(t *testing.T) {
+	validator := &fakeValidator{}
+	s := &Server{app: fiber.New(), validator: validator}
+
+	s.RegisterRoutes("/plugins/demo", func(r fiber.Router) {
+		r.Get("/hello", func(c fiber.Ctx) error {
+			return c.SendString("hello")
+		})
+	})
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "authorized", token: "Bearer valid", wantStatus: http.StatusOK, wantBody: "hello"},
+		{name: "unauthorized", token: "Bearer invalid", wantStatus: http.StatusUnauthorized, wantBody: "invalid token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/plugins/demo/hello", nil)
+			req.Header.Set("Authorization", tt.token)
+			resp, err := s.app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantBody, string(body))
+		})
+	}
+	require.Equal(t, 2, validator.authCalls)
+}
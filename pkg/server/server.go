@@ -2,8 +2,10 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/cloudcarver/anclax/lib/ws"
@@ -11,12 +13,15 @@ import (
 	"github.com/cloudcarver/anclax/pkg/config"
 	"github.com/cloudcarver/anclax/pkg/globalctx"
 	"github.com/cloudcarver/anclax/pkg/logger"
+	"github.com/cloudcarver/anclax/pkg/metrics"
 	"github.com/cloudcarver/anclax/pkg/utils"
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
 	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
 	"github.com/gofiber/fiber/v3/middleware/recover"
 	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
@@ -24,10 +29,18 @@ var log = logger.NewLogAgent("server")
 
 const ContextKeyDisableBodyLog = "anclax_disable_body_log"
 
+const apiBasePath = "/api/v1"
+
 func DisableBodyLog(c fiber.Ctx) {
 	c.Locals(ContextKeyDisableBodyLog, true)
 }
 
+// NoBodyLog is a route middleware that disables body logging for every request of the route.
+func NoBodyLog(c fiber.Ctx) error {
+	DisableBodyLog(c)
+	return c.Next()
+}
+
 type Server struct {
 	app             *fiber.App
 	host            string
@@ -38,16 +51,27 @@ type Server struct {
 	validator       apigen.Validator
 	wsc             *ws.WebsocketController
 	libCfg          *config.LibConfig
+	bodyLimit       int
+	tlsConfig       *tls.Config
+	errorStatus     *errorStatusRegistry
 	skipLogRequest  func(c fiber.Ctx) bool
-	skipLogResponse func(c fiber.Ctx) bool
+	skipLogResponse func(c fiber.Ctx, status int) bool
+	logResponseBody func(c fiber.Ctx) (string, bool)
+	sampleLog       func() bool
 }
 
+const defaultBodyLogMaxLength = 512
+
 type logRules struct {
 	hasRequestPathPrefix bool
 	requestPathPrefix    string
 	hasHealthCheckPath   bool
 	healthCheckPath      string
 	errorOnlyPrefixes    []string
+	bodyMaxLength        int
+	noBodyPrefixes       []string
+	sampleRate           uint64
+	sampleCount          *atomic.Uint64
 }
 
 func newLogRules(logCfg config.LogCfg) logRules {
@@ -66,9 +90,45 @@ func newLogRules(logCfg config.LogCfg) logRules {
 		}
 		rules.errorOnlyPrefixes = append(rules.errorOnlyPrefixes, prefix)
 	}
+	rules.bodyMaxLength = utils.UnwrapOrDefault(logCfg.BodyMaxLength, defaultBodyLogMaxLength)
+	for _, prefix := range logCfg.DisableBodyLogPathPrefixes {
+		if prefix == "" {
+			continue
+		}
+		rules.noBodyPrefixes = append(rules.noBodyPrefixes, prefix)
+	}
+	if rate := utils.UnwrapOrDefault(logCfg.SampleRate, 1); rate > 1 {
+		rules.sampleRate = uint64(rate)
+		rules.sampleCount = &atomic.Uint64{}
+	}
 	return rules
 }
 
+// sampled reports whether the current request is logged, the first of every sampleRate requests is.
+func (r logRules) sampled() bool {
+	if r.sampleRate <= 1 {
+		return true
+	}
+	return r.sampleCount.Add(1)%r.sampleRate == 1
+}
+
+// responseBody returns the response body to log, truncated to the configured length.
+func (r logRules) responseBody(c fiber.Ctx) (string, bool) {
+	if r.bodyMaxLength <= 0 || fiber.Locals[bool](c, ContextKeyDisableBodyLog) {
+		return "", false
+	}
+	ct := string(c.Response().Header.ContentType())
+	if ct == fiber.MIMEOctetStream || ct == "text/event-stream" {
+		return "", false
+	}
+	for _, prefix := range r.noBodyPrefixes {
+		if strings.HasPrefix(c.Path(), prefix) {
+			return "", false
+		}
+	}
+	return utils.TruncateString(string(c.Response().Body()), r.bodyMaxLength), true
+}
+
 func (r logRules) shouldSkipRequest(path string) bool {
 	return !r.matchesRequestPathPrefix(path) || r.isErrorOnlyPath(path)
 }
@@ -103,11 +163,28 @@ func NewServer(
 	auth auth.AuthInterface,
 	serverInterface apigen.ServerInterface,
 	validator apigen.Validator,
+	m model.ModelInterface,
 ) (*Server, error) {
+	if libCfg.Log.Format != nil || libCfg.Log.Level != nil {
+		if err := logger.Configure(utils.UnwrapOrDefault(libCfg.Log.Format, ""), utils.UnwrapOrDefault(libCfg.Log.Level, "")); err != nil {
+			return nil, err
+		}
+	}
+
+	bodyLimit := utils.UnwrapOrDefault(cfg.BodyLimit, defaultBodyLimit)
+
+	tlsConfig, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	errorStatus := &errorStatusRegistry{}
+
 	// create fiber app
 	app := fiber.New(fiber.Config{
-		ErrorHandler: utils.ErrorHandler,
-		BodyLimit:    50 * 1024 * 1024, // 50MB
+		ErrorHandler:      errorStatus.errorHandler,
+		BodyLimit:         maxBodyLimit(bodyLimit, libCfg.BodyLimits),
+		StreamRequestBody: libCfg.BodySpool != nil,
 	})
 
 	var port = 8020
@@ -133,18 +210,17 @@ func NewServer(
 		globalCtx:       globalCtx,
 		validator:       validator,
 		libCfg:          libCfg,
+		bodyLimit:       bodyLimit,
+		tlsConfig:       tlsConfig,
+		errorStatus:     errorStatus,
 	}
 
-	logRules := newLogRules(libCfg.Log)
-	s.skipLogRequest = func(c fiber.Ctx) bool {
-		return logRules.shouldSkipRequest(c.Path())
-	}
-	s.skipLogResponse = func(c fiber.Ctx) bool {
-		return logRules.shouldSkipResponse(c.Path(), c.Response().StatusCode())
-	}
+	s.setLogRules(newLogRules(libCfg.Log))
 
 	s.registerMiddleware()
 
+	s.app.Get(utils.UnwrapOrDefault(libCfg.ReadinessPath, defaultReadinessPath), NewReadinessHandler(m))
+
 	middlewares := []apigen.MiddlewareFunc{}
 	if cfg.RequestTimeout != nil {
 		middlewares = append(
@@ -158,13 +234,31 @@ func NewServer(
 		)
 	}
 
-	apigen.RegisterHandlersWithOptions(s.app, apigen.NewXMiddleware(s.serverInterface, s.validator), apigen.FiberServerOptions{
-		BaseURL:     "/api/v1",
+	routeValidator := s.validator
+	if libCfg.RateLimit != nil && libCfg.RateLimit.KeyByUser {
+		authOnce := &authOnceValidator{Validator: routeValidator}
+		routeValidator = authOnce
+		userRateLimit := NewUserRateLimitMiddleware(*libCfg.RateLimit, authOnce.AuthFunc)
+		// the middlewares are installed on the app, other routes are limited by client IP only
+		middlewares = append(middlewares, func(c fiber.Ctx) error {
+			if !strings.HasPrefix(c.Path(), apiBasePath) {
+				return c.Next()
+			}
+			return userRateLimit(c)
+		})
+	}
+
+	apigen.RegisterHandlersWithOptions(s.app, apigen.NewXMiddleware(s.serverInterface, routeValidator), apigen.FiberServerOptions{
+		BaseURL:     apiBasePath,
 		Middlewares: middlewares,
 	})
 
 	if libCfg.Ws != nil {
-		s.wsc = ws.New(globalCtx.Context(), libCfg.Ws)
+		wsCfg := *libCfg.Ws
+		if wsCfg.RequireAuth && wsCfg.Authenticator == nil {
+			wsCfg.Authenticator = newWsAuthenticator(auth)
+		}
+		s.wsc = ws.New(globalCtx.Context(), &wsCfg)
 		s.wsc.Mount(s.app)
 		log.Infof("WebSocket enabled at path: %s", s.wsc.Path())
 	}
@@ -177,50 +271,96 @@ func (s *Server) registerMiddleware() {
 		EnableStackTrace: true,
 	}))
 
-	if s.libCfg.Cors != nil {
-		s.app.Use(cors.New(*s.libCfg.Cors))
-	} else {
-		s.app.Use(cors.New(cors.Config{}))
-	}
+	s.app.Use(cors.New(newCorsConfig(s.libCfg)))
 
 	s.app.Use(requestid.New())
-	s.app.Use(func(c fiber.Ctx) error {
-		// log request
-		start := time.Now()
-		if !s.skipLogRequest(c) {
-			log.Info(
-				"request",
-				zap.String("method", c.Method()),
-				zap.String("path", c.Path()),
-				zap.String("request-id", requestid.FromContext(c)),
-			)
+	s.app.Use(requestIDContext)
+	if s.libCfg.RateLimit != nil {
+		rateLimit := *s.libCfg.RateLimit
+		if rateLimit.KeyByUser {
+			// API requests are limited after they are authenticated
+			rateLimit.SkipPathPrefixes = append(append([]string{}, rateLimit.SkipPathPrefixes...), apiBasePath)
 		}
+		s.app.Use(NewRateLimitMiddleware(rateLimit, s.libCfg.Log.HealthCheckPath))
+	}
+	if len(s.libCfg.BodyLimits) > 0 || s.libCfg.BodySpool != nil {
+		s.app.Use(NewBodyLimitMiddleware(s.bodyLimit, s.libCfg.BodyLimits))
+	}
+	if s.libCfg.BodySpool != nil {
+		s.app.Use(NewBodySpoolMiddleware(*s.libCfg.BodySpool))
+	}
+	s.app.Use(s.logRequest)
+	s.app.Use(newMetricsMiddleware(metrics.HTTPRequests, metrics.HTTPRequestDurationSeconds, s.errorStatus))
+}
 
-		err := c.Next()
-
-		// log response
-		if !s.skipLogResponse(c) {
-			end := time.Now()
-			fields := []zap.Field{
-				zap.Int("status", c.Response().StatusCode()),
-				zap.String("method", c.Method()),
-				zap.String("path", c.Path()),
-				zap.String("token", fmt.Sprintf("%v", c.Get("Authorization"))),
-				zap.String("request-id", requestid.FromContext(c)),
-				zap.Float32("latency-ms", float32(end.Sub(start).Milliseconds())),
-				zap.Error(err),
-			}
-			ct := string(c.Response().Header.ContentType())
-			if ct != fiber.MIMEOctetStream && ct != "text/event-stream" && !fiber.Locals[bool](c, ContextKeyDisableBodyLog) {
-				fields = append(fields, zap.String("body", utils.TruncateString(string(c.Response().Body()), 512)))
-			}
-			log.Info(
-				"response",
-				fields...,
-			)
+func (s *Server) setLogRules(rules logRules) {
+	s.skipLogRequest = func(c fiber.Ctx) bool {
+		return rules.shouldSkipRequest(c.Path())
+	}
+	s.skipLogResponse = func(c fiber.Ctx, status int) bool {
+		return rules.shouldSkipResponse(c.Path(), status)
+	}
+	s.logResponseBody = rules.responseBody
+	s.sampleLog = rules.sampled
+}
+
+func (s *Server) logRequest(c fiber.Ctx) error {
+	// log request
+	start := time.Now()
+	sampled := s.sampleLog()
+	if sampled && !s.skipLogRequest(c) {
+		log.Info(
+			"request",
+			zap.String("method", c.Method()),
+			zap.String("path", c.Path()),
+			zap.String("request-id", requestid.FromContext(c)),
+		)
+	}
+
+	err := c.Next()
+
+	// log response, errors are logged even if the request is not sampled. A returned error
+	// has not been through the error handler yet, so the response still has status 200.
+	status := responseStatus(c, err, s.errorStatus)
+	if (sampled || status >= 400) && !s.skipLogResponse(c, status) {
+		end := time.Now()
+		fields := []zap.Field{
+			zap.Int("status", status),
+			zap.String("method", c.Method()),
+			zap.String("path", c.Path()),
+			zap.String("token", fmt.Sprintf("%v", c.Get("Authorization"))),
+			zap.String("request-id", requestid.FromContext(c)),
+			zap.Float32("latency-ms", float32(end.Sub(start).Milliseconds())),
+			zap.Error(err),
 		}
-		return err
-	})
+		fields = append(fields, accessLogFields(c)...)
+		if body, ok := s.logResponseBody(c); ok {
+			fields = append(fields, zap.String("body", body))
+		}
+		log.Info(
+			"response",
+			fields...,
+		)
+	}
+	return err
+}
+
+// accessLogFields returns the audit fields of the response log. Anonymous requests are
+// logged with user-id 0.
+func accessLogFields(c fiber.Ctx) []zap.Field {
+	userID, _ := auth.GetUserID(c)
+	return []zap.Field{
+		zap.Int32("user-id", userID),
+		zap.Int("req-bytes", requestBytes(c)),
+	}
+}
+
+// requestBytes returns the request body size without draining streamed bodies.
+func requestBytes(c fiber.Ctx) int {
+	if c.Request().BodyStream() != nil {
+		return max(c.Request().Header.ContentLength(), 0)
+	}
+	return len(c.Request().Body())
 }
 
 func (s *Server) Websocket() *ws.WebsocketController {
@@ -233,7 +373,7 @@ func (s *Server) Listen() error {
 
 	// Start the server in a goroutine
 	go func() {
-		if err := s.app.Listen(fmt.Sprintf(":%d", s.port)); err != nil {
+		if err := s.app.Listen(fmt.Sprintf(":%d", s.port), fiber.ListenConfig{TLSConfig: s.tlsConfig}); err != nil {
 			shutdownChan <- err
 		}
 	}()
@@ -244,12 +384,29 @@ func (s *Server) Listen() error {
 		return err
 	case <-s.globalCtx.Context().Done():
 		log.Info("shutting down server due to context cancellation")
-		return s.app.Shutdown()
+		return s.Shutdown()
 	}
 }
 
+// Shutdown stops accepting connections and waits for in-flight requests to finish,
+// at most LibConfig.GracefulTimeout if set.
 func (s *Server) Shutdown() error {
-	return s.app.Shutdown()
+	if s.libCfg == nil || s.libCfg.GracefulTimeout == nil {
+		return s.app.Shutdown()
+	}
+	timeout := *s.libCfg.GracefulTimeout
+	if err := s.app.ShutdownWithTimeout(timeout); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn(
+				"graceful shutdown timed out, closing remaining connections",
+				zap.Duration("timeout", timeout),
+				zap.Int32("open-connections", s.app.Server().GetOpenConnectionsCount()),
+			)
+			return nil
+		}
+		return err
+	}
+	return nil
 }
 
 func (s *Server) GetApp() *fiber.App {
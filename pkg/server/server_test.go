@@ -1,10 +1,23 @@
 package server
 
 import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/cloudcarver/anclax/pkg/auth"
 	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/cloudcarver/anclax/pkg/globalctx"
+	"github.com/cloudcarver/anclax/pkg/logger/loggertest"
+	"github.com/cloudcarver/anclax/pkg/utils"
+	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
 func stringPtr(s string) *string {
@@ -130,3 +143,250 @@ func TestLogRules(t *testing.T) {
 		})
 	}
 }
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestLogRulesResponseBody(t *testing.T) {
+	body := strings.Repeat("a", 600)
+
+	tests := []struct {
+		name     string
+		logCfg   config.LogCfg
+		path     string
+		want     string
+		wantBody bool
+	}{
+		{
+			name:     "default truncates at 512",
+			path:     "/api/v1/users",
+			want:     strings.Repeat("a", 512) + "...",
+			wantBody: true,
+		},
+		{
+			name:     "configured length",
+			logCfg:   config.LogCfg{BodyMaxLength: intPtr(4)},
+			path:     "/api/v1/users",
+			want:     "aaaa...",
+			wantBody: true,
+		},
+		{
+			name:     "length above body size keeps full body",
+			logCfg:   config.LogCfg{BodyMaxLength: intPtr(4096)},
+			path:     "/api/v1/users",
+			want:     body,
+			wantBody: true,
+		},
+		{
+			name:     "zero disables body logging",
+			logCfg:   config.LogCfg{BodyMaxLength: intPtr(0)},
+			path:     "/api/v1/users",
+			wantBody: false,
+		},
+		{
+			name:     "disabled path prefix",
+			logCfg:   config.LogCfg{DisableBodyLogPathPrefixes: []string{"/api/v1/files"}},
+			path:     "/api/v1/files/1",
+			wantBody: false,
+		},
+		{
+			name:     "route middleware disables body logging",
+			path:     "/no-body-log",
+			wantBody: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := newLogRules(tt.logCfg)
+
+			var (
+				got    string
+				logged bool
+			)
+			app := fiber.New()
+			app.Use(func(c fiber.Ctx) error {
+				err := c.Next()
+				got, logged = rules.responseBody(c)
+				return err
+			})
+			handler := func(c fiber.Ctx) error {
+				return c.SendString(body)
+			}
+			app.Get("/no-body-log", NoBodyLog, handler)
+			app.Get("/*", handler)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, tt.wantBody, logged)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLogRequestSampling(t *testing.T) {
+	logs := loggertest.Observe(t, zap.InfoLevel)
+
+	s := &Server{errorStatus: &errorStatusRegistry{}}
+	s.setLogRules(newLogRules(config.LogCfg{SampleRate: utils.Ptr(10), BodyMaxLength: utils.Ptr(0)}))
+	app := fiber.New(fiber.Config{ErrorHandler: s.errorStatus.errorHandler})
+	app.Use(s.logRequest)
+	app.Get("/ok", func(c fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+	app.Get("/fail", func(c fiber.Ctx) error {
+		return c.SendStatus(http.StatusInternalServerError)
+	})
+	app.Get("/error", func(c fiber.Ctx) error {
+		return errors.New("boom")
+	})
+
+	for i := 0; i < 100; i++ {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/ok", nil))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	for i := 0; i < 20; i++ {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/fail", nil))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+	// returned errors are logged with the status the error handler responds with
+	for i := 0; i < 20; i++ {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/error", nil))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+
+	statuses := map[int64]int{}
+	for _, entry := range logs.FilterMessage("response").All() {
+		statuses[entry.ContextMap()["status"].(int64)]++
+	}
+	require.Equal(t, 10, statuses[http.StatusOK])
+	require.Equal(t, 40, statuses[http.StatusInternalServerError])
+}
+
+func TestAccessLogFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     int32
+		body       string
+		wantUserID int64
+	}{
+		{
+			name:       "authenticated",
+			userID:     42,
+			body:       `{"name":"anclax"}`,
+			wantUserID: 42,
+		},
+		{
+			name:       "anonymous",
+			wantUserID: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []zap.Field
+			app := fiber.New()
+			app.Use(func(c fiber.Ctx) error {
+				err := c.Next()
+				require.NotPanics(t, func() {
+					fields = accessLogFields(c)
+				})
+				return err
+			})
+			app.Post("/api/v1/items", func(c fiber.Ctx) error {
+				if tt.userID != 0 {
+					c.Locals(auth.ContextKeyUserID, tt.userID)
+				}
+				return c.SendStatus(http.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/api/v1/items", strings.NewReader(tt.body)))
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			require.Len(t, fields, 2)
+			require.Equal(t, "user-id", fields[0].Key)
+			require.Equal(t, tt.wantUserID, fields[0].Integer)
+			require.Equal(t, "req-bytes", fields[1].Key)
+			require.Equal(t, int64(len(tt.body)), fields[1].Integer)
+		})
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestListenShutdownDrainTimeout(t *testing.T) {
+	gracefulTimeout := 200 * time.Millisecond
+	port := freePort(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	app := fiber.New()
+	app.Get("/ping", func(c fiber.Ctx) error {
+		return c.SendString("pong")
+	})
+	app.Get("/slow", func(c fiber.Ctx) error {
+		close(started)
+		<-release
+		return c.SendString("done")
+	})
+
+	gctx := globalctx.New()
+	s := &Server{
+		app:       app,
+		port:      port,
+		globalCtx: gctx,
+		libCfg:    &config.LibConfig{GracefulTimeout: &gracefulTimeout},
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- s.Listen()
+	}()
+
+	baseURL := fmt.Sprintf("http://127.0.0.1:%d", port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(baseURL + "/ping")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 20*time.Millisecond)
+
+	go func() {
+		resp, err := http.Get(baseURL + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow request did not reach the handler")
+	}
+
+	begin := time.Now()
+	gctx.Cancel()
+
+	select {
+	case err := <-listenErr:
+		require.NoError(t, err)
+		require.Less(t, time.Since(begin), gracefulTimeout+time.Second)
+	case <-time.After(gracefulTimeout + 2*time.Second):
+		t.Fatal("shutdown did not complete within the graceful timeout")
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	contextKeySpooledBody = "anclax_spooled_body"
+
+	defaultSpoolThreshold = 1 << 20 // 1MB
+)
+
+// RequestBody returns the request body as an io.ReadSeeker. When the body spool middleware
+// is enabled, bodies above the threshold are backed by a temp file that is removed once
+// the request finishes, so the reader must not be used after the handler returns.
+func RequestBody(c fiber.Ctx) io.ReadSeeker {
+	if body, ok := c.Locals(contextKeySpooledBody).(io.ReadSeeker); ok {
+		return body
+	}
+	return bytes.NewReader(c.Body())
+}
+
+// NewBodySpoolMiddleware keeps request bodies up to cfg.Threshold in memory and spools
+// larger ones to a temp file, for the routes under cfg.Paths only. Their handlers read the
+// body with RequestBody. Bodies of other routes are read into memory so that fiber's body
+// accessors and binders keep working. Bodies larger than cfg.MaxSize, or than the limit
+// set by the body limit middleware, are rejected.
+func NewBodySpoolMiddleware(cfg config.BodySpoolCfg) fiber.Handler {
+	threshold := cfg.Threshold
+	if threshold <= 0 {
+		threshold = defaultSpoolThreshold
+	}
+	return func(c fiber.Ctx) error {
+		maxSize := cfg.MaxSize
+		if limit, ok := c.Locals(contextKeyBodyLimit).(int); ok && (maxSize <= 0 || int64(limit) < maxSize) {
+			maxSize = int64(limit)
+		}
+
+		if !hasPathPrefix(c.Path(), cfg.Paths) {
+			if err := bufferBody(c, maxSize); err != nil {
+				return err
+			}
+			return c.Next()
+		}
+
+		body, cleanup, err := spoolBody(c, threshold, cfg.TempDir, maxSize)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		c.Locals(contextKeySpooledBody, body)
+		return c.Next()
+	}
+}
+
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferBody reads a streamed body into memory.
+func bufferBody(c fiber.Ctx, maxSize int64) error {
+	stream := c.Request().BodyStream()
+	if stream == nil {
+		if maxSize > 0 && int64(len(c.Body())) > maxSize {
+			return fiber.ErrRequestEntityTooLarge
+		}
+		return nil
+	}
+
+	var src io.Reader = stream
+	if maxSize > 0 {
+		src = io.LimitReader(src, maxSize+1)
+	}
+	body, err := io.ReadAll(src)
+	if err != nil {
+		return errors.Wrap(err, "failed to read request body")
+	}
+	if maxSize > 0 && int64(len(body)) > maxSize {
+		return fiber.ErrRequestEntityTooLarge
+	}
+	c.Request().SetBody(body)
+	return nil
+}
+
+func spoolBody(c fiber.Ctx, threshold int64, tempDir string, maxSize int64) (io.ReadSeeker, func(), error) {
+	var src io.Reader
+	if stream := c.Request().BodyStream(); stream != nil {
+		src = stream
+	} else {
+		src = bytes.NewReader(c.Body())
+	}
+	if maxSize > 0 {
+		src = io.LimitReader(src, maxSize+1)
+	}
+
+	var head bytes.Buffer
+	n, err := io.CopyN(&head, src, threshold+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, errors.Wrap(err, "failed to read request body")
+	}
+	if maxSize > 0 && n > maxSize {
+		return nil, nil, fiber.ErrRequestEntityTooLarge
+	}
+	if n <= threshold {
+		// keep fiber's own body accessors working for small bodies
+		c.Request().SetBody(head.Bytes())
+		return bytes.NewReader(head.Bytes()), func() {}, nil
+	}
+
+	f, err := os.CreateTemp(tempDir, "anclax-body-")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create spool file")
+	}
+	cleanup := func() {
+		if err := f.Close(); err != nil {
+			log.Warn("failed to close spooled request body", zap.String("path", f.Name()), zap.Error(err))
+		}
+		if err := os.Remove(f.Name()); err != nil {
+			log.Warn("failed to remove spooled request body", zap.String("path", f.Name()), zap.Error(err))
+		}
+	}
+
+	size, err := io.Copy(f, io.MultiReader(&head, src))
+	if err != nil {
+		cleanup()
+		return nil, nil, errors.Wrap(err, "failed to spool request body")
+	}
+	if maxSize > 0 && size > maxSize {
+		cleanup()
+		return nil, nil, fiber.ErrRequestEntityTooLarge
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, errors.Wrap(err, "failed to rewind spooled request body")
+	}
+	return f, cleanup, nil
+}
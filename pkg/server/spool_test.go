@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func newSpoolTestApp(cfg config.BodySpoolCfg, handler fiber.Handler) *fiber.App {
+	cfg.Paths = []string{"/upload"}
+	app := fiber.New()
+	app.Use(NewBodySpoolMiddleware(cfg))
+	app.Post("/upload", handler)
+	app.Post("/users", handler)
+	return app
+}
+
+func TestBodySpoolKeepsSmallBodyInMemory(t *testing.T) {
+	var (
+		got      []byte
+		isFile   bool
+		fiberRaw []byte
+	)
+	app := newSpoolTestApp(config.BodySpoolCfg{Threshold: 16, TempDir: t.TempDir()}, func(c fiber.Ctx) error {
+		body := RequestBody(c)
+		_, isFile = body.(*os.File)
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		got = data
+		fiberRaw = append([]byte(nil), c.Body()...)
+		return c.SendStatus(http.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("hello")))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.False(t, isFile)
+	require.Equal(t, "hello", string(got))
+	require.Equal(t, "hello", string(fiberRaw))
+}
+
+func TestBodySpoolSpoolsLargeBodyAndCleansUp(t *testing.T) {
+	tempDir := t.TempDir()
+	payload := bytes.Repeat([]byte("0123456789"), 1024)
+
+	var (
+		spoolPath string
+		got       []byte
+		rewound   []byte
+	)
+	app := newSpoolTestApp(config.BodySpoolCfg{Threshold: 1024, TempDir: tempDir}, func(c fiber.Ctx) error {
+		body := RequestBody(c)
+		f, ok := body.(*os.File)
+		require.True(t, ok)
+		spoolPath = f.Name()
+
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		got = data
+
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		head := make([]byte, 10)
+		if _, err := io.ReadFull(body, head); err != nil {
+			return err
+		}
+		rewound = head
+		return c.SendStatus(http.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/upload", bytes.NewReader(payload)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, payload, got)
+	require.Equal(t, payload[:10], rewound)
+
+	require.NotEmpty(t, spoolPath)
+	_, err = os.Stat(spoolPath)
+	require.True(t, os.IsNotExist(err))
+
+	entries, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestBodySpoolRejectsBodyOverMaxSize(t *testing.T) {
+	tempDir := t.TempDir()
+	app := newSpoolTestApp(config.BodySpoolCfg{Threshold: 8, TempDir: tempDir, MaxSize: 32}, func(c fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/upload", bytes.NewReader(make([]byte, 64))))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	entries, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestBodySpoolKeepsBodyOfOtherRoutesForBinders(t *testing.T) {
+	tempDir := t.TempDir()
+	payload := `{"name":"` + strings.Repeat("a", 64) + `"}`
+
+	var got struct {
+		Name string `json:"name"`
+	}
+	app := newSpoolTestApp(config.BodySpoolCfg{Threshold: 8, TempDir: tempDir}, func(c fiber.Ctx) error {
+		if err := c.Bind().Body(&got); err != nil {
+			return err
+		}
+		return c.SendStatus(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, strings.Repeat("a", 64), got.Name)
+}
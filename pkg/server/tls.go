@@ -0,0 +1,61 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/pkg/errors"
+)
+
+// newTLSConfig builds the TLS config of the server from cfg, returns nil if cfg is nil.
+func newTLSConfig(cfg *config.TLS) (*tls.Config, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	certPEM, err := readPEM(cfg.CertFile, cfg.Cert)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read TLS certificate")
+	}
+	keyPEM, err := readPEM(cfg.KeyFile, cfg.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read TLS key")
+	}
+	if len(certPEM) == 0 || len(keyPEM) == 0 {
+		return nil, errors.New("TLS requires a certificate and a key")
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load TLS key pair")
+	}
+
+	ret := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	clientCAPEM, err := readPEM(cfg.ClientCAFile, cfg.ClientCA)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read TLS client CA")
+	}
+	if len(clientCAPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(clientCAPEM) {
+			return nil, errors.New("no valid certificate found in TLS client CA")
+		}
+		ret.ClientAuth = tls.RequireAndVerifyClientCert
+		ret.ClientCAs = pool
+	}
+
+	return ret, nil
+}
+
+// readPEM returns the content of path if set, otherwise the inline PEM.
+func readPEM(path, inline string) ([]byte, error) {
+	if path == "" {
+		return []byte(inline), nil
+	}
+	return os.ReadFile(path)
+}
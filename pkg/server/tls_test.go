@@ -0,0 +1,188 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/cloudcarver/anclax/pkg/globalctx"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if isCA {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	}
+
+	parentCert, parentKey := tmpl, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func startTLSServer(t *testing.T, tlsCfg *config.TLS) string {
+	t.Helper()
+
+	tlsConfig, err := newTLSConfig(tlsCfg)
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Get("/ping", func(c fiber.Ctx) error {
+		return c.SendString("pong")
+	})
+
+	gctx := globalctx.New()
+	s := &Server{
+		app:       app,
+		port:      freePort(t),
+		globalCtx: gctx,
+		libCfg:    &config.LibConfig{},
+		tlsConfig: tlsConfig,
+	}
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- s.Listen()
+	}()
+	t.Cleanup(func() {
+		gctx.Cancel()
+		select {
+		case <-listenErr:
+		case <-time.After(5 * time.Second):
+			t.Error("server did not shut down")
+		}
+	})
+
+	return fmt.Sprintf("https://127.0.0.1:%d", s.port)
+}
+
+func newTLSClient(ca *testCert, clientCert *testCert) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	tlsConfig := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if clientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{{
+			Certificate: [][]byte{clientCert.cert.Raw},
+			PrivateKey:  clientCert.key,
+		}}
+	}
+	return &http.Client{
+		Timeout:   time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+}
+
+func TestListenTLS(t *testing.T) {
+	ca := newTestCert(t, "anclax-test-ca", nil, true)
+	serverCert := newTestCert(t, "127.0.0.1", ca, false)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	require.NoError(t, os.WriteFile(certFile, serverCert.certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, serverCert.keyPEM, 0o600))
+
+	baseURL := startTLSServer(t, &config.TLS{CertFile: certFile, KeyFile: keyFile})
+	client := newTLSClient(ca, nil)
+
+	require.Eventually(t, func() bool {
+		resp, err := client.Get(baseURL + "/ping")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK && resp.TLS != nil
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestListenMutualTLS(t *testing.T) {
+	ca := newTestCert(t, "anclax-test-ca", nil, true)
+	serverCert := newTestCert(t, "127.0.0.1", ca, false)
+	clientCert := newTestCert(t, "anclax-test-client", ca, false)
+
+	baseURL := startTLSServer(t, &config.TLS{
+		Cert:     string(serverCert.certPEM),
+		Key:      string(serverCert.keyPEM),
+		ClientCA: string(ca.certPEM),
+	})
+
+	withCert := newTLSClient(ca, clientCert)
+	require.Eventually(t, func() bool {
+		resp, err := withCert.Get(baseURL + "/ping")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 20*time.Millisecond)
+
+	_, err := newTLSClient(ca, nil).Get(baseURL + "/ping")
+	require.Error(t, err)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	ret, err := newTLSConfig(nil)
+	require.NoError(t, err)
+	require.Nil(t, ret)
+
+	_, err = newTLSConfig(&config.TLS{})
+	require.Error(t, err)
+
+	ca := newTestCert(t, "anclax-test-ca", nil, true)
+	serverCert := newTestCert(t, "127.0.0.1", ca, false)
+	_, err = newTLSConfig(&config.TLS{
+		Cert:     string(serverCert.certPEM),
+		Key:      string(serverCert.keyPEM),
+		ClientCA: "not a pem",
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"github.com/cloudcarver/anclax/lib/ws"
+	"github.com/cloudcarver/anclax/pkg/auth"
+	"github.com/gofiber/fiber/v3"
+)
+
+// newWsAuthenticator validates websocket upgrade tokens with the same macaroon checks
+// as Authfunc and exposes the user context caveat as the session identity.
+func newWsAuthenticator(a auth.AuthInterface) ws.Authenticator {
+	return func(c fiber.Ctx, token string) (*ws.Identity, error) {
+		if err := a.AuthenticateToken(c, token); err != nil {
+			return nil, err
+		}
+		userID, err := auth.GetUserID(c)
+		if err != nil {
+			return nil, err
+		}
+		orgID, err := auth.GetOrgID(c)
+		if err != nil {
+			return nil, err
+		}
+		return &ws.Identity{UserID: userID, OrgID: orgID}, nil
+	}
+}
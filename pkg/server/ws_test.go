@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudcarver/anclax/lib/ws"
+	"github.com/cloudcarver/anclax/pkg/auth"
+	"github.com/gofiber/fiber/v3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWsAuthenticator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuth := auth.NewMockAuthInterface(ctrl)
+
+	mockAuth.EXPECT().AuthenticateToken(gomock.Any(), "valid").DoAndReturn(func(c fiber.Ctx, _ string) error {
+		return auth.NewUserContextCaveat(1, 2).Validate(c)
+	})
+	mockAuth.EXPECT().AuthenticateToken(gomock.Any(), "invalid").Return(errors.Wrap(fiber.ErrUnauthorized, "bad token"))
+
+	authenticate := newWsAuthenticator(mockAuth)
+
+	var identity *ws.Identity
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		var err error
+		identity, err = authenticate(c, c.Query("token"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/?token=valid", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, &ws.Identity{UserID: 1, OrgID: 2}, identity)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/?token=invalid", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
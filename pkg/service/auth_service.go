@@ -3,9 +3,14 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/cloudcarver/anclax/core"
 	"github.com/cloudcarver/anclax/pkg/auth"
+	"github.com/cloudcarver/anclax/pkg/macaroons"
+	"github.com/cloudcarver/anclax/pkg/macaroons/store"
 	"github.com/cloudcarver/anclax/pkg/utils"
 	"github.com/cloudcarver/anclax/pkg/zcore/model"
 	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
@@ -31,32 +36,151 @@ func (s *Service) SignIn(ctx context.Context, userID int32) (*apigen.Credentials
 		return nil, errors.Wrapf(err, "failed to create token")
 	}
 
+	if err := s.hooks.OnSignIn(ctx, userID, orgID); err != nil {
+		return nil, errors.Wrapf(err, "failed to run on sign in hook")
+	}
+
+	return s.credentials(token, refreshToken), nil
+}
+
+func (s *Service) credentials(accessToken, refreshToken *macaroons.Macaroon) *apigen.Credentials {
 	return &apigen.Credentials{
-		AccessToken:  token.StringToken(),
+		AccessToken:  accessToken.StringToken(),
 		RefreshToken: refreshToken.StringToken(),
 		TokenType:    apigen.Bearer,
-	}, nil
+		ExpiresIn:    int32(s.timeoutAccessToken / time.Second),
+	}
 }
 
 func (s *Service) SignInWithPassword(ctx context.Context, params apigen.SignInRequest) (*apigen.Credentials, error) {
 	user, err := s.m.GetUserByName(ctx, params.Name)
 	if err != nil {
-		if err == pgx.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errors.Wrapf(ErrUserNotFound, "user %s not found", params.Name)
 		}
 		return nil, errors.Wrapf(err, "failed to get user by name")
 	}
-	input, err := utils.HashPassword(params.Password, user.PasswordSalt)
+	ok, err := utils.CheckPassword(params.Password, user.PasswordSalt, user.PasswordHash)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to hash password")
+		return nil, errors.Wrapf(err, "failed to check password")
 	}
-	if input != user.PasswordHash {
+	if !ok {
 		return nil, ErrInvalidPassword
 	}
 
+	totp, err := s.m.GetUserTotpSecret(ctx, user.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.Wrapf(err, "failed to get totp secret")
+	}
+	if err == nil && totp.EnabledAt != nil {
+		if params.TotpCode == nil || *params.TotpCode == "" {
+			return nil, ErrTOTPRequired
+		}
+		if err := s.checkTOTPCode(ctx, totp, *params.TotpCode); err != nil {
+			return nil, err
+		}
+	}
+
 	return s.SignIn(ctx, user.ID)
 }
 
+func (s *Service) EnrollTOTP(ctx context.Context, userID int32) (string, string, error) {
+	if s.totpKey == nil {
+		return "", "", ErrTOTPDisabled
+	}
+	user, err := s.m.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", "", errors.Wrapf(ErrUserNotFound, "user %d not found", userID)
+		}
+		return "", "", errors.Wrapf(err, "failed to get user")
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	encrypted, err := encryptTOTPSecret(s.totpKey, secret)
+	if err != nil {
+		return "", "", err
+	}
+	// an enabled secret is never overwritten, otherwise anyone holding a session could
+	// switch the second factor to one they control
+	updated, err := s.m.UpsertUserTotpSecret(ctx, querier.UpsertUserTotpSecretParams{
+		UserID: userID,
+		Secret: encrypted,
+	})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to save totp secret")
+	}
+	if updated == 0 {
+		return "", "", ErrTOTPAlreadyEnrolled
+	}
+
+	return totpEncoding.EncodeToString(secret), totpURL(s.totpIssuer, user.Name, secret), nil
+}
+
+func (s *Service) VerifyTOTP(ctx context.Context, userID int32, code string) error {
+	if s.totpKey == nil {
+		return ErrTOTPDisabled
+	}
+	totp, err := s.m.GetUserTotpSecret(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTOTPNotEnrolled
+		}
+		return errors.Wrapf(err, "failed to get totp secret")
+	}
+	if err := s.checkTOTPCode(ctx, totp, code); err != nil {
+		return err
+	}
+	if totp.EnabledAt == nil {
+		if err := s.m.EnableUserTotpSecret(ctx, userID); err != nil {
+			return errors.Wrapf(err, "failed to enable totp")
+		}
+	}
+	return nil
+}
+
+// checkTOTPCode accepts each time-step at most once, a code of the last accepted step or an
+// earlier one is rejected even if it is still within the skew. Wrong codes count towards the
+// lockout of the user's TOTP.
+func (s *Service) checkTOTPCode(ctx context.Context, totp *querier.AnclaxUserTotpSecret, code string) error {
+	if s.totpKey == nil {
+		return ErrTOTPDisabled
+	}
+	now := s.now()
+	if totp.LockedUntil != nil && totp.LockedUntil.After(now) {
+		return ErrTOTPThrottled
+	}
+	secret, err := decryptTOTPSecret(s.totpKey, totp.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := validateTOTPCode(secret, code, now)
+	if !ok {
+		if err := s.m.RecordUserTotpFailure(ctx, querier.RecordUserTotpFailureParams{
+			UserID:      totp.UserID,
+			MaxAttempts: totpMaxAttempts,
+			LockedUntil: now.Add(totpLockout),
+		}); err != nil {
+			return errors.Wrapf(err, "failed to record totp failure")
+		}
+		return ErrInvalidTOTPCode
+	}
+	accepted, err := s.m.AcceptUserTotpStep(ctx, querier.AcceptUserTotpStepParams{
+		UserID:   totp.UserID,
+		LastStep: &step,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to accept totp step")
+	}
+	if accepted == 0 {
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
 func (s *Service) RefreshToken(ctx context.Context, token string) (*apigen.Credentials, error) {
 	refreshToken, roc, err := s.auth.ParseRefreshToken(ctx, token)
 	if err != nil {
@@ -83,11 +207,15 @@ func (s *Service) RefreshToken(ctx context.Context, token string) (*apigen.Crede
 		return nil, errors.Wrapf(err, "failed to create refresh token")
 	}
 
-	return &apigen.Credentials{
-		AccessToken:  accessToken.StringToken(),
-		RefreshToken: newRefreshToken.StringToken(),
-		TokenType:    apigen.Bearer,
-	}, nil
+	return s.credentials(accessToken, newRefreshToken), nil
+}
+
+func (s *Service) CreateShareLink(ctx context.Context, resourceType, resourceID string, ttl time.Duration) (string, error) {
+	token, err := s.auth.CreateToken(ctx, auth.ResourceTokenGroup(resourceType, resourceID), ttl, auth.NewResourceCaveat(resourceType, resourceID))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create share token")
+	}
+	return auth.EncodeShareToken(token.StringToken()), nil
 }
 
 type UserMeta struct {
@@ -108,11 +236,34 @@ func (s *Service) CreateNewUser(ctx context.Context, username, password string)
 }
 
 func (s *Service) CreateNewUserWithTx(ctx context.Context, tx core.Tx, username, password string) (*UserMeta, error) {
+	if err := s.passwordPolicy.validate(password); err != nil {
+		return nil, err
+	}
+
 	salt, hash, err := s.generateSaltAndHash(password)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to generate hash and salt")
 	}
+	return s.createUserWithTx(ctx, tx, username, hash, salt)
+}
+
+func (s *Service) CreateUserWithHash(ctx context.Context, username, passwordHash, passwordSalt string) (*UserMeta, error) {
+	if !utils.IsPasswordHash(passwordHash) {
+		return nil, ErrUnsupportedPasswordHash
+	}
+	var ret *UserMeta
+	if err := s.m.RunTransactionWithTx(ctx, func(tx core.Tx, txm model.ModelInterface) error {
+		u, err := s.createUserWithTx(ctx, tx, username, passwordHash, passwordSalt)
+		ret = u
+		return err
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to create user with hash")
+	}
+	return ret, nil
+}
 
+// createUserWithTx creates the user with the given credentials and its default org.
+func (s *Service) createUserWithTx(ctx context.Context, tx core.Tx, username, hash, salt string) (*UserMeta, error) {
 	txm := s.m.SpawnWithTx(tx)
 
 	org, err := txm.CreateOrg(ctx, fmt.Sprintf("%s's Org", username))
@@ -164,6 +315,69 @@ func (s *Service) CreateNewUserWithTx(ctx context.Context, tx core.Tx, username,
 	}, nil
 }
 
+func (s *Service) JoinOrgByVerifiedEmail(ctx context.Context, userID int32) (int32, error) {
+	var orgID int32
+	if err := s.m.RunTransaction(ctx, func(txm model.ModelInterface) error {
+		user, err := txm.GetUser(ctx, userID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errors.Wrapf(ErrUserNotFound, "user %d not found", userID)
+			}
+			return errors.Wrapf(err, "failed to get user")
+		}
+
+		orgID = s.orgIDByEmailDomain(user.Name)
+		if orgID == 0 {
+			return nil
+		}
+		if _, err := txm.GetOrg(ctx, orgID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errors.Errorf("org %d mapped to the email domain of %s does not exist", orgID, user.Name)
+			}
+			return errors.Wrapf(err, "failed to get org")
+		}
+
+		if _, err := txm.GetOrgUser(ctx, querier.GetOrgUserParams{OrgID: orgID, UserID: userID}); err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return errors.Wrapf(err, "failed to get organization user")
+			}
+			if _, err := txm.InsertOrgUser(ctx, querier.InsertOrgUserParams{
+				UserID: userID,
+				OrgID:  orgID,
+			}); err != nil {
+				return errors.Wrapf(err, "failed to create organization user")
+			}
+		}
+
+		if err := txm.SetUserDefaultOrg(ctx, querier.SetUserDefaultOrgParams{
+			UserID: userID,
+			OrgID:  orgID,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to set user default org")
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return orgID, nil
+}
+
+// orgIDByEmailDomain returns the org mapped to the email domain of username,
+// or 0 if username is not an email or its domain is not mapped.
+func (s *Service) orgIDByEmailDomain(username string) int32 {
+	at := strings.LastIndex(username, "@")
+	if at < 0 {
+		return 0
+	}
+	domain := username[at+1:]
+	for d, orgID := range s.orgDomains {
+		if strings.EqualFold(d, domain) {
+			return orgID
+		}
+	}
+	return 0
+}
+
 func (s *Service) DeleteUserByName(ctx context.Context, username string) error {
 	return s.m.RunTransaction(ctx, func(txm model.ModelInterface) error {
 		userID, err := txm.DeleteUserByNameReturningID(ctx, username)
@@ -187,7 +401,7 @@ func (s *Service) RestoreUserByName(ctx context.Context, username string) error
 
 func (s *Service) CreateTestAccount(ctx context.Context, username, password string) (int32, error) {
 	user, err := s.m.GetUserByName(ctx, username)
-	if err != nil && err != pgx.ErrNoRows {
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return 0, errors.Wrapf(err, "failed to get user by name")
 	}
 
@@ -195,7 +409,13 @@ func (s *Service) CreateTestAccount(ctx context.Context, username, password stri
 		return user.ID, nil
 	}
 
-	u, err := s.CreateNewUser(ctx, username, password)
+	// the test account is configured explicitly, so it is not subject to the password policy
+	salt, hash, err := s.generateSaltAndHash(password)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to generate hash and salt")
+	}
+
+	u, err := s.CreateUserWithHash(ctx, username, hash, salt)
 	if err != nil {
 		return 0, errors.Wrapf(err, "failed to create new user")
 	}
@@ -203,6 +423,10 @@ func (s *Service) CreateTestAccount(ctx context.Context, username, password stri
 }
 
 func (s *Service) UpdateUserPassword(ctx context.Context, username, password string) (int32, error) {
+	if err := s.passwordPolicy.validate(password); err != nil {
+		return 0, err
+	}
+
 	user, err := s.m.GetUserByName(ctx, username)
 	if err != nil {
 		return 0, errors.Wrapf(err, "failed to get user by name")
@@ -224,6 +448,45 @@ func (s *Service) UpdateUserPassword(ctx context.Context, username, password str
 	return user.ID, nil
 }
 
+func (s *Service) ChangePassword(ctx context.Context, userID int32, oldPassword, newPassword string) error {
+	user, err := s.m.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.Wrapf(ErrUserNotFound, "user %d not found", userID)
+		}
+		return errors.Wrapf(err, "failed to get user")
+	}
+	ok, err := utils.CheckPassword(oldPassword, user.PasswordSalt, user.PasswordHash)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check password")
+	}
+	if !ok {
+		return ErrInvalidPassword
+	}
+
+	if err := s.passwordPolicy.validate(newPassword); err != nil {
+		return err
+	}
+
+	salt, hash, err := s.generateSaltAndHash(newPassword)
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate hash and salt")
+	}
+
+	if err := s.m.UpdateUserPassword(ctx, querier.UpdateUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: hash,
+		PasswordSalt: salt,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to update user password")
+	}
+
+	if err := s.auth.InvalidateUserTokens(ctx, user.ID); err != nil {
+		return errors.Wrapf(err, "failed to invalidate user tokens")
+	}
+	return nil
+}
+
 func (s *Service) IsUsernameExists(ctx context.Context, username string) (bool, error) {
 	return s.m.IsUsernameExists(ctx, username)
 }
@@ -231,7 +494,7 @@ func (s *Service) IsUsernameExists(ctx context.Context, username string) (bool,
 func (s *Service) GetUserByUserName(ctx context.Context, username string) (*UserMeta, error) {
 	user, err := s.m.GetUserByName(ctx, username)
 	if err != nil {
-		if err == pgx.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errors.Wrapf(ErrUserNotFound, "user %s not found", username)
 		}
 		return nil, errors.Wrapf(err, "failed to get user by name")
@@ -245,3 +508,64 @@ func (s *Service) GetUserByUserName(ctx context.Context, username string) (*User
 		UserID: user.ID,
 	}, nil
 }
+
+// SessionInfo describes one active session of a user. Signing in creates a key for the
+// access token and one for the refresh token, both belong to the same session.
+type SessionInfo struct {
+	// KeyID identifies the session, it is the key ID of the access token it started with.
+	KeyID     int64
+	CreatedAt time.Time
+
+	// ExpiresAt is when the last token of the session expires, nil if one never does.
+	ExpiresAt *time.Time
+}
+
+func (s *Service) ListUserSessions(ctx context.Context, userID int32) ([]SessionInfo, error) {
+	keys, err := s.auth.ListUserTokens(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list user tokens")
+	}
+	var sessions []SessionInfo
+	index := map[int64]int{}
+	for _, key := range keys {
+		i, ok := index[key.SessionID]
+		if !ok {
+			index[key.SessionID] = len(sessions)
+			sessions = append(sessions, SessionInfo{
+				KeyID:     key.SessionID,
+				CreatedAt: key.CreatedAt,
+				ExpiresAt: key.ExpiresAt,
+			})
+			continue
+		}
+		session := &sessions[i]
+		if key.CreatedAt.Before(session.CreatedAt) {
+			session.CreatedAt = key.CreatedAt
+		}
+		if session.ExpiresAt != nil && (key.ExpiresAt == nil || key.ExpiresAt.After(*session.ExpiresAt)) {
+			session.ExpiresAt = key.ExpiresAt
+		}
+	}
+	return sessions, nil
+}
+
+func (s *Service) RevokeSession(ctx context.Context, userID int32, keyID int64) error {
+	keys, err := s.auth.ListUserTokens(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list user tokens")
+	}
+	i := slices.IndexFunc(keys, func(key store.KeyInfo) bool { return key.ID == keyID || key.SessionID == keyID })
+	if i < 0 {
+		return errors.Wrapf(ErrSessionNotFound, "session %d not found for user %d", keyID, userID)
+	}
+	sessionID := keys[i].SessionID
+	for _, key := range keys {
+		if key.SessionID != sessionID {
+			continue
+		}
+		if err := s.auth.InvalidateToken(ctx, key.ID); err != nil {
+			return errors.Wrapf(err, "failed to invalidate token")
+		}
+	}
+	return nil
+}
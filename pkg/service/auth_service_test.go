@@ -1,8 +1,13 @@
 package service
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"slices"
 	"testing"
 	"time"
 
@@ -11,13 +16,16 @@ import (
 	"github.com/cloudcarver/anclax/pkg/hooks"
 	"github.com/cloudcarver/anclax/pkg/macaroons"
 	macaroonstore "github.com/cloudcarver/anclax/pkg/macaroons/store"
+	"github.com/cloudcarver/anclax/pkg/utils"
 	"github.com/cloudcarver/anclax/pkg/zcore/model"
 	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
 	"github.com/cloudcarver/anclax/pkg/zgen/querier"
+	"github.com/gofiber/fiber/v3"
 	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestCreateNewUser(t *testing.T) {
@@ -89,6 +97,224 @@ func TestCreateNewUser(t *testing.T) {
 
 }
 
+func TestCreateUserWithHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockHooks := hooks.NewMockAnclaxHookInterface(ctrl)
+
+	var (
+		orgID    = int32(301)
+		userID   = int32(302)
+		username = "imported"
+		salt     = "imported-salt"
+		ctx      = context.Background()
+	)
+	hash, err := utils.HashPassword("imported-password", salt)
+	require.NoError(t, err)
+
+	mockModel.EXPECT().CreateOrg(ctx, fmt.Sprintf("%s's Org", username)).Return(&querier.AnclaxOrg{ID: orgID}, nil)
+	mockHooks.EXPECT().OnOrgCreated(ctx, gomock.Any(), orgID).Return(nil)
+	mockModel.EXPECT().CreateUser(ctx, querier.CreateUserParams{
+		Name:         username,
+		PasswordHash: hash,
+		PasswordSalt: salt,
+	}).Return(&querier.AnclaxUser{ID: userID}, nil)
+	mockHooks.EXPECT().OnUserCreated(ctx, gomock.Any(), userID).Return(nil)
+	mockModel.EXPECT().InsertOrgOwner(ctx, querier.InsertOrgOwnerParams{
+		UserID: userID,
+		OrgID:  orgID,
+	}).Return(nil, nil)
+	mockModel.EXPECT().InsertOrgUser(ctx, querier.InsertOrgUserParams{
+		UserID: userID,
+		OrgID:  orgID,
+	}).Return(nil, nil)
+	mockModel.EXPECT().SetUserDefaultOrg(ctx, querier.SetUserDefaultOrgParams{
+		UserID: userID,
+		OrgID:  orgID,
+	}).Return(nil)
+
+	service := &Service{
+		m:     mockModel,
+		hooks: mockHooks,
+		generateSaltAndHash: func(string) (string, string, error) {
+			return "", "", errors.New("password must not be hashed")
+		},
+	}
+
+	u, err := service.CreateUserWithHash(ctx, username, hash, salt)
+	require.NoError(t, err)
+	require.Equal(t, orgID, u.OrgID)
+	require.Equal(t, userID, u.UserID)
+}
+
+func TestSignInWithImportedBcryptHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockHooks := hooks.NewMockAnclaxHookInterface(ctrl)
+
+	var (
+		orgID    = int32(311)
+		userID   = int32(312)
+		username = "imported"
+		password = "imported-password"
+		ctx      = context.Background()
+	)
+	raw, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	hash := string(raw)
+
+	mockModel.EXPECT().CreateOrg(ctx, fmt.Sprintf("%s's Org", username)).Return(&querier.AnclaxOrg{ID: orgID}, nil)
+	mockHooks.EXPECT().OnOrgCreated(ctx, gomock.Any(), orgID).Return(nil)
+	mockModel.EXPECT().CreateUser(ctx, querier.CreateUserParams{
+		Name:         username,
+		PasswordHash: hash,
+	}).Return(&querier.AnclaxUser{ID: userID, Name: username, PasswordHash: hash}, nil)
+	mockHooks.EXPECT().OnUserCreated(ctx, gomock.Any(), userID).Return(nil)
+	mockModel.EXPECT().InsertOrgOwner(ctx, gomock.Any()).Return(nil, nil)
+	mockModel.EXPECT().InsertOrgUser(ctx, gomock.Any()).Return(nil, nil)
+	mockModel.EXPECT().SetUserDefaultOrg(ctx, gomock.Any()).Return(nil)
+
+	service := &Service{m: mockModel, hooks: mockHooks}
+	_, err = service.CreateUserWithHash(ctx, username, hash, "")
+	require.NoError(t, err)
+
+	for _, foreign := range []string{"5f4dcc3b5aa765d61d8327deb882cf99", "$2a$10$short", ""} {
+		_, err := service.CreateUserWithHash(ctx, username, foreign, "")
+		require.ErrorIs(t, err, ErrUnsupportedPasswordHash)
+	}
+
+	user := &querier.AnclaxUser{ID: userID, Name: username, PasswordHash: hash}
+	mockModel.EXPECT().GetUserByName(ctx, username).Return(user, nil).Times(2)
+	mockModel.EXPECT().GetUserTotpSecret(ctx, userID).Return(nil, pgx.ErrNoRows)
+	mockModel.EXPECT().GetUserDefaultOrg(ctx, userID).Return(orgID, nil)
+
+	baseHook := hooks.NewBaseHook()
+	caveatParser := macaroons.NewCaveatParser()
+	macaroonManager := macaroons.NewMacaroonManager(newTestKeyStore(), caveatParser)
+	authSvc, err := auth.NewAuth(&config.Config{}, macaroonManager, caveatParser, baseHook)
+	require.NoError(t, err)
+	service = &Service{m: mockModel, auth: authSvc, hooks: baseHook}
+
+	credentials, err := service.SignInWithPassword(ctx, apigen.SignInRequest{Name: username, Password: password})
+	require.NoError(t, err)
+	require.NotEmpty(t, credentials.AccessToken)
+
+	_, err = service.SignInWithPassword(ctx, apigen.SignInRequest{Name: username, Password: "wrong-password"})
+	require.ErrorIs(t, err, ErrInvalidPassword)
+}
+
+func TestJoinOrgByVerifiedEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+
+	var (
+		orgID  = int32(201)
+		userID = int32(202)
+		ctx    = context.Background()
+	)
+
+	mockModel.EXPECT().GetUser(ctx, userID).Return(&querier.AnclaxUser{ID: userID, Name: "alice@Acme.com"}, nil)
+	mockModel.EXPECT().GetOrg(ctx, orgID).Return(&querier.AnclaxOrg{ID: orgID, Name: "Acme"}, nil)
+	mockModel.EXPECT().GetOrgUser(ctx, querier.GetOrgUserParams{OrgID: orgID, UserID: userID}).Return(nil, pgx.ErrNoRows)
+	mockModel.EXPECT().InsertOrgUser(ctx, querier.InsertOrgUserParams{
+		UserID: userID,
+		OrgID:  orgID,
+	}).Return(nil, nil)
+	mockModel.EXPECT().SetUserDefaultOrg(ctx, querier.SetUserDefaultOrgParams{
+		UserID: userID,
+		OrgID:  orgID,
+	}).Return(nil)
+
+	service := &Service{
+		m:          mockModel,
+		orgDomains: map[string]int32{"acme.com": orgID},
+	}
+
+	joined, err := service.JoinOrgByVerifiedEmail(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, orgID, joined)
+}
+
+func TestJoinOrgByVerifiedEmailUnmappedDomain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	ctx := context.Background()
+
+	mockModel.EXPECT().GetUser(ctx, int32(1)).Return(&querier.AnclaxUser{ID: 1, Name: "bob@example.com"}, nil)
+
+	service := &Service{
+		m:          mockModel,
+		orgDomains: map[string]int32{"acme.com": 7},
+	}
+
+	joined, err := service.JoinOrgByVerifiedEmail(ctx, 1)
+	require.NoError(t, err)
+	require.Zero(t, joined)
+}
+
+func TestCreateNewUserMappedEmailDomainCreatesPersonalOrg(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockHooks := hooks.NewMockAnclaxHookInterface(ctrl)
+
+	var (
+		orgID    = int32(301)
+		userID   = int32(302)
+		username = "alice@acme.com"
+		ctx      = context.Background()
+	)
+
+	mockModel.EXPECT().CreateOrg(ctx, fmt.Sprintf("%s's Org", username)).Return(&querier.AnclaxOrg{ID: orgID}, nil)
+
+	mockHooks.EXPECT().OnOrgCreated(ctx, gomock.Any(), orgID).Return(nil)
+
+	mockModel.EXPECT().CreateUser(ctx, querier.CreateUserParams{
+		Name:         username,
+		PasswordHash: "hash",
+		PasswordSalt: "salt",
+	}).Return(&querier.AnclaxUser{ID: userID}, nil)
+
+	mockHooks.EXPECT().OnUserCreated(ctx, gomock.Any(), userID).Return(nil)
+
+	mockModel.EXPECT().InsertOrgOwner(ctx, querier.InsertOrgOwnerParams{
+		UserID: userID,
+		OrgID:  orgID,
+	}).Return(nil, nil)
+
+	mockModel.EXPECT().InsertOrgUser(ctx, querier.InsertOrgUserParams{
+		UserID: userID,
+		OrgID:  orgID,
+	}).Return(nil, nil)
+
+	mockModel.EXPECT().SetUserDefaultOrg(ctx, querier.SetUserDefaultOrgParams{
+		UserID: userID,
+		OrgID:  orgID,
+	}).Return(nil)
+
+	service := &Service{
+		m:          mockModel,
+		hooks:      mockHooks,
+		orgDomains: map[string]int32{"acme.com": 7},
+		generateSaltAndHash: func(string) (string, string, error) {
+			return "salt", "hash", nil
+		},
+	}
+
+	u, err := service.CreateNewUser(ctx, username, "password")
+	require.NoError(t, err)
+	require.Equal(t, orgID, u.OrgID)
+}
+
 func TestUpdateUserPassword(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -134,6 +360,65 @@ func TestUpdateUserPassword(t *testing.T) {
 	require.Equal(t, userID, resultUserID)
 }
 
+func TestChangePassword(t *testing.T) {
+	var (
+		userID      = int32(103)
+		oldPassword = "oldpassword"
+		newPassword = "newpassword"
+		ctx         = context.Background()
+	)
+	oldHash, err := utils.HashPassword(oldPassword, "oldsalt")
+	require.NoError(t, err)
+	user := &querier.AnclaxUser{ID: userID, PasswordHash: oldHash, PasswordSalt: "oldsalt"}
+
+	t.Run("correct old password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+		mockAuth := auth.NewMockAuthInterface(ctrl)
+
+		mockModel.EXPECT().GetUser(ctx, userID).Return(user, nil)
+		gomock.InOrder(
+			mockModel.EXPECT().UpdateUserPassword(ctx, querier.UpdateUserPasswordParams{
+				ID:           userID,
+				PasswordHash: "newhash",
+				PasswordSalt: "newsalt",
+			}).Return(nil),
+			mockAuth.EXPECT().InvalidateUserTokens(ctx, userID).Return(nil),
+		)
+
+		service := &Service{
+			m:    mockModel,
+			auth: mockAuth,
+			generateSaltAndHash: func(inputPassword string) (string, string, error) {
+				if inputPassword != newPassword {
+					return "", "", errors.New("password mismatch")
+				}
+				return "newsalt", "newhash", nil
+			},
+		}
+
+		require.NoError(t, service.ChangePassword(ctx, userID, oldPassword, newPassword))
+	})
+
+	t.Run("wrong old password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+		mockModel.EXPECT().GetUser(ctx, userID).Return(user, nil)
+
+		service := &Service{
+			m:    mockModel,
+			auth: auth.NewMockAuthInterface(ctrl),
+		}
+
+		err := service.ChangePassword(ctx, userID, "wrongpassword", newPassword)
+		require.ErrorIs(t, err, ErrInvalidPassword)
+	})
+}
+
 func TestDeleteUserByNameDeletesTokenKeysInTransaction(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -201,20 +486,35 @@ func TestDeleteUserByNameReturnsTokenKeyDeleteError(t *testing.T) {
 type testKeyStore struct {
 	next      int64
 	keys      map[int64][]byte
+	groups    map[int64]string
+	sessions  map[int64]int64
+	createdAt map[int64]time.Time
+	expiresAt map[int64]time.Time
 	groupKeys map[string]map[int64]struct{}
+	now       func() time.Time
 }
 
 func newTestKeyStore() *testKeyStore {
 	return &testKeyStore{
 		keys:      map[int64][]byte{},
+		groups:    map[int64]string{},
+		sessions:  map[int64]int64{},
+		createdAt: map[int64]time.Time{},
+		expiresAt: map[int64]time.Time{},
 		groupKeys: map[string]map[int64]struct{}{},
+		now:       time.Now,
 	}
 }
 
-func (s *testKeyStore) Create(_ context.Context, key []byte, _ time.Duration, group string) (int64, error) {
+func (s *testKeyStore) Create(_ context.Context, key []byte, ttl time.Duration, group string) (int64, error) {
 	s.next++
 	keyID := s.next
 	s.keys[keyID] = append([]byte(nil), key...)
+	s.groups[keyID] = group
+	s.createdAt[keyID] = s.now()
+	if ttl > 0 {
+		s.expiresAt[keyID] = s.now().Add(ttl)
+	}
 	if group != "" {
 		if s.groupKeys[group] == nil {
 			s.groupKeys[group] = map[int64]struct{}{}
@@ -224,12 +524,24 @@ func (s *testKeyStore) Create(_ context.Context, key []byte, _ time.Duration, gr
 	return keyID, nil
 }
 
-func (s *testKeyStore) Get(_ context.Context, keyID int64) ([]byte, error) {
+func (s *testKeyStore) CreateInSession(ctx context.Context, key []byte, ttl time.Duration, group string, sessionID int64) (int64, error) {
+	keyID, err := s.Create(ctx, key, ttl, group)
+	if err != nil {
+		return 0, err
+	}
+	s.sessions[keyID] = sessionID
+	return keyID, nil
+}
+
+func (s *testKeyStore) Get(_ context.Context, keyID int64) ([]byte, string, error) {
 	key, ok := s.keys[keyID]
 	if !ok {
-		return nil, macaroonstore.ErrKeyNotFound
+		return nil, "", macaroonstore.ErrKeyNotFound
+	}
+	if expiresAt, ok := s.expiresAt[keyID]; ok && !s.now().Before(expiresAt) {
+		return nil, "", macaroonstore.ErrKeyNotFound
 	}
-	return append([]byte(nil), key...), nil
+	return append([]byte(nil), key...), s.groups[keyID], nil
 }
 
 func (s *testKeyStore) Delete(_ context.Context, keyID int64) error {
@@ -258,6 +570,40 @@ func (s *testKeyStore) DeleteGroupKeys(_ context.Context, group string) error {
 	return nil
 }
 
+func (s *testKeyStore) ListGroupKeys(_ context.Context, group string) ([]macaroonstore.KeyInfo, error) {
+	var keys []macaroonstore.KeyInfo
+	for keyID := range s.groupKeys[group] {
+		info := macaroonstore.KeyInfo{ID: keyID, CreatedAt: s.createdAt[keyID], SessionID: keyID}
+		if sessionID, ok := s.sessions[keyID]; ok {
+			info.SessionID = sessionID
+		}
+		if expiresAt, ok := s.expiresAt[keyID]; ok {
+			if !s.now().Before(expiresAt) {
+				continue
+			}
+			info.ExpiresAt = &expiresAt
+		}
+		keys = append(keys, info)
+	}
+	slices.SortFunc(keys, func(a, b macaroonstore.KeyInfo) int { return cmp.Compare(b.ID, a.ID) })
+	return keys, nil
+}
+
+func (s *testKeyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	var deleted int64
+	for keyID, expiresAt := range s.expiresAt {
+		if s.now().Before(expiresAt) {
+			continue
+		}
+		if err := s.Delete(ctx, keyID); err != nil {
+			return deleted, err
+		}
+		delete(s.expiresAt, keyID)
+		deleted++
+	}
+	return deleted, nil
+}
+
 func TestRefreshTokenRotatesRealMacaroons(t *testing.T) {
 	ctx := context.Background()
 	userID := int32(102)
@@ -283,6 +629,7 @@ func TestRefreshTokenRotatesRealMacaroons(t *testing.T) {
 	credentials, err := svc.RefreshToken(ctx, refreshToken.StringToken())
 	require.NoError(t, err)
 	require.Equal(t, apigen.Bearer, credentials.TokenType)
+	require.Equal(t, int32(auth.DefaultTimeoutAccessToken/time.Second), credentials.ExpiresIn)
 	require.NotEmpty(t, credentials.AccessToken)
 	require.NotEmpty(t, credentials.RefreshToken)
 	require.NotEqual(t, accessToken.StringToken(), credentials.AccessToken)
@@ -297,6 +644,83 @@ func TestRefreshTokenRotatesRealMacaroons(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestSignInExpiresInMatchesAccessExpiry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := int32(102)
+	orgID := int32(201)
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetUserDefaultOrg(ctx, userID).Return(orgID, nil)
+
+	accessExpiry := 15 * time.Minute
+	cfg := &config.Config{Auth: config.Auth{AccessExpiry: &accessExpiry}}
+	baseHook := hooks.NewBaseHook()
+	caveatParser := macaroons.NewCaveatParser()
+	macaroonManager := macaroons.NewMacaroonManager(newTestKeyStore(), caveatParser)
+	authSvc, err := auth.NewAuth(cfg, macaroonManager, caveatParser, baseHook)
+	require.NoError(t, err)
+
+	svc := &Service{
+		m:                  mockModel,
+		auth:               authSvc,
+		hooks:              baseHook,
+		timeoutAccessToken: accessExpiry,
+	}
+	credentials, err := svc.SignIn(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, int32(900), credentials.ExpiresIn)
+
+	body, err := json.Marshal(credentials)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `"expires_in":900`)
+}
+
+func TestSignInRunsOnSignInHooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := int32(102)
+	orgID := int32(201)
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetUserDefaultOrg(ctx, userID).Return(orgID, nil).Times(2)
+
+	var signedIn [][2]int32
+	hookErr := errors.New("hook failed")
+	baseHook := hooks.NewBaseHook()
+	baseHook.RegisterOnSignIn(func(ctx context.Context, userID int32, orgID int32) error {
+		signedIn = append(signedIn, [2]int32{userID, orgID})
+		if len(signedIn) > 1 {
+			return hookErr
+		}
+		return nil
+	})
+
+	caveatParser := macaroons.NewCaveatParser()
+	macaroonManager := macaroons.NewMacaroonManager(newTestKeyStore(), caveatParser)
+	authSvc, err := auth.NewAuth(&config.Config{}, macaroonManager, caveatParser, baseHook)
+	require.NoError(t, err)
+
+	svc := &Service{
+		m:     mockModel,
+		auth:  authSvc,
+		hooks: baseHook,
+	}
+
+	credentials, err := svc.SignIn(ctx, userID)
+	require.NoError(t, err)
+	require.NotEmpty(t, credentials.AccessToken)
+	require.Equal(t, [][2]int32{{userID, orgID}}, signedIn)
+
+	credentials, err = svc.SignIn(ctx, userID)
+	require.ErrorIs(t, err, hookErr)
+	require.Nil(t, credentials)
+}
+
 func TestRefreshTokenParseFailureReturnsRefreshTokenExpired(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -311,3 +735,153 @@ func TestRefreshTokenParseFailureReturnsRefreshTokenExpired(t *testing.T) {
 	require.ErrorIs(t, err, ErrRefreshTokenExpired)
 	require.ErrorIs(t, err, macaroons.ErrMalformedToken)
 }
+
+func TestCreateShareLinkGrantsAccessToOneResourceUntilExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	keyStore := newTestKeyStore()
+	keyStore.now = func() time.Time { return now }
+
+	caveatParser := macaroons.NewCaveatParser()
+	macaroonManager := macaroons.NewMacaroonManager(keyStore, caveatParser)
+	authSvc, err := auth.NewAuth(&config.Config{}, macaroonManager, caveatParser, nil)
+	require.NoError(t, err)
+
+	svc := &Service{auth: authSvc}
+	shareToken, err := svc.CreateShareLink(ctx, "document", "42", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, url.QueryEscape(shareToken), shareToken)
+
+	app := fiber.New()
+	ok := func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	app.Get("/documents/:id", authSvc.ResourceAuthFunc("document", "id"), ok)
+	app.Get("/folders/:id", authSvc.ResourceAuthFunc("folder", "id"), ok)
+	app.Get("/me", authSvc.Authfunc, ok)
+
+	get := func(path string) int {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path+"?"+auth.QueryShareToken+"="+shareToken, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, fiber.StatusOK, get("/documents/42"))
+	require.Equal(t, fiber.StatusUnauthorized, get("/documents/43"))
+	require.Equal(t, fiber.StatusUnauthorized, get("/folders/42"))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/documents/42", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	// a share token does not sign in, not even with a user context appended by the holder
+	tokenString, err := auth.DecodeShareToken(shareToken)
+	require.NoError(t, err)
+	token, err := macaroonManager.Parse(ctx, tokenString)
+	require.NoError(t, err)
+	require.NoError(t, token.AddCaveat(auth.NewUserContextCaveat(1, 1)))
+	for _, bearer := range []string{tokenString, token.StringToken()} {
+		req := httptest.NewRequest(fiber.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	}
+
+	now = now.Add(time.Hour)
+	require.Equal(t, fiber.StatusUnauthorized, get("/documents/42"))
+}
+
+func TestShareRouteRejectsForgedResourceCaveat(t *testing.T) {
+	ctx := context.Background()
+	caveatParser := macaroons.NewCaveatParser()
+	macaroonManager := macaroons.NewMacaroonManager(newTestKeyStore(), caveatParser)
+	authSvc, err := auth.NewAuth(&config.Config{}, macaroonManager, caveatParser, nil)
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Get("/documents/:id", authSvc.ResourceAuthFunc("document", "id"), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	get := func(token *macaroons.Macaroon) int {
+		shareToken := auth.EncodeShareToken(token.StringToken())
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/documents/42?"+auth.QueryShareToken+"="+shareToken, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// a user appends a resource caveat to the own access token
+	accessToken, err := authSvc.CreateToken(ctx, auth.UserTokenGroup(104), auth.DefaultTimeoutAccessToken, auth.NewUserContextCaveat(104, 201))
+	require.NoError(t, err)
+	require.NoError(t, accessToken.AddCaveat(auth.NewResourceCaveat("document", "42")))
+	require.Equal(t, fiber.StatusUnauthorized, get(accessToken))
+
+	// a share link of another document is rewritten for this one
+	svc := &Service{auth: authSvc}
+	otherShareToken, err := svc.CreateShareLink(ctx, "document", "43", time.Hour)
+	require.NoError(t, err)
+	tokenString, err := auth.DecodeShareToken(otherShareToken)
+	require.NoError(t, err)
+	otherToken, err := macaroonManager.Parse(ctx, tokenString)
+	require.NoError(t, err)
+	require.NoError(t, otherToken.AddCaveat(auth.NewResourceCaveat("document", "42")))
+	require.Equal(t, fiber.StatusUnauthorized, get(otherToken))
+}
+
+func TestListAndRevokeUserSessions(t *testing.T) {
+	ctx := context.Background()
+	var (
+		userID  = int32(104)
+		otherID = int32(105)
+		orgID   = int32(201)
+	)
+
+	caveatParser := macaroons.NewCaveatParser()
+	macaroonManager := macaroons.NewMacaroonManager(newTestKeyStore(), caveatParser)
+	authSvc, err := auth.NewAuth(&config.Config{}, macaroonManager, caveatParser, nil)
+	require.NoError(t, err)
+
+	accessToken, err := authSvc.CreateToken(ctx, auth.UserTokenGroup(userID), auth.DefaultTimeoutAccessToken, auth.NewUserContextCaveat(userID, orgID))
+	require.NoError(t, err)
+	refreshToken, err := authSvc.CreateRefreshToken(ctx, auth.UserTokenGroup(userID), accessToken, auth.DefaultTimeoutRefreshToken)
+	require.NoError(t, err)
+	otherAccessToken, err := authSvc.CreateToken(ctx, auth.UserTokenGroup(otherID), auth.DefaultTimeoutAccessToken, auth.NewUserContextCaveat(otherID, orgID))
+	require.NoError(t, err)
+	secondAccessToken, err := authSvc.CreateToken(ctx, auth.UserTokenGroup(userID), auth.DefaultTimeoutAccessToken, auth.NewUserContextCaveat(userID, orgID))
+	require.NoError(t, err)
+
+	svc := &Service{auth: authSvc}
+
+	// the access and refresh token of a session are listed once
+	sessions, err := svc.ListUserSessions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.Equal(t, secondAccessToken.KeyID(), sessions[0].KeyID)
+	require.Equal(t, accessToken.KeyID(), sessions[1].KeyID)
+	for _, session := range sessions {
+		require.NotNil(t, session.ExpiresAt)
+	}
+
+	// keys of another user cannot be revoked
+	err = svc.RevokeSession(ctx, userID, otherAccessToken.KeyID())
+	require.ErrorIs(t, err, ErrSessionNotFound)
+	_, err = macaroonManager.Parse(ctx, otherAccessToken.StringToken())
+	require.NoError(t, err)
+
+	// revoking a session invalidates both of its tokens
+	require.NoError(t, svc.RevokeSession(ctx, userID, accessToken.KeyID()))
+	_, err = macaroonManager.Parse(ctx, accessToken.StringToken())
+	require.Error(t, err)
+	_, err = macaroonManager.Parse(ctx, refreshToken.StringToken())
+	require.Error(t, err)
+
+	sessions, err = svc.ListUserSessions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, secondAccessToken.KeyID(), sessions[0].KeyID)
+
+	err = svc.RevokeSession(ctx, userID, accessToken.KeyID())
+	require.ErrorIs(t, err, ErrSessionNotFound)
+}
@@ -2,11 +2,32 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/cloudcarver/anclax/core"
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
 	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
 	"github.com/cloudcarver/anclax/pkg/zgen/querier"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
 )
 
+type OrgRole string
+
+const (
+	OrgRoleOwner  OrgRole = "owner"
+	OrgRoleMember OrgRole = "member"
+)
+
+// OrgMember is a user of an organization. The owner is listed with OrgRoleOwner, every
+// other user with OrgRoleMember.
+type OrgMember struct {
+	UserID   int32
+	Username string
+	Role     OrgRole
+	JoinedAt time.Time
+}
+
 func orgToApigen(org *querier.AnclaxOrg) *apigen.Org {
 	return &apigen.Org{
 		ID:        org.ID,
@@ -29,3 +50,115 @@ func (s *Service) ListOrgs(ctx context.Context, id int32) ([]apigen.Org, error)
 
 	return ret, nil
 }
+
+func (s *Service) ListOrgMembers(ctx context.Context, orgID int32) ([]OrgMember, error) {
+	rows, err := s.m.ListOrgMembers(ctx, orgID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list members of org %d", orgID)
+	}
+
+	ret := make([]OrgMember, len(rows))
+	for i, row := range rows {
+		role := OrgRoleMember
+		if row.IsOwner {
+			role = OrgRoleOwner
+		}
+		ret[i] = OrgMember{
+			UserID:   row.UserID,
+			Username: row.UserName,
+			Role:     role,
+			JoinedAt: row.JoinedAt,
+		}
+	}
+	return ret, nil
+}
+
+func (s *Service) TransferOrgOwnership(ctx context.Context, orgID, newOwnerUserID int32) error {
+	return s.m.RunTransactionWithTx(ctx, func(tx core.Tx, txm model.ModelInterface) error {
+		// lock the owner first, like RemoveOrgMember, so the new owner cannot be removed concurrently
+		if _, err := txm.GetOrgOwner(ctx, orgID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return errors.Wrapf(err, "failed to get owner of org %d", orgID)
+		}
+		if _, err := txm.GetOrgUser(ctx, querier.GetOrgUserParams{
+			OrgID:  orgID,
+			UserID: newOwnerUserID,
+		}); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errors.Wrapf(ErrOrgMemberNotFound, "user %d in org %d", newOwnerUserID, orgID)
+			}
+			return errors.Wrapf(err, "failed to get org user")
+		}
+		if _, err := txm.SetOrgOwner(ctx, querier.SetOrgOwnerParams{
+			OrgID:  orgID,
+			UserID: newOwnerUserID,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to set owner of org %d", orgID)
+		}
+		return nil
+	})
+}
+
+func (s *Service) RemoveOrgMember(ctx context.Context, orgID, userID int32) error {
+	if err := s.m.RunTransactionWithTx(ctx, func(tx core.Tx, txm model.ModelInterface) error {
+		owner, err := txm.GetOrgOwner(ctx, orgID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return errors.Wrapf(err, "failed to get owner of org %d", orgID)
+		}
+		if err == nil && owner.UserID == userID {
+			return ErrRemoveOrgOwner
+		}
+
+		removed, err := txm.DeleteOrgUser(ctx, querier.DeleteOrgUserParams{
+			OrgID:  orgID,
+			UserID: userID,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete org user")
+		}
+		if removed == 0 {
+			return errors.Wrapf(ErrOrgMemberNotFound, "user %d in org %d", userID, orgID)
+		}
+		return s.resetDefaultOrg(ctx, txm, userID, orgID)
+	}); err != nil {
+		return err
+	}
+
+	// tokens carry the org they were issued for
+	if err := s.auth.InvalidateUserTokens(ctx, userID); err != nil {
+		return errors.Wrapf(err, "failed to invalidate user tokens")
+	}
+	return nil
+}
+
+// resetDefaultOrg moves the default org of the user to another org of the user if it is
+// orgID, or unsets it if the user has no other org.
+func (s *Service) resetDefaultOrg(ctx context.Context, txm model.ModelInterface, userID, orgID int32) error {
+	defaultOrg, err := txm.GetUserDefaultOrg(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get user default org")
+	}
+	if defaultOrg != orgID {
+		return nil
+	}
+
+	orgs, err := txm.ListOrgs(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list orgs of user %d", userID)
+	}
+	if len(orgs) == 0 {
+		if err := txm.DeleteUserDefaultOrg(ctx, userID); err != nil {
+			return errors.Wrapf(err, "failed to delete user default org")
+		}
+		return nil
+	}
+	if err := txm.SetUserDefaultOrg(ctx, querier.SetUserDefaultOrgParams{
+		UserID: userID,
+		OrgID:  orgs[0].ID,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to set user default org")
+	}
+	return nil
+}
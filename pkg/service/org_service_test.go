@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/auth"
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
+	"github.com/cloudcarver/anclax/pkg/zgen/querier"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListOrgMembers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	joinedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().ListOrgMembers(ctx, int32(3)).Return([]*querier.ListOrgMembersRow{
+		{UserID: 1, UserName: "alice", IsOwner: true, JoinedAt: joinedAt},
+		{UserID: 2, UserName: "bob", JoinedAt: joinedAt.Add(time.Hour)},
+		{UserID: 4, UserName: "carol", JoinedAt: joinedAt.Add(2 * time.Hour)},
+	}, nil)
+
+	service := &Service{m: mockModel}
+	members, err := service.ListOrgMembers(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, []OrgMember{
+		{UserID: 1, Username: "alice", Role: OrgRoleOwner, JoinedAt: joinedAt},
+		{UserID: 2, Username: "bob", Role: OrgRoleMember, JoinedAt: joinedAt.Add(time.Hour)},
+		{UserID: 4, Username: "carol", Role: OrgRoleMember, JoinedAt: joinedAt.Add(2 * time.Hour)},
+	}, members)
+}
+
+func TestTransferOrgOwnership(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	gomock.InOrder(
+		mockModel.EXPECT().GetOrgOwner(ctx, int32(3)).Return(&querier.AnclaxOrgOwner{OrgID: 3, UserID: 1}, nil),
+		mockModel.EXPECT().GetOrgUser(ctx, querier.GetOrgUserParams{OrgID: 3, UserID: 2}).Return(&querier.AnclaxOrgUser{OrgID: 3, UserID: 2}, nil),
+		mockModel.EXPECT().SetOrgOwner(ctx, querier.SetOrgOwnerParams{OrgID: 3, UserID: 2}).Return(&querier.AnclaxOrgOwner{OrgID: 3, UserID: 2}, nil),
+	)
+
+	service := &Service{m: mockModel}
+	require.NoError(t, service.TransferOrgOwnership(ctx, 3, 2))
+}
+
+func TestTransferOrgOwnershipRequiresMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockModel.EXPECT().GetOrgOwner(ctx, int32(3)).Return(&querier.AnclaxOrgOwner{OrgID: 3, UserID: 1}, nil)
+	mockModel.EXPECT().GetOrgUser(ctx, querier.GetOrgUserParams{OrgID: 3, UserID: 9}).Return(nil, pgx.ErrNoRows)
+
+	service := &Service{m: mockModel}
+	require.ErrorIs(t, service.TransferOrgOwnership(ctx, 3, 9), ErrOrgMemberNotFound)
+}
+
+func TestRemoveOrgMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockAuth := auth.NewMockAuthInterface(ctrl)
+	gomock.InOrder(
+		mockModel.EXPECT().GetOrgOwner(ctx, int32(3)).Return(&querier.AnclaxOrgOwner{OrgID: 3, UserID: 1}, nil),
+		mockModel.EXPECT().DeleteOrgUser(ctx, querier.DeleteOrgUserParams{OrgID: 3, UserID: 2}).Return(int64(1), nil),
+		// the removed org was the default, it moves to the user's remaining org
+		mockModel.EXPECT().GetUserDefaultOrg(ctx, int32(2)).Return(int32(3), nil),
+		mockModel.EXPECT().ListOrgs(ctx, int32(2)).Return([]*querier.AnclaxOrg{{ID: 5}}, nil),
+		mockModel.EXPECT().SetUserDefaultOrg(ctx, querier.SetUserDefaultOrgParams{UserID: 2, OrgID: 5}).Return(nil),
+		mockAuth.EXPECT().InvalidateUserTokens(ctx, int32(2)).Return(nil),
+	)
+
+	service := &Service{m: mockModel, auth: mockAuth}
+	require.NoError(t, service.RemoveOrgMember(ctx, 3, 2))
+}
+
+func TestRemoveOrgMemberErrors(t *testing.T) {
+	t.Run("owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.Background()
+		mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+		mockModel.EXPECT().GetOrgOwner(ctx, int32(3)).Return(&querier.AnclaxOrgOwner{OrgID: 3, UserID: 1}, nil)
+
+		service := &Service{m: mockModel}
+		require.ErrorIs(t, service.RemoveOrgMember(ctx, 3, 1), ErrRemoveOrgOwner)
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.Background()
+		mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+		mockModel.EXPECT().GetOrgOwner(ctx, int32(3)).Return(&querier.AnclaxOrgOwner{OrgID: 3, UserID: 1}, nil)
+		mockModel.EXPECT().DeleteOrgUser(ctx, querier.DeleteOrgUserParams{OrgID: 3, UserID: 9}).Return(int64(0), nil)
+
+		service := &Service{m: mockModel}
+		require.ErrorIs(t, service.RemoveOrgMember(ctx, 3, 9), ErrOrgMemberNotFound)
+	})
+}
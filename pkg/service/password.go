@@ -0,0 +1,60 @@
+package service
+
+import (
+	"unicode"
+
+	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/cloudcarver/anclax/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const defaultPasswordMinLength = 8
+
+type passwordPolicy struct {
+	minLength        int
+	requireMixedCase bool
+	requireDigit     bool
+	requireSymbol    bool
+}
+
+func newPasswordPolicy(cfg config.PasswordPolicy) passwordPolicy {
+	return passwordPolicy{
+		minLength:        utils.UnwrapOrDefault(cfg.MinLength, defaultPasswordMinLength),
+		requireMixedCase: cfg.RequireMixedCase,
+		requireDigit:     cfg.RequireDigit,
+		requireSymbol:    cfg.RequireSymbol,
+	}
+}
+
+// validate returns an error wrapping ErrWeakPassword that describes the first rule the
+// password breaks.
+func (p passwordPolicy) validate(password string) error {
+	if len([]rune(password)) < p.minLength {
+		return errors.Wrapf(ErrWeakPassword, "password must be at least %d characters", p.minLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.requireMixedCase && !(hasUpper && hasLower) {
+		return errors.Wrap(ErrWeakPassword, "password must contain both upper and lower case letters")
+	}
+	if p.requireDigit && !hasDigit {
+		return errors.Wrap(ErrWeakPassword, "password must contain a digit")
+	}
+	if p.requireSymbol && !hasSymbol {
+		return errors.Wrap(ErrWeakPassword, "password must contain a symbol")
+	}
+	return nil
+}
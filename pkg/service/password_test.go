@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudcarver/anclax/pkg/config"
+	"github.com/cloudcarver/anclax/pkg/utils"
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := newPasswordPolicy(config.PasswordPolicy{
+		MinLength:        utils.Ptr(10),
+		RequireMixedCase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+	})
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  string
+	}{
+		{name: "too short", password: "Ab1!", wantErr: "password must be at least 10 characters"},
+		{name: "missing upper case", password: "abcdefgh1!", wantErr: "password must contain both upper and lower case letters"},
+		{name: "missing lower case", password: "ABCDEFGH1!", wantErr: "password must contain both upper and lower case letters"},
+		{name: "missing digit", password: "Abcdefghi!", wantErr: "password must contain a digit"},
+		{name: "missing symbol", password: "Abcdefghi1", wantErr: "password must contain a symbol"},
+		{name: "strong", password: "Abcdefgh1!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.validate(tt.password)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrWeakPassword)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestDefaultPasswordPolicyOnlyChecksLength(t *testing.T) {
+	policy := newPasswordPolicy(config.PasswordPolicy{})
+	require.ErrorIs(t, policy.validate("short"), ErrWeakPassword)
+	require.NoError(t, policy.validate("longenough"))
+}
+
+func TestWeakPasswordIsRejectedBeforeTouchingUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := &Service{
+		m:              model.NewMockModelInterfaceWithTransaction(ctrl),
+		passwordPolicy: newPasswordPolicy(config.PasswordPolicy{}),
+	}
+
+	_, err := service.CreateNewUserWithTx(context.Background(), nil, "alice", "short")
+	require.ErrorIs(t, err, ErrWeakPassword)
+
+	_, err = service.UpdateUserPassword(context.Background(), "alice", "short")
+	require.ErrorIs(t, err, ErrWeakPassword)
+}
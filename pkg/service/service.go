@@ -24,7 +24,22 @@ type (
 var (
 	ErrUserNotFound                  = errors.New("user not found")
 	ErrInvalidPassword               = errors.New("invalid password")
+	ErrWeakPassword                  = errors.New("weak password")
+	ErrUnsupportedPasswordHash       = errors.New("unsupported password hash")
 	ErrRefreshTokenExpired           = errors.New("refresh token expired")
+	ErrSessionNotFound               = errors.New("session not found")
+	ErrTaskNotFound                  = errors.New("task not found")
+	ErrTaskNotPending                = errors.New("task is not pending")
+	ErrTaskAlreadyRunning            = errors.New("task is already running")
+	ErrTaskNotFinished               = errors.New("task is not finished")
+	ErrTOTPDisabled                  = errors.New("totp is not enabled on this server")
+	ErrTOTPAlreadyEnrolled           = errors.New("totp is already enrolled")
+	ErrTOTPNotEnrolled               = errors.New("totp is not enrolled")
+	ErrTOTPRequired                  = errors.New("totp code is required")
+	ErrInvalidTOTPCode               = errors.New("invalid totp code")
+	ErrTOTPThrottled                 = errors.New("too many invalid totp codes, try again later")
+	ErrOrgMemberNotFound             = errors.New("user is not a member of the organization")
+	ErrRemoveOrgOwner                = errors.New("cannot remove the owner of the organization")
 	ErrDatabaseNotFound              = errors.New("database not found")
 	ErrClusterNotFound               = errors.New("cluster not found")
 	ErrClusterHasDatabaseConnections = errors.New("cluster has database connections")
@@ -38,11 +53,19 @@ const (
 )
 
 type ServiceInterface interface {
-	// Create a new user and its default organization
+	// Create a new user and its default organization. If the user's email domain is
+	// mapped in the auth config, the user joins the mapped org instead.
 	CreateNewUser(ctx context.Context, username, password string) (*UserMeta, error)
 
 	CreateNewUserWithTx(ctx context.Context, tx core.Tx, username, password string) (*UserMeta, error)
 
+	// CreateUserWithHash creates a user like CreateNewUser, but stores the given password
+	// hash and salt as is. It is meant for importing users from another system. The hash
+	// is either produced by utils.HashPassword with the given salt, or a bcrypt or argon2id
+	// hash that carries its own salt. Other hashes are rejected with ErrUnsupportedPasswordHash
+	// since they could never sign in.
+	CreateUserWithHash(ctx context.Context, username, passwordHash, passwordSalt string) (*UserMeta, error)
+
 	GetUserByUserName(ctx context.Context, username string) (*UserMeta, error)
 
 	// IsUsernameExists returns true if the username exists
@@ -57,21 +80,88 @@ type ServiceInterface interface {
 	// SignIn authenticates a user and returns credentials
 	SignIn(ctx context.Context, userID int32) (*apigen.Credentials, error)
 
+	// SignInWithPassword checks the password, and the TOTP code if the user has enrolled TOTP,
+	// before signing the user in. Returns ErrTOTPRequired if the code is missing, and
+	// ErrTOTPDisabled if the user has enrolled TOTP but auth.totpkey is no longer set.
 	SignInWithPassword(ctx context.Context, params apigen.SignInRequest) (*apigen.Credentials, error)
 
+	// EnrollTOTP creates a new TOTP secret for the user and returns it with the otpauth URL
+	// to show as a QR code. The enrollment takes effect after the first successful VerifyTOTP.
+	EnrollTOTP(ctx context.Context, userID int32) (secret string, qrURL string, err error)
+
+	// VerifyTOTP checks the code against the user's TOTP secret, returns ErrInvalidTOTPCode
+	// if it does not match or was already used, and ErrTOTPThrottled after too many wrong
+	// codes in a row.
+	VerifyTOTP(ctx context.Context, userID int32, code string) error
+
 	RefreshToken(ctx context.Context, refreshToken string) (*apigen.Credentials, error)
 
+	// CreateShareLink returns a URL-safe token that grants access to one resource until ttl passes,
+	// routes serving the resource must be protected by auth.ResourceAuthFunc.
+	CreateShareLink(ctx context.Context, resourceType, resourceID string, ttl time.Duration) (string, error)
+
 	ListTasks(ctx context.Context) ([]apigen.Task, error)
 
+	// ListTasksFiltered returns the tasks matching every set field of opts, newest first.
+	ListTasksFiltered(ctx context.Context, opts TaskFilter) ([]apigen.Task, error)
+
 	GetTaskByID(ctx context.Context, id int32) (*apigen.Task, error)
 
+	// DeleteTask soft-deletes a completed, failed or cancelled task and its events, they are
+	// hidden from listings unless IncludeDeleted is set. Returns ErrTaskNotFinished otherwise.
+	DeleteTask(ctx context.Context, id int32) error
+
+	// RestoreTask brings back a task removed by DeleteTask.
+	RestoreTask(ctx context.Context, id int32) error
+
 	ListEvents(ctx context.Context) ([]apigen.Event, error)
 
+	// GetTaskEvents returns the events of the task in the order they happened. Returns
+	// ErrTaskNotFound if the task does not exist.
+	GetTaskEvents(ctx context.Context, taskID int32) ([]apigen.Event, error)
+
+	// ArchiveEvents moves task events created before the given time to the events archive.
+	// Events of tasks that are still pending are kept.
+	ArchiveEvents(ctx context.Context, before time.Time) (int64, error)
+
 	ListOrgs(ctx context.Context, userID int32) ([]apigen.Org, error)
 
+	// ListOrgMembers returns the users of the organization, the owner first, then members
+	// in the order they joined. Deleted users are left out.
+	ListOrgMembers(ctx context.Context, orgID int32) ([]OrgMember, error)
+
+	// TransferOrgOwnership makes a member of the organization its owner. The previous owner
+	// stays in the organization as a regular member. Returns ErrOrgMemberNotFound if the
+	// user is not a member.
+	TransferOrgOwnership(ctx context.Context, orgID, newOwnerUserID int32) error
+
+	// RemoveOrgMember removes the user from the organization and invalidates the user's
+	// tokens. The owner cannot be removed (ErrRemoveOrgOwner), transfer ownership first.
+	RemoveOrgMember(ctx context.Context, orgID, userID int32) error
+
+	// JoinOrgByVerifiedEmail adds the user to the org that Auth.OrgDomains maps the email
+	// domain of the username to, and makes it the user's default org. Call it only after
+	// verifying that the user owns the email. Returns the org ID, or 0 if the domain is not
+	// mapped.
+	JoinOrgByVerifiedEmail(ctx context.Context, userID int32) (int32, error)
+
 	UpdateUserPassword(ctx context.Context, username, password string) (int32, error)
 
-	TryExecuteTask(ctx context.Context, taskID int32) error
+	// ChangePassword sets a new password for the user after verifying the current one, and
+	// invalidates all existing tokens of the user.
+	ChangePassword(ctx context.Context, userID int32, oldPassword, newPassword string) error
+
+	// ListUserSessions returns the active sessions of the user, newest first. The access and
+	// refresh token of a session are listed once.
+	ListUserSessions(ctx context.Context, userID int32) ([]SessionInfo, error)
+
+	// RevokeSession invalidates the access and refresh token of the session with the given
+	// key ID, returns ErrSessionNotFound if it is not an active session of the user.
+	RevokeSession(ctx context.Context, userID int32, keyID int64) error
+
+	// TryExecuteTask runs the pending task of the organization on this worker without waiting
+	// for the next poll. Tasks of other organizations are reported as ErrTaskNotFound.
+	TryExecuteTask(ctx context.Context, orgID, taskID int32) error
 }
 
 type Service struct {
@@ -80,11 +170,17 @@ type Service struct {
 	hooks  hooks.AnclaxHookInterface
 	worker worker.WorkerInterface
 
-	singleSession bool
+	singleSession  bool
+	orgDomains     map[string]int32
+	passwordPolicy passwordPolicy
 
 	timeoutAccessToken  time.Duration
 	timeoutRefreshToken time.Duration
 
+	// totpKey encrypts the TOTP secrets of users, nil if TOTP is disabled
+	totpKey    []byte
+	totpIssuer string
+
 	generateSaltAndHash func(password string) (string, string, error)
 	now                 func() time.Time
 }
@@ -102,7 +198,11 @@ func NewService(
 		now:                 time.Now,
 		generateSaltAndHash: utils.GenerateSaltAndHash,
 		singleSession:       cfg.Auth.SingleSession,
+		orgDomains:          cfg.Auth.OrgDomains,
+		passwordPolicy:      newPasswordPolicy(cfg.Auth.PasswordPolicy),
 		timeoutAccessToken:  utils.UnwrapOrDefault(cfg.Auth.AccessExpiry, auth.DefaultTimeoutAccessToken),
 		timeoutRefreshToken: utils.UnwrapOrDefault(cfg.Auth.RefreshExpiry, auth.DefaultTimeoutRefreshToken),
+		totpKey:             newTOTPKey(cfg.Auth.TOTPKey),
+		totpIssuer:          utils.UnwrapOrDefault(cfg.Auth.TOTPIssuer, defaultTOTPIssuer),
 	}
 }
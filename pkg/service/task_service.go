@@ -2,9 +2,14 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/cloudcarver/anclax/core"
+	"github.com/cloudcarver/anclax/pkg/taskcore/worker"
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
 	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
 	"github.com/cloudcarver/anclax/pkg/zgen/querier"
+	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
 )
 
@@ -34,17 +39,83 @@ func taskToApiTask(task *querier.AnclaxTask) *apigen.Task {
 		CreatedAt:  task.CreatedAt,
 		UpdatedAt:  task.UpdatedAt,
 		UniqueTag:  task.UniqueTag,
+		OrgId:      task.OrgID,
+		Result:     task.Result,
+		DeletedAt:  task.DeletedAt,
 	}
 }
 
+// TaskFilter narrows down the tasks returned by ListTasksFiltered, nil fields are not filtered on.
+type TaskFilter struct {
+	Status       *apigen.TaskStatus
+	Type         *string
+	CreatedAfter *time.Time
+	// IncludeDeleted also returns soft-deleted tasks, they are hidden by default.
+	IncludeDeleted bool
+}
+
 func (s *Service) ListTasks(ctx context.Context) ([]apigen.Task, error) {
-	return nil, errors.New("not implemented")
+	return s.ListTasksFiltered(ctx, TaskFilter{})
+}
+
+func (s *Service) ListTasksFiltered(ctx context.Context, opts TaskFilter) ([]apigen.Task, error) {
+	var status *string
+	if opts.Status != nil {
+		str := string(*opts.Status)
+		status = &str
+	}
+	tasks, err := s.m.ListTasksFiltered(ctx, querier.ListTasksFilteredParams{
+		Status:         status,
+		TaskType:       opts.Type,
+		CreatedAfter:   opts.CreatedAfter,
+		IncludeDeleted: opts.IncludeDeleted,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list tasks")
+	}
+	ret := make([]apigen.Task, len(tasks))
+	for i, task := range tasks {
+		ret[i] = *taskToApiTask(task)
+	}
+	return ret, nil
 }
 
 func (s *Service) ListEvents(ctx context.Context) ([]apigen.Event, error) {
 	return nil, errors.New("not implemented")
 }
 
+func (s *Service) GetTaskEvents(ctx context.Context, taskID int32) ([]apigen.Event, error) {
+	events, err := s.m.ListTaskEvents(ctx, taskID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list events of task %d", taskID)
+	}
+	if len(events) == 0 {
+		if _, err := s.m.GetTaskByID(ctx, taskID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, errors.Wrapf(ErrTaskNotFound, "task %d", taskID)
+			}
+			return nil, errors.Wrapf(err, "failed to get task %d", taskID)
+		}
+	}
+	ret := make([]apigen.Event, len(events))
+	for i, event := range events {
+		ret[i] = apigen.Event{
+			ID:        event.ID,
+			Spec:      event.Spec,
+			CreatedAt: event.CreatedAt,
+		}
+	}
+	return ret, nil
+}
+
+func (s *Service) ArchiveEvents(ctx context.Context, before time.Time) (int64, error) {
+	archived, err := s.m.ArchiveEventsBefore(ctx, before)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to archive events")
+	}
+	return archived, nil
+}
+
 func (s *Service) GetTaskByID(ctx context.Context, id int32) (*apigen.Task, error) {
 	task, err := s.m.GetTaskByID(ctx, id)
 	if err != nil {
@@ -54,6 +125,79 @@ func (s *Service) GetTaskByID(ctx context.Context, id int32) (*apigen.Task, erro
 	return taskToApiTask(task), nil
 }
 
-func (s *Service) TryExecuteTask(ctx context.Context, id int32) error {
-	return s.worker.RunTask(ctx, id)
+// DeleteTask soft-deletes a finished task together with its events.
+// Deleting an already deleted task is a no-op.
+func (s *Service) DeleteTask(ctx context.Context, id int32) error {
+	return s.m.RunTransactionWithTx(ctx, func(tx core.Tx, txm model.ModelInterface) error {
+		deleted, err := txm.SoftDeleteTask(ctx, id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete task %d", id)
+		}
+		if deleted == 0 {
+			task, err := txm.GetTaskByID(ctx, id)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return errors.Wrapf(ErrTaskNotFound, "task %d", id)
+				}
+				return errors.Wrapf(err, "failed to get task %d", id)
+			}
+			if task.DeletedAt != nil {
+				return nil
+			}
+			return errors.Wrapf(ErrTaskNotFinished, "task %d is %s", id, task.Status)
+		}
+		if err := txm.SoftDeleteTaskEvents(ctx, id); err != nil {
+			return errors.Wrapf(err, "failed to delete events of task %d", id)
+		}
+		return nil
+	})
+}
+
+// RestoreTask undoes DeleteTask. Restoring a task that is not deleted is a no-op.
+func (s *Service) RestoreTask(ctx context.Context, id int32) error {
+	return s.m.RunTransactionWithTx(ctx, func(tx core.Tx, txm model.ModelInterface) error {
+		restored, err := txm.RestoreTask(ctx, id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to restore task %d", id)
+		}
+		if restored == 0 {
+			if _, err := txm.GetTaskByID(ctx, id); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return errors.Wrapf(ErrTaskNotFound, "task %d", id)
+				}
+				return errors.Wrapf(err, "failed to get task %d", id)
+			}
+			return nil
+		}
+		if err := txm.RestoreTaskEvents(ctx, id); err != nil {
+			return errors.Wrapf(err, "failed to restore events of task %d", id)
+		}
+		return nil
+	})
+}
+
+// TryExecuteTask runs the task on this worker without waiting for the next poll. It returns
+// ErrTaskNotFound, ErrTaskNotPending or ErrTaskAlreadyRunning if the task cannot be run.
+func (s *Service) TryExecuteTask(ctx context.Context, orgID, id int32) error {
+	task, err := s.m.GetTaskByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.Wrapf(ErrTaskNotFound, "task %d", id)
+		}
+		return errors.Wrapf(err, "failed to get task %d", id)
+	}
+	// do not tell tasks of other organizations apart from missing ones
+	if task.OrgID == nil || *task.OrgID != orgID {
+		return errors.Wrapf(ErrTaskNotFound, "task %d", id)
+	}
+	if task.Status != string(apigen.Pending) {
+		return errors.Wrapf(ErrTaskNotPending, "task %d is %s", id, task.Status)
+	}
+	if err := s.worker.RunTask(ctx, id); err != nil {
+		if errors.Is(err, worker.ErrNoTask) {
+			return errors.Wrapf(ErrTaskAlreadyRunning, "task %d", id)
+		}
+		return errors.Wrapf(err, "failed to run task %d", id)
+	}
+	return nil
 }
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/taskcore/worker"
+	"github.com/cloudcarver/anclax/pkg/utils"
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
+	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
+	"github.com/cloudcarver/anclax/pkg/zgen/querier"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestArchiveEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	before := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().ArchiveEventsBefore(ctx, before).Return(int64(3), nil)
+
+	service := &Service{m: mockModel}
+	archived, err := service.ArchiveEvents(ctx, before)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), archived)
+}
+
+func TestGetTaskEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	retryAt := createdAt.Add(time.Minute)
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().ListTaskEvents(ctx, int32(7)).Return([]*querier.AnclaxEvent{
+		{ID: 3, CreatedAt: createdAt, Spec: apigen.EventSpec{Type: apigen.TaskStarted, TaskStarted: &apigen.EventTaskStarted{TaskID: 7}}},
+		{ID: 5, CreatedAt: createdAt.Add(time.Second), Spec: apigen.EventSpec{Type: apigen.TaskError, TaskError: &apigen.EventTaskError{TaskID: 7, Error: "boom"}}},
+		{ID: 6, CreatedAt: createdAt.Add(time.Second), Spec: apigen.EventSpec{Type: apigen.TaskRetryScheduled, TaskRetryScheduled: &apigen.EventTaskRetryScheduled{TaskID: 7, NextRunAt: retryAt}}},
+		{ID: 9, CreatedAt: retryAt, Spec: apigen.EventSpec{Type: apigen.TaskCompleted, TaskCompleted: &apigen.EventTaskCompleted{TaskID: 7}}},
+	}, nil)
+
+	service := &Service{m: mockModel}
+	events, err := service.GetTaskEvents(ctx, 7)
+	require.NoError(t, err)
+
+	types := make([]apigen.EventSpecType, len(events))
+	for i, event := range events {
+		types[i] = event.Spec.Type
+	}
+	require.Equal(t, []apigen.EventSpecType{apigen.TaskStarted, apigen.TaskError, apigen.TaskRetryScheduled, apigen.TaskCompleted}, types)
+	require.Equal(t, int32(9), events[3].ID)
+	require.Equal(t, retryAt, events[3].CreatedAt)
+	require.Equal(t, retryAt, events[2].Spec.TaskRetryScheduled.NextRunAt)
+}
+
+func TestGetTaskEventsWithoutEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterface(ctrl)
+	service := &Service{m: mockModel}
+
+	mockModel.EXPECT().ListTaskEvents(ctx, int32(7)).Return(nil, nil)
+	mockModel.EXPECT().GetTaskByID(ctx, int32(7)).Return(&querier.AnclaxTask{ID: 7}, nil)
+	events, err := service.GetTaskEvents(ctx, 7)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	mockModel.EXPECT().ListTaskEvents(ctx, int32(8)).Return(nil, nil)
+	mockModel.EXPECT().GetTaskByID(ctx, int32(8)).Return(nil, pgx.ErrNoRows)
+	_, err = service.GetTaskEvents(ctx, 8)
+	require.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestListTasksFilteredByStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().ListTasksFiltered(ctx, querier.ListTasksFilteredParams{
+		Status: utils.Ptr(string(apigen.Failed)),
+	}).Return([]*querier.AnclaxTask{
+		{ID: 2, Status: string(apigen.Failed)},
+		{ID: 1, Status: string(apigen.Failed)},
+	}, nil)
+
+	service := &Service{m: mockModel}
+	tasks, err := service.ListTasksFiltered(ctx, TaskFilter{Status: utils.Ptr(apigen.Failed)})
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	for _, task := range tasks {
+		require.Equal(t, apigen.Failed, task.Status)
+	}
+	require.Equal(t, int32(2), tasks[0].ID)
+}
+
+func TestListTasksHasNoFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().ListTasksFiltered(ctx, querier.ListTasksFilteredParams{}).Return([]*querier.AnclaxTask{
+		{ID: 1, Status: string(apigen.Pending)},
+	}, nil)
+
+	service := &Service{m: mockModel}
+	tasks, err := service.ListTasks(ctx)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+}
+
+func TestListTasksIncludeDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	deletedAt := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().ListTasksFiltered(ctx, querier.ListTasksFilteredParams{IncludeDeleted: true}).Return([]*querier.AnclaxTask{
+		{ID: 2, Status: string(apigen.Pending)},
+		{ID: 1, Status: string(apigen.Completed), DeletedAt: &deletedAt},
+	}, nil)
+
+	service := &Service{m: mockModel}
+	tasks, err := service.ListTasksFiltered(ctx, TaskFilter{IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	require.Nil(t, tasks[0].DeletedAt)
+	require.Equal(t, &deletedAt, tasks[1].DeletedAt)
+}
+
+func TestDeleteTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockModel.EXPECT().SoftDeleteTask(ctx, int32(7)).Return(int64(1), nil)
+	mockModel.EXPECT().SoftDeleteTaskEvents(ctx, int32(7)).Return(nil)
+
+	service := &Service{m: mockModel}
+	require.NoError(t, service.DeleteTask(ctx, 7))
+}
+
+func TestDeleteTaskErrors(t *testing.T) {
+	deletedAt := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		task     *querier.AnclaxTask
+		getErr   error
+		expected error
+	}{
+		{
+			name:     "not found",
+			getErr:   pgx.ErrNoRows,
+			expected: ErrTaskNotFound,
+		},
+		{
+			name:     "not finished",
+			task:     &querier.AnclaxTask{ID: 7, Status: string(apigen.Pending)},
+			expected: ErrTaskNotFinished,
+		},
+		{
+			name: "already deleted",
+			task: &querier.AnclaxTask{ID: 7, Status: string(apigen.Completed), DeletedAt: &deletedAt},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+			mockModel.EXPECT().SoftDeleteTask(ctx, int32(7)).Return(int64(0), nil)
+			mockModel.EXPECT().GetTaskByID(ctx, int32(7)).Return(tc.task, tc.getErr)
+
+			service := &Service{m: mockModel}
+			err := service.DeleteTask(ctx, 7)
+			if tc.expected == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestRestoreTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockModel.EXPECT().RestoreTask(ctx, int32(7)).Return(int64(1), nil)
+	mockModel.EXPECT().RestoreTaskEvents(ctx, int32(7)).Return(nil)
+
+	service := &Service{m: mockModel}
+	require.NoError(t, service.RestoreTask(ctx, 7))
+}
+
+func TestGetTaskByIDExposesResult(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetTaskByID(ctx, int32(7)).Return(&querier.AnclaxTask{
+		ID:     7,
+		Status: string(apigen.Completed),
+		Result: []byte(`{"url":"s3://reports/7"}`),
+	}, nil)
+
+	service := &Service{m: mockModel}
+	task, err := service.GetTaskByID(ctx, 7)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"url":"s3://reports/7"}`, string(task.Result))
+}
+
+func TestTryExecuteTaskRunsPendingTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockWorker := worker.NewMockWorkerInterface(ctrl)
+	mockModel.EXPECT().GetTaskByID(ctx, int32(7)).Return(&querier.AnclaxTask{ID: 7, OrgID: utils.Ptr(int32(1)), Status: string(apigen.Pending)}, nil)
+	mockWorker.EXPECT().RunTask(ctx, int32(7)).Return(nil)
+
+	service := &Service{m: mockModel, worker: mockWorker}
+	require.NoError(t, service.TryExecuteTask(ctx, 1, 7))
+}
+
+func TestTryExecuteTaskErrors(t *testing.T) {
+	testCases := []struct {
+		name      string
+		task      *querier.AnclaxTask
+		getErr    error
+		runErr    error
+		expectRun bool
+		expected  error
+	}{
+		{
+			name:     "not found",
+			getErr:   pgx.ErrNoRows,
+			expected: ErrTaskNotFound,
+		},
+		{
+			name:     "task of another org",
+			task:     &querier.AnclaxTask{ID: 7, OrgID: utils.Ptr(int32(2)), Status: string(apigen.Pending)},
+			expected: ErrTaskNotFound,
+		},
+		{
+			name:     "task without org",
+			task:     &querier.AnclaxTask{ID: 7, Status: string(apigen.Pending)},
+			expected: ErrTaskNotFound,
+		},
+		{
+			name:     "not pending",
+			task:     &querier.AnclaxTask{ID: 7, OrgID: utils.Ptr(int32(1)), Status: string(apigen.Completed)},
+			expected: ErrTaskNotPending,
+		},
+		{
+			name:      "already running",
+			task:      &querier.AnclaxTask{ID: 7, OrgID: utils.Ptr(int32(1)), Status: string(apigen.Pending)},
+			runErr:    worker.ErrNoTask,
+			expectRun: true,
+			expected:  ErrTaskAlreadyRunning,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			mockModel := model.NewMockModelInterface(ctrl)
+			mockWorker := worker.NewMockWorkerInterface(ctrl)
+			mockModel.EXPECT().GetTaskByID(ctx, int32(7)).Return(tc.task, tc.getErr)
+			if tc.expectRun {
+				mockWorker.EXPECT().RunTask(ctx, int32(7)).Return(tc.runErr)
+			}
+
+			service := &Service{m: mockModel, worker: mockWorker}
+			require.ErrorIs(t, service.TryExecuteTask(ctx, 1, 7), tc.expected)
+		})
+	}
+}
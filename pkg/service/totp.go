@@ -0,0 +1,140 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTOTPIssuer = "anclax"
+
+	totpSecretSize = 20
+	totpDigits     = 6
+	totpModulo     = 1000000 // 10^totpDigits
+	totpPeriod     = 30 * time.Second
+	// totpSkew is the number of periods before and after the current one whose codes are
+	// still accepted, to tolerate clock drift between the server and the authenticator.
+	totpSkew = 1
+
+	// totpMaxAttempts wrong codes in a row lock the user's TOTP for totpLockout.
+	totpMaxAttempts = 5
+	totpLockout     = 5 * time.Minute
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newTOTPKey derives the key that encrypts TOTP secrets at rest from auth.totpkey, it
+// returns nil if TOTP is disabled.
+func newTOTPKey(secret string) []byte {
+	if secret == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+func newTOTPAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+func encryptTOTPSecret(key, secret []byte) ([]byte, error) {
+	aead, err := newTOTPAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to generate nonce")
+	}
+	return aead.Seal(nonce, nonce, secret, nil), nil
+}
+
+func decryptTOTPSecret(key, ciphertext []byte) ([]byte, error) {
+	aead, err := newTOTPAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	size := aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, errors.New("encrypted totp secret is too short")
+	}
+	secret, err := aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt totp secret")
+	}
+	return secret, nil
+}
+
+func generateTOTPSecret() ([]byte, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errors.Wrap(err, "failed to generate secret")
+	}
+	return secret, nil
+}
+
+// totpURL returns the otpauth URL that authenticator apps read from a QR code.
+func totpURL(issuer, account string, secret []byte) string {
+	v := url.Values{}
+	v.Set("secret", totpEncoding.EncodeToString(secret))
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(totpDigits))
+	v.Set("period", fmt.Sprint(int(totpPeriod/time.Second)))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// totpCode computes the RFC 6238 code of the period containing t.
+func totpCode(secret []byte, t time.Time) string {
+	return hotpCode(secret, uint64(totpStep(t)))
+}
+
+// totpStep returns the RFC 6238 time-step of the period containing t.
+func totpStep(t time.Time) int64 {
+	return t.Unix() / int64(totpPeriod/time.Second)
+}
+
+func hotpCode(secret []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%totpModulo)
+}
+
+// validateTOTPCode reports whether code is the code of the period containing now or of one
+// of the totpSkew periods around it, and returns the time-step it matched.
+func validateTOTPCode(secret []byte, code string, now time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := totpStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected := hotpCode(secret, uint64(step))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
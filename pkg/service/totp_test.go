@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/utils"
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
+	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
+	"github.com/cloudcarver/anclax/pkg/zgen/querier"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	// test vectors of RFC 6238 appendix B truncated to 6 digits
+	secret := []byte("12345678901234567890")
+	require.Equal(t, "287082", totpCode(secret, time.Unix(59, 0)))
+	require.Equal(t, "081804", totpCode(secret, time.Unix(1111111109, 0)))
+	require.Equal(t, "050471", totpCode(secret, time.Unix(1111111111, 0)))
+}
+
+func TestEnrollAndVerifyTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := int32(101)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var stored []byte
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetUser(ctx, userID).Return(&querier.AnclaxUser{ID: userID, Name: "alice"}, nil)
+	mockModel.EXPECT().UpsertUserTotpSecret(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, arg querier.UpsertUserTotpSecretParams) (int64, error) {
+			require.Equal(t, userID, arg.UserID)
+			stored = arg.Secret
+			return 1, nil
+		},
+	)
+
+	svc := &Service{
+		m:          mockModel,
+		now:        func() time.Time { return now },
+		totpKey:    newTOTPKey("test-key"),
+		totpIssuer: defaultTOTPIssuer,
+	}
+	secret, qrURL, err := svc.EnrollTOTP(ctx, userID)
+	require.NoError(t, err)
+
+	rawSecret, err := totpEncoding.DecodeString(secret)
+	require.NoError(t, err)
+	require.NotContains(t, string(stored), string(rawSecret), "secret must be stored encrypted")
+
+	u, err := url.Parse(qrURL)
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", u.Scheme)
+	require.Equal(t, "totp", u.Host)
+	require.Equal(t, "/anclax:alice", u.Path)
+	require.Equal(t, secret, u.Query().Get("secret"))
+
+	mockModel.EXPECT().GetUserTotpSecret(ctx, userID).Return(&querier.AnclaxUserTotpSecret{UserID: userID, Secret: stored}, nil)
+	mockModel.EXPECT().AcceptUserTotpStep(ctx, querier.AcceptUserTotpStepParams{UserID: userID, LastStep: utils.Ptr(totpStep(now))}).Return(int64(1), nil)
+	mockModel.EXPECT().EnableUserTotpSecret(ctx, userID).Return(nil)
+	require.NoError(t, svc.VerifyTOTP(ctx, userID, totpCode(rawSecret, now)))
+}
+
+func TestVerifyTOTPRejectsInvalidAndExpiredCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := int32(101)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := newTOTPKey("test-key")
+	secret := []byte("12345678901234567890")
+	encrypted, err := encryptTOTPSecret(key, secret)
+	require.NoError(t, err)
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetUserTotpSecret(ctx, userID).Return(&querier.AnclaxUserTotpSecret{
+		UserID:    userID,
+		Secret:    encrypted,
+		EnabledAt: &now,
+	}, nil).AnyTimes()
+
+	mockModel.EXPECT().AcceptUserTotpStep(ctx, querier.AcceptUserTotpStepParams{UserID: userID, LastStep: utils.Ptr(totpStep(now) - 1)}).Return(int64(1), nil)
+	mockModel.EXPECT().RecordUserTotpFailure(ctx, querier.RecordUserTotpFailureParams{
+		UserID:      userID,
+		MaxAttempts: totpMaxAttempts,
+		LockedUntil: now.Add(totpLockout),
+	}).Return(nil).Times(3)
+
+	svc := &Service{
+		m:       mockModel,
+		now:     func() time.Time { return now },
+		totpKey: key,
+	}
+
+	require.NoError(t, svc.VerifyTOTP(ctx, userID, totpCode(secret, now.Add(-totpPeriod))), "previous period is within the skew")
+	require.ErrorIs(t, svc.VerifyTOTP(ctx, userID, totpCode(secret, now.Add(-3*totpPeriod))), ErrInvalidTOTPCode)
+	require.ErrorIs(t, svc.VerifyTOTP(ctx, userID, "000000"), ErrInvalidTOTPCode)
+	require.ErrorIs(t, svc.VerifyTOTP(ctx, userID, "abc"), ErrInvalidTOTPCode)
+}
+
+func TestVerifyTOTPRejectsReplayedCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := int32(101)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := newTOTPKey("test-key")
+	secret := []byte("12345678901234567890")
+	encrypted, err := encryptTOTPSecret(key, secret)
+	require.NoError(t, err)
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetUserTotpSecret(ctx, userID).Return(&querier.AnclaxUserTotpSecret{
+		UserID:    userID,
+		Secret:    encrypted,
+		EnabledAt: &now,
+	}, nil).AnyTimes()
+	var lastStep *int64
+	mockModel.EXPECT().AcceptUserTotpStep(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, arg querier.AcceptUserTotpStepParams) (int64, error) {
+			if lastStep != nil && *lastStep >= *arg.LastStep {
+				return 0, nil
+			}
+			lastStep = arg.LastStep
+			return 1, nil
+		},
+	).Times(3)
+
+	svc := &Service{
+		m:       mockModel,
+		now:     func() time.Time { return now },
+		totpKey: key,
+	}
+
+	code := totpCode(secret, now)
+	require.NoError(t, svc.VerifyTOTP(ctx, userID, code))
+	require.ErrorIs(t, svc.VerifyTOTP(ctx, userID, code), ErrInvalidTOTPCode, "a code is accepted once")
+	require.ErrorIs(t, svc.VerifyTOTP(ctx, userID, totpCode(secret, now.Add(-totpPeriod))), ErrInvalidTOTPCode, "an earlier step is rejected")
+}
+
+func TestVerifyTOTPThrottled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := int32(101)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := newTOTPKey("test-key")
+	secret := []byte("12345678901234567890")
+	encrypted, err := encryptTOTPSecret(key, secret)
+	require.NoError(t, err)
+
+	lockedUntil := now.Add(time.Minute)
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetUserTotpSecret(ctx, userID).Return(&querier.AnclaxUserTotpSecret{
+		UserID:      userID,
+		Secret:      encrypted,
+		EnabledAt:   &now,
+		LockedUntil: &lockedUntil,
+	}, nil).Times(2)
+
+	svc := &Service{
+		m:       mockModel,
+		now:     func() time.Time { return now },
+		totpKey: key,
+	}
+	require.ErrorIs(t, svc.VerifyTOTP(ctx, userID, totpCode(secret, now)), ErrTOTPThrottled, "a valid code is refused while locked")
+
+	svc.now = func() time.Time { return lockedUntil }
+	mockModel.EXPECT().AcceptUserTotpStep(ctx, querier.AcceptUserTotpStepParams{UserID: userID, LastStep: utils.Ptr(totpStep(lockedUntil))}).Return(int64(1), nil)
+	require.NoError(t, svc.VerifyTOTP(ctx, userID, totpCode(secret, lockedUntil)))
+}
+
+func TestEnrollTOTPErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := int32(101)
+	mockModel := model.NewMockModelInterface(ctrl)
+
+	_, _, err := (&Service{m: mockModel}).EnrollTOTP(ctx, userID)
+	require.ErrorIs(t, err, ErrTOTPDisabled)
+
+	mockModel.EXPECT().GetUser(ctx, userID).Return(&querier.AnclaxUser{ID: userID, Name: "alice"}, nil)
+	mockModel.EXPECT().UpsertUserTotpSecret(ctx, gomock.Any()).Return(int64(0), nil)
+	_, _, err = (&Service{m: mockModel, totpKey: newTOTPKey("test-key")}).EnrollTOTP(ctx, userID)
+	require.ErrorIs(t, err, ErrTOTPAlreadyEnrolled)
+
+	mockModel.EXPECT().GetUserTotpSecret(ctx, userID).Return(nil, pgx.ErrNoRows)
+	err = (&Service{m: mockModel, totpKey: newTOTPKey("test-key")}).VerifyTOTP(ctx, userID, "123456")
+	require.ErrorIs(t, err, ErrTOTPNotEnrolled)
+}
+
+func TestSignInWithPasswordRequiresTOTPOnceEnrolled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := int32(101)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := newTOTPKey("test-key")
+	secret := []byte("12345678901234567890")
+	encrypted, err := encryptTOTPSecret(key, secret)
+	require.NoError(t, err)
+	hash, err := utils.HashPassword("secret", "salt")
+	require.NoError(t, err)
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetUserByName(ctx, "alice").Return(&querier.AnclaxUser{
+		ID:           userID,
+		Name:         "alice",
+		PasswordHash: hash,
+		PasswordSalt: "salt",
+	}, nil).Times(3)
+	mockModel.EXPECT().GetUserTotpSecret(ctx, userID).Return(&querier.AnclaxUserTotpSecret{
+		UserID:    userID,
+		Secret:    encrypted,
+		EnabledAt: &now,
+	}, nil).Times(3)
+	mockModel.EXPECT().RecordUserTotpFailure(ctx, gomock.Any()).Return(nil)
+
+	svc := &Service{
+		m:       mockModel,
+		now:     func() time.Time { return now },
+		totpKey: key,
+	}
+	_, err = svc.SignInWithPassword(ctx, apigen.SignInRequest{Name: "alice", Password: "secret"})
+	require.ErrorIs(t, err, ErrTOTPRequired)
+
+	_, err = svc.SignInWithPassword(ctx, apigen.SignInRequest{Name: "alice", Password: "secret", TotpCode: utils.Ptr("000000")})
+	require.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	// the key was removed from the config after the user enrolled
+	svc.totpKey = nil
+	_, err = svc.SignInWithPassword(ctx, apigen.SignInRequest{Name: "alice", Password: "secret", TotpCode: utils.Ptr(totpCode(secret, now))})
+	require.ErrorIs(t, err, ErrTOTPDisabled)
+}
@@ -46,7 +46,7 @@ func main() {
 	}
 	taskStore := store.NewTaskStore(m)
 	runner := taskgen.NewTaskRunner(taskStore)
-	executor := asynctask.NewExecutor(cfg, m, runner)
+	executor := asynctask.NewExecutor(cfg, m, runner, nil)
 	handler := taskgen.NewTaskHandler(executor)
 	gctx := globalctx.New()
 	w, err := worker.NewWorkerFromConfig(gctx, cfg, m, handler)
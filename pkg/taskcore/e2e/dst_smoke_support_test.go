@@ -163,6 +163,10 @@ func (h *smokeWorkerHandler) OnTaskFailed(ctx context.Context, tx core.Tx, faile
 	return nil
 }
 
+func (h *smokeWorkerHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	return nil
+}
+
 func (h *smokeWorkerHandler) release() {
 	signalOnce(h.proceedCh)
 }
@@ -207,6 +211,10 @@ func (h *retryWorkerHandler) OnTaskFailed(ctx context.Context, tx core.Tx, faile
 	return nil
 }
 
+func (h *retryWorkerHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	return nil
+}
+
 func (h *retryWorkerHandler) firstAttempt() <-chan struct{} {
 	return h.firstAttemptCh
 }
@@ -237,6 +245,10 @@ func (h *cronWorkerHandler) OnTaskFailed(ctx context.Context, tx core.Tx, failed
 	return nil
 }
 
+func (h *cronWorkerHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	return nil
+}
+
 func (h *cronWorkerHandler) ran() <-chan struct{} {
 	return h.ranCh
 }
@@ -253,6 +265,10 @@ func (h *noopWorkerHandler) OnTaskFailed(ctx context.Context, tx core.Tx, failed
 	return nil
 }
 
+func (h *noopWorkerHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	return nil
+}
+
 type blockingWorkerHandler struct {
 	taskType  string
 	startedCh chan struct{}
@@ -290,6 +306,10 @@ func (h *blockingWorkerHandler) OnTaskFailed(ctx context.Context, tx core.Tx, fa
 	return nil
 }
 
+func (h *blockingWorkerHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	return nil
+}
+
 func (h *blockingWorkerHandler) release() {
 	signalOnce(h.releaseCh)
 }
@@ -325,6 +345,10 @@ func (h *signalWorkerHandler) OnTaskFailed(ctx context.Context, tx core.Tx, fail
 	return nil
 }
 
+func (h *signalWorkerHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	return nil
+}
+
 func (h *signalWorkerHandler) done() <-chan struct{} {
 	return h.doneCh
 }
@@ -353,6 +377,10 @@ func (h *failureWorkerHandler) OnTaskFailed(ctx context.Context, tx core.Tx, fai
 	return nil
 }
 
+func (h *failureWorkerHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	return nil
+}
+
 func (h *failureWorkerHandler) failed() <-chan struct{} {
 	return h.failedCh
 }
@@ -612,7 +640,7 @@ func (a *runtimeActor) StartWorker(
 	if err != nil {
 		return err
 	}
-	executor := asynctask.NewExecutor(cfg, a.model, taskgen.NewTaskRunner(taskcore.NewTaskStore(a.model)))
+	executor := asynctask.NewExecutor(cfg, a.model, taskgen.NewTaskRunner(taskcore.NewTaskStore(a.model)), nil)
 	compositeHandler := taskgen.NewTaskHandler(executor)
 	compositeHandler.RegisterTaskHandler(baseHandler)
 
@@ -1468,6 +1496,10 @@ func (h *runtimeCaptureWorkerHandler) OnTaskFailed(ctx context.Context, tx core.
 	return nil
 }
 
+func (h *runtimeCaptureWorkerHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	return nil
+}
+
 type runtimeIdempotentFailOnceHandler struct {
 	taskType string
 
@@ -1520,6 +1552,10 @@ func (h *runtimeIdempotentFailOnceHandler) OnTaskFailed(ctx context.Context, tx
 	return nil
 }
 
+func (h *runtimeIdempotentFailOnceHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	return nil
+}
+
 func (h *runtimeIdempotentFailOnceHandler) AttemptCount(task string) int {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -1617,3 +1653,7 @@ func (h *runtimeContentionWorkerHandler) RegisterTaskHandler(handler worker.Task
 func (h *runtimeContentionWorkerHandler) OnTaskFailed(ctx context.Context, tx core.Tx, failedTaskSpec worker.TaskSpec, taskID int32) error {
 	return nil
 }
+
+func (h *runtimeContentionWorkerHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	return nil
+}
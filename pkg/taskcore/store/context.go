@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/cloudcarver/anclax/pkg/orgctx"
+	"github.com/pkg/errors"
+)
+
+type taskIDContextKey struct{}
+
+type taskResultContextKey struct{}
+
+// ContextWithOrgID returns a context in which pushed tasks belong to the organization unless
+// WithOrgID overrides it. The auth middleware sets it for authenticated requests, and the
+// worker for the handler of a task that belongs to an organization.
+func ContextWithOrgID(ctx context.Context, orgID int32) context.Context {
+	return orgctx.WithOrgID(ctx, orgID)
+}
+
+// OrgIDFromContext returns the organization set by ContextWithOrgID.
+func OrgIDFromContext(ctx context.Context) (int32, bool) {
+	return orgctx.OrgID(ctx)
+}
+
+// ContextWithTaskID returns a context carrying the ID of the task being run. The worker
+// sets it for task handlers.
+func ContextWithTaskID(ctx context.Context, taskID int32) context.Context {
+	return context.WithValue(ctx, taskIDContextKey{}, taskID)
+}
+
+// TaskIDFromContext returns the ID of the task whose handler is running, so handlers can
+// log and correlate without carrying the ID in their payload.
+func TaskIDFromContext(ctx context.Context) (int32, bool) {
+	taskID, ok := ctx.Value(taskIDContextKey{}).(int32)
+	return taskID, ok
+}
+
+// TaskResult holds the result a task handler sets with SetResult. The worker persists it
+// when the task completes.
+type TaskResult struct {
+	mu    sync.Mutex
+	value json.RawMessage
+}
+
+// Value returns the JSON of the result, or nil if the handler did not set one.
+func (r *TaskResult) Value() json.RawMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.value
+}
+
+// ContextWithTaskResult returns a context in which SetResult stores into r.
+func ContextWithTaskResult(ctx context.Context, r *TaskResult) context.Context {
+	return context.WithValue(ctx, taskResultContextKey{}, r)
+}
+
+// SetResult records the output of the running task, e.g. the URL of a generated report. It
+// is stored as JSON on the task row once the task completes and is discarded if the task
+// fails. Later calls replace the result.
+func SetResult(ctx context.Context, result any) error {
+	r, ok := ctx.Value(taskResultContextKey{}).(*TaskResult)
+	if !ok {
+		return ErrNoTaskResult
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal task result")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.value = raw
+	return nil
+}
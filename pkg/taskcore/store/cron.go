@@ -0,0 +1,228 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+)
+
+const cronPreviewRuns = 5
+
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+var (
+	cronMonthNames   = []string{"", "January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	cronWeekdayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+	cronUnitPrepositions = map[string]string{
+		"second":       "at",
+		"minute":       "at",
+		"hour":         "at",
+		"day-of-month": "on",
+		"month":        "in",
+		"day-of-week":  "on",
+	}
+)
+
+// cronLocation loads the IANA time zone a cron expression is evaluated in, falling back to
+// def when timezone is unset.
+func cronLocation(timezone *string, def *time.Location) (*time.Location, error) {
+	if timezone == nil || *timezone == "" {
+		return def, nil
+	}
+	loc, err := time.LoadLocation(*timezone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid cron timezone %q", *timezone)
+	}
+	return loc, nil
+}
+
+// parseCron parses a cron expression in the format used by cronjob tasks.
+func parseCron(expr string) (cron.Schedule, error) {
+	schedule, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression, format should be like second minute hour dayOfMonth month dayOfWeek")
+	}
+	return schedule, nil
+}
+
+// nextCronRun returns the next fire time of schedule after now, evaluated in timezone, or in
+// the location of now when timezone is unset.
+func nextCronRun(schedule cron.Schedule, timezone *string, now time.Time) (time.Time, error) {
+	loc, err := cronLocation(timezone, now.Location())
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(now.In(loc)), nil
+}
+
+// NextCronTime returns the next fire time of the cronjob after now. The expression is
+// evaluated in the cronjob timezone, or in the location of now when none is set.
+func NextCronTime(cronjob *apigen.TaskCronjob, now time.Time) (time.Time, error) {
+	schedule, err := parseCron(cronjob.CronExpression)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return nextCronRun(schedule, cronjob.Timezone, now)
+}
+
+// firstCronRun returns the started_at of a cronjob's first execution: now when it runs on
+// start, otherwise the next fire time of schedule in the cronjob timezone.
+func firstCronRun(schedule cron.Schedule, cronjob *apigen.TaskCronjob, now time.Time) (time.Time, error) {
+	next, err := nextCronRun(schedule, cronjob.Timezone, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if cronjob.RunOnStart != nil && *cronjob.RunOnStart {
+		return now, nil
+	}
+	return next, nil
+}
+
+// DescribeCron validates a cron expression in the format used by cronjob tasks
+// (second minute hour dayOfMonth month dayOfWeek), and returns its next fire
+// times and a human readable description of the schedule.
+func DescribeCron(expr string) ([]time.Time, string, error) {
+	return describeCron(expr, time.Now(), cronPreviewRuns)
+}
+
+func describeCron(expr string, now time.Time, runs int) ([]time.Time, string, error) {
+	schedule, err := parseCron(expr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextRuns := make([]time.Time, 0, runs)
+	next := now
+	for i := 0; i < runs; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		nextRuns = append(nextRuns, next)
+	}
+
+	timezone, fields := splitCronTimezone(expr)
+	human := describeCronFields(strings.Fields(fields))
+	if timezone != "" {
+		human += ", in " + timezone
+	}
+	return nextRuns, human, nil
+}
+
+// splitCronTimezone splits the optional CRON_TZ= or TZ= prefix accepted by the cron parser
+// off an expression, returning the zone and the remaining fields.
+func splitCronTimezone(expr string) (string, string) {
+	expr = strings.TrimSpace(expr)
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(expr, prefix) {
+			timezone, fields, _ := strings.Cut(expr[len(prefix):], " ")
+			return timezone, strings.TrimSpace(fields)
+		}
+	}
+	return "", expr
+}
+
+func describeCronFields(fields []string) string {
+	second, minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5]
+
+	var parts []string
+	switch {
+	case isSingleCronValue(second) && isSingleCronValue(minute) && isSingleCronValue(hour):
+		parts = append(parts, fmt.Sprintf("at %s:%s:%s", padCronValue(hour), padCronValue(minute), padCronValue(second)))
+	case isSingleCronValue(second) && isSingleCronValue(minute) && isWildcardCronValue(hour):
+		parts = append(parts, fmt.Sprintf("at %s:%s past every hour", padCronValue(minute), padCronValue(second)))
+	default:
+		if isWildcardCronValue(second) {
+			parts = append(parts, "every second")
+		} else {
+			parts = append(parts, describeCronField(second, "second", nil))
+		}
+		if !isWildcardCronValue(minute) {
+			parts = append(parts, describeCronField(minute, "minute", nil))
+		}
+		if !isWildcardCronValue(hour) {
+			parts = append(parts, describeCronField(hour, "hour", nil))
+		}
+	}
+
+	if !isWildcardCronValue(dom) {
+		parts = append(parts, describeCronField(dom, "day-of-month", nil))
+	}
+	if !isWildcardCronValue(month) {
+		parts = append(parts, describeCronField(month, "month", cronMonthNames))
+	}
+	if !isWildcardCronValue(dow) {
+		parts = append(parts, describeCronField(dow, "day-of-week", cronWeekdayNames))
+	}
+	if isWildcardCronValue(dom) && isWildcardCronValue(month) && isWildcardCronValue(dow) && !isWildcardCronValue(hour) {
+		parts = append(parts, "every day")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func describeCronField(field, unit string, names []string) string {
+	items := strings.Split(field, ",")
+	described := make([]string, 0, len(items))
+	for _, item := range items {
+		described = append(described, describeCronItem(item, unit, names))
+	}
+	if len(described) == 1 {
+		return described[0]
+	}
+	return strings.Join(described[:len(described)-1], ", ") + " and " + described[len(described)-1]
+}
+
+func describeCronItem(item, unit string, names []string) string {
+	prefix := cronUnitPrepositions[unit] + " "
+	if names == nil {
+		prefix += unit + " "
+	}
+	rangePart, step, hasStep := strings.Cut(item, "/")
+	start, end, hasRange := strings.Cut(rangePart, "-")
+	switch {
+	case hasStep && isWildcardCronValue(rangePart):
+		return fmt.Sprintf("every %s %ss", step, unit)
+	case hasStep && hasRange:
+		return fmt.Sprintf("every %s %ss from %s through %s", step, unit, cronValueName(start, names), cronValueName(end, names))
+	case hasStep:
+		return fmt.Sprintf("every %s %ss starting at %s", step, unit, cronValueName(rangePart, names))
+	case hasRange:
+		return prefix + cronValueName(start, names) + " through " + cronValueName(end, names)
+	default:
+		return prefix + cronValueName(item, names)
+	}
+}
+
+func cronValueName(value string, names []string) string {
+	if names == nil {
+		return value
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n >= len(names) || names[n] == "" {
+		return strings.ToUpper(value)
+	}
+	return names[n]
+}
+
+func isWildcardCronValue(field string) bool {
+	return field == "*" || field == "?"
+}
+
+func isSingleCronValue(field string) bool {
+	_, err := strconv.Atoi(field)
+	return err == nil
+}
+
+func padCronValue(field string) string {
+	if len(field) == 1 {
+		return "0" + field
+	}
+	return field
+}
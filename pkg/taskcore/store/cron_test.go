@@ -0,0 +1,107 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeCronWeekdays(t *testing.T) {
+	// Friday
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+
+	nextRuns, human, err := describeCron("0 30 9 * * 1-5", now, 5)
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC),
+		time.Date(2026, 10, 20, 9, 30, 0, 0, time.UTC),
+		time.Date(2026, 10, 21, 9, 30, 0, 0, time.UTC),
+		time.Date(2026, 10, 22, 9, 30, 0, 0, time.UTC),
+		time.Date(2026, 10, 23, 9, 30, 0, 0, time.UTC),
+	}, nextRuns)
+	require.Equal(t, "at 09:30:00, on Monday through Friday", human)
+}
+
+func TestDescribeCronHumanDescriptions(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		expr  string
+		human string
+	}{
+		{expr: "* * * * * *", human: "every second"},
+		{expr: "*/5 * * * * *", human: "every 5 seconds"},
+		{expr: "0 */15 * * * *", human: "at second 0, every 15 minutes"},
+		{expr: "0 0 * * * *", human: "at 00:00 past every hour"},
+		{expr: "0 0 0 * * *", human: "at 00:00:00, every day"},
+		{expr: "0 0 12 1 1,7 *", human: "at 12:00:00, on day-of-month 1, in January and in July"},
+		{expr: "0 0 8 * * SUN", human: "at 08:00:00, on SUN"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			nextRuns, human, err := describeCron(tc.expr, now, 3)
+			require.NoError(t, err)
+			require.Len(t, nextRuns, 3)
+			require.True(t, nextRuns[0].After(now))
+			require.Equal(t, tc.human, human)
+		})
+	}
+}
+
+func TestDescribeCronTimezonePrefix(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	nextRuns, human, err := describeCron("CRON_TZ=Asia/Tokyo 0 30 9 * * 1-5", now, 1)
+	require.NoError(t, err)
+	require.Len(t, nextRuns, 1)
+	require.True(t, nextRuns[0].Equal(time.Date(2026, 10, 19, 9, 30, 0, 0, tokyo)))
+	require.Equal(t, "at 09:30:00, on Monday through Friday, in Asia/Tokyo", human)
+
+	_, human, err = describeCron("TZ=UTC 0 0 0 * * *", now, 1)
+	require.NoError(t, err)
+	require.Equal(t, "at 00:00:00, every day, in UTC", human)
+}
+
+func TestDescribeCronEveryFiveSeconds(t *testing.T) {
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	nextRuns, _, err := describeCron("*/5 * * * * *", now, 3)
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2025, 3, 31, 12, 0, 5, 0, time.UTC),
+		time.Date(2025, 3, 31, 12, 0, 10, 0, time.UTC),
+		time.Date(2025, 3, 31, 12, 0, 15, 0, time.UTC),
+	}, nextRuns)
+}
+
+func TestDescribeCronInvalidExpression(t *testing.T) {
+	for _, expr := range []string{"", "* * * * *", "61 * * * * *", "not a cron"} {
+		t.Run(expr, func(t *testing.T) {
+			nextRuns, human, err := DescribeCron(expr)
+			require.Error(t, err)
+			require.Nil(t, nextRuns)
+			require.Empty(t, human)
+		})
+	}
+}
+
+func TestNextCronTimeEvaluatesInTimezone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	timezone := "Asia/Shanghai"
+	cronjob := &apigen.TaskCronjob{CronExpression: "0 0 9 * * *", Timezone: &timezone}
+	expected := time.Date(2025, 4, 1, 1, 0, 0, 0, time.UTC)
+
+	for _, serverLoc := range []*time.Location{time.UTC, newYork} {
+		now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC).In(serverLoc)
+		nextTime, err := NextCronTime(cronjob, now)
+		require.NoError(t, err)
+		require.True(t, expected.Equal(nextTime), "server zone %s: got %s", serverLoc, nextTime.UTC())
+	}
+}
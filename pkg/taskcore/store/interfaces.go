@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/cloudcarver/anclax/core"
 	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
@@ -27,6 +28,9 @@ var (
 
 	// The task execution was interrupted by control plane
 	ErrTaskInterrupted = errors.New("task interrupted")
+
+	// SetResult was called outside of a task handler
+	ErrNoTaskResult = errors.New("no task result in context")
 )
 
 type TaskOverride = func(task *apigen.Task) error
@@ -47,6 +51,9 @@ type TaskStoreInterface interface {
 	ResumeTask(ctx context.Context, taskID int32) error
 	ResumeTaskWithTx(ctx context.Context, tx core.Tx, taskID int32) error
 
+	UpdateTaskStatusIf(ctx context.Context, taskID int32, expected, newStatus apigen.TaskStatus) (bool, error)
+	UpdateTaskStatusIfWithTx(ctx context.Context, tx core.Tx, taskID int32, expected, newStatus apigen.TaskStatus) (bool, error)
+
 	UpdatePendingTaskPriorityByLabels(ctx context.Context, labels []string, priority int32) (int64, error)
 	UpdatePendingTaskPriorityByLabelsWithTx(ctx context.Context, tx core.Tx, labels []string, priority int32) (int64, error)
 
@@ -61,4 +68,7 @@ type TaskStoreInterface interface {
 
 	GetLastTaskErrorEvent(ctx context.Context, taskID int32) (*apigen.Event, error)
 	GetLastTaskErrorEventWithTx(ctx context.Context, tx core.Tx, taskID int32) (*apigen.Event, error)
+
+	ListScheduled(ctx context.Context, within time.Duration) ([]apigen.Task, error)
+	ListScheduledWithTx(ctx context.Context, tx core.Tx, within time.Duration) ([]apigen.Task, error)
 }
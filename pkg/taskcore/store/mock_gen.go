@@ -13,6 +13,7 @@ import (
 	context "context"
 	json "encoding/json"
 	reflect "reflect"
+	time "time"
 
 	core "github.com/cloudcarver/anclax/core"
 	apigen "github.com/cloudcarver/anclax/pkg/zgen/apigen"
@@ -161,6 +162,36 @@ func (mr *MockTaskStoreInterfaceMockRecorder) GetTaskByUniqueTagWithTx(ctx, tx,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskByUniqueTagWithTx", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetTaskByUniqueTagWithTx), ctx, tx, uniqueTag)
 }
 
+// ListScheduled mocks base method.
+func (m *MockTaskStoreInterface) ListScheduled(ctx context.Context, within time.Duration) ([]apigen.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduled", ctx, within)
+	ret0, _ := ret[0].([]apigen.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduled indicates an expected call of ListScheduled.
+func (mr *MockTaskStoreInterfaceMockRecorder) ListScheduled(ctx, within any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduled", reflect.TypeOf((*MockTaskStoreInterface)(nil).ListScheduled), ctx, within)
+}
+
+// ListScheduledWithTx mocks base method.
+func (m *MockTaskStoreInterface) ListScheduledWithTx(ctx context.Context, tx core.Tx, within time.Duration) ([]apigen.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledWithTx", ctx, tx, within)
+	ret0, _ := ret[0].([]apigen.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledWithTx indicates an expected call of ListScheduledWithTx.
+func (mr *MockTaskStoreInterfaceMockRecorder) ListScheduledWithTx(ctx, tx, within any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledWithTx", reflect.TypeOf((*MockTaskStoreInterface)(nil).ListScheduledWithTx), ctx, tx, within)
+}
+
 // PauseTask mocks base method.
 func (m *MockTaskStoreInterface) PauseTask(ctx context.Context, taskID int32) error {
 	m.ctrl.T.Helper()
@@ -334,3 +365,33 @@ func (mr *MockTaskStoreInterfaceMockRecorder) UpdatePendingTaskWeightByLabelsWit
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePendingTaskWeightByLabelsWithTx", reflect.TypeOf((*MockTaskStoreInterface)(nil).UpdatePendingTaskWeightByLabelsWithTx), ctx, tx, labels, weight)
 }
+
+// UpdateTaskStatusIf mocks base method.
+func (m *MockTaskStoreInterface) UpdateTaskStatusIf(ctx context.Context, taskID int32, expected apigen.TaskStatus, newStatus apigen.TaskStatus) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTaskStatusIf", ctx, taskID, expected, newStatus)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTaskStatusIf indicates an expected call of UpdateTaskStatusIf.
+func (mr *MockTaskStoreInterfaceMockRecorder) UpdateTaskStatusIf(ctx, taskID, expected, newStatus any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskStatusIf", reflect.TypeOf((*MockTaskStoreInterface)(nil).UpdateTaskStatusIf), ctx, taskID, expected, newStatus)
+}
+
+// UpdateTaskStatusIfWithTx mocks base method.
+func (m *MockTaskStoreInterface) UpdateTaskStatusIfWithTx(ctx context.Context, tx core.Tx, taskID int32, expected apigen.TaskStatus, newStatus apigen.TaskStatus) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTaskStatusIfWithTx", ctx, tx, taskID, expected, newStatus)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTaskStatusIfWithTx indicates an expected call of UpdateTaskStatusIfWithTx.
+func (mr *MockTaskStoreInterfaceMockRecorder) UpdateTaskStatusIfWithTx(ctx, tx, taskID, expected, newStatus any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskStatusIfWithTx", reflect.TypeOf((*MockTaskStoreInterface)(nil).UpdateTaskStatusIfWithTx), ctx, tx, taskID, expected, newStatus)
+}
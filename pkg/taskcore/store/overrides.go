@@ -22,6 +22,18 @@ func WithCronjob(cronExpression string) TaskOverride {
 	}
 }
 
+// WithCronjobRunOnStart makes the cronjob set by WithCronjob run as soon as the task is pushed,
+// later runs follow the cron expression.
+func WithCronjobRunOnStart() TaskOverride {
+	return func(task *apigen.Task) error {
+		if task.Attributes.Cronjob == nil {
+			return errors.New("run on start requires a cronjob")
+		}
+		task.Attributes.Cronjob.RunOnStart = utils.Ptr(true)
+		return nil
+	}
+}
+
 func WithDelay(delay time.Duration) TaskOverride {
 	return func(task *apigen.Task) error {
 		task.StartedAt = utils.Ptr(task.StartedAt.Add(delay))
@@ -50,6 +62,14 @@ func WithParentTaskID(parentTaskID int32) TaskOverride {
 	}
 }
 
+// WithOrgID sets the organization the task belongs to, workers restricted to other organizations skip it.
+func WithOrgID(orgID int32) TaskOverride {
+	return func(task *apigen.Task) error {
+		task.OrgId = &orgID
+		return nil
+	}
+}
+
 func WithLabels(labels []string) TaskOverride {
 	return func(task *apigen.Task) error {
 		labelsCopy := append([]string(nil), labels...)
@@ -99,3 +119,19 @@ func WithWeight(weight int32) TaskOverride {
 		return nil
 	}
 }
+
+func WithExecutionWindow(timezone string, ranges ...apigen.TaskExecutionWindowRange) TaskOverride {
+	return func(task *apigen.Task) error {
+		if len(ranges) == 0 {
+			return errors.New("execution window must have at least one range")
+		}
+		window := &apigen.TaskExecutionWindow{
+			Ranges: append([]apigen.TaskExecutionWindowRange(nil), ranges...),
+		}
+		if timezone != "" {
+			window.Timezone = &timezone
+		}
+		task.Attributes.ExecutionWindow = window
+		return nil
+	}
+}
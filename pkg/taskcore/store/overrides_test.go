@@ -142,3 +142,29 @@ func TestWithCronjobOverride(t *testing.T) {
 	require.NotNil(t, task.Attributes.Cronjob)
 	require.Equal(t, "*/5 * * * * *", task.Attributes.Cronjob.CronExpression)
 }
+
+func TestWithCronjobRunOnStartOverride(t *testing.T) {
+	task := &apigen.Task{Attributes: apigen.TaskAttributes{}}
+
+	err := WithCronjobRunOnStart()(task)
+	require.ErrorContains(t, err, "run on start requires a cronjob")
+
+	require.NoError(t, WithCronjob("*/5 * * * * *")(task))
+	require.NoError(t, WithCronjobRunOnStart()(task))
+	require.True(t, *task.Attributes.Cronjob.RunOnStart)
+}
+
+func TestWithExecutionWindowOverride(t *testing.T) {
+	task := &apigen.Task{
+		Attributes: apigen.TaskAttributes{},
+	}
+
+	err := WithExecutionWindow("Asia/Shanghai", apigen.TaskExecutionWindowRange{Start: "22:00", End: "06:00"})(task)
+	require.NoError(t, err)
+	require.NotNil(t, task.Attributes.ExecutionWindow)
+	require.Equal(t, "Asia/Shanghai", *task.Attributes.ExecutionWindow.Timezone)
+	require.Equal(t, []apigen.TaskExecutionWindowRange{{Start: "22:00", End: "06:00"}}, task.Attributes.ExecutionWindow.Ranges)
+
+	err = WithExecutionWindow("")(task)
+	require.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/cloudcarver/anclax/core"
+	"github.com/cloudcarver/anclax/pkg/taskcore/listener"
 	"github.com/cloudcarver/anclax/pkg/taskcore/types"
 	"github.com/cloudcarver/anclax/pkg/utils"
 	"github.com/cloudcarver/anclax/pkg/zcore/model"
@@ -13,7 +14,6 @@ import (
 	"github.com/cloudcarver/anclax/pkg/zgen/querier"
 	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
-	"github.com/robfig/cron/v3"
 )
 
 var (
@@ -36,8 +36,11 @@ func NewTaskStore(model model.ModelInterface) TaskStoreInterface {
 }
 
 // PushTask inserts a task and returns its ID.
-// If task.UniqueTag is set and a matching task exists, it returns the existing ID without inserting.
+// If task.UniqueTag is set and a matching task is still pending or paused, it returns the existing ID without inserting.
+// A matching task that already completed, failed or was cancelled keeps its tag, the tag is only
+// unique among unfinished tasks.
 // The task's attributes, spec, status, started_at, and unique tag are persisted as provided.
+// A cronjob task without started_at is scheduled at its first cron boundary, or now when it runs on start.
 func (s *TaskStore) PushTask(ctx context.Context, task *apigen.Task) (int32, error) {
 	return s.pushTask(ctx, s.model, task)
 }
@@ -48,13 +51,13 @@ func (s *TaskStore) PushTaskWithTx(ctx context.Context, tx core.Tx, task *apigen
 
 func (s *TaskStore) pushTask(ctx context.Context, txm model.ModelInterface, task *apigen.Task) (int32, error) {
 	if task.UniqueTag != nil {
-		task, err := txm.GetTaskByUniqueTag(ctx, task.UniqueTag)
+		existing, err := txm.GetTaskByUniqueTag(ctx, task.UniqueTag)
 		if err != nil {
 			if !errors.Is(err, pgx.ErrNoRows) {
 				return 0, errors.Wrap(err, "failed to check task by unique tag before push")
 			}
-		} else {
-			return task.ID, nil
+		} else if !listener.IsTerminalStatus(apigen.TaskStatus(existing.Status)) {
+			return existing.ID, nil
 		}
 	}
 	serialKey, serialID, err := serialAttributesFromJSON(task.Attributes)
@@ -67,6 +70,25 @@ func (s *TaskStore) pushTask(ctx context.Context, txm model.ModelInterface, task
 	}
 	task.Attributes.Priority = utils.Ptr(priority)
 	task.Attributes.Weight = utils.Ptr(weight)
+	if task.Attributes.Cronjob != nil {
+		// validated even with an explicit started_at, the worker needs both to reschedule the task
+		schedule, err := parseCron(task.Attributes.Cronjob.CronExpression)
+		if err != nil {
+			return 0, err
+		}
+		startedAt, err := firstCronRun(schedule, task.Attributes.Cronjob, s.now())
+		if err != nil {
+			return 0, err
+		}
+		if task.StartedAt == nil {
+			task.StartedAt = &startedAt
+		}
+	}
+	if task.OrgId == nil {
+		if orgID, ok := OrgIDFromContext(ctx); ok {
+			task.OrgId = &orgID
+		}
+	}
 
 	createdTask, err := txm.CreateTask(ctx, querier.CreateTaskParams{
 		Attributes:   task.Attributes,
@@ -79,6 +101,7 @@ func (s *TaskStore) pushTask(ctx context.Context, txm model.ModelInterface, task
 		SerialID:     serialID,
 		Priority:     priority,
 		Weight:       weight,
+		OrgID:        task.OrgId,
 	})
 	if err != nil {
 		return 0, errors.Wrap(err, "failed to push task")
@@ -87,8 +110,10 @@ func (s *TaskStore) pushTask(ctx context.Context, txm model.ModelInterface, task
 }
 
 // UpdateCronJob updates a task's cron expression and payload, and schedules the next run time.
-// It parses the cron expression, computes the next fire time based on the store clock,
-// and persists the updated cron metadata, payload, and started_at timestamp.
+// It parses the cron expression, computes the next fire time based on the store clock in the
+// cronjob timezone, or runs the task right away when the cronjob runs on start; both settings
+// are kept from the existing task. It persists the updated cron metadata, payload, and
+// started_at timestamp.
 func (s *TaskStore) UpdateCronJob(ctx context.Context, taskID int32, cronExpression string, spec json.RawMessage) error {
 	return s.updateCronJob(ctx, s.model, taskID, cronExpression, spec)
 }
@@ -98,21 +123,26 @@ func (s *TaskStore) UpdateCronJobWithTx(ctx context.Context, tx core.Tx, taskID
 }
 
 func (s *TaskStore) updateCronJob(ctx context.Context, txm model.ModelInterface, taskID int32, cronExpression string, spec json.RawMessage) error {
-	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
-	cron, err := parser.Parse(cronExpression)
+	cron, err := parseCron(cronExpression)
 	if err != nil {
-		return errors.Wrapf(err, "failed to parse cron expression, format should be like second minute hour dayOfMonth month dayOfWeek")
+		return err
 	}
-	nextTime := cron.Next(s.now())
 
 	task, err := txm.GetTaskByID(ctx, taskID)
 	if err != nil {
 		return errors.Wrapf(err, "failed to get task")
 	}
 
-	task.Attributes.Cronjob = &apigen.TaskCronjob{
-		CronExpression: cronExpression,
+	cronjob := &apigen.TaskCronjob{CronExpression: cronExpression}
+	if task.Attributes.Cronjob != nil {
+		cronjob.Timezone = task.Attributes.Cronjob.Timezone
+		cronjob.RunOnStart = task.Attributes.Cronjob.RunOnStart
 	}
+	nextTime, err := firstCronRun(cron, cronjob, s.now())
+	if err != nil {
+		return err
+	}
+	task.Attributes.Cronjob = cronjob
 
 	task.Spec.Payload = spec
 	serialKey, serialID, err := serialAttributesFromJSON(task.Attributes)
@@ -226,6 +256,30 @@ func (s *TaskStore) resumeTask(ctx context.Context, txm model.ModelInterface, ta
 	return nil
 }
 
+// UpdateTaskStatusIf sets the task status to newStatus only if its current status is expected.
+// It reports whether the swap happened; a missing task or a different current status returns false.
+func (s *TaskStore) UpdateTaskStatusIf(ctx context.Context, taskID int32, expected, newStatus apigen.TaskStatus) (bool, error) {
+	return s.updateTaskStatusIf(ctx, s.model, taskID, expected, newStatus)
+}
+
+func (s *TaskStore) UpdateTaskStatusIfWithTx(ctx context.Context, tx core.Tx, taskID int32, expected, newStatus apigen.TaskStatus) (bool, error) {
+	return s.updateTaskStatusIf(ctx, s.model.SpawnWithTx(tx), taskID, expected, newStatus)
+}
+
+func (s *TaskStore) updateTaskStatusIf(ctx context.Context, txm model.ModelInterface, taskID int32, expected, newStatus apigen.TaskStatus) (bool, error) {
+	if _, err := txm.UpdateTaskStatusIf(ctx, querier.UpdateTaskStatusIfParams{
+		ID:             taskID,
+		ExpectedStatus: string(expected),
+		NewStatus:      string(newStatus),
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to update task status")
+	}
+	return true, nil
+}
+
 func (s *TaskStore) UpdatePendingTaskPriorityByLabels(ctx context.Context, labels []string, priority int32) (int64, error) {
 	return s.updatePendingTaskPriorityByLabels(ctx, s.model, labels, priority)
 }
@@ -341,6 +395,32 @@ func (s *TaskStore) getLastTaskErrorEvent(ctx context.Context, txm model.ModelIn
 	}, nil
 }
 
+// ListScheduled returns pending tasks whose started_at falls within the next window,
+// earliest first. Paused tasks and tasks without a start time are not included.
+func (s *TaskStore) ListScheduled(ctx context.Context, within time.Duration) ([]apigen.Task, error) {
+	return s.listScheduled(ctx, s.model, within)
+}
+
+func (s *TaskStore) ListScheduledWithTx(ctx context.Context, tx core.Tx, within time.Duration) ([]apigen.Task, error) {
+	return s.listScheduled(ctx, s.model.SpawnWithTx(tx), within)
+}
+
+func (s *TaskStore) listScheduled(ctx context.Context, txm model.ModelInterface, within time.Duration) ([]apigen.Task, error) {
+	now := s.now()
+	tasks, err := txm.ListScheduledTasks(ctx, querier.ListScheduledTasksParams{
+		FromTime:  now,
+		UntilTime: now.Add(within),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list scheduled tasks")
+	}
+	ret := make([]apigen.Task, len(tasks))
+	for i, task := range tasks {
+		ret[i] = types.TaskToAPI(task)
+	}
+	return ret, nil
+}
+
 func serialAttributes(attributes apigen.TaskAttributes) (*string, *int32, error) {
 	if attributes.SerialKey == nil && attributes.SerialID == nil {
 		return nil, nil, nil
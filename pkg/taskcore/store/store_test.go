@@ -130,6 +130,194 @@ func TestUpdateCronJobPreservesSerialAttributes(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestUpdateCronJobEvaluatesInTimezone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// The next fire time is the same instant no matter which zone the server clock is in.
+	for _, serverLoc := range []*time.Location{time.UTC, newYork} {
+		t.Run(serverLoc.String(), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var (
+				ctx              = context.Background()
+				taskID           = int32(1)
+				cronExpression   = "0 0 9 * * *"
+				timezone         = "Asia/Shanghai"
+				currentTime      = time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC).In(serverLoc)
+				expectedNextTime = time.Date(2025, 4, 1, 1, 0, 0, 0, time.UTC)
+			)
+
+			mockModel := model.NewMockModelInterface(ctrl)
+
+			mockModel.EXPECT().GetTaskByID(ctx, taskID).Return(&querier.AnclaxTask{
+				ID: taskID,
+				Attributes: apigen.TaskAttributes{
+					Cronjob: &apigen.TaskCronjob{
+						CronExpression: "*/5 * * * * *",
+						Timezone:       &timezone,
+					},
+				},
+			}, nil)
+
+			mockModel.EXPECT().UpdateTask(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, params querier.UpdateTaskParams) error {
+					require.Equal(t, cronExpression, params.Attributes.Cronjob.CronExpression)
+					require.Equal(t, timezone, *params.Attributes.Cronjob.Timezone)
+					require.True(t, expectedNextTime.Equal(*params.StartedAt), "got %s", params.StartedAt.UTC())
+					return nil
+				},
+			)
+
+			taskStore := &TaskStore{
+				model: mockModel,
+				now: func() time.Time {
+					return currentTime
+				},
+			}
+			err := taskStore.UpdateCronJob(ctx, taskID, cronExpression, json.RawMessage(`{}`))
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestUpdateCronJobRejectsInvalidTimezone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	timezone := "Mars/Olympus_Mons"
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetTaskByID(ctx, int32(1)).Return(&querier.AnclaxTask{
+		ID: 1,
+		Attributes: apigen.TaskAttributes{
+			Cronjob: &apigen.TaskCronjob{CronExpression: "*/5 * * * * *", Timezone: &timezone},
+		},
+	}, nil)
+
+	taskStore := &TaskStore{model: mockModel, now: time.Now}
+	err := taskStore.UpdateCronJob(ctx, 1, "*/5 * * * * *", json.RawMessage(`{}`))
+	require.Error(t, err)
+	require.ErrorContains(t, err, `invalid cron timezone "Mars/Olympus_Mons"`)
+}
+
+func TestUpdateCronJobRunOnStart(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		runOnStart *bool
+		expected   time.Time
+	}{
+		{name: "enabled", runOnStart: utils.Ptr(true), expected: time.Date(2025, 3, 31, 12, 0, 1, 0, time.UTC)},
+		{name: "disabled", runOnStart: utils.Ptr(false), expected: time.Date(2025, 3, 31, 12, 0, 5, 0, time.UTC)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			currentTime := time.Date(2025, 3, 31, 12, 0, 1, 0, time.UTC)
+			mockModel := model.NewMockModelInterface(ctrl)
+			mockModel.EXPECT().GetTaskByID(ctx, int32(1)).Return(&querier.AnclaxTask{
+				ID: 1,
+				Attributes: apigen.TaskAttributes{
+					Cronjob: &apigen.TaskCronjob{CronExpression: "0 0 * * * *", RunOnStart: tc.runOnStart},
+				},
+			}, nil)
+			mockModel.EXPECT().UpdateTask(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, params querier.UpdateTaskParams) error {
+					require.Equal(t, tc.runOnStart, params.Attributes.Cronjob.RunOnStart)
+					require.Equal(t, tc.expected, *params.StartedAt)
+					return nil
+				},
+			)
+
+			taskStore := &TaskStore{model: mockModel, now: func() time.Time { return currentTime }}
+			err := taskStore.UpdateCronJob(ctx, 1, "*/5 * * * * *", json.RawMessage(`{}`))
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPushTaskCronjobFirstRun(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		runOnStart *bool
+		expected   time.Time
+	}{
+		{name: "run on start", runOnStart: utils.Ptr(true), expected: time.Date(2025, 3, 31, 12, 0, 1, 0, time.UTC)},
+		{name: "next cron boundary", runOnStart: nil, expected: time.Date(2025, 3, 31, 12, 0, 5, 0, time.UTC)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			currentTime := time.Date(2025, 3, 31, 12, 0, 1, 0, time.UTC)
+			mockModel := model.NewMockModelInterface(ctrl)
+			mockModel.EXPECT().CreateTask(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, params querier.CreateTaskParams) (*querier.AnclaxTask, error) {
+					require.NotNil(t, params.StartedAt)
+					require.Equal(t, tc.expected, *params.StartedAt)
+					return &querier.AnclaxTask{ID: 7}, nil
+				},
+			)
+
+			taskStore := &TaskStore{model: mockModel, now: func() time.Time { return currentTime }}
+			taskID, err := taskStore.PushTask(ctx, &apigen.Task{
+				Attributes: apigen.TaskAttributes{
+					Cronjob: &apigen.TaskCronjob{CronExpression: "*/5 * * * * *", RunOnStart: tc.runOnStart},
+				},
+				Status: apigen.Pending,
+			})
+			require.NoError(t, err)
+			require.Equal(t, int32(7), taskID)
+		})
+	}
+}
+
+func TestPushTaskCronjobKeepsExplicitStartedAt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	startedAt := time.Date(2025, 4, 2, 8, 0, 0, 0, time.UTC)
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().CreateTask(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params querier.CreateTaskParams) (*querier.AnclaxTask, error) {
+			require.Equal(t, startedAt, *params.StartedAt)
+			return &querier.AnclaxTask{ID: 8}, nil
+		},
+	)
+
+	taskStore := &TaskStore{model: mockModel, now: time.Now}
+	_, err := taskStore.PushTask(ctx, &apigen.Task{
+		Attributes: apigen.TaskAttributes{
+			Cronjob: &apigen.TaskCronjob{CronExpression: "*/5 * * * * *", RunOnStart: utils.Ptr(true)},
+		},
+		StartedAt: &startedAt,
+		Status:    apigen.Pending,
+	})
+	require.NoError(t, err)
+}
+
+func TestPushTaskCronjobRejectsInvalidTimezone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	startedAt := time.Date(2025, 4, 2, 8, 0, 0, 0, time.UTC)
+	timezone := "Mars/Olympus_Mons"
+	taskStore := &TaskStore{model: model.NewMockModelInterface(ctrl), now: time.Now}
+	_, err := taskStore.PushTask(context.Background(), &apigen.Task{
+		Attributes: apigen.TaskAttributes{
+			Cronjob: &apigen.TaskCronjob{CronExpression: "*/5 * * * * *", Timezone: &timezone},
+		},
+		StartedAt: &startedAt,
+		Status:    apigen.Pending,
+	})
+	require.ErrorContains(t, err, `invalid cron timezone "Mars/Olympus_Mons"`)
+}
+
 func TestPushTaskRejectsSerialIDWithoutKey(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -243,6 +431,65 @@ func TestPushTaskParentTaskID(t *testing.T) {
 	require.Equal(t, int32(77), id)
 }
 
+func TestPushTaskOrgID(t *testing.T) {
+	spec := apigen.TaskSpec{Type: "tenant", Payload: json.RawMessage(`{}`)}
+	expected := func(orgID *int32) querier.CreateTaskParams {
+		return querier.CreateTaskParams{
+			Attributes: apigen.TaskAttributes{
+				Priority: utils.Ptr(int32(0)),
+				Weight:   utils.Ptr(int32(1)),
+			},
+			Spec:     spec,
+			Status:   string(apigen.Pending),
+			Priority: 0,
+			Weight:   1,
+			OrgID:    orgID,
+		}
+	}
+
+	t.Run("from context", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := ContextWithOrgID(context.Background(), 5)
+		mockModel := model.NewMockModelInterface(ctrl)
+		mockModel.EXPECT().CreateTask(ctx, utils.NewJSONValueMatcher(t, expected(utils.Ptr(int32(5))))).Return(&querier.AnclaxTask{ID: 1}, nil)
+
+		store := &TaskStore{model: mockModel}
+		_, err := store.PushTask(ctx, &apigen.Task{Spec: spec, Status: apigen.Pending})
+		require.NoError(t, err)
+	})
+
+	t.Run("override wins over context", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := ContextWithOrgID(context.Background(), 5)
+		task := &apigen.Task{Spec: spec, Status: apigen.Pending}
+		require.NoError(t, WithOrgID(6)(task))
+
+		mockModel := model.NewMockModelInterface(ctrl)
+		mockModel.EXPECT().CreateTask(ctx, utils.NewJSONValueMatcher(t, expected(utils.Ptr(int32(6))))).Return(&querier.AnclaxTask{ID: 1}, nil)
+
+		store := &TaskStore{model: mockModel}
+		_, err := store.PushTask(ctx, task)
+		require.NoError(t, err)
+	})
+
+	t.Run("no org", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.Background()
+		mockModel := model.NewMockModelInterface(ctrl)
+		mockModel.EXPECT().CreateTask(ctx, utils.NewJSONValueMatcher(t, expected(nil))).Return(&querier.AnclaxTask{ID: 1}, nil)
+
+		store := &TaskStore{model: mockModel}
+		_, err := store.PushTask(ctx, &apigen.Task{Spec: spec, Status: apigen.Pending})
+		require.NoError(t, err)
+	})
+}
+
 func TestPushTaskUniqueTagReturnsExisting(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -264,6 +511,103 @@ func TestPushTaskUniqueTagReturnsExisting(t *testing.T) {
 	require.Equal(t, int32(99), id)
 }
 
+func TestPushTaskUniqueTagReusesPendingTask(t *testing.T) {
+	for _, status := range []apigen.TaskStatus{apigen.Pending, apigen.TaskStatusPaused} {
+		t.Run(string(status), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			uniqueTag := "unique-task"
+
+			mockModel := model.NewMockModelInterface(ctrl)
+			mockModel.EXPECT().GetTaskByUniqueTag(ctx, &uniqueTag).Return(&querier.AnclaxTask{ID: 99, Status: string(status)}, nil)
+
+			store := &TaskStore{model: mockModel}
+			id, err := store.PushTask(ctx, &apigen.Task{
+				Attributes: apigen.TaskAttributes{},
+				Spec:       apigen.TaskSpec{Type: "unique", Payload: json.RawMessage(`{"id":1}`)},
+				Status:     apigen.Pending,
+				UniqueTag:  &uniqueTag,
+			})
+			require.NoError(t, err)
+			require.Equal(t, int32(99), id)
+		})
+	}
+}
+
+func TestPushTaskUniqueTagRecreatesFinishedTask(t *testing.T) {
+	for _, status := range []apigen.TaskStatus{apigen.Completed, apigen.Failed, apigen.Cancelled} {
+		t.Run(string(status), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			uniqueTag := "unique-task"
+			spec := apigen.TaskSpec{Type: "unique", Payload: json.RawMessage(`{"id":1}`)}
+
+			mockModel := model.NewMockModelInterface(ctrl)
+			gomock.InOrder(
+				mockModel.EXPECT().GetTaskByUniqueTag(ctx, &uniqueTag).Return(&querier.AnclaxTask{ID: 99, Status: string(status)}, nil),
+				mockModel.EXPECT().CreateTask(ctx, utils.NewJSONValueMatcher(t, querier.CreateTaskParams{
+					Attributes: apigen.TaskAttributes{
+						Priority: utils.Ptr(int32(0)),
+						Weight:   utils.Ptr(int32(1)),
+					},
+					Spec:      spec,
+					Status:    string(apigen.Pending),
+					UniqueTag: &uniqueTag,
+					Priority:  0,
+					Weight:    1,
+				})).Return(&querier.AnclaxTask{ID: 100}, nil),
+			)
+
+			store := &TaskStore{model: mockModel}
+			id, err := store.PushTask(ctx, &apigen.Task{
+				Attributes: apigen.TaskAttributes{},
+				Spec:       spec,
+				Status:     apigen.Pending,
+				UniqueTag:  &uniqueTag,
+			})
+			require.NoError(t, err)
+			require.Equal(t, int32(100), id)
+		})
+	}
+}
+
+func TestPushTaskUniqueTagCreatesNewTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	uniqueTag := "unique-task"
+	spec := apigen.TaskSpec{Type: "unique", Payload: json.RawMessage(`{"id":1}`)}
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().GetTaskByUniqueTag(ctx, &uniqueTag).Return(nil, pgx.ErrNoRows)
+	mockModel.EXPECT().CreateTask(ctx, utils.NewJSONValueMatcher(t, querier.CreateTaskParams{
+		Attributes: apigen.TaskAttributes{
+			Priority: utils.Ptr(int32(0)),
+			Weight:   utils.Ptr(int32(1)),
+		},
+		Spec:      spec,
+		Status:    string(apigen.Pending),
+		UniqueTag: &uniqueTag,
+		Priority:  0,
+		Weight:    1,
+	})).Return(&querier.AnclaxTask{ID: 123}, nil)
+
+	store := &TaskStore{model: mockModel}
+	id, err := store.PushTask(ctx, &apigen.Task{
+		Attributes: apigen.TaskAttributes{},
+		Spec:       spec,
+		Status:     apigen.Pending,
+		UniqueTag:  &uniqueTag,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(123), id)
+}
+
 func TestPushTaskUniqueTagLookupFails(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -612,3 +956,112 @@ func TestUpdatePendingTaskWeightByEmptyLabelsTargetsDefaultGroup(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, int64(4), rows)
 }
+
+func TestUpdateTaskStatusIfSwapped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	taskID := int32(1)
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, querier.UpdateTaskStatusIfParams{
+		ID:             taskID,
+		ExpectedStatus: string(apigen.Pending),
+		NewStatus:      string(apigen.Completed),
+	}).Return(taskID, nil)
+
+	taskStore := &TaskStore{model: mockModel}
+	swapped, err := taskStore.UpdateTaskStatusIf(ctx, taskID, apigen.Pending, apigen.Completed)
+	require.NoError(t, err)
+	require.True(t, swapped)
+}
+
+func TestUpdateTaskStatusIfRejectedWhenStatusDiffers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	taskID := int32(1)
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, querier.UpdateTaskStatusIfParams{
+		ID:             taskID,
+		ExpectedStatus: string(apigen.Pending),
+		NewStatus:      string(apigen.Completed),
+	}).Return(int32(0), pgx.ErrNoRows)
+
+	taskStore := &TaskStore{model: mockModel}
+	swapped, err := taskStore.UpdateTaskStatusIf(ctx, taskID, apigen.Pending, apigen.Completed)
+	require.NoError(t, err)
+	require.False(t, swapped)
+}
+
+func TestUpdateTaskStatusIfError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, gomock.Any()).Return(int32(0), errors.New("db down"))
+
+	taskStore := &TaskStore{model: mockModel}
+	swapped, err := taskStore.UpdateTaskStatusIf(ctx, 1, apigen.Pending, apigen.Completed)
+	require.Error(t, err)
+	require.False(t, swapped)
+}
+
+func TestListScheduled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	first := now.Add(5 * time.Minute)
+	second := now.Add(30 * time.Minute)
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().ListScheduledTasks(ctx, querier.ListScheduledTasksParams{
+		FromTime:  now,
+		UntilTime: now.Add(time.Hour),
+	}).Return([]*querier.AnclaxTask{
+		{ID: 1, Status: string(apigen.Pending), StartedAt: &first, Spec: apigen.TaskSpec{Type: "a"}},
+		{ID: 2, Status: string(apigen.Pending), StartedAt: &second, Spec: apigen.TaskSpec{Type: "b"}},
+	}, nil)
+
+	store := &TaskStore{model: mockModel, now: func() time.Time { return now }}
+	tasks, err := store.ListScheduled(ctx, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	require.Equal(t, int32(1), tasks[0].ID)
+	require.Equal(t, &first, tasks[0].StartedAt)
+	require.Equal(t, int32(2), tasks[1].ID)
+	require.Equal(t, &second, tasks[1].StartedAt)
+}
+
+func TestListScheduledFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	mockModel.EXPECT().ListScheduledTasks(ctx, gomock.Any()).Return(nil, errors.New("boom"))
+
+	store := &TaskStore{model: mockModel, now: func() time.Time { return now }}
+	_, err := store.ListScheduled(ctx, time.Minute)
+	require.ErrorContains(t, err, "failed to list scheduled tasks")
+}
+
+func TestSetResult(t *testing.T) {
+	require.ErrorIs(t, SetResult(context.Background(), "done"), ErrNoTaskResult)
+
+	result := &TaskResult{}
+	ctx := ContextWithTaskResult(context.Background(), result)
+	require.Nil(t, result.Value())
+	require.NoError(t, SetResult(ctx, map[string]int{"rows": 1}))
+	require.NoError(t, SetResult(ctx, map[string]int{"rows": 2}))
+	require.JSONEq(t, `{"rows":2}`, string(result.Value()))
+
+	require.Error(t, SetResult(ctx, make(chan int)))
+	require.JSONEq(t, `{"rows":2}`, string(result.Value()))
+}
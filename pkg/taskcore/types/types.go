@@ -23,6 +23,9 @@ func TaskToAPI(task *querier.AnclaxTask) apigen.Task {
 	return apigen.Task{
 		ID:           task.ID,
 		ParentTaskId: task.ParentTaskID,
+		OrgId:        task.OrgID,
+		Result:       task.Result,
+		DeletedAt:    task.DeletedAt,
 		CreatedAt:    task.CreatedAt,
 		Spec:         task.Spec,
 		StartedAt:    task.StartedAt,
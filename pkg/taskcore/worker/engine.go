@@ -21,6 +21,10 @@ type Engine struct {
 	normalClaimWheel     []string
 	normalClaimCursor    int
 
+	maxConsecutiveTaskType int
+	lastClaimedType        string
+	claimedTypeStreak      int
+
 	nextCycleID int64
 	cycles      map[int64]*cycleState
 }
@@ -37,6 +41,8 @@ func NewEngine(cfg EngineConfig) *Engine {
 		hasLabels:   len(cfg.Labels) > 0,
 		concurrency: concurrency,
 		cycles:      map[int64]*cycleState{},
+
+		maxConsecutiveTaskType: cfg.MaxConsecutiveTaskType,
 	}
 
 	defaultStrict := cfg.MaxStrictPercentage
@@ -158,6 +164,7 @@ func (e *Engine) onPollTick() []Command {
 		Phase:          PhaseClaimNormal,
 		PendingGroups:  groups,
 		WeightedLabels: weighted,
+		ExcludeTypes:   e.nextExcludeTypes(),
 	}
 	e.cycles[cycleID] = cycle
 	return e.issueNextNormalClaim(cycle)
@@ -186,6 +193,7 @@ func (e *Engine) onClaimStrictResult(event Event) []Command {
 		cycle.Phase = PhaseClaimNormal
 		cycle.PendingGroups = groups
 		cycle.WeightedLabels = weighted
+		cycle.ExcludeTypes = e.nextExcludeTypes()
 		return e.issueNextNormalClaim(cycle)
 	}
 
@@ -207,8 +215,14 @@ func (e *Engine) onClaimNormalResult(event Event) []Command {
 		return nil
 	}
 	if event.Task == nil {
+		if len(cycle.ExcludeTypes) > 0 {
+			// nothing of another type is pending, fall back to the excluded types
+			cycle.ExcludeTypes = nil
+			cycle.PendingGroups = append([]string{cycle.ClaimGroup}, cycle.PendingGroups...)
+		}
 		return e.issueNextNormalClaim(cycle)
 	}
+	e.recordClaimedType(event.Task.GetType())
 	cycle.Task = copyTask(event.Task)
 	cycle.Phase = PhaseExecuting
 	return []Command{{Type: CmdExecuteTask, CycleID: cycle.ID, Task: copyTask(cycle.Task)}}
@@ -254,14 +268,34 @@ func (e *Engine) issueNextNormalClaim(cycle *cycleState) []Command {
 	}
 	group := cycle.PendingGroups[0]
 	cycle.PendingGroups = cycle.PendingGroups[1:]
+	cycle.ClaimGroup = group
 	return []Command{{
 		Type:           CmdClaimNormal,
 		CycleID:        cycle.ID,
 		Group:          group,
 		WeightedLabels: append([]string(nil), cycle.WeightedLabels...),
+		ExcludeTypes:   append([]string(nil), cycle.ExcludeTypes...),
 	}}
 }
 
+// nextExcludeTypes returns the task type to skip in the next normal claim once it has been
+// claimed maxConsecutiveTaskType times in a row, so that other types are not starved.
+func (e *Engine) nextExcludeTypes() []string {
+	if e.maxConsecutiveTaskType <= 0 || e.claimedTypeStreak < e.maxConsecutiveTaskType {
+		return nil
+	}
+	return []string{e.lastClaimedType}
+}
+
+func (e *Engine) recordClaimedType(taskType string) {
+	if taskType == e.lastClaimedType {
+		e.claimedTypeStreak++
+		return
+	}
+	e.lastClaimedType = taskType
+	e.claimedTypeStreak = 1
+}
+
 func (e *Engine) finishCycle(cycleID int64) {
 	cycle, ok := e.cycles[cycleID]
 	if !ok {
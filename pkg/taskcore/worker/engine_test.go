@@ -122,3 +122,94 @@ func TestEngineIgnoreStaleRuntimeConfigVersion(t *testing.T) {
 	e.Apply(Event{Type: EventRuntimeConfigLoaded, Config: &RuntimeConfig{Version: 2, MaxStrictPercentage: int32Ptr(100)}})
 	require.Equal(t, int64(3), e.Snapshot().RuntimeConfigVersion)
 }
+
+// claimFromBacklog emulates ClaimNormalTaskByGroup over a FIFO backlog.
+func claimFromBacklog(backlog *[]*Task, cmd Command) *Task {
+	for i, task := range *backlog {
+		excluded := false
+		for _, t := range cmd.ExcludeTypes {
+			if task.GetType() == t {
+				excluded = true
+			}
+		}
+		if !excluded {
+			*backlog = append((*backlog)[:i:i], (*backlog)[i+1:]...)
+			return task
+		}
+	}
+	return nil
+}
+
+// pullOnce runs one poll cycle to completion and returns the claimed task.
+func pullOnce(t *testing.T, e *Engine, backlog *[]*Task) *Task {
+	t.Helper()
+	cmds := e.Apply(Event{Type: EventPollTick})
+	for len(cmds) == 1 && cmds[0].Type == CmdClaimNormal {
+		task := claimFromBacklog(backlog, cmds[0])
+		cmds = e.Apply(Event{Type: EventClaimNormalResult, CycleID: cmds[0].CycleID, Task: task})
+		if task != nil {
+			require.Len(t, cmds, 1)
+			require.Equal(t, CmdExecuteTask, cmds[0].Type)
+			e.Apply(Event{Type: EventExecuteResult, CycleID: cmds[0].CycleID})
+			e.Apply(Event{Type: EventFinalizeResult, CycleID: cmds[0].CycleID})
+			return task
+		}
+	}
+	require.Empty(t, cmds)
+	return nil
+}
+
+func TestEngineTaskTypeFairness(t *testing.T) {
+	const maxConsecutive = 3
+	e := NewEngine(EngineConfig{
+		WorkerID:               "w1",
+		Concurrency:            1,
+		MaxStrictPercentage:    0,
+		MaxConsecutiveTaskType: maxConsecutive,
+	})
+
+	var backlog []*Task
+	for i := range 50 {
+		backlog = append(backlog, &Task{ID: int32(i + 1), Spec: apigen.TaskSpec{Type: "A"}})
+	}
+	backlog = append(backlog,
+		&Task{ID: 101, Spec: apigen.TaskSpec{Type: "B"}},
+		&Task{ID: 102, Spec: apigen.TaskSpec{Type: "B"}},
+	)
+
+	var pulled []string
+	for range 2 * (maxConsecutive + 1) {
+		task := pullOnce(t, e, &backlog)
+		require.NotNil(t, task)
+		pulled = append(pulled, task.GetType())
+	}
+	require.Equal(t, []string{"A", "A", "A", "B", "A", "A", "A", "B"}, pulled)
+
+	// with only A left, the excluded claim finds nothing and falls back to A
+	for range 2 * maxConsecutive {
+		task := pullOnce(t, e, &backlog)
+		require.NotNil(t, task)
+		require.Equal(t, "A", task.GetType())
+	}
+}
+
+func TestEngineTaskTypeFairnessDisabledByDefault(t *testing.T) {
+	e := NewEngine(EngineConfig{WorkerID: "w1", Concurrency: 1, MaxStrictPercentage: 0})
+
+	var backlog []*Task
+	for i := range 10 {
+		backlog = append(backlog, &Task{ID: int32(i + 1), Spec: apigen.TaskSpec{Type: "A"}})
+	}
+	backlog = append(backlog, &Task{ID: 101, Spec: apigen.TaskSpec{Type: "B"}})
+
+	for range 10 {
+		cmds := e.Apply(Event{Type: EventPollTick})
+		require.Len(t, cmds, 1)
+		require.Empty(t, cmds[0].ExcludeTypes)
+		task := claimFromBacklog(&backlog, cmds[0])
+		require.Equal(t, "A", task.GetType())
+		cmds = e.Apply(Event{Type: EventClaimNormalResult, CycleID: cmds[0].CycleID, Task: task})
+		e.Apply(Event{Type: EventExecuteResult, CycleID: cmds[0].CycleID})
+		e.Apply(Event{Type: EventFinalizeResult, CycleID: cmds[0].CycleID})
+	}
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
+)
+
+// errOutsideExecutionWindow marks a claimed task that must wait for its next
+// allowed execution window. It is not a task failure.
+var errOutsideExecutionWindow = errors.New("task is outside its execution window")
+
+// windowRange holds a time-of-day range in minutes since midnight.
+type windowRange struct {
+	start int
+	end   int
+}
+
+// nextExecutionWindowStart reports whether now falls in one of the window ranges.
+// When it does not, it returns the start of the next allowed range.
+func nextExecutionWindowStart(window *apigen.TaskExecutionWindow, now time.Time) (time.Time, bool, error) {
+	if window == nil {
+		return time.Time{}, true, nil
+	}
+	if len(window.Ranges) == 0 {
+		return time.Time{}, false, errors.New("execution window must have at least one range")
+	}
+
+	loc := time.UTC
+	if window.Timezone != nil && *window.Timezone != "" {
+		l, err := time.LoadLocation(*window.Timezone)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid execution window timezone: %w", err)
+		}
+		loc = l
+	}
+
+	ranges := make([]windowRange, 0, len(window.Ranges))
+	for _, r := range window.Ranges {
+		start, err := parseTimeOfDay(r.Start)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid execution window start: %w", err)
+		}
+		end, err := parseTimeOfDay(r.End)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid execution window end: %w", err)
+		}
+		if start == end {
+			return time.Time{}, false, fmt.Errorf("execution window range %s-%s is empty", r.Start, r.End)
+		}
+		ranges = append(ranges, windowRange{start: start, end: end})
+	}
+
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	var next time.Time
+	// Start from yesterday so ranges spanning midnight are taken into account.
+	for day := -1; day <= 1; day++ {
+		base := today.AddDate(0, 0, day)
+		for _, r := range ranges {
+			start := atTimeOfDay(base, r.start)
+			end := atTimeOfDay(base, r.end)
+			if r.end < r.start {
+				end = atTimeOfDay(base.AddDate(0, 0, 1), r.end)
+			}
+			if !local.Before(start) && local.Before(end) {
+				return time.Time{}, true, nil
+			}
+			if start.After(local) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+	return next, false, nil
+}
+
+func parseTimeOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not in HH:MM format", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func atTimeOfDay(day time.Time, minutes int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
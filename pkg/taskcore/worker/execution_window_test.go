@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextExecutionWindowStart(t *testing.T) {
+	offPeak := &apigen.TaskExecutionWindow{
+		Ranges: []apigen.TaskExecutionWindowRange{
+			{Start: "22:00", End: "06:00"},
+			{Start: "12:00", End: "13:00"},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		now      time.Time
+		inWindow bool
+		next     time.Time
+	}{
+		{
+			name:     "inside range spanning midnight before midnight",
+			now:      time.Date(2025, 4, 2, 23, 30, 0, 0, time.UTC),
+			inWindow: true,
+		},
+		{
+			name:     "inside range spanning midnight after midnight",
+			now:      time.Date(2025, 4, 3, 5, 59, 0, 0, time.UTC),
+			inWindow: true,
+		},
+		{
+			name:     "inside daytime range",
+			now:      time.Date(2025, 4, 2, 12, 0, 0, 0, time.UTC),
+			inWindow: true,
+		},
+		{
+			name: "end is exclusive",
+			now:  time.Date(2025, 4, 2, 6, 0, 0, 0, time.UTC),
+			next: time.Date(2025, 4, 2, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "outside waits for the nightly range",
+			now:  time.Date(2025, 4, 2, 15, 0, 0, 0, time.UTC),
+			next: time.Date(2025, 4, 2, 22, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next, inWindow, err := nextExecutionWindowStart(offPeak, tc.now)
+			require.NoError(t, err)
+			require.Equal(t, tc.inWindow, inWindow)
+			if !tc.inWindow {
+				require.True(t, tc.next.Equal(next), "expected %s, got %s", tc.next, next)
+			}
+		})
+	}
+}
+
+func TestNextExecutionWindowStartTimezone(t *testing.T) {
+	tz := "Asia/Shanghai"
+	window := &apigen.TaskExecutionWindow{
+		Timezone: &tz,
+		Ranges:   []apigen.TaskExecutionWindowRange{{Start: "01:00", End: "05:00"}},
+	}
+
+	// 18:30 UTC is 02:30 in Shanghai.
+	_, inWindow, err := nextExecutionWindowStart(window, time.Date(2025, 4, 2, 18, 30, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.True(t, inWindow)
+
+	next, inWindow, err := nextExecutionWindowStart(window, time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.False(t, inWindow)
+	require.True(t, time.Date(2025, 4, 2, 17, 0, 0, 0, time.UTC).Equal(next))
+}
+
+func TestNextExecutionWindowStartInvalid(t *testing.T) {
+	_, _, err := nextExecutionWindowStart(&apigen.TaskExecutionWindow{}, time.Now())
+	require.Error(t, err)
+
+	_, _, err = nextExecutionWindowStart(&apigen.TaskExecutionWindow{
+		Ranges: []apigen.TaskExecutionWindowRange{{Start: "25:00", End: "06:00"}},
+	}, time.Now())
+	require.ErrorContains(t, err, "invalid execution window start")
+
+	tz := "Nowhere/City"
+	_, _, err = nextExecutionWindowStart(&apigen.TaskExecutionWindow{
+		Timezone: &tz,
+		Ranges:   []apigen.TaskExecutionWindowRange{{Start: "01:00", End: "02:00"}},
+	}, time.Now())
+	require.ErrorContains(t, err, "invalid execution window timezone")
+}
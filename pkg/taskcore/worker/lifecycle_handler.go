@@ -14,7 +14,6 @@ import (
 	"github.com/cloudcarver/anclax/pkg/zgen/querier"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
 
@@ -43,7 +42,7 @@ func NewTaskLifeCycleHandler(model model.ModelInterface, taskHandler TaskHandler
 }
 
 func (h *TaskLifeCycleHandler) HandleAttributes(ctx context.Context, tx core.Tx, task apigen.Task) error {
-	return nil
+	return h.insertTaskStartedEvent(ctx, h.model.SpawnWithTx(tx), task)
 }
 
 func (h *TaskLifeCycleHandler) HandleFailed(ctx context.Context, tx core.Tx, task apigen.Task, execErr error) error {
@@ -55,11 +54,20 @@ func (h *TaskLifeCycleHandler) HandleFailed(ctx context.Context, tx core.Tx, tas
 	}
 
 	txm := h.model.SpawnWithTx(tx)
+	if errors.Is(execErr, errOutsideExecutionWindow) {
+		return h.deferToExecutionWindow(ctx, txm, task)
+	}
+
 	statusOverride := failureStatusOverride(execErr)
 	if statusOverride != "" {
-		if err := h.updateTaskStatusByWorker(ctx, txm, task.ID, statusOverride); err != nil {
+		if _, err := h.updateTaskStatusByWorker(ctx, txm, task.ID, statusOverride); err != nil {
 			return err
 		}
+		if statusOverride == apigen.Cancelled {
+			if err := h.insertTaskCancelledEvent(ctx, txm, task); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 
@@ -77,8 +85,12 @@ func (h *TaskLifeCycleHandler) HandleFailed(ctx context.Context, tx core.Tx, tas
 		if err := h.updateTaskStartedAtByWorker(ctx, txm, task.ID, nextTime); err != nil {
 			return err
 		}
+		// an intentional retry, such as polling for a condition, stays out of the event log
 		if !skipErrorEvent {
-			if err := h.insertTaskErrorEvent(ctx, txm, task.ID, execErr); err != nil {
+			if err := h.insertTaskErrorEvent(ctx, txm, task, execErr); err != nil {
+				return err
+			}
+			if err := h.insertTaskRetryScheduledEvent(ctx, txm, task, nextTime); err != nil {
 				return err
 			}
 		}
@@ -94,10 +106,12 @@ func (h *TaskLifeCycleHandler) HandleFailed(ctx context.Context, tx core.Tx, tas
 func (h *TaskLifeCycleHandler) HandleCompleted(ctx context.Context, tx core.Tx, task apigen.Task) error {
 	txm := h.model.SpawnWithTx(tx)
 	if task.Attributes.Cronjob != nil {
-		nextTime, err := nextCronTime(task.Attributes.Cronjob.CronExpression, h.now())
+		nextTime, err := taskcore.NextCronTime(task.Attributes.Cronjob, h.now())
 		if err != nil {
 			return err
 		}
+		// the update only matches while this worker holds the task, a worker that ran the task
+		// after its lock expired gets ErrTaskLockLost and does not reschedule it a second time
 		if err := h.updateTaskStartedAtByWorker(ctx, txm, task.ID, nextTime); err != nil {
 			return err
 		}
@@ -107,10 +121,30 @@ func (h *TaskLifeCycleHandler) HandleCompleted(ctx context.Context, tx core.Tx,
 		return nil
 	}
 
-	if err := h.updateTaskStatusByWorker(ctx, txm, task.ID, apigen.Completed); err != nil {
+	if h.taskHandler != nil {
+		if err := h.taskHandler.OnTaskCompleted(ctx, tx, TaskSpec{Spec: task.Spec}, task.ID); err != nil {
+			if !errors.Is(err, ErrUnknownTaskType) {
+				lifecycleLog.Error("task onCompleted handler error", zap.Error(err))
+			}
+		}
+	}
+
+	swapped, err := h.updateTaskStatusByWorker(ctx, txm, task.ID, apigen.Completed)
+	if err != nil {
 		return err
 	}
-	if err := h.insertTaskCompletedEvent(ctx, txm, task.ID); err != nil {
+	if !swapped {
+		return nil
+	}
+	if len(task.Result) > 0 {
+		if err := txm.UpdateTaskResult(ctx, querier.UpdateTaskResultParams{
+			ID:     task.ID,
+			Result: task.Result,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := h.insertTaskCompletedEvent(ctx, txm, task); err != nil {
 		return err
 	}
 	return nil
@@ -151,25 +185,40 @@ func nextRetryTime(interval string, now time.Time) (time.Time, error) {
 	return now.Add(duration), nil
 }
 
-func nextCronTime(expr string, now time.Time) (time.Time, error) {
-	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
-	cronExpr, err := parser.Parse(expr)
+// deferToExecutionWindow reschedules the task to the start of its next execution
+// window and gives back the attempt consumed by the claim, so it is not counted as a failure.
+func (h *TaskLifeCycleHandler) deferToExecutionWindow(ctx context.Context, txm model.ModelInterface, task apigen.Task) error {
+	nextTime, inWindow, err := nextExecutionWindowStart(task.Attributes.ExecutionWindow, h.now())
 	if err != nil {
-		return time.Time{}, fmt.Errorf("invalid cron expression: %w", err)
+		return err
+	}
+	if inWindow {
+		nextTime = h.now()
 	}
-	return cronExpr.Next(now), nil
+	if _, err := txm.DeferTaskByWorker(ctx, querier.DeferTaskByWorkerParams{
+		ID:        task.ID,
+		StartedAt: &nextTime,
+		WorkerID:  uuid.NullUUID{UUID: h.workerID, Valid: true},
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return taskcore.ErrTaskLockLost
+		}
+		return err
+	}
+	return nil
 }
 
 func (h *TaskLifeCycleHandler) handlePermanentFailure(ctx context.Context, tx core.Tx, txm model.ModelInterface, task apigen.Task, execErr error, skipErrorEvent bool) error {
 	if !skipErrorEvent {
-		if err := h.insertTaskErrorEvent(ctx, txm, task.ID, execErr); err != nil {
+		if err := h.insertTaskErrorEvent(ctx, txm, task, execErr); err != nil {
 			return err
 		}
 	}
-	if err := h.updateTaskStatusByWorker(ctx, txm, task.ID, apigen.Failed); err != nil {
+	swapped, err := h.updateTaskStatusByWorker(ctx, txm, task.ID, apigen.Failed)
+	if err != nil {
 		return err
 	}
-	if h.taskHandler != nil {
+	if swapped && h.taskHandler != nil {
 		if err := h.taskHandler.OnTaskFailed(ctx, tx, TaskSpec{Spec: task.Spec}, task.ID); err != nil {
 			if !errors.Is(err, ErrUnknownTaskType) {
 				lifecycleLog.Error("task onFailed handler error", zap.Error(err))
@@ -179,18 +228,28 @@ func (h *TaskLifeCycleHandler) handlePermanentFailure(ctx context.Context, tx co
 	return nil
 }
 
-func (h *TaskLifeCycleHandler) updateTaskStatusByWorker(ctx context.Context, txm model.ModelInterface, taskID int32, status apigen.TaskStatus) error {
-	if _, err := txm.UpdateTaskStatusByWorker(ctx, querier.UpdateTaskStatusByWorkerParams{
-		ID:       taskID,
-		Status:   string(status),
-		WorkerID: uuid.NullUUID{UUID: h.workerID, Valid: true},
+// updateTaskStatusByWorker moves a claimed task out of pending and releases the worker lock.
+// The status only changes if the task is still pending, so a concurrent transition such as a
+// cancel is not clobbered. It reports whether the status was swapped.
+func (h *TaskLifeCycleHandler) updateTaskStatusByWorker(ctx context.Context, txm model.ModelInterface, taskID int32, status apigen.TaskStatus) (bool, error) {
+	swapped := true
+	if _, err := txm.UpdateTaskStatusIf(ctx, querier.UpdateTaskStatusIfParams{
+		ID:             taskID,
+		ExpectedStatus: string(apigen.Pending),
+		NewStatus:      string(status),
 	}); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return taskcore.ErrTaskLockLost
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return false, err
 		}
-		return err
+		swapped = false
 	}
-	return nil
+	if err := h.releaseTaskLockByWorker(ctx, txm, taskID); err != nil {
+		return false, err
+	}
+	if !swapped {
+		lifecycleLog.Info("task status changed concurrently, skip transition", zap.Int32("task_id", taskID), zap.String("status", string(status)))
+	}
+	return swapped, nil
 }
 
 func (h *TaskLifeCycleHandler) updateTaskStartedAtByWorker(ctx context.Context, txm model.ModelInterface, taskID int32, startedAt time.Time) error {
@@ -220,11 +279,11 @@ func (h *TaskLifeCycleHandler) releaseTaskLockByWorker(ctx context.Context, txm
 	return nil
 }
 
-func (h *TaskLifeCycleHandler) insertTaskErrorEvent(ctx context.Context, txm model.ModelInterface, taskID int32, execErr error) error {
-	_, err := txm.InsertEvent(ctx, apigen.EventSpec{
+func (h *TaskLifeCycleHandler) insertTaskErrorEvent(ctx context.Context, txm model.ModelInterface, task apigen.Task, execErr error) error {
+	err := insertTaskEvent(ctx, txm, task, apigen.EventSpec{
 		Type: apigen.TaskError,
 		TaskError: &apigen.EventTaskError{
-			TaskID: taskID,
+			TaskID: task.ID,
 			Error:  execErr.Error(),
 		},
 	})
@@ -234,11 +293,11 @@ func (h *TaskLifeCycleHandler) insertTaskErrorEvent(ctx context.Context, txm mod
 	return nil
 }
 
-func (h *TaskLifeCycleHandler) insertTaskCompletedEvent(ctx context.Context, txm model.ModelInterface, taskID int32) error {
-	_, err := txm.InsertEvent(ctx, apigen.EventSpec{
+func (h *TaskLifeCycleHandler) insertTaskCompletedEvent(ctx context.Context, txm model.ModelInterface, task apigen.Task) error {
+	err := insertTaskEvent(ctx, txm, task, apigen.EventSpec{
 		Type: apigen.TaskCompleted,
 		TaskCompleted: &apigen.EventTaskCompleted{
-			TaskID: taskID,
+			TaskID: task.ID,
 		},
 	})
 	if err != nil {
@@ -246,3 +305,60 @@ func (h *TaskLifeCycleHandler) insertTaskCompletedEvent(ctx context.Context, txm
 	}
 	return nil
 }
+
+func (h *TaskLifeCycleHandler) insertTaskStartedEvent(ctx context.Context, txm model.ModelInterface, task apigen.Task) error {
+	err := insertTaskEvent(ctx, txm, task, apigen.EventSpec{
+		Type: apigen.TaskStarted,
+		TaskStarted: &apigen.EventTaskStarted{
+			TaskID: task.ID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("insert task started event: %w", err)
+	}
+	return nil
+}
+
+func (h *TaskLifeCycleHandler) insertTaskRetryScheduledEvent(ctx context.Context, txm model.ModelInterface, task apigen.Task, nextRunAt time.Time) error {
+	err := insertTaskEvent(ctx, txm, task, apigen.EventSpec{
+		Type: apigen.TaskRetryScheduled,
+		TaskRetryScheduled: &apigen.EventTaskRetryScheduled{
+			TaskID:    task.ID,
+			NextRunAt: nextRunAt,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("insert task retry scheduled event: %w", err)
+	}
+	return nil
+}
+
+func (h *TaskLifeCycleHandler) insertTaskCancelledEvent(ctx context.Context, txm model.ModelInterface, task apigen.Task) error {
+	err := insertTaskEvent(ctx, txm, task, apigen.EventSpec{
+		Type: apigen.TaskCancelled,
+		TaskCancelled: &apigen.EventTaskCancelled{
+			TaskID: task.ID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("insert task cancelled event: %w", err)
+	}
+	return nil
+}
+
+// insertTaskEvent inserts at most one event of each type per task attempt, so a lifecycle
+// transaction retried after an ambiguous commit does not duplicate it.
+func insertTaskEvent(ctx context.Context, txm model.ModelInterface, task apigen.Task, spec apigen.EventSpec) error {
+	key := taskEventIdempotencyKey(task.ID, task.Attempts, spec.Type)
+	if _, err := txm.InsertEvent(ctx, querier.InsertEventParams{
+		Spec:           spec,
+		IdempotencyKey: &key,
+	}); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	return nil
+}
+
+func taskEventIdempotencyKey(taskID int32, attempts int32, typ apigen.EventSpecType) string {
+	return fmt.Sprintf("task:%d:attempt:%d:%s", taskID, attempts, typ)
+}
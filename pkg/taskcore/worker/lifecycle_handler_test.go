@@ -56,20 +56,67 @@ func TestHandleFailedStatusOverride(t *testing.T) {
 	workerID := uuid.New()
 	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
 
-	mockModel.EXPECT().UpdateTaskStatusByWorker(ctx, gomock.Any()).DoAndReturn(
-		func(ctx context.Context, params querier.UpdateTaskStatusByWorkerParams) (int32, error) {
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params querier.UpdateTaskStatusIfParams) (int32, error) {
 			require.Equal(t, int32(7), params.ID)
-			require.Equal(t, string(apigen.Paused), params.Status)
-			require.Equal(t, uuid.NullUUID{UUID: workerID, Valid: true}, params.WorkerID)
+			require.Equal(t, string(apigen.Pending), params.ExpectedStatus)
+			require.Equal(t, string(apigen.Paused), params.NewStatus)
 			return params.ID, nil
 		},
 	)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, querier.ReleaseTaskLockByWorkerParams{
+		ID:       7,
+		WorkerID: uuid.NullUUID{UUID: workerID, Valid: true},
+	}).Return(int32(7), nil)
 
 	h := newLifecycleHandler(mockModel, nil, workerID, time.Now())
 	err := h.HandleFailed(ctx, &fakeTx{}, apigen.Task{ID: 7}, taskcore.ErrTaskPaused)
 	require.NoError(t, err)
 }
 
+func TestHandleFailedCancelledEmitsEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	workerID := uuid.New()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, gomock.Any()).Return(int32(7), nil)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).Return(int32(7), nil)
+	mockModel.EXPECT().InsertEvent(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params querier.InsertEventParams) (*querier.AnclaxEvent, error) {
+			require.Equal(t, apigen.TaskCancelled, params.Spec.Type)
+			require.Equal(t, &apigen.EventTaskCancelled{TaskID: 7}, params.Spec.TaskCancelled)
+			require.Equal(t, "task:7:attempt:2:TaskCancelled", *params.IdempotencyKey)
+			return &querier.AnclaxEvent{ID: 1}, nil
+		},
+	)
+
+	h := newLifecycleHandler(mockModel, nil, workerID, time.Now())
+	err := h.HandleFailed(ctx, &fakeTx{}, apigen.Task{ID: 7, Attempts: 2}, taskcore.ErrTaskCancelled)
+	require.NoError(t, err)
+}
+
+func TestHandleAttributesEmitsStartedEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockModel.EXPECT().InsertEvent(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params querier.InsertEventParams) (*querier.AnclaxEvent, error) {
+			require.Equal(t, apigen.TaskStarted, params.Spec.Type)
+			require.Equal(t, &apigen.EventTaskStarted{TaskID: 4}, params.Spec.TaskStarted)
+			require.Equal(t, "task:4:attempt:1:TaskStarted", *params.IdempotencyKey)
+			return &querier.AnclaxEvent{ID: 1}, nil
+		},
+	)
+
+	h := newLifecycleHandler(mockModel, nil, uuid.New(), time.Now())
+	require.NoError(t, h.HandleAttributes(ctx, &fakeTx{}, apigen.Task{ID: 4, Attempts: 1}))
+}
+
 func TestHandleFailedLockLostShortCircuit(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -97,14 +144,27 @@ func TestHandleFailedRetriesAndReleasesLock(t *testing.T) {
 		},
 	)
 	mockModel.EXPECT().InsertEvent(ctx, gomock.Any()).DoAndReturn(
-		func(ctx context.Context, spec apigen.EventSpec) (*querier.AnclaxEvent, error) {
+		func(ctx context.Context, params querier.InsertEventParams) (*querier.AnclaxEvent, error) {
+			spec := params.Spec
 			require.Equal(t, apigen.TaskError, spec.Type)
 			require.NotNil(t, spec.TaskError)
 			require.Equal(t, int32(9), spec.TaskError.TaskID)
 			require.Equal(t, "boom", spec.TaskError.Error)
+			require.Equal(t, "task:9:attempt:1:TaskError", *params.IdempotencyKey)
 			return &querier.AnclaxEvent{ID: 1}, nil
 		},
 	)
+	mockModel.EXPECT().InsertEvent(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params querier.InsertEventParams) (*querier.AnclaxEvent, error) {
+			spec := params.Spec
+			require.Equal(t, apigen.TaskRetryScheduled, spec.Type)
+			require.NotNil(t, spec.TaskRetryScheduled)
+			require.Equal(t, int32(9), spec.TaskRetryScheduled.TaskID)
+			require.Equal(t, now.Add(10*time.Second), spec.TaskRetryScheduled.NextRunAt)
+			require.Equal(t, "task:9:attempt:1:TaskRetryScheduled", *params.IdempotencyKey)
+			return &querier.AnclaxEvent{ID: 2}, nil
+		},
+	)
 	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).DoAndReturn(
 		func(ctx context.Context, params querier.ReleaseTaskLockByWorkerParams) (int32, error) {
 			require.Equal(t, int32(9), params.ID)
@@ -165,12 +225,13 @@ func TestHandleFailedPermanentFailureCallsHook(t *testing.T) {
 	mockHandler := NewMockTaskHandler(ctrl)
 
 	mockModel.EXPECT().InsertEvent(ctx, gomock.Any()).Return(&querier.AnclaxEvent{ID: 1}, nil)
-	mockModel.EXPECT().UpdateTaskStatusByWorker(ctx, gomock.Any()).DoAndReturn(
-		func(ctx context.Context, params querier.UpdateTaskStatusByWorkerParams) (int32, error) {
-			require.Equal(t, string(apigen.Failed), params.Status)
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params querier.UpdateTaskStatusIfParams) (int32, error) {
+			require.Equal(t, string(apigen.Failed), params.NewStatus)
 			return params.ID, nil
 		},
 	)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).Return(int32(11), nil)
 	mockHandler.EXPECT().OnTaskFailed(ctx, gomock.Any(), gomock.Any(), int32(11)).Return(nil)
 
 	h := newLifecycleHandler(mockModel, mockHandler, workerID, time.Now())
@@ -189,7 +250,8 @@ func TestHandleFailedHookIgnoresUnknownTask(t *testing.T) {
 	mockHandler := NewMockTaskHandler(ctrl)
 
 	mockModel.EXPECT().InsertEvent(ctx, gomock.Any()).Return(&querier.AnclaxEvent{ID: 1}, nil)
-	mockModel.EXPECT().UpdateTaskStatusByWorker(ctx, gomock.Any()).Return(int32(12), nil)
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, gomock.Any()).Return(int32(12), nil)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).Return(int32(12), nil)
 	mockHandler.EXPECT().OnTaskFailed(ctx, gomock.Any(), gomock.Any(), int32(12)).Return(ErrUnknownTaskType)
 
 	h := newLifecycleHandler(mockModel, mockHandler, workerID, time.Now())
@@ -224,7 +286,7 @@ func TestHandleCompletedCronjobReschedules(t *testing.T) {
 	workerID := uuid.New()
 	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
 
-	nextTime, err := nextCronTime("*/5 * * * * *", now)
+	nextTime, err := taskcore.NextCronTime(&apigen.TaskCronjob{CronExpression: "*/5 * * * * *"}, now)
 	require.NoError(t, err)
 
 	mockModel.EXPECT().UpdateTaskStartedAtByWorker(ctx, gomock.Any()).DoAndReturn(
@@ -241,6 +303,37 @@ func TestHandleCompletedCronjobReschedules(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestHandleCompletedCronjobReschedulesOnlyByLockOwner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	now := time.Date(2025, 4, 2, 12, 0, 0, 0, time.UTC)
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+
+	// the lock of the first worker expired and the task was claimed by the owner, both finish
+	owner, stale := uuid.New(), uuid.New()
+	mockModel.EXPECT().UpdateTaskStartedAtByWorker(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params querier.UpdateTaskStartedAtByWorkerParams) (int32, error) {
+			if params.WorkerID != (uuid.NullUUID{UUID: owner, Valid: true}) {
+				return 0, pgx.ErrNoRows
+			}
+			return params.ID, nil
+		},
+	).Times(2)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params querier.ReleaseTaskLockByWorkerParams) (int32, error) {
+			require.Equal(t, uuid.NullUUID{UUID: owner, Valid: true}, params.WorkerID)
+			return params.ID, nil
+		},
+	).Times(1)
+
+	task := apigen.Task{ID: 8, Attributes: apigen.TaskAttributes{Cronjob: &apigen.TaskCronjob{CronExpression: "*/5 * * * * *"}}}
+	err := newLifecycleHandler(mockModel, nil, stale, now).HandleCompleted(ctx, &fakeTx{}, task)
+	require.ErrorIs(t, err, taskcore.ErrTaskLockLost)
+	require.NoError(t, newLifecycleHandler(mockModel, nil, owner, now).HandleCompleted(ctx, &fakeTx{}, task))
+}
+
 func TestHandleCompletedUpdatesStatus(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -249,17 +342,20 @@ func TestHandleCompletedUpdatesStatus(t *testing.T) {
 	workerID := uuid.New()
 	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
 
-	mockModel.EXPECT().UpdateTaskStatusByWorker(ctx, gomock.Any()).DoAndReturn(
-		func(ctx context.Context, params querier.UpdateTaskStatusByWorkerParams) (int32, error) {
-			require.Equal(t, string(apigen.Completed), params.Status)
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params querier.UpdateTaskStatusIfParams) (int32, error) {
+			require.Equal(t, string(apigen.Completed), params.NewStatus)
 			return params.ID, nil
 		},
 	)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).Return(int32(6), nil)
 	mockModel.EXPECT().InsertEvent(ctx, gomock.Any()).DoAndReturn(
-		func(ctx context.Context, spec apigen.EventSpec) (*querier.AnclaxEvent, error) {
+		func(ctx context.Context, params querier.InsertEventParams) (*querier.AnclaxEvent, error) {
+			spec := params.Spec
 			require.Equal(t, apigen.TaskCompleted, spec.Type)
 			require.NotNil(t, spec.TaskCompleted)
 			require.Equal(t, int32(6), spec.TaskCompleted.TaskID)
+			require.Equal(t, "task:6:attempt:0:TaskCompleted", *params.IdempotencyKey)
 			return &querier.AnclaxEvent{ID: 2}, nil
 		},
 	)
@@ -270,6 +366,157 @@ func TestHandleCompletedUpdatesStatus(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestHandleCompletedStoresResult(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, gomock.Any()).Return(int32(7), nil)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).Return(int32(7), nil)
+	mockModel.EXPECT().UpdateTaskResult(ctx, querier.UpdateTaskResultParams{
+		ID:     7,
+		Result: []byte(`{"url":"s3://reports/7"}`),
+	}).Return(nil)
+	mockModel.EXPECT().InsertEvent(ctx, gomock.Any()).Return(&querier.AnclaxEvent{ID: 3}, nil)
+
+	h := newLifecycleHandler(mockModel, nil, uuid.New(), time.Now())
+	task := apigen.Task{ID: 7, Result: []byte(`{"url":"s3://reports/7"}`)}
+	require.NoError(t, h.HandleCompleted(ctx, &fakeTx{}, task))
+}
+
+func TestHandleCompletedRetriedEventIsNoop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	workerID := uuid.New()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+
+	// the events table ignores a second insert with the same idempotency key
+	inserted := map[string]int{}
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, gomock.Any()).Return(int32(6), nil).Times(2)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).Return(int32(6), nil).Times(2)
+	mockModel.EXPECT().InsertEvent(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params querier.InsertEventParams) (*querier.AnclaxEvent, error) {
+			inserted[*params.IdempotencyKey]++
+			if inserted[*params.IdempotencyKey] > 1 {
+				return nil, pgx.ErrNoRows
+			}
+			return &querier.AnclaxEvent{ID: 2}, nil
+		},
+	).Times(2)
+
+	h := newLifecycleHandler(mockModel, nil, workerID, time.Now())
+	task := apigen.Task{ID: 6, Attempts: 1}
+	require.NoError(t, h.HandleCompleted(ctx, &fakeTx{}, task))
+	require.NoError(t, h.HandleCompleted(ctx, &fakeTx{}, task))
+	require.Equal(t, map[string]int{"task:6:attempt:1:TaskCompleted": 2}, inserted)
+}
+
+func TestTaskEventIdempotencyKey(t *testing.T) {
+	require.Equal(t, taskEventIdempotencyKey(1, 2, apigen.TaskError), taskEventIdempotencyKey(1, 2, apigen.TaskError))
+	require.NotEqual(t, taskEventIdempotencyKey(1, 2, apigen.TaskError), taskEventIdempotencyKey(1, 3, apigen.TaskError))
+	require.NotEqual(t, taskEventIdempotencyKey(1, 2, apigen.TaskError), taskEventIdempotencyKey(1, 2, apigen.TaskCompleted))
+	require.NotEqual(t, taskEventIdempotencyKey(1, 2, apigen.TaskError), taskEventIdempotencyKey(12, 2, apigen.TaskError))
+}
+
+func TestHandleCompletedCallsHook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	workerID := uuid.New()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockHandler := NewMockTaskHandler(ctrl)
+
+	spec := apigen.TaskSpec{Type: "demo"}
+	gomock.InOrder(
+		mockHandler.EXPECT().OnTaskCompleted(ctx, gomock.Any(), TaskSpec{Spec: spec}, int32(13)).Return(nil),
+		mockModel.EXPECT().UpdateTaskStatusIf(ctx, gomock.Any()).Return(int32(13), nil),
+	)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).Return(int32(13), nil)
+	mockModel.EXPECT().InsertEvent(ctx, gomock.Any()).Return(&querier.AnclaxEvent{ID: 3}, nil)
+
+	h := newLifecycleHandler(mockModel, mockHandler, workerID, time.Now())
+	err := h.HandleCompleted(ctx, &fakeTx{}, apigen.Task{ID: 13, Spec: spec})
+	require.NoError(t, err)
+}
+
+func TestHandleCompletedCronjobSkipsHook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	workerID := uuid.New()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockHandler := NewMockTaskHandler(ctrl)
+
+	mockModel.EXPECT().UpdateTaskStartedAtByWorker(ctx, gomock.Any()).Return(int32(14), nil)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).Return(int32(14), nil)
+
+	h := newLifecycleHandler(mockModel, mockHandler, workerID, time.Now())
+	task := apigen.Task{ID: 14, Spec: apigen.TaskSpec{Type: "demo"}, Attributes: apigen.TaskAttributes{Cronjob: &apigen.TaskCronjob{CronExpression: "*/5 * * * * *"}}}
+	err := h.HandleCompleted(ctx, &fakeTx{}, task)
+	require.NoError(t, err)
+}
+
+func TestHandleCompletedSkipsWhenStatusChangedConcurrently(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	workerID := uuid.New()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+
+	// the task was cancelled while running, so the pending -> completed swap is rejected
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, querier.UpdateTaskStatusIfParams{
+		ID:             9,
+		ExpectedStatus: string(apigen.Pending),
+		NewStatus:      string(apigen.Completed),
+	}).Return(int32(0), pgx.ErrNoRows)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).Return(int32(9), nil)
+
+	h := newLifecycleHandler(mockModel, nil, workerID, time.Now())
+	err := h.HandleCompleted(ctx, &fakeTx{}, apigen.Task{ID: 9})
+	require.NoError(t, err)
+}
+
+func TestHandleFailedPermanentFailureSkipsHookWhenStatusChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	workerID := uuid.New()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+	mockHandler := NewMockTaskHandler(ctrl)
+
+	mockModel.EXPECT().InsertEvent(ctx, gomock.Any()).Return(&querier.AnclaxEvent{ID: 1}, nil)
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, gomock.Any()).Return(int32(0), pgx.ErrNoRows)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).Return(int32(13), nil)
+
+	h := newLifecycleHandler(mockModel, mockHandler, workerID, time.Now())
+	task := apigen.Task{ID: 13, Spec: apigen.TaskSpec{Type: "demo"}}
+	err := h.HandleFailed(ctx, &fakeTx{}, task, errors.New("boom"))
+	require.NoError(t, err)
+}
+
+func TestHandleCompletedLockLostAfterStatusSwap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+
+	mockModel.EXPECT().UpdateTaskStatusIf(ctx, gomock.Any()).Return(int32(10), nil)
+	mockModel.EXPECT().ReleaseTaskLockByWorker(ctx, gomock.Any()).Return(int32(0), pgx.ErrNoRows)
+
+	h := newLifecycleHandler(mockModel, nil, uuid.New(), time.Now())
+	err := h.HandleCompleted(ctx, &fakeTx{}, apigen.Task{ID: 10})
+	require.ErrorIs(t, err, taskcore.ErrTaskLockLost)
+}
+
 func TestHandleCompletedInvalidCronExpression(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -280,3 +527,48 @@ func TestHandleCompletedInvalidCronExpression(t *testing.T) {
 	require.Error(t, err)
 	require.ErrorContains(t, err, "invalid cron expression")
 }
+
+func TestHandleCompletedInvalidCronTimezone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timezone := "Mars/Olympus_Mons"
+	h := newLifecycleHandler(model.NewMockModelInterfaceWithTransaction(ctrl), nil, uuid.New(), time.Now())
+	task := apigen.Task{ID: 4, Attributes: apigen.TaskAttributes{Cronjob: &apigen.TaskCronjob{CronExpression: "*/5 * * * * *", Timezone: &timezone}}}
+	err := h.HandleCompleted(context.Background(), &fakeTx{}, task)
+	require.Error(t, err)
+	require.ErrorContains(t, err, `invalid cron timezone "Mars/Olympus_Mons"`)
+}
+
+func TestHandleFailedOutsideExecutionWindowDefersTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	now := time.Date(2025, 4, 2, 12, 0, 0, 0, time.UTC)
+	workerID := uuid.New()
+	mockModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+
+	mockModel.EXPECT().DeferTaskByWorker(ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, params querier.DeferTaskByWorkerParams) (int32, error) {
+			require.Equal(t, int32(11), params.ID)
+			require.Equal(t, time.Date(2025, 4, 2, 22, 0, 0, 0, time.UTC), *params.StartedAt)
+			require.Equal(t, uuid.NullUUID{UUID: workerID, Valid: true}, params.WorkerID)
+			return params.ID, nil
+		},
+	)
+
+	h := newLifecycleHandler(mockModel, nil, workerID, now)
+	task := apigen.Task{
+		ID:       11,
+		Attempts: 1,
+		Attributes: apigen.TaskAttributes{
+			RetryPolicy: &apigen.TaskRetryPolicy{Interval: "1s", MaxAttempts: 1},
+			ExecutionWindow: &apigen.TaskExecutionWindow{
+				Ranges: []apigen.TaskExecutionWindowRange{{Start: "22:00", End: "06:00"}},
+			},
+		},
+	}
+	err := h.HandleFailed(ctx, &fakeTx{}, task, errOutsideExecutionWindow)
+	require.NoError(t, err)
+}
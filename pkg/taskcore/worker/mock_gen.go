@@ -55,6 +55,20 @@ func (mr *MockTaskHandlerMockRecorder) HandleTask(ctx, task any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleTask", reflect.TypeOf((*MockTaskHandler)(nil).HandleTask), ctx, task)
 }
 
+// OnTaskCompleted mocks base method.
+func (m *MockTaskHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec TaskSpec, taskID int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OnTaskCompleted", ctx, tx, completedTaskSpec, taskID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// OnTaskCompleted indicates an expected call of OnTaskCompleted.
+func (mr *MockTaskHandlerMockRecorder) OnTaskCompleted(ctx, tx, completedTaskSpec, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnTaskCompleted", reflect.TypeOf((*MockTaskHandler)(nil).OnTaskCompleted), ctx, tx, completedTaskSpec, taskID)
+}
+
 // OnTaskFailed mocks base method.
 func (m *MockTaskHandler) OnTaskFailed(ctx context.Context, tx core.Tx, failedTaskSpec TaskSpec, taskID int32) error {
 	m.ctrl.T.Helper()
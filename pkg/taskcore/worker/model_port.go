@@ -22,6 +22,13 @@ import (
 
 var errSkipFinalize = errors.New("skip finalize")
 
+// errDeadlineTooClose marks a claimed task that was not started because the worker context
+// expires too soon. Its lock and attempt are given back so that the next pull can run it.
+var errDeadlineTooClose = errors.New("not enough time left before the worker deadline")
+
+// defaultMinExecutionBudget is the time the worker context must have left for a task to start.
+const defaultMinExecutionBudget = time.Second
+
 type ModelPort struct {
 	model model.ModelInterface
 
@@ -30,9 +37,11 @@ type ModelPort struct {
 	labels        []string
 	hasLabels     bool
 	labelsJSON    json.RawMessage
+	orgIDs        []int32
 
 	lockTTL             time.Duration
 	lockRefreshInterval time.Duration
+	minExecutionBudget  time.Duration
 	lifeCycleHandler    TaskLifeCycleHandlerInterface
 	taskHandler         TaskHandler
 	now                 func() time.Time
@@ -44,12 +53,14 @@ type ModelPort struct {
 type taskRuntimeEntry struct {
 	cancel context.CancelCauseFunc
 	done   chan struct{}
+	result *taskcore.TaskResult
 }
 
 func newTaskRuntimeEntry(cancel context.CancelCauseFunc) *taskRuntimeEntry {
 	return &taskRuntimeEntry{
 		cancel: cancel,
 		done:   make(chan struct{}),
+		result: &taskcore.TaskResult{},
 	}
 }
 
@@ -87,6 +98,7 @@ func NewModelPort(
 		labelsJSON:          labelsJSON,
 		lockTTL:             lockTTL,
 		lockRefreshInterval: lockRefreshInterval,
+		minExecutionBudget:  defaultMinExecutionBudget,
 		lifeCycleHandler:    NewTaskLifeCycleHandler(m, taskHandler, workerID),
 		taskHandler:         taskHandler,
 		now:                 time.Now,
@@ -113,6 +125,18 @@ func (p *ModelPort) MarkWorkerOffline(ctx context.Context, workerID string) erro
 	return nil
 }
 
+// SetOrgIDs restricts the claimed tasks to the ones belonging to the given organizations,
+// an empty list claims tasks of every organization.
+func (p *ModelPort) SetOrgIDs(orgIDs []int32) {
+	p.orgIDs = append([]int32(nil), orgIDs...)
+}
+
+// SetMinExecutionBudget sets the time the worker context must have left before its
+// deadline for a claimed task to start. Zero disables the check.
+func (p *ModelPort) SetMinExecutionBudget(d time.Duration) {
+	p.minExecutionBudget = d
+}
+
 func (p *ModelPort) ClaimStrict(ctx context.Context, req ClaimRequest) (*Task, error) {
 	lockExpiry := p.now().Add(-p.lockTTL)
 	var out *Task
@@ -122,6 +146,7 @@ func (p *ModelPort) ClaimStrict(ctx context.Context, req ClaimRequest) (*Task, e
 			LockExpiry: &lockExpiry,
 			Labels:     p.labels,
 			HasLabels:  p.hasLabels,
+			OrgIds:     p.orgIDs,
 		})
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
@@ -152,6 +177,8 @@ func (p *ModelPort) ClaimNormalByGroup(ctx context.Context, req ClaimNormalReque
 			HasLabels:      p.hasLabels,
 			GroupName:      req.Group,
 			WeightedLabels: append([]string(nil), req.WeightedLabels...),
+			OrgIds:         p.orgIDs,
+			ExcludeTypes:   append([]string(nil), req.ExcludeTypes...),
 		})
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
@@ -181,6 +208,7 @@ func (p *ModelPort) ClaimByID(ctx context.Context, taskID int32, req ClaimReques
 			LockExpiry: &lockExpiry,
 			Labels:     p.labels,
 			HasLabels:  p.hasLabels,
+			OrgIds:     p.orgIDs,
 		})
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
@@ -201,11 +229,28 @@ func (p *ModelPort) ClaimByID(ctx context.Context, taskID int32, req ClaimReques
 }
 
 func (p *ModelPort) ExecuteTask(ctx context.Context, task Task) error {
-	baseCtx, baseCancel := context.WithCancelCause(ctx)
+	if deadline, ok := ctx.Deadline(); ok && p.minExecutionBudget > 0 && deadline.Sub(p.now()) < p.minExecutionBudget {
+		return errDeadlineTooClose
+	}
+
+	baseCtx, baseCancel := context.WithCancelCause(taskcore.ContextWithTaskID(ctx, task.ID))
 	p.registerTaskRuntime(task.ID, baseCancel)
 	defer func() {
 		baseCancel(nil)
 	}()
+	baseCtx = taskcore.ContextWithTaskResult(baseCtx, p.taskRuntimeEntry(task.ID).result)
+	if task.OrgID != nil {
+		// tasks pushed by the handler belong to the same organization
+		baseCtx = taskcore.ContextWithOrgID(baseCtx, *task.OrgID)
+	}
+
+	_, inWindow, err := nextExecutionWindowStart(task.Attributes.ExecutionWindow, p.now())
+	if err != nil {
+		return err
+	}
+	if !inWindow {
+		return errOutsideExecutionWindow
+	}
 
 	execCtx, cancel, err := p.withTaskTimeout(baseCtx, task)
 	if err != nil {
@@ -255,6 +300,15 @@ func (p *ModelPort) FinalizeTask(ctx context.Context, task Task, execErr error)
 	if errors.Is(execErr, errSkipFinalize) {
 		return nil
 	}
+	if errors.Is(execErr, errDeadlineTooClose) {
+		if err := p.deferTask(ctx, task.ID); err != nil {
+			if errors.Is(err, taskcore.ErrTaskLockLost) {
+				return nil
+			}
+			return fmt.Errorf("finalize deferred task: %w", err)
+		}
+		return nil
+	}
 	if errors.Is(execErr, taskcore.ErrTaskInterrupted) {
 		if err := p.releaseTaskLock(ctx, task.ID); err != nil {
 			if errors.Is(err, taskcore.ErrTaskLockLost) {
@@ -279,6 +333,9 @@ func (p *ModelPort) FinalizeTask(ctx context.Context, task Task, execErr error)
 		return nil
 	}
 
+	if entry := p.taskRuntimeEntry(task.ID); entry != nil {
+		apiTask.Result = entry.result.Value()
+	}
 	err := p.model.RunTransactionWithTx(ctx, func(tx core.Tx, txm model.ModelInterface) error {
 		return p.lifeCycleHandler.HandleCompleted(ctx, tx, apiTask)
 	})
@@ -479,6 +536,7 @@ func taskFromQuerier(task *querier.AnclaxTask) *Task {
 		Attempts:   apiTask.Attempts,
 		Attributes: apiTask.Attributes,
 		Spec:       apiTask.Spec,
+		OrgID:      apiTask.OrgId,
 	}
 }
 
@@ -488,6 +546,7 @@ func taskToAPI(task Task) apigen.Task {
 		Attempts:   task.Attempts,
 		Attributes: task.Attributes,
 		Spec:       task.Spec,
+		OrgId:      task.OrgID,
 	}
 }
 
@@ -522,6 +581,23 @@ func (p *ModelPort) releaseTaskLock(ctx context.Context, taskID int32) error {
 	})
 }
 
+// deferTask releases the lock of a task that was claimed but not started, and gives back
+// the attempt consumed by the claim, so it is not counted as a failure.
+func (p *ModelPort) deferTask(ctx context.Context, taskID int32) error {
+	now := p.now()
+	if _, err := p.model.DeferTaskByWorker(ctx, querier.DeferTaskByWorkerParams{
+		ID:        taskID,
+		StartedAt: &now,
+		WorkerID:  p.workerIDParam,
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return taskcore.ErrTaskLockLost
+		}
+		return err
+	}
+	return nil
+}
+
 func (p *ModelPort) taskInterruptCause(ctx context.Context) error {
 	cause := context.Cause(ctx)
 	switch {
@@ -214,11 +214,12 @@ func TestClaimPathsHasLabelsMatrix(t *testing.T) {
 			func(ctx context.Context, params querier.ClaimNormalTaskByGroupParams) (*querier.AnclaxTask, error) {
 				require.Equal(t, labels, params.Labels)
 				require.True(t, params.HasLabels)
+				require.Equal(t, []string{"flood"}, params.ExcludeTypes)
 				return nil, pgx.ErrNoRows
 			},
 		)
 
-		_, err = port.ClaimNormalByGroup(context.Background(), ClaimNormalRequest{Group: DefaultWeightGroup})
+		_, err = port.ClaimNormalByGroup(context.Background(), ClaimNormalRequest{Group: DefaultWeightGroup, ExcludeTypes: []string{"flood"}})
 		require.ErrorIs(t, err, ErrNoTask)
 	})
 
@@ -253,6 +254,75 @@ func TestClaimPathsHasLabelsMatrix(t *testing.T) {
 	})
 }
 
+func TestClaimPathsRestrictToConfiguredOrgs(t *testing.T) {
+	orgA := []int32{1}
+
+	newPort := func(t *testing.T, ctrl *gomock.Controller) (*ModelPort, *model.ExtendMockModel) {
+		mockModel := model.NewMockModelInterface(ctrl)
+		mockTxModel := model.NewMockModelInterfaceWithTransaction(ctrl)
+		mockTx := core.NewMockTx(ctrl)
+
+		port, err := NewModelPort(mockModel, uuid.New(), nil, nil, 5*time.Second, 0)
+		require.NoError(t, err)
+		port.SetOrgIDs(orgA)
+
+		mockModel.EXPECT().RunTransactionWithTx(context.Background(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, f func(core.Tx, model.ModelInterface) error) error {
+				return f(mockTx, mockTxModel)
+			},
+		)
+		return port, mockTxModel
+	}
+
+	t.Run("strict claim skips tasks of other orgs", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		port, mockTxModel := newPort(t, ctrl)
+		mockTxModel.EXPECT().ClaimStrictTask(context.Background(), gomock.AssignableToTypeOf(querier.ClaimStrictTaskParams{})).DoAndReturn(
+			func(ctx context.Context, params querier.ClaimStrictTaskParams) (*querier.AnclaxTask, error) {
+				require.Equal(t, orgA, params.OrgIds)
+				return nil, pgx.ErrNoRows
+			},
+		)
+
+		_, err := port.ClaimStrict(context.Background(), ClaimRequest{})
+		require.ErrorIs(t, err, ErrNoTask)
+	})
+
+	t.Run("normal claim skips tasks of other orgs", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		port, mockTxModel := newPort(t, ctrl)
+		mockTxModel.EXPECT().ClaimNormalTaskByGroup(context.Background(), gomock.AssignableToTypeOf(querier.ClaimNormalTaskByGroupParams{})).DoAndReturn(
+			func(ctx context.Context, params querier.ClaimNormalTaskByGroupParams) (*querier.AnclaxTask, error) {
+				require.Equal(t, orgA, params.OrgIds)
+				return nil, pgx.ErrNoRows
+			},
+		)
+
+		_, err := port.ClaimNormalByGroup(context.Background(), ClaimNormalRequest{Group: DefaultWeightGroup})
+		require.ErrorIs(t, err, ErrNoTask)
+	})
+
+	t.Run("claim-by-id does not run a task of another org", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		port, mockTxModel := newPort(t, ctrl)
+		mockTxModel.EXPECT().ClaimTaskByID(context.Background(), gomock.AssignableToTypeOf(querier.ClaimTaskByIDParams{})).DoAndReturn(
+			func(ctx context.Context, params querier.ClaimTaskByIDParams) (*querier.AnclaxTask, error) {
+				require.Equal(t, orgA, params.OrgIds)
+				return nil, pgx.ErrNoRows
+			},
+		)
+
+		_, err := port.ClaimByID(context.Background(), 123, ClaimRequest{})
+		require.ErrorIs(t, err, ErrNoTask)
+	})
+}
+
 func TestStartLockRefreshTransientErrorsDoNotInterrupt(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -440,3 +510,215 @@ func TestTaskInterruptCauseRecognizesPauseAndCancel(t *testing.T) {
 	pause(taskcore.ErrTaskPaused)
 	require.ErrorIs(t, port.taskInterruptCause(pauseCtx), taskcore.ErrTaskPaused)
 }
+
+func TestExecuteTaskRespectsExecutionWindow(t *testing.T) {
+	window := &apigen.TaskExecutionWindow{
+		Ranges: []apigen.TaskExecutionWindowRange{{Start: "01:00", End: "05:00"}},
+	}
+
+	t.Run("outside window skips handler", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockModel := model.NewMockModelInterface(ctrl)
+		taskHandler := NewMockTaskHandler(ctrl)
+		port, err := NewModelPort(mockModel, uuid.New(), nil, taskHandler, 5*time.Second, 0)
+		require.NoError(t, err)
+		port.now = func() time.Time { return time.Date(2025, 4, 2, 12, 0, 0, 0, time.UTC) }
+		port.lifeCycleHandler = &fakeTaskLifeCycleHandler{}
+
+		task := Task{ID: 31, Attributes: apigen.TaskAttributes{ExecutionWindow: window}}
+		err = port.ExecuteTask(context.Background(), task)
+		require.ErrorIs(t, err, errOutsideExecutionWindow)
+		port.completeTaskRuntime(task.ID)
+	})
+
+	t.Run("inside window runs handler", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockModel := model.NewMockModelInterface(ctrl)
+		taskHandler := NewMockTaskHandler(ctrl)
+		port, err := NewModelPort(mockModel, uuid.New(), nil, taskHandler, 5*time.Second, 0)
+		require.NoError(t, err)
+		port.now = func() time.Time { return time.Date(2025, 4, 2, 3, 0, 0, 0, time.UTC) }
+		port.lifeCycleHandler = &fakeTaskLifeCycleHandler{}
+
+		task := Task{ID: 32, Attributes: apigen.TaskAttributes{ExecutionWindow: window}}
+		mockModel.EXPECT().RunTransactionWithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, f func(core.Tx, model.ModelInterface) error) error {
+				return f(&fakeTx{}, mockModel)
+			},
+		)
+		taskHandler.EXPECT().HandleTask(gomock.Any(), task).Return(nil)
+
+		require.NoError(t, port.ExecuteTask(context.Background(), task))
+		port.completeTaskRuntime(task.ID)
+	})
+}
+
+func TestExecuteTaskSkipsTaskNearWorkerDeadline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	taskHandler := NewMockTaskHandler(ctrl)
+	port, err := NewModelPort(mockModel, uuid.New(), nil, taskHandler, 5*time.Second, 0)
+	require.NoError(t, err)
+	port.lifeCycleHandler = &fakeTaskLifeCycleHandler{
+		handleAttributes: func(ctx context.Context, tx core.Tx, task apigen.Task) error {
+			t.Fatalf("task near the deadline must not be started")
+			return nil
+		},
+	}
+
+	// the deadline is an hour away by the wall clock, but close by the port clock
+	deadline := time.Now().Add(time.Hour)
+	now := deadline.Add(-100 * time.Millisecond)
+	port.now = func() time.Time { return now }
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	task := Task{ID: 34}
+	err = port.ExecuteTask(ctx, task)
+	require.ErrorIs(t, err, errDeadlineTooClose)
+
+	// the lock and the attempt are given back so that the next pull runs the task
+	mockModel.EXPECT().DeferTaskByWorker(ctx, querier.DeferTaskByWorkerParams{
+		ID:        task.ID,
+		StartedAt: &now,
+		WorkerID:  port.workerIDParam,
+	}).Return(task.ID, nil)
+	require.NoError(t, port.FinalizeTask(ctx, task, err))
+
+	// with the check disabled the task runs
+	port.SetMinExecutionBudget(0)
+	port.lifeCycleHandler = &fakeTaskLifeCycleHandler{}
+	mockModel.EXPECT().RunTransactionWithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, f func(core.Tx, model.ModelInterface) error) error {
+			return f(&fakeTx{}, mockModel)
+		},
+	)
+	taskHandler.EXPECT().HandleTask(gomock.Any(), task).Return(nil)
+	require.NoError(t, port.ExecuteTask(ctx, task))
+	port.completeTaskRuntime(task.ID)
+}
+
+func TestExecuteTaskSetsTaskIDInContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	taskHandler := NewMockTaskHandler(ctrl)
+	port, err := NewModelPort(mockModel, uuid.New(), nil, taskHandler, 5*time.Second, 0)
+	require.NoError(t, err)
+	port.lifeCycleHandler = &fakeTaskLifeCycleHandler{}
+
+	task := Task{ID: 33}
+	mockModel.EXPECT().RunTransactionWithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, f func(core.Tx, model.ModelInterface) error) error {
+			return f(&fakeTx{}, mockModel)
+		},
+	)
+	taskHandler.EXPECT().HandleTask(gomock.Any(), task).DoAndReturn(
+		func(ctx context.Context, task Task) error {
+			taskID, ok := taskcore.TaskIDFromContext(ctx)
+			require.True(t, ok)
+			require.Equal(t, int32(33), taskID)
+			return nil
+		},
+	)
+
+	require.NoError(t, port.ExecuteTask(context.Background(), task))
+	port.completeTaskRuntime(task.ID)
+
+	_, ok := taskcore.TaskIDFromContext(context.Background())
+	require.False(t, ok)
+}
+
+func TestExecuteTaskSetsOrgIDInContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockModel := model.NewMockModelInterface(ctrl)
+	taskHandler := NewMockTaskHandler(ctrl)
+	port, err := NewModelPort(mockModel, uuid.New(), nil, taskHandler, 5*time.Second, 0)
+	require.NoError(t, err)
+	port.lifeCycleHandler = &fakeTaskLifeCycleHandler{}
+
+	orgID := int32(7)
+	task := Task{ID: 33, OrgID: &orgID}
+	mockModel.EXPECT().RunTransactionWithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, f func(core.Tx, model.ModelInterface) error) error {
+			return f(&fakeTx{}, mockModel)
+		},
+	)
+	taskHandler.EXPECT().HandleTask(gomock.Any(), task).DoAndReturn(
+		func(ctx context.Context, task Task) error {
+			got, ok := taskcore.OrgIDFromContext(ctx)
+			require.True(t, ok)
+			require.Equal(t, orgID, got)
+			return nil
+		},
+	)
+
+	require.NoError(t, port.ExecuteTask(context.Background(), task))
+	port.completeTaskRuntime(task.ID)
+}
+
+func TestExecuteTaskResultIsPersistedOnlyOnCompletion(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		execErr error
+	}{
+		{name: "completed", execErr: nil},
+		{name: "failed", execErr: stdErrors.New("boom")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockModel := model.NewMockModelInterface(ctrl)
+			taskHandler := NewMockTaskHandler(ctrl)
+			port, err := NewModelPort(mockModel, uuid.New(), nil, taskHandler, 5*time.Second, 0)
+			require.NoError(t, err)
+
+			var completed, failed *apigen.Task
+			port.lifeCycleHandler = &fakeTaskLifeCycleHandler{
+				handleCompleted: func(ctx context.Context, tx core.Tx, task apigen.Task) error {
+					completed = &task
+					return nil
+				},
+				handleFailed: func(ctx context.Context, tx core.Tx, task apigen.Task, execErr error) error {
+					failed = &task
+					return nil
+				},
+			}
+
+			task := Task{ID: 34}
+			mockModel.EXPECT().RunTransactionWithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, f func(core.Tx, model.ModelInterface) error) error {
+					return f(&fakeTx{}, mockModel)
+				},
+			).Times(2)
+			taskHandler.EXPECT().HandleTask(gomock.Any(), task).DoAndReturn(
+				func(ctx context.Context, task Task) error {
+					require.NoError(t, taskcore.SetResult(ctx, map[string]string{"url": "s3://reports/34"}))
+					return tc.execErr
+				},
+			)
+
+			execErr := port.ExecuteTask(context.Background(), task)
+			require.Equal(t, tc.execErr, execErr)
+			require.NoError(t, port.FinalizeTask(context.Background(), task, execErr))
+
+			if tc.execErr == nil {
+				require.NotNil(t, completed)
+				require.JSONEq(t, `{"url":"s3://reports/34"}`, string(completed.Result))
+			} else {
+				require.NotNil(t, failed)
+				require.Empty(t, failed.Result)
+			}
+		})
+	}
+}
@@ -3,12 +3,16 @@ package worker
 import (
 	"context"
 	"errors"
+	"math/rand"
 	"sync"
 	"time"
 )
 
 type RuntimeOptions struct {
-	PollInterval          time.Duration
+	PollInterval time.Duration
+	// PollJitter spreads polls of workers started together, each interval is picked at random
+	// within PollInterval ± PollJitter*PollInterval. It is clamped to [0, 1], zero disables it.
+	PollJitter            float64
 	HeartbeatInterval     time.Duration
 	RuntimeConfigInterval time.Duration
 	OnError               func(error)
@@ -38,6 +42,9 @@ type Runtime struct {
 
 	inbox chan runtimeEnvelope
 
+	// randFloat returns a number in [0, 1) used to jitter the poll interval.
+	randFloat func() float64
+
 	stopOnce sync.Once
 	stopCh   chan struct{}
 	loopDone chan struct{}
@@ -53,14 +60,16 @@ func NewRuntime(engine *Engine, port Port, opts RuntimeOptions) *Runtime {
 	if opts.RuntimeConfigInterval < 0 {
 		opts.RuntimeConfigInterval = 0
 	}
+	opts.PollJitter = min(max(opts.PollJitter, 0), 1)
 
 	r := &Runtime{
-		engine:   engine,
-		port:     port,
-		opts:     opts,
-		inbox:    make(chan runtimeEnvelope, 2048),
-		stopCh:   make(chan struct{}),
-		loopDone: make(chan struct{}),
+		engine:    engine,
+		port:      port,
+		opts:      opts,
+		inbox:     make(chan runtimeEnvelope, 2048),
+		randFloat: rand.Float64,
+		stopCh:    make(chan struct{}),
+		loopDone:  make(chan struct{}),
 	}
 	go r.eventLoop()
 	return r
@@ -251,6 +260,7 @@ func (r *Runtime) Start(ctx context.Context) {
 
 	var (
 		pollTicker   *time.Ticker
+		pollTimer    *time.Timer
 		heartTicker  *time.Ticker
 		configTicker *time.Ticker
 
@@ -259,7 +269,11 @@ func (r *Runtime) Start(ctx context.Context) {
 		configCh <-chan time.Time
 	)
 
-	if r.opts.PollInterval > 0 {
+	if r.opts.PollInterval > 0 && r.opts.PollJitter > 0 {
+		pollTimer = time.NewTimer(r.nextPollInterval())
+		pollCh = pollTimer.C
+		defer pollTimer.Stop()
+	} else if r.opts.PollInterval > 0 {
 		pollTicker = time.NewTicker(r.opts.PollInterval)
 		pollCh = pollTicker.C
 		defer pollTicker.Stop()
@@ -283,6 +297,9 @@ func (r *Runtime) Start(ctx context.Context) {
 			r.Step(context.Background(), Event{Type: EventStop})
 			return
 		case <-pollCh:
+			if pollTimer != nil {
+				pollTimer.Reset(r.nextPollInterval())
+			}
 			r.enqueue(ctx, Event{Type: EventPollTick}, false)
 		case <-heartCh:
 			r.enqueue(ctx, Event{Type: EventHeartbeatTick}, false)
@@ -292,6 +309,16 @@ func (r *Runtime) Start(ctx context.Context) {
 	}
 }
 
+// nextPollInterval returns the poll interval with a random jitter of up to
+// ±PollJitter*PollInterval applied.
+func (r *Runtime) nextPollInterval() time.Duration {
+	if r.opts.PollJitter == 0 {
+		return r.opts.PollInterval
+	}
+	offset := (2*r.randFloat() - 1) * r.opts.PollJitter * float64(r.opts.PollInterval)
+	return max(r.opts.PollInterval+time.Duration(offset), time.Millisecond)
+}
+
 func (r *Runtime) execCommand(ctx context.Context, cmd Command) []Event {
 	switch cmd.Type {
 	case CmdClaimStrict:
@@ -322,6 +349,7 @@ func (r *Runtime) execCommand(ctx context.Context, cmd Command) []Event {
 		cycleID := cmd.CycleID
 		group := cmd.Group
 		weighted := append([]string(nil), cmd.WeightedLabels...)
+		excludeTypes := append([]string(nil), cmd.ExcludeTypes...)
 
 		go func() {
 			task, err := r.port.ClaimNormalByGroup(ctx, ClaimNormalRequest{
@@ -332,6 +360,7 @@ func (r *Runtime) execCommand(ctx context.Context, cmd Command) []Event {
 				},
 				Group:          group,
 				WeightedLabels: weighted,
+				ExcludeTypes:   excludeTypes,
 			})
 			if errors.Is(err, ErrNoTask) {
 				err = nil
@@ -3,6 +3,8 @@ package worker
 import (
 	"context"
 	stdErrors "errors"
+	"math/rand"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -360,3 +362,66 @@ func TestWorkerShutsDownWhenHeartbeatFails(t *testing.T) {
 		return len(port.offlineCalls) == 1
 	}, time.Second, 10*time.Millisecond)
 }
+
+func TestRuntimePollIntervalJitter(t *testing.T) {
+	eng := NewEngine(EngineConfig{WorkerID: "w-jitter", Concurrency: 1})
+	rt := NewRuntime(eng, &scriptedPort{}, RuntimeOptions{PollInterval: time.Second, PollJitter: 0.2})
+	defer rt.Close()
+
+	rolls := []float64{0, 0.25, 0.5, 0.75}
+	rt.randFloat = func() float64 {
+		roll := rolls[0]
+		rolls = rolls[1:]
+		return roll
+	}
+	require.Equal(t, 800*time.Millisecond, rt.nextPollInterval())
+	require.Equal(t, 900*time.Millisecond, rt.nextPollInterval())
+	require.Equal(t, time.Second, rt.nextPollInterval())
+	require.Equal(t, 1100*time.Millisecond, rt.nextPollInterval())
+
+	rt.randFloat = rand.Float64
+	seen := map[time.Duration]struct{}{}
+	for range 100 {
+		interval := rt.nextPollInterval()
+		require.GreaterOrEqual(t, interval, 800*time.Millisecond)
+		require.Less(t, interval, 1200*time.Millisecond)
+		seen[interval] = struct{}{}
+	}
+	require.Greater(t, len(seen), 1, "successive intervals must vary")
+}
+
+func TestRuntimePollJitterIsClamped(t *testing.T) {
+	eng := NewEngine(EngineConfig{WorkerID: "w-jitter", Concurrency: 1})
+
+	rt := NewRuntime(eng, &scriptedPort{}, RuntimeOptions{PollInterval: time.Second})
+	rt.randFloat = func() float64 { return 0 }
+	require.Equal(t, time.Second, rt.nextPollInterval())
+	rt.Close()
+
+	rt = NewRuntime(eng, &scriptedPort{}, RuntimeOptions{PollInterval: time.Second, PollJitter: 3})
+	rt.randFloat = func() float64 { return 0 }
+	require.Equal(t, time.Millisecond, rt.nextPollInterval())
+	rt.Close()
+}
+
+func TestRuntimeStartPollsRepeatedlyWithJitter(t *testing.T) {
+	eng := NewEngine(EngineConfig{WorkerID: "w-jitter", Concurrency: 1})
+	port := &scriptedPort{}
+	rt := NewRuntime(eng, port, RuntimeOptions{PollInterval: 10 * time.Millisecond, PollJitter: 0.5})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rt.Start(ctx)
+
+	require.Eventually(t, func() bool {
+		port.mu.Lock()
+		defer port.mu.Unlock()
+		claims := 0
+		for _, call := range port.callOrder {
+			if strings.HasPrefix(call, "claim_") {
+				claims++
+			}
+		}
+		return claims >= 3
+	}, time.Second, 5*time.Millisecond)
+}
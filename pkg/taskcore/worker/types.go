@@ -73,6 +73,9 @@ type Task struct {
 	Attempts   int32
 	Attributes apigen.TaskAttributes
 	Spec       apigen.TaskSpec
+
+	// OrgID is the organization the task belongs to, nil for tasks without one.
+	OrgID *int32
 }
 
 func (t *Task) GetType() string {
@@ -112,6 +115,7 @@ type Command struct {
 	ExecErr        error
 	Group          string
 	WeightedLabels []string
+	ExcludeTypes   []string
 	RequestID      string
 	AppliedVersion int64
 }
@@ -126,6 +130,8 @@ type ClaimNormalRequest struct {
 	ClaimRequest
 	Group          string
 	WeightedLabels []string
+	// ExcludeTypes skips pending tasks of these types.
+	ExcludeTypes []string
 }
 
 type Port interface {
@@ -157,6 +163,9 @@ type EngineConfig struct {
 	Concurrency         int
 	MaxStrictPercentage int32
 	LabelWeights        map[string]int32
+	// MaxConsecutiveTaskType is the number of normal tasks of one type claimed in a row
+	// after which the next claim prefers other types. 0 disables the fairness check.
+	MaxConsecutiveTaskType int
 }
 
 type Snapshot struct {
@@ -180,4 +189,7 @@ type cycleState struct {
 	Task           *Task
 	PendingGroups  []string
 	WeightedLabels []string
+	ExcludeTypes   []string
+	// ClaimGroup is the group of the in-flight normal claim.
+	ClaimGroup string
 }
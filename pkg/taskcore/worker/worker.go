@@ -89,10 +89,19 @@ func BuildWorkerComponents(cfg *config.Config, m model.ModelInterface, taskHandl
 		maxStrictPercentage = int32(*cfg.Worker.MaxStrictPercentage)
 	}
 
+	maxConsecutiveTaskType := 0
+	if cfg.Worker.MaxConsecutiveTaskType != nil {
+		maxConsecutiveTaskType = *cfg.Worker.MaxConsecutiveTaskType
+	}
+
 	port, err := NewModelPort(m, workerID, labels, taskHandler, lockTTL, lockRefreshInterval)
 	if err != nil {
 		return nil, err
 	}
+	port.SetOrgIDs(cfg.Worker.OrgIDs)
+	if cfg.Worker.MinExecutionBudget != nil {
+		port.SetMinExecutionBudget(*cfg.Worker.MinExecutionBudget)
+	}
 
 	engine := NewEngine(EngineConfig{
 		WorkerID:            workerID.String(),
@@ -102,10 +111,17 @@ func BuildWorkerComponents(cfg *config.Config, m model.ModelInterface, taskHandl
 		LabelWeights: map[string]int32{
 			DefaultWeightGroup: 1,
 		},
+		MaxConsecutiveTaskType: maxConsecutiveTaskType,
 	})
 
+	pollJitter := 0.0
+	if cfg.Worker.PollJitter != nil {
+		pollJitter = *cfg.Worker.PollJitter
+	}
+
 	runtime := NewRuntime(engine, port, RuntimeOptions{
 		PollInterval:          pollInterval,
+		PollJitter:            pollJitter,
 		HeartbeatInterval:     heartbeatInterval,
 		RuntimeConfigInterval: runtimeConfigPoll,
 		OnError: func(err error) {
@@ -198,6 +214,8 @@ func (w *Worker) Start() {
 	}
 }
 
+// RunTask claims and executes the task immediately, it returns ErrNoTask if the task
+// cannot be claimed, e.g. it is not pending or is leased by another worker.
 func (w *Worker) RunTask(ctx context.Context, taskID int32) error {
 	if err := w.acquireSlot(ctx); err != nil {
 		return err
@@ -206,13 +224,10 @@ func (w *Worker) RunTask(ctx context.Context, taskID int32) error {
 
 	task, err := w.port.ClaimByID(ctx, taskID, ClaimRequest{})
 	if err != nil {
-		if err == ErrNoTask {
-			return nil
-		}
 		return err
 	}
 	if task == nil {
-		return nil
+		return ErrNoTask
 	}
 
 	execErr := w.port.ExecuteTask(ctx, *task)
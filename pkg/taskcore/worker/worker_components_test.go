@@ -21,6 +21,15 @@ func TestBuildWorkerComponentsAddsReservedWorkerLabel(t *testing.T) {
 	require.Contains(t, labels, "worker:"+workerID)
 }
 
+func TestBuildWorkerComponentsSetsOrgIDs(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Worker.OrgIDs = []int32{1, 2}
+
+	components, err := BuildWorkerComponents(cfg, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []int32{1, 2}, components.Port.orgIDs)
+}
+
 func TestBuildWorkerComponentsNilConfigError(t *testing.T) {
 	components, err := BuildWorkerComponents(nil, nil, nil)
 	require.Error(t, err)
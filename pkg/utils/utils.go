@@ -3,7 +3,9 @@ package utils
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -12,6 +14,8 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var CurrentVersion string
@@ -46,6 +50,102 @@ func HashPassword(password string, salt string) (string, error) {
 	return hashed, nil
 }
 
+// IsPasswordHash reports whether hash can be checked by CheckPassword: a hex encoded
+// SHA-256 digest produced by HashPassword, a bcrypt hash or an argon2id hash in the PHC
+// string format.
+func IsPasswordHash(hash string) bool {
+	switch {
+	case isBcryptHash(hash):
+		_, err := bcrypt.Cost([]byte(hash))
+		return err == nil
+	case strings.HasPrefix(hash, argon2idPrefix):
+		_, err := parseArgon2idHash(hash)
+		return err == nil
+	}
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil && strings.ToLower(hash) == hash
+}
+
+// CheckPassword reports whether password matches hash. Hashes are recognized by their
+// prefix, bcrypt ($2a$, $2b$, $2y$) and argon2id ($argon2id$) hashes imported from other
+// systems carry their own salt, other hashes are checked against HashPassword(password, salt).
+func CheckPassword(password, salt, hash string) (bool, error) {
+	switch {
+	case isBcryptHash(hash):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, errors.Wrap(err, "failed to compare bcrypt hash")
+		}
+		return true, nil
+	case strings.HasPrefix(hash, argon2idPrefix):
+		h, err := parseArgon2idHash(hash)
+		if err != nil {
+			return false, err
+		}
+		key := argon2.IDKey([]byte(password), h.salt, h.time, h.memory, h.threads, uint32(len(h.key)))
+		return subtle.ConstantTimeCompare(key, h.key) == 1, nil
+	}
+	input, err := HashPassword(password, salt)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare([]byte(input), []byte(hash)) == 1, nil
+}
+
+const argon2idPrefix = "$argon2id$"
+
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+type argon2idHash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// parseArgon2idHash parses $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>, salt and key are
+// base64 encoded without padding.
+func parseArgon2idHash(hash string) (*argon2idHash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return nil, errors.New("invalid argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, errors.Wrap(err, "invalid argon2id version")
+	}
+	if version != argon2.Version {
+		return nil, errors.Errorf("unsupported argon2id version %d", version)
+	}
+	var h argon2idHash
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time, &h.threads); err != nil {
+		return nil, errors.Wrap(err, "invalid argon2id parameters")
+	}
+	if h.memory == 0 || h.time == 0 || h.threads == 0 {
+		return nil, errors.New("invalid argon2id parameters")
+	}
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, errors.Wrap(err, "invalid argon2id salt")
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, errors.Wrap(err, "invalid argon2id key")
+	}
+	if len(h.key) == 0 {
+		return nil, errors.New("invalid argon2id key")
+	}
+	return &h, nil
+}
+
 func GenerateSaltAndHash(password string) (string, string, error) {
 	salt := fmt.Sprintf("salt-%d", rand.Int31())
 	hashedPassword, err := HashPassword(password, salt)
@@ -0,0 +1,61 @@
+package model
+
+import (
+	context "context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/require"
+)
+
+func newStubMigrator(t *testing.T) *migrate.Migrate {
+	t.Helper()
+
+	src, err := iofs.New(fstest.MapFS{
+		"0001_init.up.sql":     {Data: []byte("CREATE TABLE a ();")},
+		"0001_init.down.sql":   {Data: []byte("DROP TABLE a;")},
+		"0002_second.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+		"0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"0003_third.up.sql":    {Data: []byte("CREATE TABLE c ();")},
+		"0003_third.down.sql":  {Data: []byte("DROP TABLE c;")},
+	}, ".")
+	require.NoError(t, err)
+	db, err := stub.WithInstance(nil, &stub.Config{})
+	require.NoError(t, err)
+	m, err := migrate.NewWithInstance("iofs", src, "stub", db)
+	require.NoError(t, err)
+	return m
+}
+
+func TestMigrationVersion(t *testing.T) {
+	ctx := context.Background()
+	m := &Model{migrator: newStubMigrator(t)}
+
+	version, dirty, err := m.MigrationVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint(0), version)
+	require.False(t, dirty)
+
+	require.NoError(t, m.Migrate(ctx, 3))
+	version, dirty, err = m.MigrationVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint(3), version)
+	require.False(t, dirty)
+
+	require.NoError(t, m.Migrate(ctx, 2))
+	version, _, err = m.MigrationVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint(2), version)
+
+	// migrating to the current version is a no-op
+	require.NoError(t, m.Migrate(ctx, 2))
+	require.Error(t, m.Migrate(ctx, 4))
+}
+
+func TestMigrationVersionWithoutMigrator(t *testing.T) {
+	_, _, err := (&Model{}).MigrationVersion(context.Background())
+	require.Error(t, err)
+}
@@ -24,9 +24,17 @@ func (e *ExtendMockModel) RunTransactionWithTx(ctx context.Context, f func(tx co
 	return f(nil, e)
 }
 
+func (e *ExtendMockModel) RunTransactionWithRetry(ctx context.Context, maxAttempts int, f func(model ModelInterface) error) error {
+	return f(e)
+}
+
 func (e *ExtendMockModel) SpawnWithTx(tx core.Tx) ModelInterface {
 	return e
 }
 
+func (e *ExtendMockModel) ReadOnly() ModelInterface {
+	return e
+}
+
 func (e *ExtendMockModel) Close() {
 }
@@ -16,7 +16,6 @@ import (
 	time "time"
 
 	core "github.com/cloudcarver/anclax/core"
-	apigen "github.com/cloudcarver/anclax/pkg/zgen/apigen"
 	querier "github.com/cloudcarver/anclax/pkg/zgen/querier"
 	uuid "github.com/google/uuid"
 	gomock "go.uber.org/mock/gomock"
@@ -46,6 +45,36 @@ func (m *MockModelInterface) EXPECT() *MockModelInterfaceMockRecorder {
 	return m.recorder
 }
 
+// AcceptUserTotpStep mocks base method.
+func (m *MockModelInterface) AcceptUserTotpStep(ctx context.Context, arg querier.AcceptUserTotpStepParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptUserTotpStep", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptUserTotpStep indicates an expected call of AcceptUserTotpStep.
+func (mr *MockModelInterfaceMockRecorder) AcceptUserTotpStep(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptUserTotpStep", reflect.TypeOf((*MockModelInterface)(nil).AcceptUserTotpStep), ctx, arg)
+}
+
+// ArchiveEventsBefore mocks base method.
+func (m *MockModelInterface) ArchiveEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveEventsBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchiveEventsBefore indicates an expected call of ArchiveEventsBefore.
+func (mr *MockModelInterfaceMockRecorder) ArchiveEventsBefore(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveEventsBefore", reflect.TypeOf((*MockModelInterface)(nil).ArchiveEventsBefore), ctx, before)
+}
+
 // ClaimNormalTaskByGroup mocks base method.
 func (m *MockModelInterface) ClaimNormalTaskByGroup(ctx context.Context, arg querier.ClaimNormalTaskByGroupParams) (*querier.AnclaxTask, error) {
 	m.ctrl.T.Helper()
@@ -208,6 +237,36 @@ func (mr *MockModelInterfaceMockRecorder) CreateWorkerRuntimeConfig(ctx, payload
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWorkerRuntimeConfig", reflect.TypeOf((*MockModelInterface)(nil).CreateWorkerRuntimeConfig), ctx, payload)
 }
 
+// DeferTaskByWorker mocks base method.
+func (m *MockModelInterface) DeferTaskByWorker(ctx context.Context, arg querier.DeferTaskByWorkerParams) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeferTaskByWorker", ctx, arg)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeferTaskByWorker indicates an expected call of DeferTaskByWorker.
+func (mr *MockModelInterfaceMockRecorder) DeferTaskByWorker(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeferTaskByWorker", reflect.TypeOf((*MockModelInterface)(nil).DeferTaskByWorker), ctx, arg)
+}
+
+// DeleteExpiredOpaqueKeys mocks base method.
+func (m *MockModelInterface) DeleteExpiredOpaqueKeys(ctx context.Context, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredOpaqueKeys", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredOpaqueKeys indicates an expected call of DeleteExpiredOpaqueKeys.
+func (mr *MockModelInterfaceMockRecorder) DeleteExpiredOpaqueKeys(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredOpaqueKeys", reflect.TypeOf((*MockModelInterface)(nil).DeleteExpiredOpaqueKeys), ctx, now)
+}
+
 // DeleteKeyPair mocks base method.
 func (m *MockModelInterface) DeleteKeyPair(ctx context.Context, accessKey string) error {
 	m.ctrl.T.Helper()
@@ -250,6 +309,21 @@ func (mr *MockModelInterfaceMockRecorder) DeleteOpaqueKeys(ctx, group any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOpaqueKeys", reflect.TypeOf((*MockModelInterface)(nil).DeleteOpaqueKeys), ctx, group)
 }
 
+// DeleteOrgUser mocks base method.
+func (m *MockModelInterface) DeleteOrgUser(ctx context.Context, arg querier.DeleteOrgUserParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrgUser", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOrgUser indicates an expected call of DeleteOrgUser.
+func (mr *MockModelInterfaceMockRecorder) DeleteOrgUser(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrgUser", reflect.TypeOf((*MockModelInterface)(nil).DeleteOrgUser), ctx, arg)
+}
+
 // DeleteUserByName mocks base method.
 func (m *MockModelInterface) DeleteUserByName(ctx context.Context, name string) error {
 	m.ctrl.T.Helper()
@@ -279,6 +353,34 @@ func (mr *MockModelInterfaceMockRecorder) DeleteUserByNameReturningID(ctx, name
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserByNameReturningID", reflect.TypeOf((*MockModelInterface)(nil).DeleteUserByNameReturningID), ctx, name)
 }
 
+// DeleteUserDefaultOrg mocks base method.
+func (m *MockModelInterface) DeleteUserDefaultOrg(ctx context.Context, userID int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserDefaultOrg", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserDefaultOrg indicates an expected call of DeleteUserDefaultOrg.
+func (mr *MockModelInterfaceMockRecorder) DeleteUserDefaultOrg(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserDefaultOrg", reflect.TypeOf((*MockModelInterface)(nil).DeleteUserDefaultOrg), ctx, userID)
+}
+
+// EnableUserTotpSecret mocks base method.
+func (m *MockModelInterface) EnableUserTotpSecret(ctx context.Context, userID int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUserTotpSecret", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableUserTotpSecret indicates an expected call of EnableUserTotpSecret.
+func (mr *MockModelInterfaceMockRecorder) EnableUserTotpSecret(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUserTotpSecret", reflect.TypeOf((*MockModelInterface)(nil).EnableUserTotpSecret), ctx, userID)
+}
+
 // GetKeyPair mocks base method.
 func (m *MockModelInterface) GetKeyPair(ctx context.Context, accessKey string) (*querier.AnclaxAccessKeyPair, error) {
 	m.ctrl.T.Helper()
@@ -309,6 +411,21 @@ func (mr *MockModelInterfaceMockRecorder) GetLastTaskErrorEvent(ctx, taskID any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastTaskErrorEvent", reflect.TypeOf((*MockModelInterface)(nil).GetLastTaskErrorEvent), ctx, taskID)
 }
 
+// GetLatestEventID mocks base method.
+func (m *MockModelInterface) GetLatestEventID(ctx context.Context) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestEventID", ctx)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestEventID indicates an expected call of GetLatestEventID.
+func (mr *MockModelInterfaceMockRecorder) GetLatestEventID(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestEventID", reflect.TypeOf((*MockModelInterface)(nil).GetLatestEventID), ctx)
+}
+
 // GetLatestWorkerRuntimeConfig mocks base method.
 func (m *MockModelInterface) GetLatestWorkerRuntimeConfig(ctx context.Context) (*querier.AnclaxWorkerRuntimeConfig, error) {
 	m.ctrl.T.Helper()
@@ -325,10 +442,10 @@ func (mr *MockModelInterfaceMockRecorder) GetLatestWorkerRuntimeConfig(ctx any)
 }
 
 // GetOpaqueKey mocks base method.
-func (m *MockModelInterface) GetOpaqueKey(ctx context.Context, id int64) ([]byte, error) {
+func (m *MockModelInterface) GetOpaqueKey(ctx context.Context, id int64) (*querier.GetOpaqueKeyRow, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetOpaqueKey", ctx, id)
-	ret0, _ := ret[0].([]byte)
+	ret0, _ := ret[0].(*querier.GetOpaqueKeyRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -369,6 +486,36 @@ func (mr *MockModelInterfaceMockRecorder) GetOrgByName(ctx, name any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrgByName", reflect.TypeOf((*MockModelInterface)(nil).GetOrgByName), ctx, name)
 }
 
+// GetOrgOwner mocks base method.
+func (m *MockModelInterface) GetOrgOwner(ctx context.Context, orgID int32) (*querier.AnclaxOrgOwner, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrgOwner", ctx, orgID)
+	ret0, _ := ret[0].(*querier.AnclaxOrgOwner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrgOwner indicates an expected call of GetOrgOwner.
+func (mr *MockModelInterfaceMockRecorder) GetOrgOwner(ctx, orgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrgOwner", reflect.TypeOf((*MockModelInterface)(nil).GetOrgOwner), ctx, orgID)
+}
+
+// GetOrgUser mocks base method.
+func (m *MockModelInterface) GetOrgUser(ctx context.Context, arg querier.GetOrgUserParams) (*querier.AnclaxOrgUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrgUser", ctx, arg)
+	ret0, _ := ret[0].(*querier.AnclaxOrgUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrgUser indicates an expected call of GetOrgUser.
+func (mr *MockModelInterfaceMockRecorder) GetOrgUser(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrgUser", reflect.TypeOf((*MockModelInterface)(nil).GetOrgUser), ctx, arg)
+}
+
 // GetTaskByID mocks base method.
 func (m *MockModelInterface) GetTaskByID(ctx context.Context, id int32) (*querier.AnclaxTask, error) {
 	m.ctrl.T.Helper()
@@ -459,6 +606,21 @@ func (mr *MockModelInterfaceMockRecorder) GetUserDefaultOrg(ctx, userID any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDefaultOrg", reflect.TypeOf((*MockModelInterface)(nil).GetUserDefaultOrg), ctx, userID)
 }
 
+// GetUserTotpSecret mocks base method.
+func (m *MockModelInterface) GetUserTotpSecret(ctx context.Context, userID int32) (*querier.AnclaxUserTotpSecret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTotpSecret", ctx, userID)
+	ret0, _ := ret[0].(*querier.AnclaxUserTotpSecret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTotpSecret indicates an expected call of GetUserTotpSecret.
+func (mr *MockModelInterfaceMockRecorder) GetUserTotpSecret(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTotpSecret", reflect.TypeOf((*MockModelInterface)(nil).GetUserTotpSecret), ctx, userID)
+}
+
 // GetWorkerRuntimeConfigByVersion mocks base method.
 func (m *MockModelInterface) GetWorkerRuntimeConfigByVersion(ctx context.Context, version int64) (*querier.AnclaxWorkerRuntimeConfig, error) {
 	m.ctrl.T.Helper()
@@ -503,18 +665,18 @@ func (mr *MockModelInterfaceMockRecorder) IncrementAttempts(ctx, id any) *gomock
 }
 
 // InsertEvent mocks base method.
-func (m *MockModelInterface) InsertEvent(ctx context.Context, spec apigen.EventSpec) (*querier.AnclaxEvent, error) {
+func (m *MockModelInterface) InsertEvent(ctx context.Context, arg querier.InsertEventParams) (*querier.AnclaxEvent, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertEvent", ctx, spec)
+	ret := m.ctrl.Call(m, "InsertEvent", ctx, arg)
 	ret0, _ := ret[0].(*querier.AnclaxEvent)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // InsertEvent indicates an expected call of InsertEvent.
-func (mr *MockModelInterfaceMockRecorder) InsertEvent(ctx, spec any) *gomock.Call {
+func (mr *MockModelInterfaceMockRecorder) InsertEvent(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertEvent", reflect.TypeOf((*MockModelInterface)(nil).InsertEvent), ctx, spec)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertEvent", reflect.TypeOf((*MockModelInterface)(nil).InsertEvent), ctx, arg)
 }
 
 // InsertOrgOwner mocks base method.
@@ -562,6 +724,21 @@ func (mr *MockModelInterfaceMockRecorder) IsUsernameExists(ctx, name any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUsernameExists", reflect.TypeOf((*MockModelInterface)(nil).IsUsernameExists), ctx, name)
 }
 
+// ListActiveOpaqueKeysByGroup mocks base method.
+func (m *MockModelInterface) ListActiveOpaqueKeysByGroup(ctx context.Context, arg querier.ListActiveOpaqueKeysByGroupParams) ([]*querier.ListActiveOpaqueKeysByGroupRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveOpaqueKeysByGroup", ctx, arg)
+	ret0, _ := ret[0].([]*querier.ListActiveOpaqueKeysByGroupRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveOpaqueKeysByGroup indicates an expected call of ListActiveOpaqueKeysByGroup.
+func (mr *MockModelInterfaceMockRecorder) ListActiveOpaqueKeysByGroup(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveOpaqueKeysByGroup", reflect.TypeOf((*MockModelInterface)(nil).ListActiveOpaqueKeysByGroup), ctx, arg)
+}
+
 // ListAllPendingTasks mocks base method.
 func (m *MockModelInterface) ListAllPendingTasks(ctx context.Context) ([]*querier.AnclaxTask, error) {
 	m.ctrl.T.Helper()
@@ -577,6 +754,21 @@ func (mr *MockModelInterfaceMockRecorder) ListAllPendingTasks(ctx any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllPendingTasks", reflect.TypeOf((*MockModelInterface)(nil).ListAllPendingTasks), ctx)
 }
 
+// ListEventsAfterID mocks base method.
+func (m *MockModelInterface) ListEventsAfterID(ctx context.Context, arg querier.ListEventsAfterIDParams) ([]*querier.AnclaxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEventsAfterID", ctx, arg)
+	ret0, _ := ret[0].([]*querier.AnclaxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEventsAfterID indicates an expected call of ListEventsAfterID.
+func (mr *MockModelInterfaceMockRecorder) ListEventsAfterID(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEventsAfterID", reflect.TypeOf((*MockModelInterface)(nil).ListEventsAfterID), ctx, arg)
+}
+
 // ListLaggingAliveWorkers mocks base method.
 func (m *MockModelInterface) ListLaggingAliveWorkers(ctx context.Context, arg querier.ListLaggingAliveWorkersParams) ([]uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -607,6 +799,21 @@ func (mr *MockModelInterfaceMockRecorder) ListOnlineWorkerIDs(ctx, heartbeatCuto
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOnlineWorkerIDs", reflect.TypeOf((*MockModelInterface)(nil).ListOnlineWorkerIDs), ctx, heartbeatCutoff)
 }
 
+// ListOrgMembers mocks base method.
+func (m *MockModelInterface) ListOrgMembers(ctx context.Context, orgID int32) ([]*querier.ListOrgMembersRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrgMembers", ctx, orgID)
+	ret0, _ := ret[0].([]*querier.ListOrgMembersRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrgMembers indicates an expected call of ListOrgMembers.
+func (mr *MockModelInterfaceMockRecorder) ListOrgMembers(ctx, orgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrgMembers", reflect.TypeOf((*MockModelInterface)(nil).ListOrgMembers), ctx, orgID)
+}
+
 // ListOrgs mocks base method.
 func (m *MockModelInterface) ListOrgs(ctx context.Context, userID int32) ([]*querier.AnclaxOrg, error) {
 	m.ctrl.T.Helper()
@@ -622,6 +829,21 @@ func (mr *MockModelInterfaceMockRecorder) ListOrgs(ctx, userID any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrgs", reflect.TypeOf((*MockModelInterface)(nil).ListOrgs), ctx, userID)
 }
 
+// ListScheduledTasks mocks base method.
+func (m *MockModelInterface) ListScheduledTasks(ctx context.Context, arg querier.ListScheduledTasksParams) ([]*querier.AnclaxTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTasks", ctx, arg)
+	ret0, _ := ret[0].([]*querier.AnclaxTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTasks indicates an expected call of ListScheduledTasks.
+func (mr *MockModelInterfaceMockRecorder) ListScheduledTasks(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTasks", reflect.TypeOf((*MockModelInterface)(nil).ListScheduledTasks), ctx, arg)
+}
+
 // ListTaskDescendantIDs mocks base method.
 func (m *MockModelInterface) ListTaskDescendantIDs(ctx context.Context, parentTaskID *int32) ([]int32, error) {
 	m.ctrl.T.Helper()
@@ -637,6 +859,21 @@ func (mr *MockModelInterfaceMockRecorder) ListTaskDescendantIDs(ctx, parentTaskI
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaskDescendantIDs", reflect.TypeOf((*MockModelInterface)(nil).ListTaskDescendantIDs), ctx, parentTaskID)
 }
 
+// ListTaskEvents mocks base method.
+func (m *MockModelInterface) ListTaskEvents(ctx context.Context, taskID int32) ([]*querier.AnclaxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTaskEvents", ctx, taskID)
+	ret0, _ := ret[0].([]*querier.AnclaxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTaskEvents indicates an expected call of ListTaskEvents.
+func (mr *MockModelInterfaceMockRecorder) ListTaskEvents(ctx, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaskEvents", reflect.TypeOf((*MockModelInterface)(nil).ListTaskEvents), ctx, taskID)
+}
+
 // ListTaskIDsByTags mocks base method.
 func (m *MockModelInterface) ListTaskIDsByTags(ctx context.Context, arg querier.ListTaskIDsByTagsParams) ([]int32, error) {
 	m.ctrl.T.Helper()
@@ -652,6 +889,21 @@ func (mr *MockModelInterfaceMockRecorder) ListTaskIDsByTags(ctx, arg any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaskIDsByTags", reflect.TypeOf((*MockModelInterface)(nil).ListTaskIDsByTags), ctx, arg)
 }
 
+// ListTasksFiltered mocks base method.
+func (m *MockModelInterface) ListTasksFiltered(ctx context.Context, arg querier.ListTasksFilteredParams) ([]*querier.AnclaxTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTasksFiltered", ctx, arg)
+	ret0, _ := ret[0].([]*querier.AnclaxTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTasksFiltered indicates an expected call of ListTasksFiltered.
+func (mr *MockModelInterfaceMockRecorder) ListTasksFiltered(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasksFiltered", reflect.TypeOf((*MockModelInterface)(nil).ListTasksFiltered), ctx, arg)
+}
+
 // ListTerminalTaskWaitStatuses mocks base method.
 func (m *MockModelInterface) ListTerminalTaskWaitStatuses(ctx context.Context, ids []int32) ([]*querier.ListTerminalTaskWaitStatusesRow, error) {
 	m.ctrl.T.Helper()
@@ -681,6 +933,78 @@ func (mr *MockModelInterfaceMockRecorder) MarkWorkerOffline(ctx, id any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWorkerOffline", reflect.TypeOf((*MockModelInterface)(nil).MarkWorkerOffline), ctx, id)
 }
 
+// Migrate mocks base method.
+func (m *MockModelInterface) Migrate(ctx context.Context, targetVersion uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Migrate", ctx, targetVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Migrate indicates an expected call of Migrate.
+func (mr *MockModelInterfaceMockRecorder) Migrate(ctx, targetVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Migrate", reflect.TypeOf((*MockModelInterface)(nil).Migrate), ctx, targetVersion)
+}
+
+// MigrationVersion mocks base method.
+func (m *MockModelInterface) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrationVersion", ctx)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MigrationVersion indicates an expected call of MigrationVersion.
+func (mr *MockModelInterfaceMockRecorder) MigrationVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrationVersion", reflect.TypeOf((*MockModelInterface)(nil).MigrationVersion), ctx)
+}
+
+// Ping mocks base method.
+func (m *MockModelInterface) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockModelInterfaceMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockModelInterface)(nil).Ping), ctx)
+}
+
+// ReadOnly mocks base method.
+func (m *MockModelInterface) ReadOnly() ModelInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadOnly")
+	ret0, _ := ret[0].(ModelInterface)
+	return ret0
+}
+
+// ReadOnly indicates an expected call of ReadOnly.
+func (mr *MockModelInterfaceMockRecorder) ReadOnly() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadOnly", reflect.TypeOf((*MockModelInterface)(nil).ReadOnly))
+}
+
+// RecordUserTotpFailure mocks base method.
+func (m *MockModelInterface) RecordUserTotpFailure(ctx context.Context, arg querier.RecordUserTotpFailureParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordUserTotpFailure", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordUserTotpFailure indicates an expected call of RecordUserTotpFailure.
+func (mr *MockModelInterfaceMockRecorder) RecordUserTotpFailure(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordUserTotpFailure", reflect.TypeOf((*MockModelInterface)(nil).RecordUserTotpFailure), ctx, arg)
+}
+
 // RefreshTaskLock mocks base method.
 func (m *MockModelInterface) RefreshTaskLock(ctx context.Context, arg querier.RefreshTaskLockParams) (int32, error) {
 	m.ctrl.T.Helper()
@@ -711,6 +1035,35 @@ func (mr *MockModelInterfaceMockRecorder) ReleaseTaskLockByWorker(ctx, arg any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseTaskLockByWorker", reflect.TypeOf((*MockModelInterface)(nil).ReleaseTaskLockByWorker), ctx, arg)
 }
 
+// RestoreTask mocks base method.
+func (m *MockModelInterface) RestoreTask(ctx context.Context, id int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTask", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreTask indicates an expected call of RestoreTask.
+func (mr *MockModelInterfaceMockRecorder) RestoreTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTask", reflect.TypeOf((*MockModelInterface)(nil).RestoreTask), ctx, id)
+}
+
+// RestoreTaskEvents mocks base method.
+func (m *MockModelInterface) RestoreTaskEvents(ctx context.Context, taskID int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTaskEvents", ctx, taskID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreTaskEvents indicates an expected call of RestoreTaskEvents.
+func (mr *MockModelInterfaceMockRecorder) RestoreTaskEvents(ctx, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTaskEvents", reflect.TypeOf((*MockModelInterface)(nil).RestoreTaskEvents), ctx, taskID)
+}
+
 // RestoreUserByName mocks base method.
 func (m *MockModelInterface) RestoreUserByName(ctx context.Context, name string) error {
 	m.ctrl.T.Helper()
@@ -739,6 +1092,20 @@ func (mr *MockModelInterfaceMockRecorder) RunTransaction(ctx, f any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunTransaction", reflect.TypeOf((*MockModelInterface)(nil).RunTransaction), ctx, f)
 }
 
+// RunTransactionWithRetry mocks base method.
+func (m *MockModelInterface) RunTransactionWithRetry(ctx context.Context, maxAttempts int, f func(ModelInterface) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunTransactionWithRetry", ctx, maxAttempts, f)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunTransactionWithRetry indicates an expected call of RunTransactionWithRetry.
+func (mr *MockModelInterfaceMockRecorder) RunTransactionWithRetry(ctx, maxAttempts, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunTransactionWithRetry", reflect.TypeOf((*MockModelInterface)(nil).RunTransactionWithRetry), ctx, maxAttempts, f)
+}
+
 // RunTransactionWithTx mocks base method.
 func (m *MockModelInterface) RunTransactionWithTx(ctx context.Context, f func(core.Tx, ModelInterface) error) error {
 	m.ctrl.T.Helper()
@@ -753,6 +1120,21 @@ func (mr *MockModelInterfaceMockRecorder) RunTransactionWithTx(ctx, f any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunTransactionWithTx", reflect.TypeOf((*MockModelInterface)(nil).RunTransactionWithTx), ctx, f)
 }
 
+// SetOrgOwner mocks base method.
+func (m *MockModelInterface) SetOrgOwner(ctx context.Context, arg querier.SetOrgOwnerParams) (*querier.AnclaxOrgOwner, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOrgOwner", ctx, arg)
+	ret0, _ := ret[0].(*querier.AnclaxOrgOwner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetOrgOwner indicates an expected call of SetOrgOwner.
+func (mr *MockModelInterfaceMockRecorder) SetOrgOwner(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOrgOwner", reflect.TypeOf((*MockModelInterface)(nil).SetOrgOwner), ctx, arg)
+}
+
 // SetUserDefaultOrg mocks base method.
 func (m *MockModelInterface) SetUserDefaultOrg(ctx context.Context, arg querier.SetUserDefaultOrgParams) error {
 	m.ctrl.T.Helper()
@@ -767,6 +1149,35 @@ func (mr *MockModelInterfaceMockRecorder) SetUserDefaultOrg(ctx, arg any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDefaultOrg", reflect.TypeOf((*MockModelInterface)(nil).SetUserDefaultOrg), ctx, arg)
 }
 
+// SoftDeleteTask mocks base method.
+func (m *MockModelInterface) SoftDeleteTask(ctx context.Context, id int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteTask", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SoftDeleteTask indicates an expected call of SoftDeleteTask.
+func (mr *MockModelInterfaceMockRecorder) SoftDeleteTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteTask", reflect.TypeOf((*MockModelInterface)(nil).SoftDeleteTask), ctx, id)
+}
+
+// SoftDeleteTaskEvents mocks base method.
+func (m *MockModelInterface) SoftDeleteTaskEvents(ctx context.Context, taskID int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteTaskEvents", ctx, taskID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDeleteTaskEvents indicates an expected call of SoftDeleteTaskEvents.
+func (mr *MockModelInterfaceMockRecorder) SoftDeleteTaskEvents(ctx, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteTaskEvents", reflect.TypeOf((*MockModelInterface)(nil).SoftDeleteTaskEvents), ctx, taskID)
+}
+
 // SpawnWithTx mocks base method.
 func (m *MockModelInterface) SpawnWithTx(tx core.Tx) ModelInterface {
 	m.ctrl.T.Helper()
@@ -825,6 +1236,20 @@ func (mr *MockModelInterfaceMockRecorder) UpdateTask(ctx, arg any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTask", reflect.TypeOf((*MockModelInterface)(nil).UpdateTask), ctx, arg)
 }
 
+// UpdateTaskResult mocks base method.
+func (m *MockModelInterface) UpdateTaskResult(ctx context.Context, arg querier.UpdateTaskResultParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTaskResult", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTaskResult indicates an expected call of UpdateTaskResult.
+func (mr *MockModelInterfaceMockRecorder) UpdateTaskResult(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskResult", reflect.TypeOf((*MockModelInterface)(nil).UpdateTaskResult), ctx, arg)
+}
+
 // UpdateTaskStartedAt mocks base method.
 func (m *MockModelInterface) UpdateTaskStartedAt(ctx context.Context, arg querier.UpdateTaskStartedAtParams) error {
 	m.ctrl.T.Helper()
@@ -883,6 +1308,21 @@ func (mr *MockModelInterfaceMockRecorder) UpdateTaskStatusByWorker(ctx, arg any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskStatusByWorker", reflect.TypeOf((*MockModelInterface)(nil).UpdateTaskStatusByWorker), ctx, arg)
 }
 
+// UpdateTaskStatusIf mocks base method.
+func (m *MockModelInterface) UpdateTaskStatusIf(ctx context.Context, arg querier.UpdateTaskStatusIfParams) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTaskStatusIf", ctx, arg)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTaskStatusIf indicates an expected call of UpdateTaskStatusIf.
+func (mr *MockModelInterfaceMockRecorder) UpdateTaskStatusIf(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskStatusIf", reflect.TypeOf((*MockModelInterface)(nil).UpdateTaskStatusIf), ctx, arg)
+}
+
 // UpdateUserPassword mocks base method.
 func (m *MockModelInterface) UpdateUserPassword(ctx context.Context, arg querier.UpdateUserPasswordParams) error {
 	m.ctrl.T.Helper()
@@ -926,6 +1366,21 @@ func (mr *MockModelInterfaceMockRecorder) UpdateWorkerHeartbeat(ctx, id any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWorkerHeartbeat", reflect.TypeOf((*MockModelInterface)(nil).UpdateWorkerHeartbeat), ctx, id)
 }
 
+// UpsertUserTotpSecret mocks base method.
+func (m *MockModelInterface) UpsertUserTotpSecret(ctx context.Context, arg querier.UpsertUserTotpSecretParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertUserTotpSecret", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertUserTotpSecret indicates an expected call of UpsertUserTotpSecret.
+func (mr *MockModelInterfaceMockRecorder) UpsertUserTotpSecret(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertUserTotpSecret", reflect.TypeOf((*MockModelInterface)(nil).UpsertUserTotpSecret), ctx, arg)
+}
+
 // UpsertWorker mocks base method.
 func (m *MockModelInterface) UpsertWorker(ctx context.Context, arg querier.UpsertWorkerParams) (*querier.AnclaxWorker, error) {
 	m.ctrl.T.Helper()
@@ -3,7 +3,9 @@ package model
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"net/url"
+	"sync/atomic"
 	"time"
 
 	"github.com/cloudcarver/anclax/core"
@@ -16,6 +18,7 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
 
@@ -28,12 +31,33 @@ var (
 	ErrAlreadyInTransaction = errors.New("already in transaction")
 )
 
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+
+	txRetryBaseBackoff = 10 * time.Millisecond
+	txRetryMaxBackoff  = time.Second
+)
+
 type ModelInterface interface {
 	querier.Querier
 	RunTransaction(ctx context.Context, f func(model ModelInterface) error) error
 	RunTransactionWithTx(ctx context.Context, f func(tx core.Tx, model ModelInterface) error) error
+	// RunTransactionWithRetry is RunTransaction, but re-runs f in a new transaction when it fails with a
+	// serialization failure or a deadlock, at most maxAttempts times in total. f must be safe to re-run,
+	// e.g. it must not have side effects outside of the transaction.
+	RunTransactionWithRetry(ctx context.Context, maxAttempts int, f func(model ModelInterface) error) error
 	InTransaction() bool
 	SpawnWithTx(tx core.Tx) ModelInterface
+	// ReadOnly returns a model whose queries run on a read replica if any is configured.
+	// Transactions of the returned model still run on the primary.
+	ReadOnly() ModelInterface
+	// MigrationVersion returns the current schema version, 0 if no migration was applied, and whether
+	// the last migration failed halfway.
+	MigrationVersion(ctx context.Context) (uint, bool, error)
+	// Migrate migrates the schema up or down to targetVersion.
+	Migrate(ctx context.Context, targetVersion uint) error
+	Ping(ctx context.Context) error
 	Close()
 }
 
@@ -42,6 +66,11 @@ type Model struct {
 	beginTx       func(ctx context.Context) (core.Tx, error)
 	p             *pgxpool.Pool
 	inTransaction bool
+	queryTimeout  time.Duration
+	migrator      *migrate.Migrate
+
+	replicas    []querier.DBTX
+	nextReplica atomic.Uint32
 }
 
 func (m *Model) Close() {
@@ -49,6 +78,43 @@ func (m *Model) Close() {
 	if m.p != nil {
 		m.p.Close()
 	}
+	if m.migrator != nil {
+		if srcErr, dbErr := m.migrator.Close(); srcErr != nil || dbErr != nil {
+			log.Warnf("failed to close migrator, source: %v, database: %v", srcErr, dbErr)
+		}
+	}
+}
+
+// Ping checks that the primary database is reachable. Only the model returned by NewModel can be pinged.
+func (m *Model) Ping(ctx context.Context) error {
+	if m.p == nil {
+		return errors.New("ping is only supported by the root model")
+	}
+	return m.p.Ping(ctx)
+}
+
+func (m *Model) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	if m.migrator == nil {
+		return 0, false, errors.New("migrations are only supported by the root model")
+	}
+	version, dirty, err := m.migrator.Version()
+	if err != nil {
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return 0, false, nil
+		}
+		return 0, false, errors.Wrap(err, "failed to get migration version")
+	}
+	return version, dirty, nil
+}
+
+func (m *Model) Migrate(ctx context.Context, targetVersion uint) error {
+	if m.migrator == nil {
+		return errors.New("migrations are only supported by the root model")
+	}
+	if err := m.migrator.Migrate(targetVersion); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return errors.Wrapf(err, "failed to migrate to version %d", targetVersion)
+	}
+	return nil
 }
 
 func (m *Model) InTransaction() bool {
@@ -59,13 +125,28 @@ func (m *Model) BeginTx(ctx context.Context) (core.Tx, error) {
 	return m.beginTx(ctx)
 }
 
+// SpawnWithTx returns a model bound to tx. Transactions run on the returned model are nested
+// in tx using savepoints.
 func (m *Model) SpawnWithTx(tx core.Tx) ModelInterface {
 	return &Model{
-		Querier: querier.New(tx),
+		Querier: querier.New(withQueryTimeout(tx, m.queryTimeout)),
 		beginTx: func(ctx context.Context) (core.Tx, error) {
-			return nil, ErrAlreadyInTransaction
+			return beginSavepoint(ctx, tx)
 		},
 		inTransaction: true,
+		queryTimeout:  m.queryTimeout,
+	}
+}
+
+func (m *Model) ReadOnly() ModelInterface {
+	if m.inTransaction || len(m.replicas) == 0 {
+		return m
+	}
+	replica := m.replicas[int(m.nextReplica.Add(1)-1)%len(m.replicas)]
+	return &Model{
+		Querier:      querier.New(withQueryTimeout(replica, m.queryTimeout)),
+		beginTx:      m.beginTx,
+		queryTimeout: m.queryTimeout,
 	}
 }
 
@@ -99,6 +180,38 @@ func (m *Model) RunTransaction(ctx context.Context, f func(model ModelInterface)
 	})
 }
 
+func (m *Model) RunTransactionWithRetry(ctx context.Context, maxAttempts int, f func(model ModelInterface) error) error {
+	if m.inTransaction {
+		// a serialization failure aborts the outer transaction, so only its owner can retry
+		return m.RunTransaction(ctx, f)
+	}
+	backoff := txRetryBaseBackoff
+	for attempt := 1; ; attempt++ {
+		err := m.RunTransaction(ctx, f)
+		if err == nil || attempt >= maxAttempts || !isRetryableTxError(err) {
+			return err
+		}
+		log.Infof("retrying transaction after attempt %d failed: %s", attempt, err.Error())
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff + time.Duration(rand.Int63n(int64(backoff)))):
+		}
+		backoff = min(backoff*2, txRetryMaxBackoff)
+	}
+}
+
+// isRetryableTxError reports whether err is a serialization failure or a deadlock, after which
+// the transaction can succeed if it is run again.
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+}
+
 func NewModel(cfg *config.Config, libCfg *config.LibConfig, cm *closer.CloserManager) (ModelInterface, error) {
 	var dsn string
 	if cfg.Pg.DSN != nil {
@@ -117,12 +230,10 @@ func NewModel(cfg *config.Config, libCfg *config.LibConfig, cm *closer.CloserMan
 		dsn = url.String()
 	}
 
-	config, err := pgxpool.ParseConfig(dsn)
+	config, err := newPoolConfig(dsn, cfg.Pg, libCfg)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse pgxpool config: %s", utils.ReplaceSensitiveStringBySha256(dsn, cfg.Pg.Password))
 	}
-	config.MaxConns = libCfg.Pg.MaxConnections
-	config.MinConns = libCfg.Pg.MinConnections
 
 	var (
 		retryLimit = 10
@@ -185,12 +296,15 @@ func NewModel(cfg *config.Config, libCfg *config.LibConfig, cm *closer.CloserMan
 		}
 	}
 
+	queryTimeout := utils.UnwrapOrDefault(cfg.Pg.QueryTimeout, 0)
 	ret := &Model{
-		Querier: querier.New(p),
+		Querier: querier.New(withQueryTimeout(p, queryTimeout)),
 		beginTx: func(ctx context.Context) (core.Tx, error) {
 			return p.Begin(ctx)
 		},
-		p: p,
+		p:            p,
+		queryTimeout: queryTimeout,
+		migrator:     m,
 	}
 
 	cm.Register(func(ctx context.Context) error {
@@ -198,5 +312,54 @@ func NewModel(cfg *config.Config, libCfg *config.LibConfig, cm *closer.CloserMan
 		return nil
 	})
 
+	for i, replicaDSN := range cfg.Pg.ReplicaDSNs {
+		replica, err := newReplicaPool(replicaDSN, cfg.Pg, libCfg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to init pgxpool for replica %d", i)
+		}
+		ret.replicas = append(ret.replicas, replica)
+		cm.Register(func(ctx context.Context) error {
+			replica.Close()
+			return nil
+		})
+	}
+
 	return ret, nil
 }
+
+// newPoolConfig parses dsn and applies the pool sizing of pg, falling back to libCfg.Pg for the
+// connection counts and to the pgxpool defaults for the connection lifetimes.
+func newPoolConfig(dsn string, pg config.Pg, libCfg *config.LibConfig) (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	poolCfg.MaxConns = utils.UnwrapOrDefault(pg.MaxConns, libCfg.Pg.MaxConnections)
+	poolCfg.MinConns = utils.UnwrapOrDefault(pg.MinConns, libCfg.Pg.MinConnections)
+	poolCfg.MaxConnLifetime = utils.UnwrapOrDefault(pg.MaxConnLifetime, poolCfg.MaxConnLifetime)
+	poolCfg.MaxConnIdleTime = utils.UnwrapOrDefault(pg.MaxConnIdleTime, poolCfg.MaxConnIdleTime)
+	if poolCfg.MinConns > poolCfg.MaxConns {
+		return nil, errors.Errorf("min connections %d is greater than max connections %d", poolCfg.MinConns, poolCfg.MaxConns)
+	}
+	return poolCfg, nil
+}
+
+func newReplicaPool(dsn string, pg config.Pg, libCfg *config.LibConfig) (*pgxpool.Pool, error) {
+	config, err := newPoolConfig(dsn, pg, libCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse replica dsn")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, errors.Wrap(err, "failed to ping replica")
+	}
+	return pool, nil
+}
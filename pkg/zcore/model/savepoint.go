@@ -0,0 +1,61 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudcarver/anclax/core"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// savepoint is a nested transaction of tx. Commit releases the savepoint, and Rollback only
+// undoes the changes made after it, so the outer transaction can still commit.
+type savepoint struct {
+	tx     core.Tx
+	name   string
+	depth  int
+	closed bool
+}
+
+func beginSavepoint(ctx context.Context, tx core.Tx) (core.Tx, error) {
+	depth := 1
+	if parent, ok := tx.(*savepoint); ok {
+		depth = parent.depth + 1
+	}
+	name := fmt.Sprintf("anclax_sp_%d", depth)
+	if _, err := tx.Exec(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, err
+	}
+	return &savepoint{tx: tx, name: name, depth: depth}, nil
+}
+
+func (s *savepoint) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return s.tx.Exec(ctx, sql, args...)
+}
+
+func (s *savepoint) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return s.tx.Query(ctx, sql, args...)
+}
+
+func (s *savepoint) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return s.tx.QueryRow(ctx, sql, args...)
+}
+
+func (s *savepoint) Commit(ctx context.Context) error {
+	if s.closed {
+		return pgx.ErrTxClosed
+	}
+	s.closed = true
+	_, err := s.tx.Exec(ctx, "RELEASE SAVEPOINT "+s.name)
+	return err
+}
+
+func (s *savepoint) Rollback(ctx context.Context) error {
+	if s.closed {
+		return pgx.ErrTxClosed
+	}
+	s.closed = true
+	_, err := s.tx.Exec(ctx, "ROLLBACK TO SAVEPOINT "+s.name)
+	return err
+}
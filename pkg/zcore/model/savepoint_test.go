@@ -0,0 +1,73 @@
+package model
+
+import (
+	context "context"
+	"testing"
+
+	"github.com/cloudcarver/anclax/core"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNestedTransactionUsesSavepoints(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockTx := core.NewMockTx(ctrl)
+	m := &Model{
+		beginTx: func(ctx context.Context) (core.Tx, error) {
+			return mockTx, nil
+		},
+	}
+
+	exec := func(sql string) any {
+		return mockTx.EXPECT().Exec(gomock.Any(), sql).Return(pgconn.CommandTag{}, nil)
+	}
+	gomock.InOrder(
+		// failed inner transaction
+		exec("SAVEPOINT anclax_sp_1"),
+		exec("ROLLBACK TO SAVEPOINT anclax_sp_1"),
+		// successful inner transaction with a nested one
+		exec("SAVEPOINT anclax_sp_1"),
+		exec("SAVEPOINT anclax_sp_2"),
+		exec("RELEASE SAVEPOINT anclax_sp_2"),
+		exec("RELEASE SAVEPOINT anclax_sp_1"),
+		mockTx.EXPECT().Commit(gomock.Any()).Return(nil),
+		mockTx.EXPECT().Rollback(gomock.Any()).Return(pgx.ErrTxClosed),
+	)
+
+	errInner := errors.New("inner failed")
+	err := m.RunTransaction(ctx, func(txm ModelInterface) error {
+		err := txm.RunTransaction(ctx, func(ModelInterface) error {
+			return errInner
+		})
+		require.ErrorIs(t, err, errInner)
+
+		return txm.RunTransaction(ctx, func(inner ModelInterface) error {
+			return inner.RunTransaction(ctx, func(ModelInterface) error {
+				return nil
+			})
+		})
+	})
+	require.NoError(t, err)
+}
+
+func TestSavepointClosed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockTx := core.NewMockTx(ctrl)
+	mockTx.EXPECT().Exec(gomock.Any(), "SAVEPOINT anclax_sp_1").Return(pgconn.CommandTag{}, nil)
+	mockTx.EXPECT().Exec(gomock.Any(), "RELEASE SAVEPOINT anclax_sp_1").Return(pgconn.CommandTag{}, nil)
+
+	sp, err := beginSavepoint(ctx, mockTx)
+	require.NoError(t, err)
+	require.NoError(t, sp.Commit(ctx))
+	require.ErrorIs(t, sp.Rollback(ctx), pgx.ErrTxClosed)
+	require.ErrorIs(t, sp.Commit(ctx), pgx.ErrTxClosed)
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/zgen/querier"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout overrides the default per-statement timeout (config.Pg.QueryTimeout) for the
+// queries run with the returned context. A timeout <= 0 disables the timeout.
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// timeoutDB bounds each statement run on db by a timeout. The timeout covers reading the
+// results, so it is released when the rows are closed or the row is scanned.
+type timeoutDB struct {
+	db      querier.DBTX
+	timeout time.Duration
+}
+
+func withQueryTimeout(db querier.DBTX, timeout time.Duration) querier.DBTX {
+	if timeout <= 0 {
+		return db
+	}
+	return &timeoutDB{db: db, timeout: timeout}
+}
+
+func (t *timeoutDB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := t.timeout
+	if override, ok := ctx.Value(queryTimeoutKey{}).(time.Duration); ok {
+		timeout = override
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (t *timeoutDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+	return t.db.Exec(ctx, sql, args...)
+}
+
+func (t *timeoutDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, cancel := t.withTimeout(ctx)
+	rows, err := t.db.Query(ctx, sql, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &timeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+func (t *timeoutDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, cancel := t.withTimeout(ctx)
+	return &timeoutRow{row: t.db.QueryRow(ctx, sql, args...), cancel: cancel}
+}
+
+type timeoutRows struct {
+	pgx.Rows
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRows) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+type timeoutRow struct {
+	row    pgx.Row
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRow) Scan(dest ...any) error {
+	defer r.cancel()
+	return r.row.Scan(dest...)
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	context "context"
+	"testing"
+	"time"
+
+	"github.com/cloudcarver/anclax/pkg/zgen/querier"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingDB blocks every statement until its context is done.
+type blockingDB struct{}
+
+func (blockingDB) Exec(ctx context.Context, _ string, _ ...interface{}) (pgconn.CommandTag, error) {
+	<-ctx.Done()
+	return pgconn.CommandTag{}, ctx.Err()
+}
+
+func (blockingDB) Query(ctx context.Context, _ string, _ ...interface{}) (pgx.Rows, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingDB) QueryRow(ctx context.Context, _ string, _ ...interface{}) pgx.Row {
+	return blockingRow{ctx: ctx}
+}
+
+type blockingRow struct {
+	ctx context.Context
+}
+
+func (r blockingRow) Scan(_ ...any) error {
+	<-r.ctx.Done()
+	return r.ctx.Err()
+}
+
+func TestQueryTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	m := &Model{Querier: querier.New(withQueryTimeout(blockingDB{}, timeout))}
+
+	begin := time.Now()
+	_, err := m.GetLatestEventID(context.Background())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.GreaterOrEqual(t, time.Since(begin), timeout)
+	require.Less(t, time.Since(begin), time.Second)
+
+	begin = time.Now()
+	_, err = m.ListAllPendingTasks(context.Background())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(begin), time.Second)
+}
+
+func TestQueryTimeoutOverride(t *testing.T) {
+	m := &Model{Querier: querier.New(withQueryTimeout(blockingDB{}, time.Hour))}
+
+	begin := time.Now()
+	_, err := m.GetLatestEventID(WithQueryTimeout(context.Background(), 20*time.Millisecond))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(begin), time.Second)
+
+	// disabling the timeout leaves the caller's deadline in charge
+	ctx, cancel := context.WithTimeout(WithQueryTimeout(context.Background(), 0), 20*time.Millisecond)
+	defer cancel()
+	_, err = m.GetLatestEventID(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -3,8 +3,11 @@ package apigen
 const (
 	Bearer = CredentialsTokenTypeBearer
 
-	TaskError     = EventSpecTypeTaskError
-	TaskCompleted = EventSpecTypeTaskCompleted
+	TaskError          = EventSpecTypeTaskError
+	TaskCompleted      = EventSpecTypeTaskCompleted
+	TaskStarted        = EventSpecTypeTaskStarted
+	TaskRetryScheduled = EventSpecTypeTaskRetryScheduled
+	TaskCancelled      = EventSpecTypeTaskCancelled
 
 	OnFailed = TaskEventsOnFailed
 
@@ -29,8 +29,11 @@ const (
 
 // Defines values for EventSpecType.
 const (
-	EventSpecTypeTaskError     EventSpecType = "TaskError"
-	EventSpecTypeTaskCompleted EventSpecType = "TaskCompleted"
+	EventSpecTypeTaskError          EventSpecType = "TaskError"
+	EventSpecTypeTaskCompleted      EventSpecType = "TaskCompleted"
+	EventSpecTypeTaskStarted        EventSpecType = "TaskStarted"
+	EventSpecTypeTaskRetryScheduled EventSpecType = "TaskRetryScheduled"
+	EventSpecTypeTaskCancelled      EventSpecType = "TaskCancelled"
 )
 
 // Defines values for TaskEvents.
@@ -51,6 +54,8 @@ const (
 type Credentials struct {
 	// JWT access token
 	AccessToken string `json:"accessToken"`
+	// Seconds until the access token expires, named as in OAuth 2.0 token responses (RFC 6749) so standard clients can read it
+	ExpiresIn int32 `json:"expires_in"`
 	// JWT refresh token for obtaining new access tokens
 	RefreshToken string `json:"refreshToken"`
 	// Token type
@@ -66,9 +71,17 @@ type Event struct {
 
 // EventSpec defines model for EventSpec.
 type EventSpec struct {
-	TaskCompleted *EventTaskCompleted `json:"taskCompleted,omitempty"`
-	TaskError     *EventTaskError     `json:"taskError,omitempty"`
-	Type          EventSpecType       `json:"type"`
+	TaskCancelled      *EventTaskCancelled      `json:"taskCancelled,omitempty"`
+	TaskCompleted      *EventTaskCompleted      `json:"taskCompleted,omitempty"`
+	TaskError          *EventTaskError          `json:"taskError,omitempty"`
+	TaskRetryScheduled *EventTaskRetryScheduled `json:"taskRetryScheduled,omitempty"`
+	TaskStarted        *EventTaskStarted        `json:"taskStarted,omitempty"`
+	Type               EventSpecType            `json:"type"`
+}
+
+// EventTaskCancelled defines model for EventTaskCancelled.
+type EventTaskCancelled struct {
+	TaskID int32 `json:"taskID"`
 }
 
 // EventTaskCompleted defines model for EventTaskCompleted.
@@ -82,6 +95,18 @@ type EventTaskError struct {
 	TaskID int32  `json:"taskID"`
 }
 
+// EventTaskRetryScheduled defines model for EventTaskRetryScheduled.
+type EventTaskRetryScheduled struct {
+	// When the task will be attempted again
+	NextRunAt time.Time `json:"nextRunAt"`
+	TaskID    int32     `json:"taskID"`
+}
+
+// EventTaskStarted defines model for EventTaskStarted.
+type EventTaskStarted struct {
+	TaskID int32 `json:"taskID"`
+}
+
 // Org defines model for Org.
 type Org struct {
 	ID        int32     `json:"ID"`
@@ -102,6 +127,8 @@ type SignInRequest struct {
 	Name string `json:"name"`
 	// User's password
 	Password string `json:"password"`
+	// Current TOTP code, required once the user has enrolled TOTP
+	TotpCode *string `json:"totpCode,omitempty"`
 }
 
 // SignUpRequest defines model for SignUpRequest.
@@ -119,13 +146,19 @@ type Task struct {
 	Attempts   int32          `json:"attempts"`
 	Attributes TaskAttributes `json:"attributes"`
 	CreatedAt  time.Time      `json:"createdAt"`
-	Events     []TaskEvents   `json:"events"`
-	LockedAt   *time.Time     `json:"lockedAt,omitempty"`
+	// When the task was soft-deleted, deleted tasks are hidden from listings by default
+	DeletedAt *time.Time   `json:"deletedAt,omitempty"`
+	Events    []TaskEvents `json:"events"`
+	LockedAt  *time.Time   `json:"lockedAt,omitempty"`
+	// Organization the task belongs to
+	OrgId *int32 `json:"orgId,omitempty"`
 	// Parent task ID if this task was spawned from another task
-	ParentTaskId *int32     `json:"parentTaskId,omitempty"`
-	Spec         TaskSpec   `json:"spec"`
-	StartedAt    *time.Time `json:"startedAt,omitempty"`
-	Status       TaskStatus `json:"status"`
+	ParentTaskId *int32 `json:"parentTaskId,omitempty"`
+	// Output the task handler set with SetResult, present once the task completed
+	Result    json.RawMessage `json:"result,omitempty"`
+	Spec      TaskSpec        `json:"spec"`
+	StartedAt *time.Time      `json:"startedAt,omitempty"`
+	Status    TaskStatus      `json:"status"`
 	// Unique tag of the task
 	UniqueTag *string   `json:"uniqueTag,omitempty"`
 	UpdatedAt time.Time `json:"updatedAt"`
@@ -135,7 +168,8 @@ type Task struct {
 
 // TaskAttributes defines model for TaskAttributes.
 type TaskAttributes struct {
-	Cronjob *TaskCronjob `json:"cronjob,omitempty"`
+	Cronjob         *TaskCronjob         `json:"cronjob,omitempty"`
+	ExecutionWindow *TaskExecutionWindow `json:"executionWindow,omitempty"`
 	// Worker claim and scheduling labels. A worker must have all task labels to claim the task.
 	Labels *[]string `json:"labels,omitempty"`
 	// Strict priority of the task. Higher number runs first. Zero means normal weighted scheduling.
@@ -156,6 +190,26 @@ type TaskAttributes struct {
 // TaskCronjob defines model for TaskCronjob.
 type TaskCronjob struct {
 	CronExpression string `json:"cronExpression"`
+	// Run the task as soon as it is pushed or its cron job is updated instead of waiting for the first cron boundary. Later runs follow the cron expression.
+	RunOnStart *bool `json:"runOnStart,omitempty"`
+	// IANA time zone the cron expression is evaluated in, e.g. Asia/Shanghai. Defaults to the server time zone.
+	Timezone *string `json:"timezone,omitempty"`
+}
+
+// TaskExecutionWindow defines model for TaskExecutionWindow.
+type TaskExecutionWindow struct {
+	// Allowed time-of-day ranges. The task only runs when the current time falls in one of them.
+	Ranges []TaskExecutionWindowRange `json:"ranges"`
+	// IANA time zone the ranges are evaluated in, e.g. Asia/Shanghai. Defaults to UTC.
+	Timezone *string `json:"timezone,omitempty"`
+}
+
+// TaskExecutionWindowRange defines model for TaskExecutionWindowRange.
+type TaskExecutionWindowRange struct {
+	// Exclusive end time of day in HH:MM format, e.g. 06:00. An end before the start spans midnight.
+	End string `json:"end"`
+	// Inclusive start time of day in HH:MM format, e.g. 22:00
+	Start string `json:"start"`
 }
 
 // TaskRetryPolicy defines model for TaskRetryPolicy.
@@ -27,9 +27,18 @@ type AnclaxAccessRule struct {
 }
 
 type AnclaxEvent struct {
-	ID        int32
-	Spec      apigen.EventSpec
-	CreatedAt time.Time
+	ID             int32
+	Spec           apigen.EventSpec
+	CreatedAt      time.Time
+	IdempotencyKey *string
+	DeletedAt      *time.Time
+}
+
+type AnclaxEventsArchive struct {
+	ID         int32
+	Spec       apigen.EventSpec
+	CreatedAt  time.Time
+	ArchivedAt time.Time
 }
 
 type AnclaxOpaqueKey struct {
@@ -38,6 +47,8 @@ type AnclaxOpaqueKey struct {
 	Group     *string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	ExpiresAt *time.Time
+	SessionID *int64
 }
 
 type AnclaxOrg struct {
@@ -94,6 +105,9 @@ type AnclaxTask struct {
 	Priority     int32
 	Weight       int32
 	ParentTaskID *int32
+	OrgID        *int32
+	Result       []byte
+	DeletedAt    *time.Time
 }
 
 type AnclaxUser struct {
@@ -112,6 +126,17 @@ type AnclaxUserDefaultOrg struct {
 	CreatedAt time.Time
 }
 
+type AnclaxUserTotpSecret struct {
+	UserID         int32
+	Secret         []byte
+	EnabledAt      *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	LastStep       *int64
+	FailedAttempts int32
+	LockedUntil    *time.Time
+}
+
 type AnclaxUsersRole struct {
 	UserID    int32
 	RoleID    int32
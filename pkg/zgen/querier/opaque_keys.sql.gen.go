@@ -7,24 +7,44 @@ package querier
 
 import (
 	"context"
+	"time"
 )
 
 const createOpaqueKey = `-- name: CreateOpaqueKey :one
-INSERT INTO anclax.opaque_keys ("group", key) VALUES ($1, $2) RETURNING id
+INSERT INTO anclax.opaque_keys ("group", key, expires_at, session_id) VALUES ($1, $2, $3, $4) RETURNING id
 `
 
 type CreateOpaqueKeyParams struct {
-	Group *string
-	Key   []byte
+	Group     *string
+	Key       []byte
+	ExpiresAt *time.Time
+	SessionID *int64
 }
 
 func (q *Queries) CreateOpaqueKey(ctx context.Context, arg CreateOpaqueKeyParams) (int64, error) {
-	row := q.db.QueryRow(ctx, createOpaqueKey, arg.Group, arg.Key)
+	row := q.db.QueryRow(ctx, createOpaqueKey,
+		arg.Group,
+		arg.Key,
+		arg.ExpiresAt,
+		arg.SessionID,
+	)
 	var id int64
 	err := row.Scan(&id)
 	return id, err
 }
 
+const deleteExpiredOpaqueKeys = `-- name: DeleteExpiredOpaqueKeys :execrows
+DELETE FROM anclax.opaque_keys WHERE expires_at <= $1::timestamptz
+`
+
+func (q *Queries) DeleteExpiredOpaqueKeys(ctx context.Context, now time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredOpaqueKeys, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteOpaqueKey = `-- name: DeleteOpaqueKey :exec
 DELETE FROM anclax.opaque_keys WHERE id = $1
 `
@@ -44,12 +64,60 @@ func (q *Queries) DeleteOpaqueKeys(ctx context.Context, group *string) error {
 }
 
 const getOpaqueKey = `-- name: GetOpaqueKey :one
-SELECT key FROM anclax.opaque_keys WHERE id = $1
+SELECT key, "group" FROM anclax.opaque_keys WHERE id = $1
 `
 
-func (q *Queries) GetOpaqueKey(ctx context.Context, id int64) ([]byte, error) {
+type GetOpaqueKeyRow struct {
+	Key   []byte
+	Group *string
+}
+
+func (q *Queries) GetOpaqueKey(ctx context.Context, id int64) (*GetOpaqueKeyRow, error) {
 	row := q.db.QueryRow(ctx, getOpaqueKey, id)
-	var key []byte
-	err := row.Scan(&key)
-	return key, err
+	var i GetOpaqueKeyRow
+	err := row.Scan(&i.Key, &i.Group)
+	return &i, err
+}
+
+const listActiveOpaqueKeysByGroup = `-- name: ListActiveOpaqueKeysByGroup :many
+SELECT id, created_at, expires_at, session_id FROM anclax.opaque_keys
+WHERE "group" = $1 AND (expires_at IS NULL OR expires_at > $2::timestamptz)
+ORDER BY created_at DESC, id DESC
+`
+
+type ListActiveOpaqueKeysByGroupParams struct {
+	Group *string
+	Now   time.Time
+}
+
+type ListActiveOpaqueKeysByGroupRow struct {
+	ID        int64
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+	SessionID *int64
+}
+
+func (q *Queries) ListActiveOpaqueKeysByGroup(ctx context.Context, arg ListActiveOpaqueKeysByGroupParams) ([]*ListActiveOpaqueKeysByGroupRow, error) {
+	rows, err := q.db.Query(ctx, listActiveOpaqueKeysByGroup, arg.Group, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*ListActiveOpaqueKeysByGroupRow
+	for rows.Next() {
+		var i ListActiveOpaqueKeysByGroupRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.SessionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
@@ -7,6 +7,7 @@ package querier
 
 import (
 	"context"
+	"time"
 )
 
 const createOrg = `-- name: CreateOrg :one
@@ -26,6 +27,23 @@ func (q *Queries) CreateOrg(ctx context.Context, name string) (*AnclaxOrg, error
 	return &i, err
 }
 
+const deleteOrgUser = `-- name: DeleteOrgUser :execrows
+DELETE FROM anclax.org_users WHERE org_id = $1 AND user_id = $2
+`
+
+type DeleteOrgUserParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+func (q *Queries) DeleteOrgUser(ctx context.Context, arg DeleteOrgUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOrgUser, arg.OrgID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getOrg = `-- name: GetOrg :one
 SELECT id, name, tz, created_at, updated_at FROM anclax.orgs WHERE id = $1
 `
@@ -60,6 +78,38 @@ func (q *Queries) GetOrgByName(ctx context.Context, name string) (*AnclaxOrg, er
 	return &i, err
 }
 
+const getOrgOwner = `-- name: GetOrgOwner :one
+SELECT org_id, user_id, created_at FROM anclax.org_owners WHERE org_id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetOrgOwner(ctx context.Context, orgID int32) (*AnclaxOrgOwner, error) {
+	row := q.db.QueryRow(ctx, getOrgOwner, orgID)
+	var i AnclaxOrgOwner
+	err := row.Scan(&i.OrgID, &i.UserID, &i.CreatedAt)
+	return &i, err
+}
+
+const getOrgUser = `-- name: GetOrgUser :one
+SELECT org_id, user_id, created_at, updated_at FROM anclax.org_users WHERE org_id = $1 AND user_id = $2
+`
+
+type GetOrgUserParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+func (q *Queries) GetOrgUser(ctx context.Context, arg GetOrgUserParams) (*AnclaxOrgUser, error) {
+	row := q.db.QueryRow(ctx, getOrgUser, arg.OrgID, arg.UserID)
+	var i AnclaxOrgUser
+	err := row.Scan(
+		&i.OrgID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
 const insertOrgOwner = `-- name: InsertOrgOwner :one
 INSERT INTO anclax.org_owners (org_id, user_id) VALUES ($1, $2) RETURNING org_id, user_id, created_at
 `
@@ -97,6 +147,52 @@ func (q *Queries) InsertOrgUser(ctx context.Context, arg InsertOrgUserParams) (*
 	return &i, err
 }
 
+const listOrgMembers = `-- name: ListOrgMembers :many
+SELECT
+    users.id AS user_id,
+    users.name AS user_name,
+    (org_owners.user_id IS NOT NULL)::bool AS is_owner,
+    org_users.created_at AS joined_at
+FROM anclax.org_users AS org_users
+JOIN anclax.users AS users ON org_users.user_id = users.id
+LEFT JOIN anclax.org_owners AS org_owners
+    ON org_owners.org_id = org_users.org_id AND org_owners.user_id = org_users.user_id
+WHERE org_users.org_id = $1 AND users.deleted_at IS NULL
+ORDER BY is_owner DESC, org_users.created_at, users.id
+`
+
+type ListOrgMembersRow struct {
+	UserID   int32
+	UserName string
+	IsOwner  bool
+	JoinedAt time.Time
+}
+
+func (q *Queries) ListOrgMembers(ctx context.Context, orgID int32) ([]*ListOrgMembersRow, error) {
+	rows, err := q.db.Query(ctx, listOrgMembers, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*ListOrgMembersRow
+	for rows.Next() {
+		var i ListOrgMembersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.UserName,
+			&i.IsOwner,
+			&i.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listOrgs = `-- name: ListOrgs :many
 SELECT orgs.id, orgs.name, orgs.tz, orgs.created_at, orgs.updated_at
 FROM anclax.org_users 
@@ -129,3 +225,21 @@ func (q *Queries) ListOrgs(ctx context.Context, userID int32) ([]*AnclaxOrg, err
 	}
 	return items, nil
 }
+
+const setOrgOwner = `-- name: SetOrgOwner :one
+INSERT INTO anclax.org_owners (org_id, user_id) VALUES ($1, $2)
+ON CONFLICT (org_id) DO UPDATE SET user_id = EXCLUDED.user_id
+RETURNING org_id, user_id, created_at
+`
+
+type SetOrgOwnerParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+func (q *Queries) SetOrgOwner(ctx context.Context, arg SetOrgOwnerParams) (*AnclaxOrgOwner, error) {
+	row := q.db.QueryRow(ctx, setOrgOwner, arg.OrgID, arg.UserID)
+	var i AnclaxOrgOwner
+	err := row.Scan(&i.OrgID, &i.UserID, &i.CreatedAt)
+	return &i, err
+}
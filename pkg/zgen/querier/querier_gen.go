@@ -9,11 +9,12 @@ import (
 	"encoding/json"
 	"time"
 
-	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AcceptUserTotpStep(ctx context.Context, arg AcceptUserTotpStepParams) (int64, error)
+	ArchiveEventsBefore(ctx context.Context, before time.Time) (int64, error)
 	ClaimNormalTaskByGroup(ctx context.Context, arg ClaimNormalTaskByGroupParams) (*AnclaxTask, error)
 	ClaimStrictTask(ctx context.Context, arg ClaimStrictTaskParams) (*AnclaxTask, error)
 	ClaimTask(ctx context.Context, arg ClaimTaskParams) (*AnclaxTask, error)
@@ -24,51 +25,75 @@ type Querier interface {
 	CreateTask(ctx context.Context, arg CreateTaskParams) (*AnclaxTask, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (*AnclaxUser, error)
 	CreateWorkerRuntimeConfig(ctx context.Context, payload json.RawMessage) (*AnclaxWorkerRuntimeConfig, error)
+	DeferTaskByWorker(ctx context.Context, arg DeferTaskByWorkerParams) (int32, error)
+	DeleteExpiredOpaqueKeys(ctx context.Context, now time.Time) (int64, error)
 	DeleteKeyPair(ctx context.Context, accessKey string) error
 	DeleteOpaqueKey(ctx context.Context, id int64) error
 	DeleteOpaqueKeys(ctx context.Context, group *string) error
+	DeleteOrgUser(ctx context.Context, arg DeleteOrgUserParams) (int64, error)
 	DeleteUserByName(ctx context.Context, name string) error
 	DeleteUserByNameReturningID(ctx context.Context, name string) (int32, error)
+	DeleteUserDefaultOrg(ctx context.Context, userID int32) error
+	EnableUserTotpSecret(ctx context.Context, userID int32) error
 	GetKeyPair(ctx context.Context, accessKey string) (*AnclaxAccessKeyPair, error)
 	GetLastTaskErrorEvent(ctx context.Context, taskID int32) (*AnclaxEvent, error)
+	GetLatestEventID(ctx context.Context) (int32, error)
 	GetLatestWorkerRuntimeConfig(ctx context.Context) (*AnclaxWorkerRuntimeConfig, error)
-	GetOpaqueKey(ctx context.Context, id int64) ([]byte, error)
+	GetOpaqueKey(ctx context.Context, id int64) (*GetOpaqueKeyRow, error)
 	GetOrg(ctx context.Context, id int32) (*AnclaxOrg, error)
 	GetOrgByName(ctx context.Context, name string) (*AnclaxOrg, error)
+	GetOrgOwner(ctx context.Context, orgID int32) (*AnclaxOrgOwner, error)
+	GetOrgUser(ctx context.Context, arg GetOrgUserParams) (*AnclaxOrgUser, error)
 	GetTaskByID(ctx context.Context, id int32) (*AnclaxTask, error)
 	GetTaskByUniqueTag(ctx context.Context, uniqueTag *string) (*AnclaxTask, error)
 	GetTaskWaitStatusByID(ctx context.Context, id int32) (*GetTaskWaitStatusByIDRow, error)
 	GetUser(ctx context.Context, id int32) (*AnclaxUser, error)
 	GetUserByName(ctx context.Context, name string) (*AnclaxUser, error)
 	GetUserDefaultOrg(ctx context.Context, userID int32) (int32, error)
+	GetUserTotpSecret(ctx context.Context, userID int32) (*AnclaxUserTotpSecret, error)
 	GetWorkerRuntimeConfigByVersion(ctx context.Context, version int64) (*AnclaxWorkerRuntimeConfig, error)
 	IncrementAttempts(ctx context.Context, id int32) error
-	InsertEvent(ctx context.Context, spec apigen.EventSpec) (*AnclaxEvent, error)
+	InsertEvent(ctx context.Context, arg InsertEventParams) (*AnclaxEvent, error)
 	InsertOrgOwner(ctx context.Context, arg InsertOrgOwnerParams) (*AnclaxOrgOwner, error)
 	InsertOrgUser(ctx context.Context, arg InsertOrgUserParams) (*AnclaxOrgUser, error)
 	IsUsernameExists(ctx context.Context, name string) (bool, error)
+	ListActiveOpaqueKeysByGroup(ctx context.Context, arg ListActiveOpaqueKeysByGroupParams) ([]*ListActiveOpaqueKeysByGroupRow, error)
 	ListAllPendingTasks(ctx context.Context) ([]*AnclaxTask, error)
+	ListEventsAfterID(ctx context.Context, arg ListEventsAfterIDParams) ([]*AnclaxEvent, error)
 	ListLaggingAliveWorkers(ctx context.Context, arg ListLaggingAliveWorkersParams) ([]uuid.UUID, error)
 	ListOnlineWorkerIDs(ctx context.Context, heartbeatCutoff time.Time) ([]uuid.UUID, error)
+	ListOrgMembers(ctx context.Context, orgID int32) ([]*ListOrgMembersRow, error)
 	ListOrgs(ctx context.Context, userID int32) ([]*AnclaxOrg, error)
+	ListScheduledTasks(ctx context.Context, arg ListScheduledTasksParams) ([]*AnclaxTask, error)
 	ListTaskDescendantIDs(ctx context.Context, parentTaskID *int32) ([]int32, error)
+	ListTaskEvents(ctx context.Context, taskID int32) ([]*AnclaxEvent, error)
 	ListTaskIDsByTags(ctx context.Context, arg ListTaskIDsByTagsParams) ([]int32, error)
+	ListTasksFiltered(ctx context.Context, arg ListTasksFilteredParams) ([]*AnclaxTask, error)
 	ListTerminalTaskWaitStatuses(ctx context.Context, ids []int32) ([]*ListTerminalTaskWaitStatusesRow, error)
 	MarkWorkerOffline(ctx context.Context, id uuid.UUID) error
+	RecordUserTotpFailure(ctx context.Context, arg RecordUserTotpFailureParams) error
 	RefreshTaskLock(ctx context.Context, arg RefreshTaskLockParams) (int32, error)
 	ReleaseTaskLockByWorker(ctx context.Context, arg ReleaseTaskLockByWorkerParams) (int32, error)
+	RestoreTask(ctx context.Context, id int32) (int64, error)
+	RestoreTaskEvents(ctx context.Context, taskID int32) error
 	RestoreUserByName(ctx context.Context, name string) error
+	SetOrgOwner(ctx context.Context, arg SetOrgOwnerParams) (*AnclaxOrgOwner, error)
 	SetUserDefaultOrg(ctx context.Context, arg SetUserDefaultOrgParams) error
+	SoftDeleteTask(ctx context.Context, id int32) (int64, error)
+	SoftDeleteTaskEvents(ctx context.Context, taskID int32) error
 	UpdatePendingTaskPriorityByLabels(ctx context.Context, arg UpdatePendingTaskPriorityByLabelsParams) (int64, error)
 	UpdatePendingTaskWeightByLabels(ctx context.Context, arg UpdatePendingTaskWeightByLabelsParams) (int64, error)
 	UpdateTask(ctx context.Context, arg UpdateTaskParams) error
+	UpdateTaskResult(ctx context.Context, arg UpdateTaskResultParams) error
 	UpdateTaskStartedAt(ctx context.Context, arg UpdateTaskStartedAtParams) error
 	UpdateTaskStartedAtByWorker(ctx context.Context, arg UpdateTaskStartedAtByWorkerParams) (int32, error)
 	UpdateTaskStatus(ctx context.Context, arg UpdateTaskStatusParams) error
 	UpdateTaskStatusByWorker(ctx context.Context, arg UpdateTaskStatusByWorkerParams) (int32, error)
+	UpdateTaskStatusIf(ctx context.Context, arg UpdateTaskStatusIfParams) (int32, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
 	UpdateWorkerAppliedConfigVersion(ctx context.Context, arg UpdateWorkerAppliedConfigVersionParams) error
 	UpdateWorkerHeartbeat(ctx context.Context, id uuid.UUID) (*AnclaxWorker, error)
+	UpsertUserTotpSecret(ctx context.Context, arg UpsertUserTotpSecretParams) (int64, error)
 	UpsertWorker(ctx context.Context, arg UpsertWorkerParams) (*AnclaxWorker, error)
 	VerifyTaskOwnership(ctx context.Context, arg VerifyTaskOwnershipParams) (int32, error)
 }
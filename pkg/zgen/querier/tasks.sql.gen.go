@@ -14,10 +14,34 @@ import (
 	"github.com/google/uuid"
 )
 
+const archiveEventsBefore = `-- name: ArchiveEventsBefore :execrows
+WITH archived AS (
+    DELETE FROM anclax.events AS e
+    WHERE e.created_at < $1::timestamptz
+      AND e.spec->>'type' IN ('TaskStarted', 'TaskCompleted', 'TaskError', 'TaskRetryScheduled', 'TaskCancelled')
+      AND NOT EXISTS (
+          SELECT 1 FROM anclax.tasks AS t
+          WHERE t.id = COALESCE(e.spec->'taskStarted'->>'taskID', e.spec->'taskCompleted'->>'taskID', e.spec->'taskError'->>'taskID', e.spec->'taskRetryScheduled'->>'taskID', e.spec->'taskCancelled'->>'taskID')::int
+            AND t.status = 'pending'
+      )
+    RETURNING e.id, e.spec, e.created_at
+)
+INSERT INTO anclax.events_archive (id, spec, created_at)
+SELECT id, spec, created_at FROM archived
+`
+
+func (q *Queries) ArchiveEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, archiveEventsBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const claimNormalTaskByGroup = `-- name: ClaimNormalTaskByGroup :one
 WITH
     eligible AS (
-        SELECT t.id, t.attributes, t.spec, t.status, t.unique_tag, t.started_at, t.created_at, t.updated_at, t.attempts, t.locked_at, t.worker_id, t.serial_key, t.serial_id, t.priority, t.weight, t.parent_task_id
+        SELECT t.id, t.attributes, t.spec, t.status, t.unique_tag, t.started_at, t.created_at, t.updated_at, t.attempts, t.locked_at, t.worker_id, t.serial_key, t.serial_id, t.priority, t.weight, t.parent_task_id, t.org_id, t.result, t.deleted_at
         FROM anclax.tasks t
         WHERE
             t.status = 'pending'
@@ -56,6 +80,14 @@ WITH
                     AND (t.attributes->'labels' ? $5::text)
                 )
             )
+            AND (
+                COALESCE(array_length($7::int[], 1), 0) = 0
+                OR t.org_id = ANY($7::int[])
+            )
+            AND (
+                COALESCE(array_length($8::text[], 1), 0) = 0
+                OR COALESCE(t.spec->>'type', '') <> ALL($8::text[])
+            )
     ),
     locked_serial_keys AS (
         SELECT DISTINCT t.serial_key
@@ -108,7 +140,7 @@ WHERE
     anclax.tasks.id = (SELECT id FROM candidate)
     AND anclax.tasks.status = 'pending'
     AND (anclax.tasks.locked_at IS NULL OR anclax.tasks.locked_at < $2)
-RETURNING id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id
+RETURNING id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id, org_id, result, deleted_at
 `
 
 type ClaimNormalTaskByGroupParams struct {
@@ -118,6 +150,8 @@ type ClaimNormalTaskByGroupParams struct {
 	HasLabels      bool
 	GroupName      string
 	WeightedLabels []string
+	OrgIds         []int32
+	ExcludeTypes   []string
 }
 
 func (q *Queries) ClaimNormalTaskByGroup(ctx context.Context, arg ClaimNormalTaskByGroupParams) (*AnclaxTask, error) {
@@ -128,6 +162,8 @@ func (q *Queries) ClaimNormalTaskByGroup(ctx context.Context, arg ClaimNormalTas
 		arg.HasLabels,
 		arg.GroupName,
 		arg.WeightedLabels,
+		arg.OrgIds,
+		arg.ExcludeTypes,
 	)
 	var i AnclaxTask
 	err := row.Scan(
@@ -147,6 +183,9 @@ func (q *Queries) ClaimNormalTaskByGroup(ctx context.Context, arg ClaimNormalTas
 		&i.Priority,
 		&i.Weight,
 		&i.ParentTaskID,
+		&i.OrgID,
+		&i.Result,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
@@ -154,7 +193,7 @@ func (q *Queries) ClaimNormalTaskByGroup(ctx context.Context, arg ClaimNormalTas
 const claimStrictTask = `-- name: ClaimStrictTask :one
 WITH
     eligible AS (
-        SELECT t.id, t.attributes, t.spec, t.status, t.unique_tag, t.started_at, t.created_at, t.updated_at, t.attempts, t.locked_at, t.worker_id, t.serial_key, t.serial_id, t.priority, t.weight, t.parent_task_id
+        SELECT t.id, t.attributes, t.spec, t.status, t.unique_tag, t.started_at, t.created_at, t.updated_at, t.attempts, t.locked_at, t.worker_id, t.serial_key, t.serial_id, t.priority, t.weight, t.parent_task_id, t.org_id, t.result, t.deleted_at
         FROM anclax.tasks t
         WHERE
             t.status = 'pending'
@@ -177,6 +216,10 @@ WITH
                     )
                 )
             )
+            AND (
+                COALESCE(array_length($5::int[], 1), 0) = 0
+                OR t.org_id = ANY($5::int[])
+            )
     ),
     locked_serial_keys AS (
         SELECT DISTINCT t.serial_key
@@ -229,7 +272,7 @@ WHERE
     anclax.tasks.id = (SELECT id FROM candidate)
     AND anclax.tasks.status = 'pending'
     AND (anclax.tasks.locked_at IS NULL OR anclax.tasks.locked_at < $2)
-RETURNING id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id
+RETURNING id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id, org_id, result, deleted_at
 `
 
 type ClaimStrictTaskParams struct {
@@ -237,6 +280,7 @@ type ClaimStrictTaskParams struct {
 	LockExpiry *time.Time
 	Labels     []string
 	HasLabels  bool
+	OrgIds     []int32
 }
 
 func (q *Queries) ClaimStrictTask(ctx context.Context, arg ClaimStrictTaskParams) (*AnclaxTask, error) {
@@ -245,6 +289,7 @@ func (q *Queries) ClaimStrictTask(ctx context.Context, arg ClaimStrictTaskParams
 		arg.LockExpiry,
 		arg.Labels,
 		arg.HasLabels,
+		arg.OrgIds,
 	)
 	var i AnclaxTask
 	err := row.Scan(
@@ -264,6 +309,9 @@ func (q *Queries) ClaimStrictTask(ctx context.Context, arg ClaimStrictTaskParams
 		&i.Priority,
 		&i.Weight,
 		&i.ParentTaskID,
+		&i.OrgID,
+		&i.Result,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
@@ -271,7 +319,7 @@ func (q *Queries) ClaimStrictTask(ctx context.Context, arg ClaimStrictTaskParams
 const claimTask = `-- name: ClaimTask :one
 WITH
     eligible AS (
-        SELECT t.id, t.attributes, t.spec, t.status, t.unique_tag, t.started_at, t.created_at, t.updated_at, t.attempts, t.locked_at, t.worker_id, t.serial_key, t.serial_id, t.priority, t.weight, t.parent_task_id
+        SELECT t.id, t.attributes, t.spec, t.status, t.unique_tag, t.started_at, t.created_at, t.updated_at, t.attempts, t.locked_at, t.worker_id, t.serial_key, t.serial_id, t.priority, t.weight, t.parent_task_id, t.org_id, t.result, t.deleted_at
         FROM anclax.tasks t
         WHERE
             t.status = 'pending'
@@ -293,6 +341,10 @@ WITH
                     )
                 )
             )
+            AND (
+                COALESCE(array_length($5::int[], 1), 0) = 0
+                OR t.org_id = ANY($5::int[])
+            )
     ),
     locked_serial_keys AS (
         SELECT DISTINCT t.serial_key
@@ -345,7 +397,7 @@ WHERE
     anclax.tasks.id = (SELECT id FROM candidate)
     AND anclax.tasks.status = 'pending'
     AND (anclax.tasks.locked_at IS NULL OR anclax.tasks.locked_at < $2)
-RETURNING id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id
+RETURNING id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id, org_id, result, deleted_at
 `
 
 type ClaimTaskParams struct {
@@ -353,6 +405,7 @@ type ClaimTaskParams struct {
 	LockExpiry *time.Time
 	Labels     []string
 	HasLabels  bool
+	OrgIds     []int32
 }
 
 func (q *Queries) ClaimTask(ctx context.Context, arg ClaimTaskParams) (*AnclaxTask, error) {
@@ -361,6 +414,7 @@ func (q *Queries) ClaimTask(ctx context.Context, arg ClaimTaskParams) (*AnclaxTa
 		arg.LockExpiry,
 		arg.Labels,
 		arg.HasLabels,
+		arg.OrgIds,
 	)
 	var i AnclaxTask
 	err := row.Scan(
@@ -380,6 +434,9 @@ func (q *Queries) ClaimTask(ctx context.Context, arg ClaimTaskParams) (*AnclaxTa
 		&i.Priority,
 		&i.Weight,
 		&i.ParentTaskID,
+		&i.OrgID,
+		&i.Result,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
@@ -387,7 +444,7 @@ func (q *Queries) ClaimTask(ctx context.Context, arg ClaimTaskParams) (*AnclaxTa
 const claimTaskByID = `-- name: ClaimTaskByID :one
 WITH
     eligible AS (
-        SELECT t.id, t.attributes, t.spec, t.status, t.unique_tag, t.started_at, t.created_at, t.updated_at, t.attempts, t.locked_at, t.worker_id, t.serial_key, t.serial_id, t.priority, t.weight, t.parent_task_id
+        SELECT t.id, t.attributes, t.spec, t.status, t.unique_tag, t.started_at, t.created_at, t.updated_at, t.attempts, t.locked_at, t.worker_id, t.serial_key, t.serial_id, t.priority, t.weight, t.parent_task_id, t.org_id, t.result, t.deleted_at
         FROM anclax.tasks t
         WHERE
             t.id = $3
@@ -410,6 +467,10 @@ WITH
                     )
                 )
             )
+            AND (
+                COALESCE(array_length($6::int[], 1), 0) = 0
+                OR t.org_id = ANY($6::int[])
+            )
     ),
     locked_serial_keys AS (
         SELECT DISTINCT t.serial_key
@@ -461,7 +522,7 @@ WHERE
     anclax.tasks.id = (SELECT id FROM candidate)
     AND anclax.tasks.status = 'pending'
     AND (anclax.tasks.locked_at IS NULL OR anclax.tasks.locked_at < $2)
-RETURNING id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id
+RETURNING id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id, org_id, result, deleted_at
 `
 
 type ClaimTaskByIDParams struct {
@@ -470,6 +531,7 @@ type ClaimTaskByIDParams struct {
 	ID         int32
 	Labels     []string
 	HasLabels  bool
+	OrgIds     []int32
 }
 
 func (q *Queries) ClaimTaskByID(ctx context.Context, arg ClaimTaskByIDParams) (*AnclaxTask, error) {
@@ -479,6 +541,7 @@ func (q *Queries) ClaimTaskByID(ctx context.Context, arg ClaimTaskByIDParams) (*
 		arg.ID,
 		arg.Labels,
 		arg.HasLabels,
+		arg.OrgIds,
 	)
 	var i AnclaxTask
 	err := row.Scan(
@@ -498,13 +561,16 @@ func (q *Queries) ClaimTaskByID(ctx context.Context, arg ClaimTaskByIDParams) (*
 		&i.Priority,
 		&i.Weight,
 		&i.ParentTaskID,
+		&i.OrgID,
+		&i.Result,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
 
 const createTask = `-- name: CreateTask :one
-INSERT INTO anclax.tasks (attributes, spec, status, started_at, unique_tag, parent_task_id, serial_key, serial_id, priority, weight)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (unique_tag) DO NOTHING RETURNING id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id
+INSERT INTO anclax.tasks (attributes, spec, status, started_at, unique_tag, parent_task_id, serial_key, serial_id, priority, weight, org_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (unique_tag) WHERE status NOT IN ('completed', 'failed', 'cancelled') DO NOTHING RETURNING id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id, org_id, result, deleted_at
 `
 
 type CreateTaskParams struct {
@@ -518,6 +584,7 @@ type CreateTaskParams struct {
 	SerialID     *int32
 	Priority     int32
 	Weight       int32
+	OrgID        *int32
 }
 
 func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (*AnclaxTask, error) {
@@ -532,6 +599,7 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (*Anclax
 		arg.SerialID,
 		arg.Priority,
 		arg.Weight,
+		arg.OrgID,
 	)
 	var i AnclaxTask
 	err := row.Scan(
@@ -551,12 +619,40 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (*Anclax
 		&i.Priority,
 		&i.Weight,
 		&i.ParentTaskID,
+		&i.OrgID,
+		&i.Result,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
 
+const deferTaskByWorker = `-- name: DeferTaskByWorker :one
+UPDATE anclax.tasks
+SET
+    started_at = $2,
+    attempts = GREATEST(attempts - 1, 0),
+    locked_at = NULL,
+    worker_id = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND worker_id = $3
+RETURNING id
+`
+
+type DeferTaskByWorkerParams struct {
+	ID        int32
+	StartedAt *time.Time
+	WorkerID  uuid.NullUUID
+}
+
+func (q *Queries) DeferTaskByWorker(ctx context.Context, arg DeferTaskByWorkerParams) (int32, error) {
+	row := q.db.QueryRow(ctx, deferTaskByWorker, arg.ID, arg.StartedAt, arg.WorkerID)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getLastTaskErrorEvent = `-- name: GetLastTaskErrorEvent :one
-SELECT id, spec, created_at FROM anclax.events
+SELECT id, spec, created_at, idempotency_key, deleted_at FROM anclax.events
 WHERE spec->>'type' = 'TaskError'
   AND (spec->'taskError'->>'taskID')::int = $1::int
 ORDER BY created_at DESC
@@ -566,12 +662,29 @@ LIMIT 1
 func (q *Queries) GetLastTaskErrorEvent(ctx context.Context, taskID int32) (*AnclaxEvent, error) {
 	row := q.db.QueryRow(ctx, getLastTaskErrorEvent, taskID)
 	var i AnclaxEvent
-	err := row.Scan(&i.ID, &i.Spec, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Spec,
+		&i.CreatedAt,
+		&i.IdempotencyKey,
+		&i.DeletedAt,
+	)
 	return &i, err
 }
 
+const getLatestEventID = `-- name: GetLatestEventID :one
+SELECT COALESCE(MAX(id), 0)::int AS id FROM anclax.events
+`
+
+func (q *Queries) GetLatestEventID(ctx context.Context) (int32, error) {
+	row := q.db.QueryRow(ctx, getLatestEventID)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getTaskByID = `-- name: GetTaskByID :one
-SELECT id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id FROM anclax.tasks
+SELECT id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id, org_id, result, deleted_at FROM anclax.tasks
 WHERE id = $1
 `
 
@@ -595,13 +708,18 @@ func (q *Queries) GetTaskByID(ctx context.Context, id int32) (*AnclaxTask, error
 		&i.Priority,
 		&i.Weight,
 		&i.ParentTaskID,
+		&i.OrgID,
+		&i.Result,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
 
 const getTaskByUniqueTag = `-- name: GetTaskByUniqueTag :one
-SELECT id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id FROM anclax.tasks
+SELECT id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id, org_id, result, deleted_at FROM anclax.tasks
 WHERE unique_tag = $1
+ORDER BY id DESC
+LIMIT 1
 `
 
 func (q *Queries) GetTaskByUniqueTag(ctx context.Context, uniqueTag *string) (*AnclaxTask, error) {
@@ -624,6 +742,9 @@ func (q *Queries) GetTaskByUniqueTag(ctx context.Context, uniqueTag *string) (*A
 		&i.Priority,
 		&i.Weight,
 		&i.ParentTaskID,
+		&i.OrgID,
+		&i.Result,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
@@ -658,20 +779,32 @@ func (q *Queries) IncrementAttempts(ctx context.Context, id int32) error {
 }
 
 const insertEvent = `-- name: InsertEvent :one
-INSERT INTO anclax.events (spec)
-VALUES ($1)
-RETURNING id, spec, created_at
+INSERT INTO anclax.events (spec, idempotency_key)
+VALUES ($1, $2)
+ON CONFLICT (idempotency_key) DO NOTHING
+RETURNING id, spec, created_at, idempotency_key, deleted_at
 `
 
-func (q *Queries) InsertEvent(ctx context.Context, spec apigen.EventSpec) (*AnclaxEvent, error) {
-	row := q.db.QueryRow(ctx, insertEvent, spec)
+type InsertEventParams struct {
+	Spec           apigen.EventSpec
+	IdempotencyKey *string
+}
+
+func (q *Queries) InsertEvent(ctx context.Context, arg InsertEventParams) (*AnclaxEvent, error) {
+	row := q.db.QueryRow(ctx, insertEvent, arg.Spec, arg.IdempotencyKey)
 	var i AnclaxEvent
-	err := row.Scan(&i.ID, &i.Spec, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Spec,
+		&i.CreatedAt,
+		&i.IdempotencyKey,
+		&i.DeletedAt,
+	)
 	return &i, err
 }
 
 const listAllPendingTasks = `-- name: ListAllPendingTasks :many
-SELECT id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id FROM anclax.tasks
+SELECT id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id, org_id, result, deleted_at FROM anclax.tasks
 WHERE
     status = 'pending'
     AND (
@@ -705,6 +838,103 @@ func (q *Queries) ListAllPendingTasks(ctx context.Context) ([]*AnclaxTask, error
 			&i.Priority,
 			&i.Weight,
 			&i.ParentTaskID,
+			&i.OrgID,
+			&i.Result,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsAfterID = `-- name: ListEventsAfterID :many
+SELECT id, spec, created_at, idempotency_key, deleted_at FROM anclax.events
+WHERE id > $1::int
+  AND ($2::bool OR deleted_at IS NULL)
+ORDER BY id
+LIMIT $3::int
+`
+
+type ListEventsAfterIDParams struct {
+	AfterID        int32
+	IncludeDeleted bool
+	MaxCount       int32
+}
+
+func (q *Queries) ListEventsAfterID(ctx context.Context, arg ListEventsAfterIDParams) ([]*AnclaxEvent, error) {
+	rows, err := q.db.Query(ctx, listEventsAfterID, arg.AfterID, arg.IncludeDeleted, arg.MaxCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*AnclaxEvent
+	for rows.Next() {
+		var i AnclaxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Spec,
+			&i.CreatedAt,
+			&i.IdempotencyKey,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledTasks = `-- name: ListScheduledTasks :many
+SELECT id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id, org_id, result, deleted_at FROM anclax.tasks
+WHERE
+    status = 'pending'
+    AND started_at >= $1::timestamptz
+    AND started_at <= $2::timestamptz
+ORDER BY started_at, id
+`
+
+type ListScheduledTasksParams struct {
+	FromTime  time.Time
+	UntilTime time.Time
+}
+
+func (q *Queries) ListScheduledTasks(ctx context.Context, arg ListScheduledTasksParams) ([]*AnclaxTask, error) {
+	rows, err := q.db.Query(ctx, listScheduledTasks, arg.FromTime, arg.UntilTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*AnclaxTask
+	for rows.Next() {
+		var i AnclaxTask
+		if err := rows.Scan(
+			&i.ID,
+			&i.Attributes,
+			&i.Spec,
+			&i.Status,
+			&i.UniqueTag,
+			&i.StartedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Attempts,
+			&i.LockedAt,
+			&i.WorkerID,
+			&i.SerialKey,
+			&i.SerialID,
+			&i.Priority,
+			&i.Weight,
+			&i.ParentTaskID,
+			&i.OrgID,
+			&i.Result,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -749,6 +979,39 @@ func (q *Queries) ListTaskDescendantIDs(ctx context.Context, parentTaskID *int32
 	return items, nil
 }
 
+const listTaskEvents = `-- name: ListTaskEvents :many
+SELECT id, spec, created_at, idempotency_key, deleted_at FROM anclax.events
+WHERE deleted_at IS NULL
+  AND COALESCE(spec->'taskStarted'->>'taskID', spec->'taskCompleted'->>'taskID', spec->'taskError'->>'taskID', spec->'taskRetryScheduled'->>'taskID', spec->'taskCancelled'->>'taskID')::int = $1::int
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListTaskEvents(ctx context.Context, taskID int32) ([]*AnclaxEvent, error) {
+	rows, err := q.db.Query(ctx, listTaskEvents, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*AnclaxEvent
+	for rows.Next() {
+		var i AnclaxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Spec,
+			&i.CreatedAt,
+			&i.IdempotencyKey,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTaskIDsByTags = `-- name: ListTaskIDsByTags :many
 SELECT t.id
 FROM anclax.tasks t
@@ -800,6 +1063,68 @@ func (q *Queries) ListTaskIDsByTags(ctx context.Context, arg ListTaskIDsByTagsPa
 	return items, nil
 }
 
+const listTasksFiltered = `-- name: ListTasksFiltered :many
+SELECT id, attributes, spec, status, unique_tag, started_at, created_at, updated_at, attempts, locked_at, worker_id, serial_key, serial_id, priority, weight, parent_task_id, org_id, result, deleted_at FROM anclax.tasks
+WHERE
+    ($1::text IS NULL OR status = $1::text)
+    AND ($2::text IS NULL OR spec->>'type' = $2::text)
+    AND ($3::timestamptz IS NULL OR created_at > $3::timestamptz)
+    AND ($4::bool OR deleted_at IS NULL)
+ORDER BY id DESC
+`
+
+type ListTasksFilteredParams struct {
+	Status         *string
+	TaskType       *string
+	CreatedAfter   *time.Time
+	IncludeDeleted bool
+}
+
+func (q *Queries) ListTasksFiltered(ctx context.Context, arg ListTasksFilteredParams) ([]*AnclaxTask, error) {
+	rows, err := q.db.Query(ctx, listTasksFiltered,
+		arg.Status,
+		arg.TaskType,
+		arg.CreatedAfter,
+		arg.IncludeDeleted,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*AnclaxTask
+	for rows.Next() {
+		var i AnclaxTask
+		if err := rows.Scan(
+			&i.ID,
+			&i.Attributes,
+			&i.Spec,
+			&i.Status,
+			&i.UniqueTag,
+			&i.StartedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Attempts,
+			&i.LockedAt,
+			&i.WorkerID,
+			&i.SerialKey,
+			&i.SerialID,
+			&i.Priority,
+			&i.Weight,
+			&i.ParentTaskID,
+			&i.OrgID,
+			&i.Result,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTerminalTaskWaitStatuses = `-- name: ListTerminalTaskWaitStatuses :many
 SELECT id, status
 FROM anclax.tasks
@@ -870,6 +1195,58 @@ func (q *Queries) ReleaseTaskLockByWorker(ctx context.Context, arg ReleaseTaskLo
 	return id, err
 }
 
+const restoreTask = `-- name: RestoreTask :execrows
+UPDATE anclax.tasks
+SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) RestoreTask(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreTask, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreTaskEvents = `-- name: RestoreTaskEvents :exec
+UPDATE anclax.events
+SET deleted_at = NULL
+WHERE deleted_at IS NOT NULL
+  AND COALESCE(spec->'taskStarted'->>'taskID', spec->'taskCompleted'->>'taskID', spec->'taskError'->>'taskID', spec->'taskRetryScheduled'->>'taskID', spec->'taskCancelled'->>'taskID')::int = $1::int
+`
+
+func (q *Queries) RestoreTaskEvents(ctx context.Context, taskID int32) error {
+	_, err := q.db.Exec(ctx, restoreTaskEvents, taskID)
+	return err
+}
+
+const softDeleteTask = `-- name: SoftDeleteTask :execrows
+UPDATE anclax.tasks
+SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL AND status IN ('completed', 'failed', 'cancelled')
+`
+
+func (q *Queries) SoftDeleteTask(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, softDeleteTask, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const softDeleteTaskEvents = `-- name: SoftDeleteTaskEvents :exec
+UPDATE anclax.events
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE deleted_at IS NULL
+  AND COALESCE(spec->'taskStarted'->>'taskID', spec->'taskCompleted'->>'taskID', spec->'taskError'->>'taskID', spec->'taskRetryScheduled'->>'taskID', spec->'taskCancelled'->>'taskID')::int = $1::int
+`
+
+func (q *Queries) SoftDeleteTaskEvents(ctx context.Context, taskID int32) error {
+	_, err := q.db.Exec(ctx, softDeleteTaskEvents, taskID)
+	return err
+}
+
 const updatePendingTaskPriorityByLabels = `-- name: UpdatePendingTaskPriorityByLabels :execrows
 UPDATE anclax.tasks
 SET
@@ -977,6 +1354,22 @@ func (q *Queries) UpdateTask(ctx context.Context, arg UpdateTaskParams) error {
 	return err
 }
 
+const updateTaskResult = `-- name: UpdateTaskResult :exec
+UPDATE anclax.tasks
+SET result = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type UpdateTaskResultParams struct {
+	ID     int32
+	Result []byte
+}
+
+func (q *Queries) UpdateTaskResult(ctx context.Context, arg UpdateTaskResultParams) error {
+	_, err := q.db.Exec(ctx, updateTaskResult, arg.ID, arg.Result)
+	return err
+}
+
 const updateTaskStartedAt = `-- name: UpdateTaskStartedAt :exec
 UPDATE anclax.tasks
 SET started_at = $2, updated_at = CURRENT_TIMESTAMP
@@ -1055,6 +1448,28 @@ func (q *Queries) UpdateTaskStatusByWorker(ctx context.Context, arg UpdateTaskSt
 	return id, err
 }
 
+const updateTaskStatusIf = `-- name: UpdateTaskStatusIf :one
+UPDATE anclax.tasks
+SET
+    status = $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $2 AND status = $3
+RETURNING id
+`
+
+type UpdateTaskStatusIfParams struct {
+	NewStatus      string
+	ID             int32
+	ExpectedStatus string
+}
+
+func (q *Queries) UpdateTaskStatusIf(ctx context.Context, arg UpdateTaskStatusIfParams) (int32, error) {
+	row := q.db.QueryRow(ctx, updateTaskStatusIf, arg.NewStatus, arg.ID, arg.ExpectedStatus)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
 const verifyTaskOwnership = `-- name: VerifyTaskOwnership :one
 SELECT id FROM anclax.tasks
 WHERE id = $1 AND worker_id = $2
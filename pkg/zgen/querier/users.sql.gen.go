@@ -7,8 +7,27 @@ package querier
 
 import (
 	"context"
+	"time"
 )
 
+const acceptUserTotpStep = `-- name: AcceptUserTotpStep :execrows
+UPDATE anclax.user_totp_secrets SET last_step = $2, failed_attempts = 0, locked_until = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND (last_step IS NULL OR last_step < $2)
+`
+
+type AcceptUserTotpStepParams struct {
+	UserID   int32
+	LastStep *int64
+}
+
+func (q *Queries) AcceptUserTotpStep(ctx context.Context, arg AcceptUserTotpStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, acceptUserTotpStep, arg.UserID, arg.LastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO anclax.users (
     name,
@@ -60,6 +79,25 @@ func (q *Queries) DeleteUserByNameReturningID(ctx context.Context, name string)
 	return id, err
 }
 
+const deleteUserDefaultOrg = `-- name: DeleteUserDefaultOrg :exec
+DELETE FROM anclax.user_default_orgs WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserDefaultOrg(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteUserDefaultOrg, userID)
+	return err
+}
+
+const enableUserTotpSecret = `-- name: EnableUserTotpSecret :exec
+UPDATE anclax.user_totp_secrets SET enabled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND enabled_at IS NULL
+`
+
+func (q *Queries) EnableUserTotpSecret(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, enableUserTotpSecret, userID)
+	return err
+}
+
 const getUser = `-- name: GetUser :one
 SELECT id, name, password_hash, password_salt, created_at, updated_at, deleted_at FROM anclax.users
 WHERE id = $1 AND deleted_at IS NULL
@@ -111,6 +149,26 @@ func (q *Queries) GetUserDefaultOrg(ctx context.Context, userID int32) (int32, e
 	return org_id, err
 }
 
+const getUserTotpSecret = `-- name: GetUserTotpSecret :one
+SELECT user_id, secret, enabled_at, created_at, updated_at, last_step, failed_attempts, locked_until FROM anclax.user_totp_secrets WHERE user_id = $1
+`
+
+func (q *Queries) GetUserTotpSecret(ctx context.Context, userID int32) (*AnclaxUserTotpSecret, error) {
+	row := q.db.QueryRow(ctx, getUserTotpSecret, userID)
+	var i AnclaxUserTotpSecret
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.EnabledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastStep,
+		&i.FailedAttempts,
+		&i.LockedUntil,
+	)
+	return &i, err
+}
+
 const isUsernameExists = `-- name: IsUsernameExists :one
 SELECT EXISTS (SELECT 1 FROM anclax.users WHERE name = $1)
 `
@@ -122,6 +180,25 @@ func (q *Queries) IsUsernameExists(ctx context.Context, name string) (bool, erro
 	return exists, err
 }
 
+const recordUserTotpFailure = `-- name: RecordUserTotpFailure :exec
+UPDATE anclax.user_totp_secrets SET
+    failed_attempts = CASE WHEN failed_attempts + 1 >= $1::int THEN 0 ELSE failed_attempts + 1 END,
+    locked_until = CASE WHEN failed_attempts + 1 >= $1::int THEN $2::timestamptz ELSE locked_until END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $3
+`
+
+type RecordUserTotpFailureParams struct {
+	MaxAttempts int32
+	LockedUntil time.Time
+	UserID      int32
+}
+
+func (q *Queries) RecordUserTotpFailure(ctx context.Context, arg RecordUserTotpFailureParams) error {
+	_, err := q.db.Exec(ctx, recordUserTotpFailure, arg.MaxAttempts, arg.LockedUntil, arg.UserID)
+	return err
+}
+
 const restoreUserByName = `-- name: RestoreUserByName :exec
 UPDATE anclax.users SET deleted_at = NULL WHERE name = $1
 `
@@ -161,3 +238,23 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash, arg.PasswordSalt)
 	return err
 }
+
+const upsertUserTotpSecret = `-- name: UpsertUserTotpSecret :execrows
+INSERT INTO anclax.user_totp_secrets (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET secret = $2, updated_at = CURRENT_TIMESTAMP
+WHERE anclax.user_totp_secrets.enabled_at IS NULL
+`
+
+type UpsertUserTotpSecretParams struct {
+	UserID int32
+	Secret []byte
+}
+
+func (q *Queries) UpsertUserTotpSecret(ctx context.Context, arg UpsertUserTotpSecretParams) (int64, error) {
+	result, err := q.db.Exec(ctx, upsertUserTotpSecret, arg.UserID, arg.Secret)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
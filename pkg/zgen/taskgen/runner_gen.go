@@ -39,6 +39,10 @@ const (
 	StressProbe = "stressProbe"
 
 	CancelObservableProbe = "cancelObservableProbe"
+
+	ArchiveEvents = "archiveEvents"
+
+	AsyncUserCreatedHook = "asyncUserCreatedHook"
 )
 
 type TaskRunner interface {
@@ -86,6 +90,16 @@ type TaskRunner interface {
 	RunCancelObservableProbe(ctx context.Context, params *CancelObservableProbeParameters, overrides ...taskcore.TaskOverride) (int32, error)
 	// Long-running observable task used to validate cancellation semantics under chaos
 	RunCancelObservableProbeWithTx(ctx context.Context, tx core.Tx, params *CancelObservableProbeParameters, overrides ...taskcore.TaskOverride) (int32, error)
+
+	// Move task completed and task error events older than the retention to the events archive
+	RunArchiveEvents(ctx context.Context, params *ArchiveEventsParameters, overrides ...taskcore.TaskOverride) (int32, error)
+	// Move task completed and task error events older than the retention to the events archive
+	RunArchiveEventsWithTx(ctx context.Context, tx core.Tx, params *ArchiveEventsParameters, overrides ...taskcore.TaskOverride) (int32, error)
+
+	// Run an async hook registered with RegisterOnUserCreatedAsync for a created user
+	RunAsyncUserCreatedHook(ctx context.Context, params *AsyncUserCreatedHookParameters, overrides ...taskcore.TaskOverride) (int32, error)
+	// Run an async hook registered with RegisterOnUserCreatedAsync for a created user
+	RunAsyncUserCreatedHookWithTx(ctx context.Context, tx core.Tx, params *AsyncUserCreatedHookParameters, overrides ...taskcore.TaskOverride) (int32, error)
 }
 
 type Client struct {
@@ -147,6 +161,7 @@ func (c *Client) runDeleteOpaqueKey(ctx context.Context, taskstore taskcore.Task
 	}
 	return taskID, nil
 }
+
 func (c *Client) RunBroadcastUpdateWorkerRuntimeConfig(ctx context.Context, params *BroadcastUpdateWorkerRuntimeConfigParameters, overrides ...taskcore.TaskOverride) (int32, error) {
 	return c.runBroadcastUpdateWorkerRuntimeConfig(ctx, c.taskStore, nil, params, overrides...)
 }
@@ -195,6 +210,7 @@ func (c *Client) runBroadcastUpdateWorkerRuntimeConfig(ctx context.Context, task
 	}
 	return taskID, nil
 }
+
 func (c *Client) RunApplyWorkerRuntimeConfigToWorker(ctx context.Context, params *ApplyWorkerRuntimeConfigToWorkerParameters, overrides ...taskcore.TaskOverride) (int32, error) {
 	return c.runApplyWorkerRuntimeConfigToWorker(ctx, c.taskStore, nil, params, overrides...)
 }
@@ -243,6 +259,7 @@ func (c *Client) runApplyWorkerRuntimeConfigToWorker(ctx context.Context, taskst
 	}
 	return taskID, nil
 }
+
 func (c *Client) RunBroadcastCancelTask(ctx context.Context, params *BroadcastCancelTaskParameters, overrides ...taskcore.TaskOverride) (int32, error) {
 	return c.runBroadcastCancelTask(ctx, c.taskStore, nil, params, overrides...)
 }
@@ -291,6 +308,7 @@ func (c *Client) runBroadcastCancelTask(ctx context.Context, taskstore taskcore.
 	}
 	return taskID, nil
 }
+
 func (c *Client) RunCancelTaskOnWorker(ctx context.Context, params *CancelTaskOnWorkerParameters, overrides ...taskcore.TaskOverride) (int32, error) {
 	return c.runCancelTaskOnWorker(ctx, c.taskStore, nil, params, overrides...)
 }
@@ -339,6 +357,7 @@ func (c *Client) runCancelTaskOnWorker(ctx context.Context, taskstore taskcore.T
 	}
 	return taskID, nil
 }
+
 func (c *Client) RunBroadcastPauseTask(ctx context.Context, params *BroadcastPauseTaskParameters, overrides ...taskcore.TaskOverride) (int32, error) {
 	return c.runBroadcastPauseTask(ctx, c.taskStore, nil, params, overrides...)
 }
@@ -387,6 +406,7 @@ func (c *Client) runBroadcastPauseTask(ctx context.Context, taskstore taskcore.T
 	}
 	return taskID, nil
 }
+
 func (c *Client) RunPauseTaskOnWorker(ctx context.Context, params *PauseTaskOnWorkerParameters, overrides ...taskcore.TaskOverride) (int32, error) {
 	return c.runPauseTaskOnWorker(ctx, c.taskStore, nil, params, overrides...)
 }
@@ -435,6 +455,7 @@ func (c *Client) runPauseTaskOnWorker(ctx context.Context, taskstore taskcore.Ta
 	}
 	return taskID, nil
 }
+
 func (c *Client) RunStressProbe(ctx context.Context, params *StressProbeParameters, overrides ...taskcore.TaskOverride) (int32, error) {
 	return c.runStressProbe(ctx, c.taskStore, nil, params, overrides...)
 }
@@ -482,6 +503,7 @@ func (c *Client) runStressProbe(ctx context.Context, taskstore taskcore.TaskStor
 	}
 	return taskID, nil
 }
+
 func (c *Client) RunCancelObservableProbe(ctx context.Context, params *CancelObservableProbeParameters, overrides ...taskcore.TaskOverride) (int32, error) {
 	return c.runCancelObservableProbe(ctx, c.taskStore, nil, params, overrides...)
 }
@@ -530,6 +552,102 @@ func (c *Client) runCancelObservableProbe(ctx context.Context, taskstore taskcor
 	return taskID, nil
 }
 
+func (c *Client) RunArchiveEvents(ctx context.Context, params *ArchiveEventsParameters, overrides ...taskcore.TaskOverride) (int32, error) {
+	return c.runArchiveEvents(ctx, c.taskStore, nil, params, overrides...)
+}
+
+func (c *Client) RunArchiveEventsWithTx(ctx context.Context, tx core.Tx, params *ArchiveEventsParameters, overrides ...taskcore.TaskOverride) (int32, error) {
+	return c.runArchiveEvents(ctx, c.taskStore, tx, params, overrides...)
+}
+
+func (c *Client) runArchiveEvents(ctx context.Context, taskstore taskcore.TaskStoreInterface, tx core.Tx, params *ArchiveEventsParameters, overrides ...taskcore.TaskOverride) (int32, error) {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return 0, err
+	}
+
+	spec := apigen.TaskSpec{
+		Type:    ArchiveEvents,
+		Payload: payload,
+	}
+	attributes := apigen.TaskAttributes{}
+	attributes.Timeout = utils.Ptr("10m")
+	attributes.RetryPolicy = &apigen.TaskRetryPolicy{
+		Interval:    "5m",
+		MaxAttempts: 3,
+	}
+
+	task := &apigen.Task{
+		Attributes: attributes,
+		Spec:       spec,
+		Status:     apigen.Pending,
+	}
+
+	for _, override := range overrides {
+		if err := override(task); err != nil {
+			return 0, errors.Wrap(err, "failed to apply task override")
+		}
+	}
+	var taskID int32
+	if tx == nil {
+		taskID, err = taskstore.PushTask(ctx, task)
+	} else {
+		taskID, err = taskstore.PushTaskWithTx(ctx, tx, task)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return taskID, nil
+}
+
+func (c *Client) RunAsyncUserCreatedHook(ctx context.Context, params *AsyncUserCreatedHookParameters, overrides ...taskcore.TaskOverride) (int32, error) {
+	return c.runAsyncUserCreatedHook(ctx, c.taskStore, nil, params, overrides...)
+}
+
+func (c *Client) RunAsyncUserCreatedHookWithTx(ctx context.Context, tx core.Tx, params *AsyncUserCreatedHookParameters, overrides ...taskcore.TaskOverride) (int32, error) {
+	return c.runAsyncUserCreatedHook(ctx, c.taskStore, tx, params, overrides...)
+}
+
+func (c *Client) runAsyncUserCreatedHook(ctx context.Context, taskstore taskcore.TaskStoreInterface, tx core.Tx, params *AsyncUserCreatedHookParameters, overrides ...taskcore.TaskOverride) (int32, error) {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return 0, err
+	}
+
+	spec := apigen.TaskSpec{
+		Type:    AsyncUserCreatedHook,
+		Payload: payload,
+	}
+	attributes := apigen.TaskAttributes{}
+
+	attributes.RetryPolicy = &apigen.TaskRetryPolicy{
+		Interval:    "1m",
+		MaxAttempts: 10,
+	}
+
+	task := &apigen.Task{
+		Attributes: attributes,
+		Spec:       spec,
+		Status:     apigen.Pending,
+	}
+
+	for _, override := range overrides {
+		if err := override(task); err != nil {
+			return 0, errors.Wrap(err, "failed to apply task override")
+		}
+	}
+	var taskID int32
+	if tx == nil {
+		taskID, err = taskstore.PushTask(ctx, task)
+	} else {
+		taskID, err = taskstore.PushTaskWithTx(ctx, tx, task)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return taskID, nil
+}
+
 type DeleteOpaqueKeyParameters struct {
 	// The ID of the opaque key to delete
 	KeyID int64 `json:"keyID" yaml:"keyID"`
@@ -537,30 +655,30 @@ type DeleteOpaqueKeyParameters struct {
 
 type BroadcastUpdateWorkerRuntimeConfigParameters struct {
 	// Poll interval used while waiting for DB convergence
-	AckPollInterval *string `json:"ackPollInterval" yaml:"ackPollInterval"`
+	AckPollInterval *string `json:"ackPollInterval,omitempty" yaml:"ackPollInterval"`
 
 	// Default weight for unlabeled task group
-	DefaultWeight *int32 `json:"defaultWeight" yaml:"defaultWeight"`
+	DefaultWeight *int32 `json:"defaultWeight,omitempty" yaml:"defaultWeight"`
 
 	// Label names for weighted groups
-	Labels []string `json:"labels" yaml:"labels"`
+	Labels []string `json:"labels,omitempty" yaml:"labels"`
 
 	// Maximum percentage of strict-priority slots (0-100)
-	MaxStrictPercentage *int32 `json:"maxStrictPercentage" yaml:"maxStrictPercentage"`
+	MaxStrictPercentage *int32 `json:"maxStrictPercentage,omitempty" yaml:"maxStrictPercentage"`
 
 	// Correlation ID for this broadcast command
-	RequestID *string `json:"requestID" yaml:"requestID"`
+	RequestID *string `json:"requestID,omitempty" yaml:"requestID"`
 
 	// Weights for labels by index
-	Weights []int32 `json:"weights" yaml:"weights"`
+	Weights []int32 `json:"weights,omitempty" yaml:"weights"`
 
 	// Fixed snapshot of worker IDs targeted by this broadcast request
-	WorkerIDs []uuid.UUID `json:"workerIDs" yaml:"workerIDs"`
+	WorkerIDs []uuid.UUID `json:"workerIDs,omitempty" yaml:"workerIDs"`
 }
 
 type ApplyWorkerRuntimeConfigToWorkerParameters struct {
 	// Correlation ID of the parent broadcast command
-	RequestID *string `json:"requestID" yaml:"requestID"`
+	RequestID *string `json:"requestID,omitempty" yaml:"requestID"`
 
 	// Runtime config version to apply
 	Version int64 `json:"version" yaml:"version"`
@@ -571,21 +689,21 @@ type ApplyWorkerRuntimeConfigToWorkerParameters struct {
 
 type BroadcastCancelTaskParameters struct {
 	// Poll interval used while waiting worker command tasks
-	AckPollInterval *string `json:"ackPollInterval" yaml:"ackPollInterval"`
+	AckPollInterval *string `json:"ackPollInterval,omitempty" yaml:"ackPollInterval"`
 
 	// Correlation ID for this broadcast command
-	RequestID *string `json:"requestID" yaml:"requestID"`
+	RequestID *string `json:"requestID,omitempty" yaml:"requestID"`
 
 	// Task IDs to interrupt on each target worker
 	TaskIDs []int32 `json:"taskIDs" yaml:"taskIDs"`
 
 	// Fixed snapshot of worker IDs targeted by this broadcast request
-	WorkerIDs []uuid.UUID `json:"workerIDs" yaml:"workerIDs"`
+	WorkerIDs []uuid.UUID `json:"workerIDs,omitempty" yaml:"workerIDs"`
 }
 
 type CancelTaskOnWorkerParameters struct {
 	// Correlation ID of the parent broadcast command
-	RequestID *string `json:"requestID" yaml:"requestID"`
+	RequestID *string `json:"requestID,omitempty" yaml:"requestID"`
 
 	// Task IDs to interrupt on the target worker
 	TaskIDs []int32 `json:"taskIDs" yaml:"taskIDs"`
@@ -596,21 +714,21 @@ type CancelTaskOnWorkerParameters struct {
 
 type BroadcastPauseTaskParameters struct {
 	// Poll interval used while waiting worker command tasks
-	AckPollInterval *string `json:"ackPollInterval" yaml:"ackPollInterval"`
+	AckPollInterval *string `json:"ackPollInterval,omitempty" yaml:"ackPollInterval"`
 
 	// Correlation ID for this broadcast command
-	RequestID *string `json:"requestID" yaml:"requestID"`
+	RequestID *string `json:"requestID,omitempty" yaml:"requestID"`
 
 	// Task IDs to interrupt on each target worker
 	TaskIDs []int32 `json:"taskIDs" yaml:"taskIDs"`
 
 	// Fixed snapshot of worker IDs targeted by this broadcast request
-	WorkerIDs []uuid.UUID `json:"workerIDs" yaml:"workerIDs"`
+	WorkerIDs []uuid.UUID `json:"workerIDs,omitempty" yaml:"workerIDs"`
 }
 
 type PauseTaskOnWorkerParameters struct {
 	// Correlation ID of the parent broadcast command
-	RequestID *string `json:"requestID" yaml:"requestID"`
+	RequestID *string `json:"requestID,omitempty" yaml:"requestID"`
 
 	// Task IDs to interrupt on the target worker
 	TaskIDs []int32 `json:"taskIDs" yaml:"taskIDs"`
@@ -621,7 +739,7 @@ type PauseTaskOnWorkerParameters struct {
 
 type StressProbeParameters struct {
 	// Optional failure mode for chaos tests. Set to "always" to return a retryable error every run.
-	FailMode *string `json:"failMode" yaml:"failMode"`
+	FailMode *string `json:"failMode,omitempty" yaml:"failMode"`
 
 	// Logical group name for test-side metrics and labels
 	Group string `json:"group" yaml:"group"`
@@ -630,10 +748,10 @@ type StressProbeParameters struct {
 	JobID int64 `json:"jobID" yaml:"jobID"`
 
 	// Optional signal service base URL used by running tasks to emit observable heartbeats
-	SignalBaseURL *string `json:"signalBaseURL" yaml:"signalBaseURL"`
+	SignalBaseURL *string `json:"signalBaseURL,omitempty" yaml:"signalBaseURL"`
 
 	// Optional interval in milliseconds between signal emissions while the task is running
-	SignalIntervalMs *int32 `json:"signalIntervalMs" yaml:"signalIntervalMs"`
+	SignalIntervalMs *int32 `json:"signalIntervalMs,omitempty" yaml:"signalIntervalMs"`
 
 	// Simulated task execution time in milliseconds
 	SleepMs int32 `json:"sleepMs" yaml:"sleepMs"`
@@ -644,10 +762,23 @@ type CancelObservableProbeParameters struct {
 	Group string `json:"group" yaml:"group"`
 
 	// Signal service base URL used by the task to emit observable heartbeats
-	SignalBaseURL *string `json:"signalBaseURL" yaml:"signalBaseURL"`
+	SignalBaseURL *string `json:"signalBaseURL,omitempty" yaml:"signalBaseURL"`
 
 	// Interval in milliseconds between signal emissions while the task is running
-	SignalIntervalMs *int32 `json:"signalIntervalMs" yaml:"signalIntervalMs"`
+	SignalIntervalMs *int32 `json:"signalIntervalMs,omitempty" yaml:"signalIntervalMs"`
+}
+
+type ArchiveEventsParameters struct {
+	// Events created longer ago than this duration are archived, e.g. 720h
+	Retention string `json:"retention" yaml:"retention"`
+}
+
+type AsyncUserCreatedHookParameters struct {
+	// The name the async hook was registered with
+	Hook string `json:"hook" yaml:"hook"`
+
+	// The ID of the created user
+	UserID int32 `json:"userID" yaml:"userID"`
 }
 
 func (r *DeleteOpaqueKeyParameters) Parse(spec json.RawMessage) error {
@@ -722,6 +853,22 @@ func (r *CancelObservableProbeParameters) Marshal() (json.RawMessage, error) {
 	return json.Marshal(r)
 }
 
+func (r *ArchiveEventsParameters) Parse(spec json.RawMessage) error {
+	return json.Unmarshal(spec, r)
+}
+
+func (r *ArchiveEventsParameters) Marshal() (json.RawMessage, error) {
+	return json.Marshal(r)
+}
+
+func (r *AsyncUserCreatedHookParameters) Parse(spec json.RawMessage) error {
+	return json.Unmarshal(spec, r)
+}
+
+func (r *AsyncUserCreatedHookParameters) Marshal() (json.RawMessage, error) {
+	return json.Marshal(r)
+}
+
 type ExecutorInterface interface {
 	// Delete an opaque key
 	ExecuteDeleteOpaqueKey(ctx context.Context, task worker.Task, params *DeleteOpaqueKeyParameters) error
@@ -752,6 +899,12 @@ type ExecutorInterface interface {
 
 	// Long-running observable task used to validate cancellation semantics under chaos
 	ExecuteCancelObservableProbe(ctx context.Context, task worker.Task, params *CancelObservableProbeParameters) error
+
+	// Move task completed and task error events older than the retention to the events archive
+	ExecuteArchiveEvents(ctx context.Context, task worker.Task, params *ArchiveEventsParameters) error
+
+	// Run an async hook registered with RegisterOnUserCreatedAsync for a created user
+	ExecuteAsyncUserCreatedHook(ctx context.Context, task worker.Task, params *AsyncUserCreatedHookParameters) error
 }
 
 type TaskHandler struct {
@@ -845,6 +998,20 @@ func (f *TaskHandler) HandleTask(ctx context.Context, task worker.Task) error {
 		}
 		return f.executor.ExecuteCancelObservableProbe(ctx, task, &params)
 
+	case ArchiveEvents:
+		var params ArchiveEventsParameters
+		if err := json.Unmarshal(task.GetPayload(), &params); err != nil {
+			return fmt.Errorf("failed to parse archiveEvents parameters: %w", err)
+		}
+		return f.executor.ExecuteArchiveEvents(ctx, task, &params)
+
+	case AsyncUserCreatedHook:
+		var params AsyncUserCreatedHookParameters
+		if err := json.Unmarshal(task.GetPayload(), &params); err != nil {
+			return fmt.Errorf("failed to parse asyncUserCreatedHook parameters: %w", err)
+		}
+		return f.executor.ExecuteAsyncUserCreatedHook(ctx, task, &params)
+
 	default:
 		return errors.Wrapf(worker.ErrUnknownTaskType, "unknown task type: %s", task.GetType())
 	}
@@ -873,3 +1040,21 @@ func (f *TaskHandler) OnTaskFailed(ctx context.Context, tx core.Tx, failedTaskSp
 		return nil // No hook configured for this task type
 	}
 }
+
+func (f *TaskHandler) OnTaskCompleted(ctx context.Context, tx core.Tx, completedTaskSpec worker.TaskSpec, taskID int32) error {
+	for _, handler := range f.externalTaskHandler {
+		if err := handler.OnTaskCompleted(ctx, tx, completedTaskSpec, taskID); err != nil {
+			if errors.Is(err, worker.ErrUnknownTaskType) {
+				continue
+			}
+			return err
+		}
+		return nil
+	}
+
+	// Call the appropriate OnXXXCompleted hook method
+	switch completedTaskSpec.GetType() {
+	default:
+		return nil // No hook configured for this task type
+	}
+}
@@ -83,6 +83,86 @@ func (mr *MockTaskRunnerMockRecorder) RunApplyWorkerRuntimeConfigToWorkerWithTx(
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunApplyWorkerRuntimeConfigToWorkerWithTx", reflect.TypeOf((*MockTaskRunner)(nil).RunApplyWorkerRuntimeConfigToWorkerWithTx), varargs...)
 }
 
+// RunArchiveEvents mocks base method.
+func (m *MockTaskRunner) RunArchiveEvents(ctx context.Context, params *ArchiveEventsParameters, overrides ...store.TaskOverride) (int32, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, params}
+	for _, a := range overrides {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RunArchiveEvents", varargs...)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunArchiveEvents indicates an expected call of RunArchiveEvents.
+func (mr *MockTaskRunnerMockRecorder) RunArchiveEvents(ctx, params any, overrides ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, params}, overrides...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunArchiveEvents", reflect.TypeOf((*MockTaskRunner)(nil).RunArchiveEvents), varargs...)
+}
+
+// RunArchiveEventsWithTx mocks base method.
+func (m *MockTaskRunner) RunArchiveEventsWithTx(ctx context.Context, tx core.Tx, params *ArchiveEventsParameters, overrides ...store.TaskOverride) (int32, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, tx, params}
+	for _, a := range overrides {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RunArchiveEventsWithTx", varargs...)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunArchiveEventsWithTx indicates an expected call of RunArchiveEventsWithTx.
+func (mr *MockTaskRunnerMockRecorder) RunArchiveEventsWithTx(ctx, tx, params any, overrides ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, tx, params}, overrides...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunArchiveEventsWithTx", reflect.TypeOf((*MockTaskRunner)(nil).RunArchiveEventsWithTx), varargs...)
+}
+
+// RunAsyncUserCreatedHook mocks base method.
+func (m *MockTaskRunner) RunAsyncUserCreatedHook(ctx context.Context, params *AsyncUserCreatedHookParameters, overrides ...store.TaskOverride) (int32, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, params}
+	for _, a := range overrides {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RunAsyncUserCreatedHook", varargs...)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunAsyncUserCreatedHook indicates an expected call of RunAsyncUserCreatedHook.
+func (mr *MockTaskRunnerMockRecorder) RunAsyncUserCreatedHook(ctx, params any, overrides ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, params}, overrides...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunAsyncUserCreatedHook", reflect.TypeOf((*MockTaskRunner)(nil).RunAsyncUserCreatedHook), varargs...)
+}
+
+// RunAsyncUserCreatedHookWithTx mocks base method.
+func (m *MockTaskRunner) RunAsyncUserCreatedHookWithTx(ctx context.Context, tx core.Tx, params *AsyncUserCreatedHookParameters, overrides ...store.TaskOverride) (int32, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, tx, params}
+	for _, a := range overrides {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RunAsyncUserCreatedHookWithTx", varargs...)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunAsyncUserCreatedHookWithTx indicates an expected call of RunAsyncUserCreatedHookWithTx.
+func (mr *MockTaskRunnerMockRecorder) RunAsyncUserCreatedHookWithTx(ctx, tx, params any, overrides ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, tx, params}, overrides...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunAsyncUserCreatedHookWithTx", reflect.TypeOf((*MockTaskRunner)(nil).RunAsyncUserCreatedHookWithTx), varargs...)
+}
+
 // RunBroadcastCancelTask mocks base method.
 func (m *MockTaskRunner) RunBroadcastCancelTask(ctx context.Context, params *BroadcastCancelTaskParameters, overrides ...store.TaskOverride) (int32, error) {
 	m.ctrl.T.Helper()
@@ -441,6 +521,34 @@ func (mr *MockExecutorInterfaceMockRecorder) ExecuteApplyWorkerRuntimeConfigToWo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteApplyWorkerRuntimeConfigToWorker", reflect.TypeOf((*MockExecutorInterface)(nil).ExecuteApplyWorkerRuntimeConfigToWorker), ctx, task, params)
 }
 
+// ExecuteArchiveEvents mocks base method.
+func (m *MockExecutorInterface) ExecuteArchiveEvents(ctx context.Context, task worker.Task, params *ArchiveEventsParameters) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteArchiveEvents", ctx, task, params)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExecuteArchiveEvents indicates an expected call of ExecuteArchiveEvents.
+func (mr *MockExecutorInterfaceMockRecorder) ExecuteArchiveEvents(ctx, task, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteArchiveEvents", reflect.TypeOf((*MockExecutorInterface)(nil).ExecuteArchiveEvents), ctx, task, params)
+}
+
+// ExecuteAsyncUserCreatedHook mocks base method.
+func (m *MockExecutorInterface) ExecuteAsyncUserCreatedHook(ctx context.Context, task worker.Task, params *AsyncUserCreatedHookParameters) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteAsyncUserCreatedHook", ctx, task, params)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExecuteAsyncUserCreatedHook indicates an expected call of ExecuteAsyncUserCreatedHook.
+func (mr *MockExecutorInterfaceMockRecorder) ExecuteAsyncUserCreatedHook(ctx, task, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteAsyncUserCreatedHook", reflect.TypeOf((*MockExecutorInterface)(nil).ExecuteAsyncUserCreatedHook), ctx, task, params)
+}
+
 // ExecuteBroadcastCancelTask mocks base method.
 func (m *MockExecutorInterface) ExecuteBroadcastCancelTask(ctx context.Context, task worker.Task, params *BroadcastCancelTaskParameters) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,65 @@
+package taskgen
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	taskcore "github.com/cloudcarver/anclax/pkg/taskcore/store"
+	"github.com/cloudcarver/anclax/pkg/zgen/apigen"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRunDeleteOpaqueKeyPushesTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	taskStore := taskcore.NewMockTaskStoreInterface(ctrl)
+
+	var pushed *apigen.Task
+	taskStore.EXPECT().PushTask(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, task *apigen.Task) (int32, error) {
+		pushed = task
+		return 42, nil
+	})
+
+	runner := NewTaskRunner(taskStore)
+	taskID, err := runner.RunDeleteOpaqueKey(ctx, &DeleteOpaqueKeyParameters{KeyID: 7})
+	require.NoError(t, err)
+	require.Equal(t, int32(42), taskID)
+
+	require.Equal(t, apigen.Pending, pushed.Status)
+	require.Equal(t, DeleteOpaqueKey, pushed.Spec.Type)
+	var params DeleteOpaqueKeyParameters
+	require.NoError(t, json.Unmarshal(pushed.Spec.Payload, &params))
+	require.Equal(t, int64(7), params.KeyID)
+	require.Equal(t, &apigen.TaskRetryPolicy{Interval: "30m", MaxAttempts: -1}, pushed.Attributes.RetryPolicy)
+	require.Nil(t, pushed.Attributes.Timeout)
+	require.Nil(t, pushed.Attributes.Cronjob)
+}
+
+func TestRunStressProbeAppliesAttributesAndOverrides(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	taskStore := taskcore.NewMockTaskStoreInterface(ctrl)
+
+	var pushed *apigen.Task
+	taskStore.EXPECT().PushTask(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, task *apigen.Task) (int32, error) {
+		pushed = task
+		return 1, nil
+	})
+
+	runner := NewTaskRunner(taskStore)
+	_, err := runner.RunStressProbe(ctx, &StressProbeParameters{Group: "g", JobID: 1, SleepMs: 10}, taskcore.WithUniqueTag("probe-1"))
+	require.NoError(t, err)
+
+	require.Equal(t, StressProbe, pushed.Spec.Type)
+	require.NotNil(t, pushed.Attributes.Timeout)
+	require.Equal(t, "30s", *pushed.Attributes.Timeout)
+	require.Equal(t, &apigen.TaskRetryPolicy{Interval: "1s", MaxAttempts: 1}, pushed.Attributes.RetryPolicy)
+	require.NotNil(t, pushed.UniqueTag)
+	require.Equal(t, "probe-1", *pushed.UniqueTag)
+}
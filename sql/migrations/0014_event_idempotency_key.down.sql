@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS anclax.events_idempotency_key_idx;
+
+ALTER TABLE anclax.events
+    DROP COLUMN IF EXISTS idempotency_key;
+
+COMMIT;
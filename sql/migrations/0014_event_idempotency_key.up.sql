@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE anclax.events
+    ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS events_idempotency_key_idx
+    ON anclax.events (idempotency_key);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS anclax.opaque_keys_expires_at_idx;
+
+ALTER TABLE anclax.opaque_keys
+    DROP COLUMN IF EXISTS expires_at;
+
+COMMIT;
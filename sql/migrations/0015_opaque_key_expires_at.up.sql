@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE anclax.opaque_keys
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS opaque_keys_expires_at_idx
+    ON anclax.opaque_keys (expires_at)
+    WHERE expires_at IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS anclax.tasks_pending_org_id_idx;
+
+ALTER TABLE anclax.tasks
+    DROP COLUMN IF EXISTS org_id;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE anclax.tasks
+    ADD COLUMN IF NOT EXISTS org_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS tasks_pending_org_id_idx
+    ON anclax.tasks (org_id)
+    WHERE status = 'pending' AND org_id IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE anclax.tasks
+    DROP COLUMN IF EXISTS result;
+
+COMMIT;
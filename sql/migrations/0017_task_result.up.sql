@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE anclax.tasks
+    ADD COLUMN IF NOT EXISTS result JSONB;
+
+COMMIT;
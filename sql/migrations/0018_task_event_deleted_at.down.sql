@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE anclax.events
+    DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE anclax.tasks
+    DROP COLUMN IF EXISTS deleted_at;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE anclax.tasks
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE anclax.events
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+COMMIT;
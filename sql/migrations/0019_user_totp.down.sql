@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS anclax.user_totp_secrets;
+
+COMMIT;
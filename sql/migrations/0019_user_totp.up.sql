@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS anclax.user_totp_secrets (
+    user_id    INTEGER     NOT NULL REFERENCES anclax.users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    secret     BYTEA       NOT NULL,
+    enabled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (user_id)
+);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS anclax.opaque_keys_session_id_idx;
+
+ALTER TABLE anclax.opaque_keys
+    DROP COLUMN IF EXISTS session_id;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- a refresh key belongs to the session of the access key it was issued with
+ALTER TABLE anclax.opaque_keys
+    ADD COLUMN IF NOT EXISTS session_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS opaque_keys_session_id_idx
+    ON anclax.opaque_keys (session_id);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE anclax.user_totp_secrets
+    DROP COLUMN IF EXISTS locked_until,
+    DROP COLUMN IF EXISTS failed_attempts,
+    DROP COLUMN IF EXISTS last_step;
+
+COMMIT;
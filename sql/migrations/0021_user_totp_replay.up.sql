@@ -0,0 +1,10 @@
+BEGIN;
+
+-- last_step is the latest TOTP time-step accepted for the user, codes of it or of any
+-- earlier step are rejected so that an observed code cannot be replayed
+ALTER TABLE anclax.user_totp_secrets
+    ADD COLUMN IF NOT EXISTS last_step       BIGINT,
+    ADD COLUMN IF NOT EXISTS failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS locked_until    TIMESTAMPTZ;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- only the latest task of a tag keeps it
+UPDATE anclax.tasks t
+SET unique_tag = NULL
+WHERE t.unique_tag IS NOT NULL
+  AND EXISTS (
+    SELECT 1 FROM anclax.tasks o
+    WHERE o.unique_tag = t.unique_tag AND o.id > t.id
+  );
+
+DROP INDEX IF EXISTS anclax.tasks_unique_tag_idx;
+DROP INDEX IF EXISTS anclax.tasks_unique_tag_active_idx;
+
+ALTER TABLE anclax.tasks
+    ADD CONSTRAINT tasks_unique_tag_key UNIQUE (unique_tag);
+
+COMMIT;
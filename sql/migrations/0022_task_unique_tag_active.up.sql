@@ -0,0 +1,15 @@
+BEGIN;
+
+-- a unique tag only has to be unique among tasks that have not finished, so a tagged task can
+-- run again once the previous one completed, failed or was cancelled
+ALTER TABLE anclax.tasks
+    DROP CONSTRAINT IF EXISTS tasks_unique_tag_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_unique_tag_active_idx
+    ON anclax.tasks (unique_tag)
+    WHERE status NOT IN ('completed', 'failed', 'cancelled');
+
+CREATE INDEX IF NOT EXISTS tasks_unique_tag_idx
+    ON anclax.tasks (unique_tag);
+
+COMMIT;
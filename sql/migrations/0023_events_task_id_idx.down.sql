@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS anclax.events_task_id_idx;
+
+COMMIT;
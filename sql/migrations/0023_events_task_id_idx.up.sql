@@ -0,0 +1,8 @@
+BEGIN;
+
+-- task events are looked up by the task ID in their spec, the expression must match the one
+-- in ListTaskEvents, SoftDeleteTaskEvents and RestoreTaskEvents
+CREATE INDEX IF NOT EXISTS events_task_id_idx
+    ON anclax.events ((COALESCE(spec->'taskStarted'->>'taskID', spec->'taskCompleted'->>'taskID', spec->'taskError'->>'taskID', spec->'taskRetryScheduled'->>'taskID', spec->'taskCancelled'->>'taskID')::int));
+
+COMMIT;
@@ -1,11 +1,19 @@
 -- name: CreateOpaqueKey :one
-INSERT INTO anclax.opaque_keys ("group", key) VALUES ($1, $2) RETURNING id;
+INSERT INTO anclax.opaque_keys ("group", key, expires_at, session_id) VALUES ($1, $2, $3, $4) RETURNING id;
 
 -- name: GetOpaqueKey :one
-SELECT key FROM anclax.opaque_keys WHERE id = $1;
+SELECT key, "group" FROM anclax.opaque_keys WHERE id = $1;
 
 -- name: DeleteOpaqueKey :exec
 DELETE FROM anclax.opaque_keys WHERE id = $1;
 
+-- name: DeleteExpiredOpaqueKeys :execrows
+DELETE FROM anclax.opaque_keys WHERE expires_at <= sqlc.arg(now)::timestamptz;
+
 -- name: DeleteOpaqueKeys :exec
 DELETE FROM anclax.opaque_keys WHERE "group" = $1;
+
+-- name: ListActiveOpaqueKeysByGroup :many
+SELECT id, created_at, expires_at, session_id FROM anclax.opaque_keys
+WHERE "group" = sqlc.arg('group') AND (expires_at IS NULL OR expires_at > sqlc.arg(now)::timestamptz)
+ORDER BY created_at DESC, id DESC;
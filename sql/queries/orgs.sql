@@ -19,4 +19,31 @@ FROM anclax.org_users
 JOIN anclax.orgs AS orgs ON anclax.org_users.org_id = orgs.id
 WHERE anclax.org_users.user_id = $1;
 
+-- name: ListOrgMembers :many
+SELECT
+    users.id AS user_id,
+    users.name AS user_name,
+    (org_owners.user_id IS NOT NULL)::bool AS is_owner,
+    org_users.created_at AS joined_at
+FROM anclax.org_users AS org_users
+JOIN anclax.users AS users ON org_users.user_id = users.id
+LEFT JOIN anclax.org_owners AS org_owners
+    ON org_owners.org_id = org_users.org_id AND org_owners.user_id = org_users.user_id
+WHERE org_users.org_id = $1 AND users.deleted_at IS NULL
+ORDER BY is_owner DESC, org_users.created_at, users.id;
+
+-- name: GetOrgOwner :one
+SELECT * FROM anclax.org_owners WHERE org_id = $1 FOR UPDATE;
+
+-- name: SetOrgOwner :one
+INSERT INTO anclax.org_owners (org_id, user_id) VALUES ($1, $2)
+ON CONFLICT (org_id) DO UPDATE SET user_id = EXCLUDED.user_id
+RETURNING *;
+
+-- name: GetOrgUser :one
+SELECT * FROM anclax.org_users WHERE org_id = $1 AND user_id = $2;
+
+-- name: DeleteOrgUser :execrows
+DELETE FROM anclax.org_users WHERE org_id = $1 AND user_id = $2;
+
 -- name: GetUserDefaultOrg :one
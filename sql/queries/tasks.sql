@@ -23,6 +23,10 @@ WITH
                     )
                 )
             )
+            AND (
+                COALESCE(array_length(sqlc.arg(org_ids)::int[], 1), 0) = 0
+                OR t.org_id = ANY(sqlc.arg(org_ids)::int[])
+            )
     ),
     locked_serial_keys AS (
         SELECT DISTINCT t.serial_key
@@ -103,6 +107,10 @@ WITH
                     )
                 )
             )
+            AND (
+                COALESCE(array_length(sqlc.arg(org_ids)::int[], 1), 0) = 0
+                OR t.org_id = ANY(sqlc.arg(org_ids)::int[])
+            )
     ),
     locked_serial_keys AS (
         SELECT DISTINCT t.serial_key
@@ -199,6 +207,14 @@ WITH
                     AND (t.attributes->'labels' ? sqlc.arg(group_name)::text)
                 )
             )
+            AND (
+                COALESCE(array_length(sqlc.arg(org_ids)::int[], 1), 0) = 0
+                OR t.org_id = ANY(sqlc.arg(org_ids)::int[])
+            )
+            AND (
+                COALESCE(array_length(sqlc.arg(exclude_types)::text[], 1), 0) = 0
+                OR COALESCE(t.spec->>'type', '') <> ALL(sqlc.arg(exclude_types)::text[])
+            )
     ),
     locked_serial_keys AS (
         SELECT DISTINCT t.serial_key
@@ -279,6 +295,10 @@ WITH
                     )
                 )
             )
+            AND (
+                COALESCE(array_length(sqlc.arg(org_ids)::int[], 1), 0) = 0
+                OR t.org_id = ANY(sqlc.arg(org_ids)::int[])
+            )
     ),
     locked_serial_keys AS (
         SELECT DISTINCT t.serial_key
@@ -340,6 +360,45 @@ WHERE
         started_at IS NULL OR started_at < NOW()
     );
 
+-- name: ListScheduledTasks :many
+SELECT * FROM anclax.tasks
+WHERE
+    status = 'pending'
+    AND started_at >= sqlc.arg(from_time)::timestamptz
+    AND started_at <= sqlc.arg(until_time)::timestamptz
+ORDER BY started_at, id;
+
+-- name: ListTasksFiltered :many
+SELECT * FROM anclax.tasks
+WHERE
+    (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+    AND (sqlc.narg(task_type)::text IS NULL OR spec->>'type' = sqlc.narg(task_type)::text)
+    AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at > sqlc.narg(created_after)::timestamptz)
+    AND (sqlc.arg(include_deleted)::bool OR deleted_at IS NULL)
+ORDER BY id DESC;
+
+-- name: SoftDeleteTask :execrows
+UPDATE anclax.tasks
+SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL AND status IN ('completed', 'failed', 'cancelled');
+
+-- name: RestoreTask :execrows
+UPDATE anclax.tasks
+SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: SoftDeleteTaskEvents :exec
+UPDATE anclax.events
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE deleted_at IS NULL
+  AND COALESCE(spec->'taskStarted'->>'taskID', spec->'taskCompleted'->>'taskID', spec->'taskError'->>'taskID', spec->'taskRetryScheduled'->>'taskID', spec->'taskCancelled'->>'taskID')::int = sqlc.arg(task_id)::int;
+
+-- name: RestoreTaskEvents :exec
+UPDATE anclax.events
+SET deleted_at = NULL
+WHERE deleted_at IS NOT NULL
+  AND COALESCE(spec->'taskStarted'->>'taskID', spec->'taskCompleted'->>'taskID', spec->'taskError'->>'taskID', spec->'taskRetryScheduled'->>'taskID', spec->'taskCancelled'->>'taskID')::int = sqlc.arg(task_id)::int;
+
 -- name: UpdateTaskStatus :exec
 UPDATE anclax.tasks
 SET
@@ -347,6 +406,14 @@ SET
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1;
 
+-- name: UpdateTaskStatusIf :one
+UPDATE anclax.tasks
+SET
+    status = sqlc.arg(new_status),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(expected_status)
+RETURNING id;
+
 -- name: UpdateTaskStatusByWorker :one
 UPDATE anclax.tasks
 SET
@@ -375,12 +442,28 @@ UPDATE anclax.tasks
 SET started_at = $2, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1;
 
+-- name: UpdateTaskResult :exec
+UPDATE anclax.tasks
+SET result = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
 -- name: UpdateTaskStartedAtByWorker :one
 UPDATE anclax.tasks
 SET started_at = $2, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1 AND worker_id = $3
 RETURNING id;
 
+-- name: DeferTaskByWorker :one
+UPDATE anclax.tasks
+SET
+    started_at = $2,
+    attempts = GREATEST(attempts - 1, 0),
+    locked_at = NULL,
+    worker_id = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND worker_id = $3
+RETURNING id;
+
 -- name: RefreshTaskLock :one
 UPDATE anclax.tasks
 SET locked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
@@ -394,18 +477,36 @@ WHERE id = $1 AND worker_id = $2
 RETURNING id;
 
 -- name: CreateTask :one
-INSERT INTO anclax.tasks (attributes, spec, status, started_at, unique_tag, parent_task_id, serial_key, serial_id, priority, weight)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (unique_tag) DO NOTHING RETURNING *;
+INSERT INTO anclax.tasks (attributes, spec, status, started_at, unique_tag, parent_task_id, serial_key, serial_id, priority, weight, org_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (unique_tag) WHERE status NOT IN ('completed', 'failed', 'cancelled') DO NOTHING RETURNING *;
 
 -- name: GetTaskByUniqueTag :one
 SELECT * FROM anclax.tasks
-WHERE unique_tag = $1;
+WHERE unique_tag = $1
+ORDER BY id DESC
+LIMIT 1;
 
 -- name: InsertEvent :one
-INSERT INTO anclax.events (spec)
-VALUES ($1)
+INSERT INTO anclax.events (spec, idempotency_key)
+VALUES ($1, $2)
+ON CONFLICT (idempotency_key) DO NOTHING
 RETURNING *;
 
+-- name: ArchiveEventsBefore :execrows
+WITH archived AS (
+    DELETE FROM anclax.events AS e
+    WHERE e.created_at < sqlc.arg(before)::timestamptz
+      AND e.spec->>'type' IN ('TaskStarted', 'TaskCompleted', 'TaskError', 'TaskRetryScheduled', 'TaskCancelled')
+      AND NOT EXISTS (
+          SELECT 1 FROM anclax.tasks AS t
+          WHERE t.id = COALESCE(e.spec->'taskStarted'->>'taskID', e.spec->'taskCompleted'->>'taskID', e.spec->'taskError'->>'taskID', e.spec->'taskRetryScheduled'->>'taskID', e.spec->'taskCancelled'->>'taskID')::int
+            AND t.status = 'pending'
+      )
+    RETURNING e.id, e.spec, e.created_at
+)
+INSERT INTO anclax.events_archive (id, spec, created_at)
+SELECT id, spec, created_at FROM archived;
+
 -- name: GetLastTaskErrorEvent :one
 SELECT * FROM anclax.events
 WHERE spec->>'type' = 'TaskError'
@@ -413,6 +514,22 @@ WHERE spec->>'type' = 'TaskError'
 ORDER BY created_at DESC
 LIMIT 1;
 
+-- name: GetLatestEventID :one
+SELECT COALESCE(MAX(id), 0)::int AS id FROM anclax.events;
+
+-- name: ListEventsAfterID :many
+SELECT * FROM anclax.events
+WHERE id > sqlc.arg(after_id)::int
+  AND (sqlc.arg(include_deleted)::bool OR deleted_at IS NULL)
+ORDER BY id
+LIMIT sqlc.arg(max_count)::int;
+
+-- name: ListTaskEvents :many
+SELECT * FROM anclax.events
+WHERE deleted_at IS NULL
+  AND COALESCE(spec->'taskStarted'->>'taskID', spec->'taskCompleted'->>'taskID', spec->'taskError'->>'taskID', spec->'taskRetryScheduled'->>'taskID', spec->'taskCancelled'->>'taskID')::int = sqlc.arg(task_id)::int
+ORDER BY created_at, id;
+
 -- name: GetTaskByID :one
 SELECT * FROM anclax.tasks
 WHERE id = $1;
@@ -35,5 +35,32 @@ ON CONFLICT (user_id) DO UPDATE SET org_id = $2;
 SELECT org_id FROM anclax.user_default_orgs
 WHERE user_id = $1;
 
+-- name: DeleteUserDefaultOrg :exec
+DELETE FROM anclax.user_default_orgs WHERE user_id = $1;
+
 -- name: UpdateUserPassword :exec
 UPDATE anclax.users SET password_hash = $2, password_salt = $3 WHERE id = $1;
+
+-- name: UpsertUserTotpSecret :execrows
+INSERT INTO anclax.user_totp_secrets (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET secret = $2, updated_at = CURRENT_TIMESTAMP
+WHERE anclax.user_totp_secrets.enabled_at IS NULL;
+
+-- name: GetUserTotpSecret :one
+SELECT * FROM anclax.user_totp_secrets WHERE user_id = $1;
+
+-- name: EnableUserTotpSecret :exec
+UPDATE anclax.user_totp_secrets SET enabled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND enabled_at IS NULL;
+
+-- name: AcceptUserTotpStep :execrows
+UPDATE anclax.user_totp_secrets SET last_step = $2, failed_attempts = 0, locked_until = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND (last_step IS NULL OR last_step < $2);
+
+-- name: RecordUserTotpFailure :exec
+UPDATE anclax.user_totp_secrets SET
+    failed_attempts = CASE WHEN failed_attempts + 1 >= sqlc.arg(max_attempts)::int THEN 0 ELSE failed_attempts + 1 END,
+    locked_until = CASE WHEN failed_attempts + 1 >= sqlc.arg(max_attempts)::int THEN sqlc.arg(locked_until)::timestamptz ELSE locked_until END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id);
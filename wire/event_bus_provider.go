@@ -0,0 +1,13 @@
+package wire
+
+import (
+	"github.com/cloudcarver/anclax/pkg/app/closer"
+	"github.com/cloudcarver/anclax/pkg/eventbus"
+	"github.com/cloudcarver/anclax/pkg/zcore/model"
+)
+
+func NewEventBus(m model.ModelInterface, cm *closer.CloserManager) *eventbus.EventBus {
+	b := eventbus.NewEventBus(m)
+	cm.Register(b.Close)
+	return b
+}
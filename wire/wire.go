@@ -34,6 +34,7 @@ func InitializeApplication(cfg *config.Config, libCfg *config.LibConfig) (*app.A
 		controller.NewValidator,
 		model.NewModel,
 		NewTaskEventListener,
+		NewEventBus,
 		server.NewServer,
 		auth.NewAuth,
 		macaroons.NewMacaroonManager,
@@ -48,7 +49,7 @@ func InitializeApplication(cfg *config.Config, libCfg *config.LibConfig) (*app.A
 		taskgen.NewTaskRunner,
 		asynctask.NewExecutor,
 		wire.Bind(new(taskgen.ExecutorInterface), new(*asynctask.Executor)),
-		hooks.NewBaseHook,
+		hooks.NewBaseHookWithTaskRunner,
 	)
 	return nil, nil
 }
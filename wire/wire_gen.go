@@ -40,7 +40,7 @@ func InitializeApplication(cfg *config.Config, libCfg *config.LibConfig) (*app.A
 	keyStore := store2.NewStore(modelInterface, taskRunner)
 	caveatParserInterface := macaroons.NewCaveatParser()
 	macaroonManagerInterface := macaroons.NewMacaroonManager(keyStore, caveatParserInterface)
-	anclaxHookInterface := hooks.NewBaseHook()
+	anclaxHookInterface := hooks.NewBaseHookWithTaskRunner(taskRunner)
 	authInterface, err := auth.NewAuth(cfg, macaroonManagerInterface, caveatParserInterface, anclaxHookInterface)
 	if err != nil {
 		return nil, err
@@ -48,12 +48,12 @@ func InitializeApplication(cfg *config.Config, libCfg *config.LibConfig) (*app.A
 	serviceInterface := service.NewService(cfg, modelInterface, authInterface, anclaxHookInterface)
 	serverInterface := controller.NewController(serviceInterface, authInterface, cfg)
 	validator := controller.NewValidator(modelInterface, authInterface)
-	serverServer, err := server.NewServer(cfg, libCfg, globalContext, authInterface, serverInterface, validator)
+	serverServer, err := server.NewServer(cfg, libCfg, globalContext, authInterface, serverInterface, validator, modelInterface)
 	if err != nil {
 		return nil, err
 	}
 	metricsServer := metrics.NewMetricsServer(cfg, globalContext)
-	executor := asynctask.NewExecutor(cfg, modelInterface, taskRunner)
+	executor := asynctask.NewExecutor(cfg, modelInterface, taskRunner, anclaxHookInterface)
 	taskHandler := taskgen.NewTaskHandler(executor)
 	workerInterface, err := NewConfiguredWorker(globalContext, cfg, modelInterface, taskHandler, executor)
 	if err != nil {
@@ -62,7 +62,8 @@ func InitializeApplication(cfg *config.Config, libCfg *config.LibConfig) (*app.A
 	debugServer := app.NewDebugServer(cfg, globalContext)
 	taskEventListener := NewTaskEventListener(modelInterface, closerManager)
 	workerControlPlane := ctrl.NewWorkerControlPlane(modelInterface, taskRunner, taskStoreInterface, taskEventListener)
-	application, err := app.NewApplication(globalContext, cfg, serverServer, metricsServer, workerInterface, debugServer, authInterface, taskStoreInterface, workerControlPlane, serviceInterface, anclaxHookInterface, caveatParserInterface, closerManager)
+	eventBus := NewEventBus(modelInterface, closerManager)
+	application, err := app.NewApplication(globalContext, cfg, serverServer, metricsServer, workerInterface, debugServer, authInterface, taskStoreInterface, workerControlPlane, serviceInterface, anclaxHookInterface, caveatParserInterface, eventBus, taskRunner, keyStore, closerManager)
 	if err != nil {
 		return nil, err
 	}
@@ -26,7 +26,7 @@ func TestNewConfiguredWorkerInvalidConfig(t *testing.T) {
 	badID := "not-uuid"
 	cfg := &config.Config{}
 	cfg.Worker.WorkerID = &badID
-	w, err := NewConfiguredWorker(gctx, cfg, nil, nil, asynctask.NewExecutor(&config.Config{}, nil, nil))
+	w, err := NewConfiguredWorker(gctx, cfg, nil, nil, asynctask.NewExecutor(&config.Config{}, nil, nil, nil))
 	require.Error(t, err)
 	require.Nil(t, w)
 }
@@ -35,7 +35,7 @@ func TestNewConfiguredWorker_DefaultUsesWorker(t *testing.T) {
 	gctx := globalctx.New()
 	t.Cleanup(gctx.Cancel)
 
-	w, err := NewConfiguredWorker(gctx, &config.Config{}, nil, nil, asynctask.NewExecutor(&config.Config{}, nil, nil))
+	w, err := NewConfiguredWorker(gctx, &config.Config{}, nil, nil, asynctask.NewExecutor(&config.Config{}, nil, nil, nil))
 	require.NoError(t, err)
 	require.NotNil(t, w)
 	_, ok := w.(*worker.Worker)